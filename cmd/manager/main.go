@@ -8,6 +8,7 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"flag"
 	"fmt"
@@ -15,14 +16,15 @@ import (
 	"github.com/oracle/coherence-operator/pkg/flags"
 	"github.com/oracle/coherence-operator/pkg/operator"
 	cohrest "github.com/oracle/coherence-operator/pkg/rest"
+	"github.com/oracle/coherence-operator/pkg/webhook"
 	"k8s.io/klog"
 	"net/http"
 	"os"
 	"runtime"
-	"strings"
 	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
+	"k8s.io/client-go/discovery"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/client-go/rest"
 
@@ -125,6 +127,24 @@ func main() {
 		os.Exit(1)
 	}
 
+	// >>>>>>>> Coherence Operator code added to Operator SDK the generated file ---------------------------
+	// fail fast on a version of Kubernetes that does not support server-side apply
+	dc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		log.Error(err, "Error creating discovery client")
+		os.Exit(1)
+	}
+	serverVersion, err := dc.ServerVersion()
+	if err != nil {
+		log.Error(err, "Error getting the Kubernetes server version")
+		os.Exit(1)
+	}
+	if err := operator.CheckKubernetesVersion(serverVersion); err != nil {
+		log.Error(err, "Unsupported Kubernetes version")
+		os.Exit(1)
+	}
+	// <<<<<<<< Coherence Operator code added to Operator SDK the generated file ---------------------------
+
 	ctx := context.TODO()
 	// Become the leader before proceeding
 	err = leader.Become(ctx, "coherence-operator-lock")
@@ -137,6 +157,11 @@ func main() {
 	mgr, err := manager.New(cfg, manager.Options{
 		Namespace:          namespace,
 		MetricsBindAddress: fmt.Sprintf("%s:%d", metricsHost, metricsPort),
+		// >>>>>>>> Coherence Operator code added to Operator SDK the generated file ---------------------------
+		Port:                   int(cohf.WebhookPort),
+		CertDir:                cohf.WebhookCertDir,
+		HealthProbeBindAddress: cohf.HealthProbeAddress,
+		// <<<<<<<< Coherence Operator code added to Operator SDK the generated file ---------------------------
 	})
 	if err != nil {
 		log.Error(err, "")
@@ -146,7 +171,7 @@ func main() {
 	// >>>>>>>> Coherence Operator code added to Operator SDK the generated file ---------------------------
 
 	// we must start the Operator ReST endpoint before any controllers start
-	restServer, err := cohrest.StartRestServer(mgr, cohf)
+	restServer, err := cohrest.StartRestServer(mgr, cohf, namespace, cohrest.ParseBuildInfo(BuildInfo))
 	if err != nil {
 		log.Error(err, "Error starting ReST server")
 		os.Exit(1)
@@ -154,14 +179,32 @@ func main() {
 
 	// wait until we can hit the server to ensure that it is up
 	log.Info("Waiting for rest server to start")
+	healthURL := fmt.Sprintf("http://localhost:%d/healthz", restServer.GetPort())
+	healthClient := http.DefaultClient
+	if restServer.IsTLS() {
+		// the server's certificate is only used to check that it is up, so there is no need to verify it
+		healthURL = fmt.Sprintf("https://localhost:%d/healthz", restServer.GetPort())
+		healthClient = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	}
 	for i := 0; i < 10; i++ {
-		_, err = http.Get(fmt.Sprintf("http://localhost:%d/ready", restServer.GetPort()))
+		resp, err := healthClient.Get(healthURL)
 		if err == nil {
+			_ = resp.Body.Close()
 			break
 		}
 		time.Sleep(1 * time.Second)
 	}
 
+	// the Manager's health probes include the ReST server
+	if err := mgr.AddHealthzCheck("rest-server", restServer.Healthz); err != nil {
+		log.Error(err, "Error adding ReST server health check")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("rest-server", restServer.Readyz); err != nil {
+		log.Error(err, "Error adding ReST server readiness check")
+		os.Exit(1)
+	}
+
 	// ensure that the CRDs exist
 	if err := operator.EnsureCRDs(mgr, cohf, log); err != nil {
 		log.Error(err, "Error ensuring that CRDs exist")
@@ -169,6 +212,7 @@ func main() {
 	}
 
 	operator.SetHostAndPort(restServer.GetHostAndPort(cohf))
+	operator.SetTLS(restServer.IsTLS(), restServer.GetCACertificate())
 
 	// <<<<<<<< Coherence Operator code added to Operator SDK the generated file ---------------------------
 
@@ -186,6 +230,18 @@ func main() {
 		os.Exit(1)
 	}
 
+	// >>>>>>>> Coherence Operator code added to Operator SDK the generated file ---------------------------
+
+	// Setup the admission web-hooks
+	if cohf.EnableWebhooks {
+		if err := webhook.AddToManager(mgr, cohf); err != nil {
+			log.Error(err, "Error adding admission web-hooks")
+			os.Exit(1)
+		}
+	}
+
+	// <<<<<<<< Coherence Operator code added to Operator SDK the generated file ---------------------------
+
 	if err = serveCRMetrics(cfg); err != nil {
 		log.Info("Could not generate and serve custom resource metrics", "error", err.Error())
 	}
@@ -271,29 +327,9 @@ func serveCRMetrics(cfg *rest.Config) error {
 
 // PrintBuildInfo prints the Coherence Operator build information to the log.
 func printBuildInfo(log logr.Logger) {
-	var (
-		version string
-		commit  string
-		date    string
-	)
-
-	if BuildInfo != "" {
-		parts := strings.Split(BuildInfo, "|")
-
-		if len(parts) > 0 {
-			version = parts[0]
-		}
-
-		if len(parts) > 1 {
-			commit = parts[1]
-		}
-
-		if len(parts) > 2 {
-			date = strings.Replace(parts[2], ".", " ", -1)
-		}
-	}
+	info := cohrest.ParseBuildInfo(BuildInfo)
 
-	log.Info(fmt.Sprintf("Coherence Operator Version: %s", version))
-	log.Info(fmt.Sprintf("Coherence Operator Git commit: %s", commit))
-	log.Info(fmt.Sprintf("Coherence Operator Build Time: %s", date))
+	log.Info(fmt.Sprintf("Coherence Operator Version: %s", info.Version))
+	log.Info(fmt.Sprintf("Coherence Operator Git commit: %s", info.GitCommit))
+	log.Info(fmt.Sprintf("Coherence Operator Build Time: %s", info.BuildDate))
 }
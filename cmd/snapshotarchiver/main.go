@@ -0,0 +1,47 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+// The snapshot-archiver copies the persistence snapshot files of a Coherence member to and from a snapshot store.
+// It is copied into the Coherence container by the utils init container and is run by the Operator using
+//
+//	snapshot-archiver archive|retrieve|remove
+//
+// with the JSON archive configuration, which may contain store credentials, read from stdin.
+// On success the manifest of the member's archived files is written to stdout as JSON.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/oracle/coherence-operator/pkg/archive"
+	"os"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		exit(fmt.Errorf("usage: %s %s|%s|%s", os.Args[0], archive.OperationArchive, archive.OperationRetrieve, archive.OperationRemove))
+	}
+
+	cfg := archive.Config{}
+	if err := json.NewDecoder(os.Stdin).Decode(&cfg); err != nil {
+		exit(fmt.Errorf("failed to read the archive configuration from stdin: %s", err.Error()))
+	}
+
+	manifest, err := archive.Run(os.Args[1], cfg)
+	if err != nil {
+		exit(err)
+	}
+	if manifest != nil {
+		if err = json.NewEncoder(os.Stdout).Encode(manifest); err != nil {
+			exit(err)
+		}
+	}
+}
+
+func exit(err error) {
+	_, _ = fmt.Fprintln(os.Stderr, err.Error())
+	os.Exit(1)
+}
@@ -90,6 +90,16 @@ func main() {
 		}
 	}
 
+	archiver := filesDir + string(os.PathSeparator) + "snapshot-archiver"
+	_, err = os.Stat(archiver)
+	if err == nil {
+		fmt.Println("Copying snapshot-archiver utility")
+		err = utils.CopyFile(archiver, utilDir+string(os.PathSeparator)+"snapshot-archiver")
+		if err != nil {
+			panic(err)
+		}
+	}
+
 	var dirNames []string
 
 	_, err = os.Stat(persistenceDir)
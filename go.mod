@@ -15,6 +15,7 @@ require (
 	github.com/operator-framework/operator-sdk v0.15.1
 	github.com/pborman/uuid v1.2.0
 	github.com/pkg/errors v0.8.1
+	github.com/prometheus/client_golang v1.2.1
 	github.com/spf13/pflag v1.0.5
 	github.com/tebeka/go2xunit v1.4.10
 	github.com/technosophos/moniker v0.0.0-20180509230615-a5dbd03a2245 // indirect
@@ -470,14 +470,42 @@ commonConfiguration()
 
 #   Configure the Coherence member's site and rack
     if [[ "${GET_SITE}" != "" ]]
+    then
+      operatorCurlOptions
+      TOPOLOGY=""
+      case "${COH_TOPOLOGY_INFO_LOCATION}" in
+          http://\$*|https://\$*)
+              ;;
+          http://*|https://*)
+              if [[ "${OPERATOR_REQUEST_TIMEOUT}" != "" ]]
+              then
+                TIMEOUT=${OPERATOR_REQUEST_TIMEOUT}
+              else
+                TIMEOUT=120
+              fi
+
+              TOPOLOGY=$(curl --silent --fail ${OPERATOR_CURL_OPTS} -m ${TIMEOUT} -X GET ${COH_TOPOLOGY_INFO_LOCATION})
+              if [[ $? != 0 ]]
+              then
+                  TOPOLOGY=""
+              else
+                  echo "Topology value: ${TOPOLOGY}"
+                  SITE=$(echo "${TOPOLOGY}" | sed -n 's/.*"site":"\([^"]*\)".*/\1/p')
+                  RACK=$(echo "${TOPOLOGY}" | sed -n 's/.*"rack":"\([^"]*\)".*/\1/p')
+              fi
+              ;;
+      esac
+    fi
+
+    if [[ "${GET_SITE}" != "" && "${TOPOLOGY}" == "" ]]
     then
       if [[ "${COH_SITE_INFO_LOCATION}" != "" ]]
       then
           case "${COH_SITE_INFO_LOCATION}" in
-              http://\$*)
+              http://\$*|https://\$*)
                   SITE=""
                   ;;
-              http://*)
+              http://*|https://*)
                   if [[ "${OPERATOR_REQUEST_TIMEOUT}" != "" ]]
                   then
                     TIMEOUT=${OPERATOR_REQUEST_TIMEOUT}
@@ -485,7 +513,7 @@ commonConfiguration()
                     TIMEOUT=120
                   fi
 
-                  SITE=$(curl --silent -m ${TIMEOUT} -X GET ${COH_SITE_INFO_LOCATION})
+                  SITE=$(curl --silent ${OPERATOR_CURL_OPTS} -m ${TIMEOUT} -X GET ${COH_SITE_INFO_LOCATION})
                   if [[ $? != 0 ]]
                   then
                       SITE=""
@@ -504,10 +532,10 @@ commonConfiguration()
       if [[ "${COH_RACK_INFO_LOCATION}" != "" ]]
       then
           case "${COH_RACK_INFO_LOCATION}" in
-              http://\$*)
+              http://\$*|https://\$*)
                   RACK=""
                   ;;
-              http://*)
+              http://*|https://*)
                   if [[ "${OPERATOR_REQUEST_TIMEOUT}" != "" ]]
                   then
                     TIMEOUT=${OPERATOR_REQUEST_TIMEOUT}
@@ -515,7 +543,7 @@ commonConfiguration()
                     TIMEOUT=30
                   fi
 
-                  RACK=$(curl --silent -m ${TIMEOUT} ${COH_RACK_INFO_LOCATION})
+                  RACK=$(curl --silent ${OPERATOR_CURL_OPTS} -m ${TIMEOUT} ${COH_RACK_INFO_LOCATION})
                   if [[ $? != 0 ]]
                   then
                       RACK=""
@@ -627,6 +655,38 @@ configure_12_2_1_4()
     }
 
 
+# ---------------------------------------------------------------------------
+# Set the curl options used to make requests to the Operator ReST server.
+# If the Operator serves HTTPS the Operator URLs are changed to use https
+# and the server is verified using the Operator's CA certificate.
+# If the Pod has a service account token it is sent as a bearer token.
+# ---------------------------------------------------------------------------
+operatorCurlOptions()
+    {
+    OPERATOR_CURL_OPTS=""
+    OPERATOR_CURL_DIR=${TMPDIR:-/tmp}
+
+    if [[ "${OPERATOR_TLS}" == "true" ]]
+    then
+        COH_TOPOLOGY_INFO_LOCATION=$(echo "${COH_TOPOLOGY_INFO_LOCATION}" | sed 's/^http:/https:/')
+        COH_SITE_INFO_LOCATION=$(echo "${COH_SITE_INFO_LOCATION}" | sed 's/^http:/https:/')
+        COH_RACK_INFO_LOCATION=$(echo "${COH_RACK_INFO_LOCATION}" | sed 's/^http:/https:/')
+        if [[ "${OPERATOR_CA}" != "" ]]
+        then
+            echo "${OPERATOR_CA}" > ${OPERATOR_CURL_DIR}/operator-ca.crt
+            OPERATOR_CURL_OPTS="${OPERATOR_CURL_OPTS} --cacert ${OPERATOR_CURL_DIR}/operator-ca.crt"
+        fi
+    fi
+
+    TOKEN_FILE=/var/run/secrets/kubernetes.io/serviceaccount/token
+    if [[ -f "${TOKEN_FILE}" ]]
+    then
+#       the token is passed in a header file so that it is not visible in the process arguments
+        (umask 077; echo "Authorization: Bearer $(cat ${TOKEN_FILE})" > ${OPERATOR_CURL_DIR}/operator-auth.header)
+        OPERATOR_CURL_OPTS="${OPERATOR_CURL_OPTS} -H @${OPERATOR_CURL_DIR}/operator-auth.header"
+    fi
+    }
+
 # ---------------------------------------------------------------------------
 # Add the configuration properties to enable SSL
 # on the management over ReST endpoint.
@@ -7,7 +7,12 @@
 package v1
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"time"
 )
 
@@ -42,6 +47,13 @@ const (
 
 	// The key of the label used to hold the Coherence Operator version name
 	CoherenceOperatorVersionLabel string = "coherenceOperatorVersion"
+
+	// The finalizer added to CoherenceClusters and CoherenceRoles so that the Operator
+	// can perform an ordered teardown of the cluster members when they are deleted
+	CoherenceFinalizer string = "coherence.oracle.com/finalizer"
+
+	// The annotation added to resources generated by the Operator holding the hash of the spec they were generated from
+	SpecHashAnnotation string = "coherence.oracle.com/spec-hash"
 )
 
 // ----- helper functions ---------------------------------------------------
@@ -74,6 +86,34 @@ func MergeMap(m1, m2 map[string]string) map[string]string {
 	return merged
 }
 
+// Returns true if the object has the CoherenceFinalizer.
+func HasFinalizer(o metav1.Object) bool {
+	for _, f := range o.GetFinalizers() {
+		if f == CoherenceFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns a hash of the json representation of a spec.
+// The json encoding sorts map keys so specs that only differ in map ordering have the same hash.
+// Only fields tagged omitempty are omitted when empty, so a nil field without that tag and the
+// same field set to an empty value can still give different hashes.
+func ComputeHash(spec interface{}) (string, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Returns the value of the SpecHashAnnotation on an object, or "" if the annotation is not present.
+func GetSpecHash(o metav1.Object) string {
+	return o.GetAnnotations()[SpecHashAnnotation]
+}
+
 // ----- ApplicationSpec struct ---------------------------------------------
 
 // The specification of the application deployed into the Coherence
@@ -207,6 +247,12 @@ type CoherenceSpec struct {
 	// to the same volume configured for persistence data in the Persistence section.
 	// +optional
 	Snapshot *PersistentStorageSpec `json:"snapshot,omitempty"`
+	// SnapshotOnDelete is a flag indicating whether a persistence snapshot of the role's
+	// partitioned cache services is created before the role is deleted. Creating a snapshot
+	// requires Coherence management over REST to be enabled for the role.
+	// If not specified the default value is false.
+	// +optional
+	SnapshotOnDelete *bool `json:"snapshotOnDelete,omitempty"`
 	// Management configures Coherence management over REST
 	//   Note: Coherence management over REST will be available in 12.2.1.4.
 	// +optional
@@ -264,6 +310,12 @@ func (in *CoherenceSpec) DeepCopyWithDefaults(defaults *CoherenceSpec) *Coherenc
 		clone.LogLevel = defaults.LogLevel
 	}
 
+	if in.SnapshotOnDelete != nil {
+		clone.SnapshotOnDelete = in.SnapshotOnDelete
+	} else {
+		clone.SnapshotOnDelete = defaults.SnapshotOnDelete
+	}
+
 	if in.ExcludeFromWKA != nil {
 		clone.ExcludeFromWKA = in.ExcludeFromWKA
 	} else {
@@ -273,6 +325,11 @@ func (in *CoherenceSpec) DeepCopyWithDefaults(defaults *CoherenceSpec) *Coherenc
 	return &clone
 }
 
+// IsSnapshotOnDelete returns true if a persistence snapshot should be created before this role is deleted.
+func (in *CoherenceSpec) IsSnapshotOnDelete() bool {
+	return in != nil && in.SnapshotOnDelete != nil && *in.SnapshotOnDelete
+}
+
 // IsWKAMember returns true if this role is a WKA list member.
 func (in *CoherenceSpec) IsWKAMember() bool {
 	return in != nil && (in.ExcludeFromWKA == nil || !*in.ExcludeFromWKA)
@@ -498,6 +555,11 @@ type PersistentStorageSpec struct {
 	Volume *corev1.VolumeSource `json:"volume,omitempty"` // from k8s.io/api/core/v1
 }
 
+// IsEnabled returns true if persistent storage is enabled.
+func (in *PersistentStorageSpec) IsEnabled() bool {
+	return in != nil && in.Enabled != nil && *in.Enabled
+}
+
 // DeepCopyWithDefaults returns a copy of this PersistentStorageSpec struct with any nil or not set values set
 // by the corresponding value in the defaults PersistentStorageSpec struct.
 func (in *PersistentStorageSpec) DeepCopyWithDefaults(defaults *PersistentStorageSpec) *PersistentStorageSpec {
@@ -1408,6 +1470,20 @@ type ScalingSpec struct {
 	// a different handler may be specified.
 	// +optional
 	Probe *ScalingProbe `json:"probe,omitempty"`
+	// The minimum Coherence partition HA status that every partitioned cache service must have
+	// before a member of the role can be removed. One of ENDANGERED, NODE-SAFE, MACHINE-SAFE,
+	// RACK-SAFE or SITE-SAFE. When set the HA status of each service is obtained using Coherence
+	// management over ReST, which must be enabled for the role, instead of the StatusHA probe.
+	// +optional
+	MinimumHAStatus *HAStatus `json:"minimumHAStatus,omitempty"`
+	// Overrides of the minimum HA status for individual partitioned cache services, keyed by service name.
+	// This allows services whose caches are permitted lower guarantees to be excluded from the minimum
+	// HA status. When set the HA status of each service is obtained using Coherence management over ReST.
+	// +optional
+	ServiceMinimumHAStatus map[string]HAStatus `json:"serviceMinimumHAStatus,omitempty"`
+	// Schedule is a list of scheduled changes to the number of replicas of the role.
+	// +optional
+	Schedule *ScalingSchedule `json:"schedule,omitempty"`
 }
 
 // DeepCopyWithDefaults returns a copy of this ScalingSpec struct with any nil or not set values set
@@ -1433,9 +1509,267 @@ func (in *ScalingSpec) DeepCopyWithDefaults(defaults *ScalingSpec) *ScalingSpec
 		clone.Policy = defaults.Policy
 	}
 
+	if in.MinimumHAStatus != nil {
+		clone.MinimumHAStatus = in.MinimumHAStatus
+	} else {
+		clone.MinimumHAStatus = defaults.MinimumHAStatus
+	}
+
+	// Schedule is NOT merged
+	if in.Schedule != nil {
+		clone.Schedule = in.Schedule.DeepCopy()
+	} else {
+		clone.Schedule = defaults.Schedule.DeepCopy()
+	}
+
+	if in.ServiceMinimumHAStatus != nil || defaults.ServiceMinimumHAStatus != nil {
+		clone.ServiceMinimumHAStatus = make(map[string]HAStatus)
+		for k, v := range defaults.ServiceMinimumHAStatus {
+			clone.ServiceMinimumHAStatus[k] = v
+		}
+		for k, v := range in.ServiceMinimumHAStatus {
+			clone.ServiceMinimumHAStatus[k] = v
+		}
+	}
+
+	return &clone
+}
+
+// HasSchedule returns true if the scaling spec has any scheduled changes to the number of replicas.
+func (in *ScalingSpec) HasSchedule() bool {
+	return in != nil && in.Schedule != nil && len(in.Schedule.Entries) > 0
+}
+
+// HasMinimumHAStatus returns true if a minimum HA status has been configured for all
+// services or for any individual service.
+func (in *ScalingSpec) HasMinimumHAStatus() bool {
+	return in != nil && (in.MinimumHAStatus != nil || len(in.ServiceMinimumHAStatus) > 0)
+}
+
+// GetMinimumHAStatus returns the minimum HA status required for the specified partitioned cache service.
+// A service specific override takes precedence over the minimum for all services. If neither is
+// configured the default of NODE-SAFE is returned.
+func (in *ScalingSpec) GetMinimumHAStatus(service string) HAStatus {
+	if in != nil {
+		if status, found := in.ServiceMinimumHAStatus[service]; found {
+			return status
+		}
+		if in.MinimumHAStatus != nil {
+			return *in.MinimumHAStatus
+		}
+	}
+	return HAStatusNodeSafe
+}
+
+// ----- ScalingSchedule -------------------------------------------------
+
+// ScalingSchedule is a list of scheduled changes to the number of replicas of a role.
+// +k8s:openapi-gen=true
+type ScalingSchedule struct {
+	// The name of the time zone that the schedule entries are evaluated in, for example "Europe/London".
+	// The default if not specified is UTC.
+	// +optional
+	TimeZone *string `json:"timeZone,omitempty"`
+	// The scheduled changes to the number of replicas of the role.
+	// +listType=atomic
+	// +optional
+	Entries []ScheduledReplicas `json:"entries,omitempty"`
+}
+
+// GetLocation returns the location of the schedule's time zone.
+func (in *ScalingSchedule) GetLocation() (*time.Location, error) {
+	if in == nil || in.TimeZone == nil || *in.TimeZone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(*in.TimeZone)
+}
+
+// ScheduledReplicas is a scheduled change to the number of replicas of a role.
+// +k8s:openapi-gen=true
+type ScheduledReplicas struct {
+	// Cron is a five field cron expression for when the role should be scaled, in the form
+	// "minute hour day-of-month month day-of-week", for example "0 7 * * MON-FRI" for 07:00 on weekdays.
+	Cron string `json:"cron"`
+	// The number of replicas to scale the role to.
+	Replicas int32 `json:"replicas"`
+}
+
+// ----- AutoscalingSpec -------------------------------------------------
+
+// AutoscalingSpec configures the Operator's built-in autoscaler for a role.
+// When enabled the Operator periodically reads metrics from the role's members using Coherence
+// management over ReST and scales the role between the minimum and maximum number of replicas so
+// that the average value of each metric is close to its target. Scaling down always uses safe scaling.
+// +k8s:openapi-gen=true
+type AutoscalingSpec struct {
+	// Enabled enables the autoscaler for the role. Coherence management over ReST must also be enabled.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+	// The minimum number of replicas the autoscaler can scale the role down to.
+	// The default if not specified is 1.
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// The maximum number of replicas the autoscaler can scale the role up to.
+	// If not specified the role will not be scaled above the minimum number of replicas.
+	// +optional
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+	// The number of seconds between evaluations of the metrics.
+	// The default if not specified is 60 seconds.
+	// +optional
+	IntervalSeconds *int32 `json:"intervalSeconds,omitempty"`
+	// The number of seconds after the role was last scaled by the autoscaler before it can be scaled down.
+	// The default if not specified is 300 seconds.
+	// +optional
+	ScaleDownDelaySeconds *int32 `json:"scaleDownDelaySeconds,omitempty"`
+	// The policies used to calculate the desired number of replicas.
+	// The largest number of replicas calculated from the policies is used.
+	// +listType=atomic
+	// +optional
+	Policies []AutoscalingPolicy `json:"policies,omitempty"`
+}
+
+// The default number of seconds between evaluations of the autoscaling metrics.
+const DefaultAutoscalingIntervalSeconds int32 = 60
+
+// The default number of seconds after a role was last autoscaled before it can be scaled down.
+const DefaultAutoscalingScaleDownDelaySeconds int32 = 300
+
+// IsEnabled returns true if the autoscaler is enabled.
+func (in *AutoscalingSpec) IsEnabled() bool {
+	return in != nil && in.Enabled != nil && *in.Enabled
+}
+
+// GetMinReplicas returns the minimum number of replicas the autoscaler can scale the role down to.
+func (in *AutoscalingSpec) GetMinReplicas() int32 {
+	if in == nil || in.MinReplicas == nil {
+		return 1
+	}
+	return *in.MinReplicas
+}
+
+// GetMaxReplicas returns the maximum number of replicas the autoscaler can scale the role up to.
+// If not set the minimum number of replicas is returned.
+func (in *AutoscalingSpec) GetMaxReplicas() int32 {
+	if in == nil || in.MaxReplicas == nil {
+		return in.GetMinReplicas()
+	}
+	return *in.MaxReplicas
+}
+
+// GetInterval returns the time between evaluations of the autoscaling metrics.
+func (in *AutoscalingSpec) GetInterval() time.Duration {
+	if in == nil || in.IntervalSeconds == nil || *in.IntervalSeconds <= 0 {
+		return time.Second * time.Duration(DefaultAutoscalingIntervalSeconds)
+	}
+	return time.Second * time.Duration(*in.IntervalSeconds)
+}
+
+// GetScaleDownDelay returns the time after the role was last autoscaled before it can be scaled down.
+func (in *AutoscalingSpec) GetScaleDownDelay() time.Duration {
+	if in == nil || in.ScaleDownDelaySeconds == nil || *in.ScaleDownDelaySeconds < 0 {
+		return time.Second * time.Duration(DefaultAutoscalingScaleDownDelaySeconds)
+	}
+	return time.Second * time.Duration(*in.ScaleDownDelaySeconds)
+}
+
+// DeepCopyWithDefaults returns a copy of this AutoscalingSpec struct with any nil or not set values set
+// by the corresponding value in the defaults AutoscalingSpec struct.
+func (in *AutoscalingSpec) DeepCopyWithDefaults(defaults *AutoscalingSpec) *AutoscalingSpec {
+	if in == nil {
+		if defaults != nil {
+			return defaults.DeepCopy()
+		}
+		return nil
+	}
+
+	if defaults == nil {
+		return in.DeepCopy()
+	}
+
+	clone := AutoscalingSpec{}
+
+	if in.Enabled != nil {
+		clone.Enabled = in.Enabled
+	} else {
+		clone.Enabled = defaults.Enabled
+	}
+
+	if in.MinReplicas != nil {
+		clone.MinReplicas = in.MinReplicas
+	} else {
+		clone.MinReplicas = defaults.MinReplicas
+	}
+
+	if in.MaxReplicas != nil {
+		clone.MaxReplicas = in.MaxReplicas
+	} else {
+		clone.MaxReplicas = defaults.MaxReplicas
+	}
+
+	if in.IntervalSeconds != nil {
+		clone.IntervalSeconds = in.IntervalSeconds
+	} else {
+		clone.IntervalSeconds = defaults.IntervalSeconds
+	}
+
+	if in.ScaleDownDelaySeconds != nil {
+		clone.ScaleDownDelaySeconds = in.ScaleDownDelaySeconds
+	} else {
+		clone.ScaleDownDelaySeconds = defaults.ScaleDownDelaySeconds
+	}
+
+	// Policies are NOT merged
+	if in.Policies != nil {
+		clone.Policies = make([]AutoscalingPolicy, len(in.Policies))
+		for i := range in.Policies {
+			in.Policies[i].DeepCopyInto(&clone.Policies[i])
+		}
+	} else if defaults.Policies != nil {
+		clone.Policies = make([]AutoscalingPolicy, len(defaults.Policies))
+		for i := range defaults.Policies {
+			defaults.Policies[i].DeepCopyInto(&clone.Policies[i])
+		}
+	}
+
 	return &clone
 }
 
+// ----- AutoscalingPolicy -----------------------------------------------
+
+// AutoscalingPolicy is a metric used by the autoscaler and the target average value of that metric per member.
+// +k8s:openapi-gen=true
+type AutoscalingPolicy struct {
+	// The metric to scale on, one of HeapUsage, PartitionsPerMember, RequestQueueDepth or CacheSize.
+	Metric AutoscalingMetric `json:"metric"`
+	// The name of the partitioned cache service to read the metric from. This applies to the
+	// PartitionsPerMember, RequestQueueDepth and CacheSize metrics. If not set the PartitionsPerMember
+	// and RequestQueueDepth metrics use the highest value from all partitioned cache services.
+	// +optional
+	Service *string `json:"service,omitempty"`
+	// The name of the cache to read the CacheSize metric from. This field is required for the CacheSize metric.
+	// +optional
+	Cache *string `json:"cache,omitempty"`
+	// The target average value of the metric per member of the role.
+	Target int64 `json:"target"`
+}
+
+// ----- AutoscalingMetric type ------------------------------------------
+
+// AutoscalingMetric is a Coherence metric that the autoscaler can scale a role on.
+type AutoscalingMetric string
+
+// Autoscaling metric constants
+const (
+	// HeapUsage is the percentage of the maximum heap used by the role's members.
+	HeapUsageMetric AutoscalingMetric = "HeapUsage"
+	// PartitionsPerMember is the number of partitions of a partitioned cache service per storage enabled member.
+	PartitionsPerMemberMetric AutoscalingMetric = "PartitionsPerMember"
+	// RequestQueueDepth is the number of tasks waiting to be executed by a service on the role's members.
+	RequestQueueDepthMetric AutoscalingMetric = "RequestQueueDepth"
+	// CacheSize is the number of entries of a cache held by the role's members.
+	CacheSizeMetric AutoscalingMetric = "CacheSize"
+)
+
 // ----- ScalingProbe ----------------------------------------------------
 
 // ScalingProbe is the handler that will be used to determine how to check for StatusHA in a CoherenceRole.
@@ -1667,6 +2001,134 @@ const (
 	ParallelUpSafeDownScaling ScalingPolicy = "ParallelUpSafeDown"
 )
 
+// ----- HAStatus type ------------------------------------------------------
+
+// HAStatus is the high availability status of a Coherence partitioned cache service.
+type HAStatus string
+
+// HA status constants, in increasing order of safety.
+const (
+	// ENDANGERED means that the loss of a single cluster member could cause data loss.
+	HAStatusEndangered HAStatus = "ENDANGERED"
+	// NODE-SAFE means that the loss of any single cluster member will not cause data loss.
+	HAStatusNodeSafe HAStatus = "NODE-SAFE"
+	// MACHINE-SAFE means that the loss of all of the cluster members on any single machine will not cause data loss.
+	HAStatusMachineSafe HAStatus = "MACHINE-SAFE"
+	// RACK-SAFE means that the loss of all of the cluster members in any single rack will not cause data loss.
+	HAStatusRackSafe HAStatus = "RACK-SAFE"
+	// SITE-SAFE means that the loss of all of the cluster members in any single site will not cause data loss.
+	HAStatusSiteSafe HAStatus = "SITE-SAFE"
+)
+
+// The HAStatusCode values reported by Coherence management for each HA status.
+var haStatusCodes = map[HAStatus]int{
+	HAStatusEndangered:  0,
+	HAStatusNodeSafe:    1,
+	HAStatusMachineSafe: 2,
+	HAStatusRackSafe:    3,
+	HAStatusSiteSafe:    4,
+}
+
+// Code returns the Coherence HAStatusCode for the HA status or -1 if the status is not a known HA status.
+func (in HAStatus) Code() int {
+	if code, found := haStatusCodes[in]; found {
+		return code
+	}
+	return -1
+}
+
+// IsValid returns true if the HA status is a known HA status.
+func (in HAStatus) IsValid() bool {
+	return in.Code() >= 0
+}
+
+// IsSatisfiedBy returns true if a service with the specified HAStatus and HAStatusCode, as reported
+// by Coherence management, is at least as safe as this HA status. The HAStatus name is used if it is
+// a known status, otherwise the HAStatusCode is used.
+func (in HAStatus) IsSatisfiedBy(status string, code int) bool {
+	if actual := HAStatus(status).Code(); actual >= 0 {
+		code = actual
+	}
+	return code >= in.Code()
+}
+
+// ----- UpgradePolicy type -------------------------------------------------
+
+// UpgradePolicy describes a policy for restarting the Pods of a cluster role when the role's spec changes
+type UpgradePolicy string
+
+// Upgrade policy constants
+const (
+	// Rolling means that the Pods of a role will be restarted by the StatefulSet's rolling update strategy.
+	RollingUpgrade UpgradePolicy = "Rolling"
+	// Safe means that the Operator will restart the Pods of a role one at a time, waiting for every partitioned
+	// cache service to be at least NODE-SAFE, or the role's configured minimum HA status, with no remaining
+	// partition transfers before restarting the next Pod.
+	SafeUpgrade UpgradePolicy = "Safe"
+)
+
+// ----- PodDisruptionBudgetSpec ---------------------------------------------
+
+// PodDisruptionBudgetSpec configures the PodDisruptionBudget that the Operator creates for a role.
+// Only one of MinAvailable and MaxUnavailable can be set, if neither is set MaxUnavailable is 1.
+// While the role is not StatusHA the Operator sets MaxUnavailable to zero so that no Pods can be evicted.
+// +k8s:openapi-gen=true
+type PodDisruptionBudgetSpec struct {
+	// Enabled controls whether the Operator creates a PodDisruptionBudget for the role.
+	// The default if not specified is true for storage enabled roles and false for storage disabled roles.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+	// The number or percentage of the role's Pods that must still be available after an eviction.
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+	// The number or percentage of the role's Pods that can be unavailable after an eviction.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// IsEnabled returns true if a PodDisruptionBudget should be created for a role,
+// where the default depends on whether the role is storage enabled.
+func (in *PodDisruptionBudgetSpec) IsEnabled(storageEnabled bool) bool {
+	if in == nil || in.Enabled == nil {
+		return storageEnabled
+	}
+	return *in.Enabled
+}
+
+// DeepCopyWithDefaults returns a copy of this PodDisruptionBudgetSpec struct with any nil or not set values set
+// by the corresponding value in the defaults PodDisruptionBudgetSpec struct.
+func (in *PodDisruptionBudgetSpec) DeepCopyWithDefaults(defaults *PodDisruptionBudgetSpec) *PodDisruptionBudgetSpec {
+	if in == nil {
+		if defaults != nil {
+			return defaults.DeepCopy()
+		}
+		return nil
+	}
+
+	if defaults == nil {
+		return in.DeepCopy()
+	}
+
+	clone := PodDisruptionBudgetSpec{}
+
+	if in.Enabled != nil {
+		clone.Enabled = in.Enabled
+	} else {
+		clone.Enabled = defaults.Enabled
+	}
+
+	// MinAvailable and MaxUnavailable are mutually exclusive so are NOT merged individually
+	if in.MinAvailable != nil || in.MaxUnavailable != nil {
+		clone.MinAvailable = in.MinAvailable
+		clone.MaxUnavailable = in.MaxUnavailable
+	} else {
+		clone.MinAvailable = defaults.MinAvailable
+		clone.MaxUnavailable = defaults.MaxUnavailable
+	}
+
+	return &clone
+}
+
 // ----- LocalObjectReference -----------------------------------------------
 
 // LocalObjectReference contains enough information to let you locate the
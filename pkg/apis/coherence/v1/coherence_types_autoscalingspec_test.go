@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package v1_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	coherence "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"time"
+)
+
+var _ = Describe("Testing AutoscalingSpec struct", func() {
+
+	Context("Copying an AutoscalingSpec using DeepCopyWithDefaults", func() {
+		var original *coherence.AutoscalingSpec
+		var defaults *coherence.AutoscalingSpec
+		var clone *coherence.AutoscalingSpec
+
+		specOne := &coherence.AutoscalingSpec{
+			Enabled:               boolPtr(true),
+			MinReplicas:           int32Ptr(2),
+			MaxReplicas:           int32Ptr(10),
+			IntervalSeconds:       int32Ptr(30),
+			ScaleDownDelaySeconds: int32Ptr(600),
+			Policies:              []coherence.AutoscalingPolicy{{Metric: coherence.HeapUsageMetric, Target: 70}},
+		}
+
+		specTwo := &coherence.AutoscalingSpec{
+			Enabled:               boolPtr(false),
+			MinReplicas:           int32Ptr(3),
+			MaxReplicas:           int32Ptr(6),
+			IntervalSeconds:       int32Ptr(120),
+			ScaleDownDelaySeconds: int32Ptr(60),
+			Policies: []coherence.AutoscalingPolicy{
+				{Metric: coherence.CacheSizeMetric, Cache: stringPtr("test"), Target: 1000},
+			},
+		}
+
+		JustBeforeEach(func() {
+			clone = original.DeepCopyWithDefaults(defaults)
+		})
+
+		When("original and defaults are nil", func() {
+			BeforeEach(func() {
+				original = nil
+				defaults = nil
+			})
+
+			It("the copy should be nil", func() {
+				Expect(clone).Should(BeNil())
+			})
+		})
+
+		When("defaults is nil", func() {
+			BeforeEach(func() {
+				original = specOne
+				defaults = nil
+			})
+
+			It("should copy the original", func() {
+				Expect(clone).To(Equal(original))
+			})
+		})
+
+		When("original is nil", func() {
+			BeforeEach(func() {
+				original = nil
+				defaults = specTwo
+			})
+
+			It("should copy the defaults", func() {
+				Expect(clone).To(Equal(defaults))
+			})
+		})
+
+		When("all original fields are set", func() {
+			BeforeEach(func() {
+				original = specOne
+				defaults = specTwo
+			})
+
+			It("should copy the original", func() {
+				Expect(clone).To(Equal(original))
+			})
+		})
+
+		When("original fields are not set", func() {
+			BeforeEach(func() {
+				original = &coherence.AutoscalingSpec{MaxReplicas: int32Ptr(20)}
+				defaults = specTwo
+			})
+
+			It("should use the defaults for the fields that are not set", func() {
+				expected := specTwo.DeepCopy()
+				expected.MaxReplicas = int32Ptr(20)
+				Expect(clone).To(Equal(expected))
+			})
+		})
+	})
+
+	Context("Getting autoscaling values", func() {
+		It("should not be enabled if nil", func() {
+			var spec *coherence.AutoscalingSpec
+			Expect(spec.IsEnabled()).To(BeFalse())
+		})
+
+		It("should use the default values", func() {
+			spec := &coherence.AutoscalingSpec{}
+			Expect(spec.GetMinReplicas()).To(Equal(int32(1)))
+			Expect(spec.GetMaxReplicas()).To(Equal(int32(1)))
+			Expect(spec.GetInterval()).To(Equal(time.Minute))
+			Expect(spec.GetScaleDownDelay()).To(Equal(time.Minute * 5))
+		})
+
+		It("should use the configured values", func() {
+			spec := &coherence.AutoscalingSpec{
+				MinReplicas:           int32Ptr(2),
+				MaxReplicas:           int32Ptr(8),
+				IntervalSeconds:       int32Ptr(10),
+				ScaleDownDelaySeconds: int32Ptr(0),
+			}
+			Expect(spec.GetMinReplicas()).To(Equal(int32(2)))
+			Expect(spec.GetMaxReplicas()).To(Equal(int32(8)))
+			Expect(spec.GetInterval()).To(Equal(time.Second * 10))
+			Expect(spec.GetScaleDownDelay()).To(Equal(time.Duration(0)))
+		})
+	})
+})
@@ -0,0 +1,71 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package v1_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	coherence "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var _ = Describe("Testing PodDisruptionBudgetSpec struct", func() {
+
+	one := intstr.FromInt(1)
+	half := intstr.FromString("50%")
+
+	Context("Copying a PodDisruptionBudgetSpec using DeepCopyWithDefaults", func() {
+		It("should copy nil original and nil defaults as nil", func() {
+			var original *coherence.PodDisruptionBudgetSpec
+			Expect(original.DeepCopyWithDefaults(nil)).To(BeNil())
+		})
+
+		It("should use the enabled flag from the defaults if not set in the original", func() {
+			original := &coherence.PodDisruptionBudgetSpec{MaxUnavailable: &one}
+			defaults := &coherence.PodDisruptionBudgetSpec{Enabled: boolPtr(false)}
+			clone := original.DeepCopyWithDefaults(defaults)
+			Expect(*clone.Enabled).To(BeFalse())
+			Expect(clone.MaxUnavailable).To(Equal(&one))
+		})
+
+		It("should not merge the minimum available with the maximum unavailable from the defaults", func() {
+			original := &coherence.PodDisruptionBudgetSpec{MinAvailable: &half}
+			defaults := &coherence.PodDisruptionBudgetSpec{MaxUnavailable: &one}
+			clone := original.DeepCopyWithDefaults(defaults)
+			Expect(clone.MinAvailable).To(Equal(&half))
+			Expect(clone.MaxUnavailable).To(BeNil())
+		})
+
+		It("should use the budget from the defaults if not set in the original", func() {
+			original := &coherence.PodDisruptionBudgetSpec{Enabled: boolPtr(true)}
+			defaults := &coherence.PodDisruptionBudgetSpec{MinAvailable: &half}
+			clone := original.DeepCopyWithDefaults(defaults)
+			Expect(*clone.Enabled).To(BeTrue())
+			Expect(clone.MinAvailable).To(Equal(&half))
+		})
+	})
+
+	Context("Determining whether a PodDisruptionBudget is enabled", func() {
+		It("should default to enabled for storage enabled roles", func() {
+			spec := coherence.CoherenceRoleSpec{}
+			Expect(spec.IsPodDisruptionBudgetEnabled()).To(BeTrue())
+		})
+
+		It("should default to disabled for storage disabled roles", func() {
+			spec := coherence.CoherenceRoleSpec{Coherence: &coherence.CoherenceSpec{StorageEnabled: boolPtr(false)}}
+			Expect(spec.IsPodDisruptionBudgetEnabled()).To(BeFalse())
+		})
+
+		It("should use the enabled flag if set", func() {
+			spec := coherence.CoherenceRoleSpec{
+				Coherence:           &coherence.CoherenceSpec{StorageEnabled: boolPtr(false)},
+				PodDisruptionBudget: &coherence.PodDisruptionBudgetSpec{Enabled: boolPtr(true)},
+			}
+			Expect(spec.IsPodDisruptionBudgetEnabled()).To(BeTrue())
+		})
+	})
+})
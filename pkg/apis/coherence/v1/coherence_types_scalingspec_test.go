@@ -0,0 +1,155 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package v1_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	coherence "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"time"
+)
+
+var _ = Describe("Testing ScalingSpec struct", func() {
+
+	machineSafe := coherence.HAStatusMachineSafe
+	rackSafe := coherence.HAStatusRackSafe
+
+	Context("Copying a ScalingSpec using DeepCopyWithDefaults", func() {
+		It("should use the minimum HA status from the original", func() {
+			original := &coherence.ScalingSpec{MinimumHAStatus: &rackSafe}
+			defaults := &coherence.ScalingSpec{MinimumHAStatus: &machineSafe}
+			clone := original.DeepCopyWithDefaults(defaults)
+			Expect(*clone.MinimumHAStatus).To(Equal(coherence.HAStatusRackSafe))
+		})
+
+		It("should use the minimum HA status from the defaults if not set in the original", func() {
+			original := &coherence.ScalingSpec{}
+			defaults := &coherence.ScalingSpec{MinimumHAStatus: &machineSafe}
+			clone := original.DeepCopyWithDefaults(defaults)
+			Expect(*clone.MinimumHAStatus).To(Equal(coherence.HAStatusMachineSafe))
+		})
+
+		It("should merge the service minimum HA statuses", func() {
+			original := &coherence.ScalingSpec{
+				ServiceMinimumHAStatus: map[string]coherence.HAStatus{
+					"One": coherence.HAStatusEndangered,
+					"Two": coherence.HAStatusNodeSafe,
+				},
+			}
+			defaults := &coherence.ScalingSpec{
+				ServiceMinimumHAStatus: map[string]coherence.HAStatus{
+					"Two":   coherence.HAStatusSiteSafe,
+					"Three": coherence.HAStatusRackSafe,
+				},
+			}
+			clone := original.DeepCopyWithDefaults(defaults)
+			Expect(clone.ServiceMinimumHAStatus).To(Equal(map[string]coherence.HAStatus{
+				"One":   coherence.HAStatusEndangered,
+				"Two":   coherence.HAStatusNodeSafe,
+				"Three": coherence.HAStatusRackSafe,
+			}))
+		})
+	})
+
+	Context("Getting the minimum HA status for a service", func() {
+		It("should be NODE-SAFE for a nil ScalingSpec", func() {
+			var spec *coherence.ScalingSpec
+			Expect(spec.HasMinimumHAStatus()).To(BeFalse())
+			Expect(spec.GetMinimumHAStatus("Foo")).To(Equal(coherence.HAStatusNodeSafe))
+		})
+
+		It("should use the minimum HA status for all services", func() {
+			spec := &coherence.ScalingSpec{MinimumHAStatus: &machineSafe}
+			Expect(spec.HasMinimumHAStatus()).To(BeTrue())
+			Expect(spec.GetMinimumHAStatus("Foo")).To(Equal(coherence.HAStatusMachineSafe))
+		})
+
+		It("should use the service override", func() {
+			spec := &coherence.ScalingSpec{
+				MinimumHAStatus:        &machineSafe,
+				ServiceMinimumHAStatus: map[string]coherence.HAStatus{"Foo": coherence.HAStatusEndangered},
+			}
+			Expect(spec.GetMinimumHAStatus("Foo")).To(Equal(coherence.HAStatusEndangered))
+			Expect(spec.GetMinimumHAStatus("Bar")).To(Equal(coherence.HAStatusMachineSafe))
+		})
+
+		It("should have a minimum HA status when only a service override is set", func() {
+			spec := &coherence.ScalingSpec{
+				ServiceMinimumHAStatus: map[string]coherence.HAStatus{"Foo": coherence.HAStatusSiteSafe},
+			}
+			Expect(spec.HasMinimumHAStatus()).To(BeTrue())
+			Expect(spec.GetMinimumHAStatus("Bar")).To(Equal(coherence.HAStatusNodeSafe))
+		})
+	})
+
+	Context("Copying a ScalingSpec with a schedule using DeepCopyWithDefaults", func() {
+		scheduleOne := &coherence.ScalingSchedule{Entries: []coherence.ScheduledReplicas{{Cron: "0 7 * * *", Replicas: 6}}}
+		scheduleTwo := &coherence.ScalingSchedule{Entries: []coherence.ScheduledReplicas{{Cron: "0 19 * * *", Replicas: 3}}}
+
+		It("should use the schedule from the original", func() {
+			original := &coherence.ScalingSpec{Schedule: scheduleOne}
+			defaults := &coherence.ScalingSpec{Schedule: scheduleTwo}
+			clone := original.DeepCopyWithDefaults(defaults)
+			Expect(clone.Schedule).To(Equal(scheduleOne))
+			Expect(clone.HasSchedule()).To(BeTrue())
+		})
+
+		It("should use the schedule from the defaults if not set in the original", func() {
+			original := &coherence.ScalingSpec{}
+			defaults := &coherence.ScalingSpec{Schedule: scheduleTwo}
+			clone := original.DeepCopyWithDefaults(defaults)
+			Expect(clone.Schedule).To(Equal(scheduleTwo))
+		})
+
+		It("should not have a schedule without entries", func() {
+			spec := &coherence.ScalingSpec{Schedule: &coherence.ScalingSchedule{}}
+			Expect(spec.HasSchedule()).To(BeFalse())
+		})
+	})
+
+	Context("Getting the location of a ScalingSchedule", func() {
+		It("should be UTC if no time zone is set", func() {
+			loc, err := (&coherence.ScalingSchedule{}).GetLocation()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loc).To(Equal(time.UTC))
+		})
+
+		It("should load the time zone", func() {
+			loc, err := (&coherence.ScalingSchedule{TimeZone: stringPtr("Europe/London")}).GetLocation()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loc.String()).To(Equal("Europe/London"))
+		})
+
+		It("should fail for an unknown time zone", func() {
+			_, err := (&coherence.ScalingSchedule{TimeZone: stringPtr("Foo/Bar")}).GetLocation()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("Comparing HA statuses", func() {
+		It("should be satisfied by the same status", func() {
+			Expect(coherence.HAStatusMachineSafe.IsSatisfiedBy("MACHINE-SAFE", 2)).To(BeTrue())
+		})
+
+		It("should be satisfied by a safer status", func() {
+			Expect(coherence.HAStatusMachineSafe.IsSatisfiedBy("SITE-SAFE", 4)).To(BeTrue())
+		})
+
+		It("should not be satisfied by a less safe status", func() {
+			Expect(coherence.HAStatusMachineSafe.IsSatisfiedBy("NODE-SAFE", 1)).To(BeFalse())
+		})
+
+		It("should use the status code if the status name is not known", func() {
+			Expect(coherence.HAStatusMachineSafe.IsSatisfiedBy("", 3)).To(BeTrue())
+			Expect(coherence.HAStatusMachineSafe.IsSatisfiedBy("", 0)).To(BeFalse())
+		})
+
+		It("should not be valid for an unknown status", func() {
+			Expect(coherence.HAStatus("FOO-SAFE").IsValid()).To(BeFalse())
+		})
+	})
+})
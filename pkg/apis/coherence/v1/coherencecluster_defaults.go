@@ -0,0 +1,38 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package v1
+
+// SetDefaults sets the default values for any fields in the CoherenceCluster spec that have
+// not been set so that the stored spec reflects what the operator will actually deploy.
+// The scaling policy of a role is only set once the storage enabled flag that decides it has been
+// set, either on the role or in the cluster's default role spec.
+// The images are the operator's default Coherence and Coherence Utils images, either may be nil.
+func (in *CoherenceCluster) SetDefaults(coherenceImage, utilsImage *string) {
+	if in == nil {
+		return
+	}
+
+	if len(in.Spec.Roles) == 0 {
+		in.Spec.CoherenceRoleSpec.SetDefaults(coherenceImage, utilsImage)
+		return
+	}
+
+	// values common to all roles are set in the cluster level defaults
+	// so that they are still inherited by roles that do not override them
+	in.Spec.CoherenceRoleSpec.setCommonDefaults(coherenceImage, utilsImage)
+
+	defaults := in.Spec.CoherenceRoleSpec
+	for i := range in.Spec.Roles {
+		role := &in.Spec.Roles[i]
+		if role.Role == "" {
+			role.Role = role.GetRoleName()
+		}
+
+		// the effective scaling policy depends on the merged role spec
+		role.setScalingPolicyDefault(role.DeepCopyWithDefaults(&defaults))
+	}
+}
@@ -0,0 +1,165 @@
+/*
+ * Copyright (c) 2020, Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package v1_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	coherence "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+)
+
+var _ = Describe("Setting CoherenceCluster defaults", func() {
+	var (
+		cohImage   = stringPtr("coherence:1.0")
+		utilsImage = stringPtr("utils:1.0")
+	)
+
+	It("should set the defaults in a cluster with no roles", func() {
+		cluster := &coherence.CoherenceCluster{}
+		cluster.SetDefaults(cohImage, utilsImage)
+
+		spec := cluster.Spec.CoherenceRoleSpec
+		Expect(spec.Role).To(Equal(coherence.DefaultRoleName))
+		Expect(spec.Replicas).To(Equal(int32Ptr(coherence.DefaultReplicas)))
+		Expect(spec.HealthPort).To(Equal(int32Ptr(coherence.DefaultHealthPort)))
+		Expect(spec.Scaling).To(BeNil())
+		Expect(spec.GetEffectiveScalingPolicy()).To(Equal(coherence.ParallelUpSafeDownScaling))
+		Expect(spec.GetCoherenceImage()).To(Equal(cohImage))
+		Expect(spec.GetCoherenceUtilsImage()).To(Equal(utilsImage))
+	})
+
+	It("should not overwrite fields that are already set", func() {
+		policy := coherence.SafeScaling
+		cluster := &coherence.CoherenceCluster{}
+		cluster.Spec.Role = "data"
+		cluster.Spec.Replicas = int32Ptr(1)
+		cluster.Spec.HealthPort = int32Ptr(1234)
+		cluster.Spec.Scaling = &coherence.ScalingSpec{Policy: &policy}
+		cluster.Spec.Coherence = &coherence.CoherenceSpec{ImageSpec: coherence.ImageSpec{Image: stringPtr("foo:1.0")}}
+
+		expected := cluster.DeepCopy()
+		expected.Spec.CoherenceUtils = &coherence.ImageSpec{Image: utilsImage}
+
+		cluster.SetDefaults(cohImage, utilsImage)
+		Expect(cluster).To(Equal(expected))
+	})
+
+	It("should not set images if there are no default images", func() {
+		cluster := &coherence.CoherenceCluster{}
+		cluster.SetDefaults(nil, nil)
+
+		Expect(cluster.Spec.Coherence).To(BeNil())
+		Expect(cluster.Spec.CoherenceUtils).To(BeNil())
+	})
+
+	It("should set the defaults in a cluster with roles", func() {
+		cluster := &coherence.CoherenceCluster{}
+		cluster.Spec.Replicas = int32Ptr(2)
+		cluster.Spec.Roles = []coherence.CoherenceRoleSpec{
+			{},
+			{Role: "proxy", Replicas: int32Ptr(1), Coherence: &coherence.CoherenceSpec{StorageEnabled: boolPtr(false)}},
+		}
+		cluster.SetDefaults(cohImage, utilsImage)
+
+		Expect(cluster.Spec.Replicas).To(Equal(int32Ptr(2)))
+		Expect(cluster.Spec.HealthPort).To(Equal(int32Ptr(coherence.DefaultHealthPort)))
+		Expect(cluster.Spec.GetCoherenceImage()).To(Equal(cohImage))
+		Expect(cluster.Spec.GetCoherenceUtilsImage()).To(Equal(utilsImage))
+
+		storage := cluster.Spec.Roles[0]
+		Expect(storage.Role).To(Equal(coherence.DefaultRoleName))
+		Expect(storage.Replicas).To(BeNil())
+		Expect(storage.Scaling).To(BeNil())
+
+		proxy := cluster.Spec.Roles[1]
+		Expect(proxy.Role).To(Equal("proxy"))
+		Expect(proxy.Replicas).To(Equal(int32Ptr(1)))
+		Expect(*proxy.Scaling.Policy).To(Equal(coherence.ParallelScaling))
+
+		roles := cluster.GetRoles()
+		effective := roles[coherence.DefaultRoleName]
+		Expect(effective.GetEffectiveScalingPolicy()).To(Equal(coherence.ParallelUpSafeDownScaling))
+		effectiveProxy := roles["proxy"]
+		Expect(effectiveProxy.GetEffectiveScalingPolicy()).To(Equal(coherence.ParallelScaling))
+		Expect(effective.GetCoherenceImage()).To(Equal(cohImage))
+		Expect(effective.Replicas).To(Equal(int32Ptr(2)))
+		Expect(roles["proxy"].HealthPort).To(Equal(int32Ptr(coherence.DefaultHealthPort)))
+	})
+
+	It("should resolve the scaling policy of a role from its current spec", func() {
+		cluster := &coherence.CoherenceCluster{}
+		cluster.Spec.Roles = []coherence.CoherenceRoleSpec{{Role: "data"}}
+		cluster.SetDefaults(cohImage, utilsImage)
+		role := cluster.GetRoles()["data"]
+		Expect(role.GetEffectiveScalingPolicy()).To(Equal(coherence.ParallelUpSafeDownScaling))
+
+		// storage is disabled after the defaults were set
+		cluster.Spec.Roles[0].Coherence = &coherence.CoherenceSpec{StorageEnabled: boolPtr(false)}
+		cluster.SetDefaults(cohImage, utilsImage)
+		role = cluster.GetRoles()["data"]
+		Expect(role.GetEffectiveScalingPolicy()).To(Equal(coherence.ParallelScaling))
+	})
+
+	It("should set the scaling policy of a role that inherits the storage enabled flag", func() {
+		cluster := &coherence.CoherenceCluster{}
+		cluster.Spec.Coherence = &coherence.CoherenceSpec{StorageEnabled: boolPtr(true)}
+		cluster.Spec.Roles = []coherence.CoherenceRoleSpec{{Role: "data"}}
+		cluster.SetDefaults(cohImage, utilsImage)
+
+		Expect(cluster.Spec.Scaling).To(BeNil())
+		Expect(*cluster.Spec.Roles[0].Scaling.Policy).To(Equal(coherence.ParallelUpSafeDownScaling))
+	})
+
+	It("should set the scaling policy of a cluster with no roles that sets the storage enabled flag", func() {
+		cluster := &coherence.CoherenceCluster{}
+		cluster.Spec.Coherence = &coherence.CoherenceSpec{StorageEnabled: boolPtr(false)}
+		cluster.SetDefaults(cohImage, utilsImage)
+
+		Expect(*cluster.Spec.Scaling.Policy).To(Equal(coherence.ParallelScaling))
+	})
+
+	It("should not set the scaling policy of a role that inherits a scaling policy", func() {
+		policy := coherence.SafeScaling
+		cluster := &coherence.CoherenceCluster{}
+		cluster.Spec.Scaling = &coherence.ScalingSpec{Policy: &policy}
+		cluster.Spec.Roles = []coherence.CoherenceRoleSpec{{Role: "data", Coherence: &coherence.CoherenceSpec{StorageEnabled: boolPtr(false)}}}
+		cluster.SetDefaults(cohImage, utilsImage)
+
+		Expect(cluster.Spec.Roles[0].Scaling).To(BeNil())
+		role := cluster.GetRoles()["data"]
+		Expect(role.GetEffectiveScalingPolicy()).To(Equal(coherence.SafeScaling))
+	})
+
+	It("should not change the effective role specs when defaults are set twice", func() {
+		cluster := &coherence.CoherenceCluster{}
+		cluster.Spec.Roles = []coherence.CoherenceRoleSpec{
+			{Role: "data"},
+			{Role: "proxy", Coherence: &coherence.CoherenceSpec{StorageEnabled: boolPtr(false)}},
+		}
+		cluster.SetDefaults(cohImage, utilsImage)
+
+		expected := cluster.DeepCopy()
+		cluster.SetDefaults(stringPtr("coherence:2.0"), stringPtr("utils:2.0"))
+		Expect(cluster).To(Equal(expected))
+	})
+
+	It("should not change a CoherenceRole created from a defaulted cluster", func() {
+		cluster := &coherence.CoherenceCluster{}
+		cluster.Spec.Roles = []coherence.CoherenceRoleSpec{
+			{Role: "data"},
+			{Role: "proxy", Coherence: &coherence.CoherenceSpec{StorageEnabled: boolPtr(false)}},
+		}
+		cluster.SetDefaults(cohImage, utilsImage)
+
+		for _, spec := range cluster.GetRoles() {
+			role := &coherence.CoherenceRole{Spec: spec}
+			expected := role.DeepCopy()
+			role.SetDefaults(cohImage, utilsImage)
+			Expect(role).To(Equal(expected))
+		}
+	})
+})
@@ -7,7 +7,9 @@
 package v1
 
 import (
+	"fmt"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"strings"
 	"time"
 )
 
@@ -84,6 +86,16 @@ type CoherenceClusterStatus struct {
 	// +listType=map
 	// +listMapKey=role
 	RoleStatus []ClusterRoleStatus `json:"roleStatus,omitempty"`
+	// ObservedGeneration is the most recent generation of the cluster that has been reconciled by the Operator.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The status conditions of the cluster.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions Conditions `json:"conditions,omitempty"`
 }
 
 // Set the CoherenceRoleSpec
@@ -188,6 +200,47 @@ func init() {
 	SchemeBuilder.Register(&CoherenceCluster{}, &CoherenceClusterList{})
 }
 
+// SetCondition sets a status condition on the cluster observed at the cluster's current generation.
+// Returns true if the cluster's conditions were changed.
+func (in *CoherenceCluster) SetCondition(t ConditionType, status bool, reason, message string) bool {
+	if in == nil {
+		return false
+	}
+	return in.Status.Conditions.SetCondition(NewCondition(t, status, in.Generation, reason, message))
+}
+
+// UpdateConditions sets the Available, Progressing and Degraded conditions of the cluster from the status
+// of its roles. The cluster is available when all of its roles are ready and degraded if any role has failed.
+func (in *CoherenceCluster) UpdateConditions() {
+	if in == nil {
+		return
+	}
+
+	var failed []string
+	for _, r := range in.Status.RoleStatus {
+		if r.Status == RoleStatusFailed {
+			failed = append(failed, r.Role)
+		}
+	}
+
+	available := in.Status.Roles > 0 && in.Status.Ready >= in.Status.Roles
+	readyMessage := fmt.Sprintf("%d of %d roles are ready", in.Status.Ready, in.Status.Roles)
+
+	if available {
+		in.SetCondition(ConditionAvailable, true, ReasonRolesReady, readyMessage)
+		in.SetCondition(ConditionProgressing, false, ReasonRolesReady, readyMessage)
+	} else {
+		in.SetCondition(ConditionAvailable, false, ReasonRolesNotReady, readyMessage)
+		in.SetCondition(ConditionProgressing, len(failed) == 0, ReasonRolesNotReady, readyMessage)
+	}
+
+	if len(failed) > 0 {
+		in.SetCondition(ConditionDegraded, true, ReasonRoleFailed, "failed roles: "+strings.Join(failed, ", "))
+	} else {
+		in.SetCondition(ConditionDegraded, false, ReasonReconcileSucceeded, "")
+	}
+}
+
 func (in *CoherenceCluster) GetWkaServiceName() string {
 	if in == nil {
 		return ""
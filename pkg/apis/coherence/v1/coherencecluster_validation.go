@@ -0,0 +1,176 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package v1
+
+import (
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"strings"
+)
+
+// Validate validates a CoherenceCluster returning an error if the cluster spec is invalid.
+func (in *CoherenceCluster) Validate() error {
+	if in == nil {
+		return nil
+	}
+	return toInvalidError("CoherenceCluster", in.Name, in.validateSpec())
+}
+
+// ValidateUpdate validates an update to a CoherenceCluster returning an error if the updated
+// cluster spec is invalid or changes fields of existing roles that cannot be changed.
+func (in *CoherenceCluster) ValidateUpdate(previous *CoherenceCluster) error {
+	if in == nil {
+		return nil
+	}
+
+	if in.GetDeletionTimestamp() != nil {
+		// the cluster is being deleted so allow the update, for example to remove finalizers
+		return nil
+	}
+
+	errs := in.validateSpec()
+
+	if previous != nil {
+		oldRoles := previous.GetRoles()
+		for name, role := range in.GetRoles() {
+			if oldRole, found := oldRoles[name]; found {
+				errs = append(errs, role.validateImmutableFields(&oldRole, in.rolePath(name))...)
+			}
+		}
+	}
+
+	return toInvalidError("CoherenceCluster", in.Name, errs)
+}
+
+// validateSpec validates the CoherenceClusterSpec.
+func (in *CoherenceCluster) validateSpec() field.ErrorList {
+	var errs field.ErrorList
+
+	specPath := field.NewPath("spec")
+
+	if len(in.Spec.Roles) == 0 {
+		errs = append(errs, in.Spec.CoherenceRoleSpec.validate(specPath)...)
+		return append(errs, in.Spec.CoherenceRoleSpec.validateManagementRequired(specPath)...)
+	}
+
+	// the default role spec is still validated as its values apply to all roles
+	errs = append(errs, in.Spec.CoherenceRoleSpec.validateReplicas(specPath)...)
+	errs = append(errs, in.Spec.CoherenceRoleSpec.validateScalingPolicy(specPath)...)
+	errs = append(errs, in.Spec.CoherenceRoleSpec.validateUpgradePolicy(specPath)...)
+	errs = append(errs, in.Spec.CoherenceRoleSpec.validateMinimumHAStatus(specPath)...)
+	errs = append(errs, in.Spec.CoherenceRoleSpec.validateAutoscaling(specPath)...)
+	errs = append(errs, in.Spec.CoherenceRoleSpec.validateScalingSchedule(specPath)...)
+	errs = append(errs, in.Spec.CoherenceRoleSpec.validatePodDisruptionBudget(specPath)...)
+
+	rolesPath := specPath.Child("roles")
+	names := make(map[string]bool)
+	for i, role := range in.Spec.Roles {
+		rolePath := rolesPath.Index(i)
+		name := role.GetRoleName()
+		if names[name] {
+			errs = append(errs, field.Duplicate(rolePath.Child("role"), name))
+		}
+		names[name] = true
+		errs = append(errs, role.validate(rolePath)...)
+		// the role may inherit its scaling and management settings from the default role spec
+		effective := role.DeepCopyWithDefaults(&in.Spec.CoherenceRoleSpec)
+		errs = append(errs, effective.validateManagementRequired(rolePath)...)
+	}
+
+	for i, role := range in.Spec.Roles {
+		quorumPath := rolesPath.Index(i).Child("startQuorum")
+		for j, q := range role.StartQuorum {
+			if !names[q.Role] {
+				errs = append(errs, field.NotFound(quorumPath.Index(j).Child("role"), q.Role))
+			}
+		}
+	}
+
+	if cycle := in.findStartQuorumCycle(); len(cycle) > 0 {
+		msg := "start quorum dependencies form a cycle: " + strings.Join(cycle, " -> ")
+		errs = append(errs, field.Invalid(rolesPath, cycle[0], msg))
+	}
+
+	return errs
+}
+
+// findStartQuorumCycle returns the names of the roles forming a cycle in the start
+// quorum dependencies, or an empty slice if there is no cycle.
+func (in *CoherenceCluster) findStartQuorumCycle() []string {
+	dependencies := make(map[string][]string)
+	var names []string
+	for _, role := range in.Spec.Roles {
+		name := role.GetRoleName()
+		names = append(names, name)
+		for _, q := range role.StartQuorum {
+			dependencies[name] = append(dependencies[name], q.Role)
+		}
+	}
+
+	const (
+		visiting = iota + 1
+		visited
+	)
+
+	state := make(map[string]int)
+	var path []string
+	var visit func(name string) []string
+
+	visit = func(name string) []string {
+		switch state[name] {
+		case visiting:
+			// found a cycle, return the part of the path that forms it
+			for i, n := range path {
+				if n == name {
+					return append(append([]string{}, path[i:]...), name)
+				}
+			}
+			return []string{name, name}
+		case visited:
+			return nil
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+		for _, dependency := range dependencies[name] {
+			if cycle := visit(dependency); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+
+	for _, name := range names {
+		if cycle := visit(name); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// rolePath returns the field path of the spec for the named role.
+func (in *CoherenceCluster) rolePath(name string) *field.Path {
+	specPath := field.NewPath("spec")
+	for i, role := range in.Spec.Roles {
+		if role.GetRoleName() == name {
+			return specPath.Child("roles").Index(i)
+		}
+	}
+	return specPath
+}
+
+// toInvalidError converts a field.ErrorList to an Invalid API error, or nil if the list is empty.
+func toInvalidError(kind, name string, errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	gk := schema.GroupKind{Group: SchemeGroupVersion.Group, Kind: kind}
+	return errors.NewInvalid(gk, name, errs)
+}
@@ -0,0 +1,389 @@
+/*
+ * Copyright (c) 2020, Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package v1_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	coherence "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var _ = Describe("Validating CoherenceCluster", func() {
+
+	newCluster := func(roles ...coherence.CoherenceRoleSpec) *coherence.CoherenceCluster {
+		cluster := &coherence.CoherenceCluster{}
+		cluster.Name = "test"
+		cluster.Spec.Roles = roles
+		return cluster
+	}
+
+	pvc := func(size string) *corev1.PersistentVolumeClaimSpec {
+		return &corev1.PersistentVolumeClaimSpec{
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+			},
+		}
+	}
+
+	It("should allow a cluster with no roles", func() {
+		Expect(newCluster().Validate()).To(Succeed())
+	})
+
+	It("should allow a valid cluster with multiple roles", func() {
+		policy := coherence.SafeScaling
+		cluster := newCluster(
+			coherence.CoherenceRoleSpec{Role: "data", Replicas: int32Ptr(3), Scaling: &coherence.ScalingSpec{Policy: &policy}},
+			coherence.CoherenceRoleSpec{Role: "proxy", StartQuorum: []coherence.StartQuorum{{Role: "data", PodCount: 1}}},
+		)
+		Expect(cluster.Validate()).To(Succeed())
+	})
+
+	It("should reject duplicate role names", func() {
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "data"}, coherence.CoherenceRoleSpec{Role: "data"})
+		err := cluster.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.roles[1].role: Duplicate value: \"data\""))
+	})
+
+	It("should reject duplicate default role names", func() {
+		cluster := newCluster(coherence.CoherenceRoleSpec{}, coherence.CoherenceRoleSpec{Role: coherence.DefaultRoleName})
+		err := cluster.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.roles[1].role: Duplicate value"))
+	})
+
+	It("should reject negative replicas in the default role", func() {
+		cluster := newCluster()
+		cluster.Spec.Replicas = int32Ptr(-1)
+		err := cluster.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.replicas: Invalid value: -1"))
+	})
+
+	It("should reject negative replicas in a role", func() {
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "data", Replicas: int32Ptr(-2)})
+		err := cluster.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.roles[0].replicas: Invalid value: -2"))
+	})
+
+	It("should reject an unknown scaling policy", func() {
+		policy := coherence.ScalingPolicy("Fast")
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "data", Scaling: &coherence.ScalingSpec{Policy: &policy}})
+		err := cluster.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.roles[0].scaling.policy: Unsupported value: \"Fast\""))
+	})
+
+	It("should reject an unknown upgrade policy", func() {
+		policy := coherence.UpgradePolicy("Fast")
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "data", UpgradePolicy: &policy})
+		err := cluster.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.roles[0].upgradePolicy: Unsupported value: \"Fast\""))
+	})
+
+	It("should reject an unknown minimum HA status", func() {
+		status := coherence.HAStatus("FOO-SAFE")
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "data", Scaling: &coherence.ScalingSpec{MinimumHAStatus: &status}})
+		err := cluster.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.roles[0].scaling.minimumHAStatus: Unsupported value: \"FOO-SAFE\""))
+	})
+
+	It("should reject an unknown service minimum HA status", func() {
+		scaling := &coherence.ScalingSpec{ServiceMinimumHAStatus: map[string]coherence.HAStatus{"Foo": "FOO-SAFE"}}
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "data", Scaling: scaling})
+		err := cluster.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.roles[0].scaling.serviceMinimumHAStatus[Foo]: Unsupported value: \"FOO-SAFE\""))
+	})
+
+	It("should reject a minimum HA status without management over ReST", func() {
+		status := coherence.HAStatusMachineSafe
+		scaling := &coherence.ScalingSpec{MinimumHAStatus: &status, ServiceMinimumHAStatus: map[string]coherence.HAStatus{"Foo": status}}
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "data", Scaling: scaling})
+		err := cluster.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.roles[0].scaling.minimumHAStatus: Forbidden"))
+		Expect(err.Error()).To(ContainSubstring("spec.roles[0].scaling.serviceMinimumHAStatus: Forbidden"))
+	})
+
+	It("should accept a minimum HA status with management over ReST enabled in the default role spec", func() {
+		status := coherence.HAStatusMachineSafe
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "data", Scaling: &coherence.ScalingSpec{MinimumHAStatus: &status}})
+		cluster.Spec.Coherence = &coherence.CoherenceSpec{Management: &coherence.PortSpecWithSSL{Enabled: boolPtr(true)}}
+		Expect(cluster.Validate()).To(Succeed())
+	})
+
+	It("should reject a CoherenceRole with a minimum HA status without management over ReST", func() {
+		status := coherence.HAStatusMachineSafe
+		role := &coherence.CoherenceRole{}
+		role.Name = "test-data"
+		role.Spec = coherence.CoherenceRoleSpec{Role: "data", Scaling: &coherence.ScalingSpec{MinimumHAStatus: &status}}
+		Expect(errors.IsInvalid(role.Validate())).To(BeTrue())
+
+		role.Spec.Coherence = &coherence.CoherenceSpec{Management: &coherence.PortSpecWithSSL{Enabled: boolPtr(true)}}
+		Expect(role.Validate()).To(Succeed())
+	})
+
+	It("should reject a snapshot on delete without management over ReST", func() {
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "data"})
+		cluster.Spec.Coherence = &coherence.CoherenceSpec{SnapshotOnDelete: boolPtr(true)}
+		err := cluster.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.roles[0].coherence.snapshotOnDelete: Forbidden"))
+
+		cluster.Spec.Coherence.Management = &coherence.PortSpecWithSSL{Enabled: boolPtr(true)}
+		Expect(cluster.Validate()).To(Succeed())
+	})
+
+	It("should reject a CoherenceRole with a snapshot on delete without management over ReST", func() {
+		role := &coherence.CoherenceRole{}
+		role.Name = "test-data"
+		role.Spec = coherence.CoherenceRoleSpec{Role: "data", Coherence: &coherence.CoherenceSpec{SnapshotOnDelete: boolPtr(true)}}
+		err := role.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.coherence.snapshotOnDelete: Forbidden"))
+
+		role.Spec.Coherence.Management = &coherence.PortSpecWithSSL{Enabled: boolPtr(true)}
+		Expect(role.Validate()).To(Succeed())
+	})
+
+	It("should reject autoscaling policies without management over ReST", func() {
+		policies := []coherence.AutoscalingPolicy{{Metric: coherence.HeapUsageMetric, Target: 70}}
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "data"})
+		cluster.Spec.Autoscaling = &coherence.AutoscalingSpec{Enabled: boolPtr(true), MaxReplicas: int32Ptr(5), Policies: policies}
+		err := cluster.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.roles[0].autoscaling.policies: Forbidden"))
+
+		cluster.Spec.Coherence = &coherence.CoherenceSpec{Management: &coherence.PortSpecWithSSL{Enabled: boolPtr(true)}}
+		Expect(cluster.Validate()).To(Succeed())
+	})
+
+	It("should reject a CoherenceRole with autoscaling policies without management over ReST", func() {
+		policies := []coherence.AutoscalingPolicy{{Metric: coherence.HeapUsageMetric, Target: 70}}
+		role := &coherence.CoherenceRole{}
+		role.Name = "test-data"
+		role.Spec = coherence.CoherenceRoleSpec{
+			Role:        "data",
+			Autoscaling: &coherence.AutoscalingSpec{Enabled: boolPtr(true), MaxReplicas: int32Ptr(5), Policies: policies},
+		}
+		err := role.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.autoscaling.policies: Forbidden"))
+
+		role.Spec.Coherence = &coherence.CoherenceSpec{Management: &coherence.PortSpecWithSSL{Enabled: boolPtr(true)}}
+		Expect(role.Validate()).To(Succeed())
+	})
+
+	It("should reject autoscaling with a maximum less than the minimum replicas", func() {
+		autoscaling := &coherence.AutoscalingSpec{Enabled: boolPtr(true), MinReplicas: int32Ptr(3), MaxReplicas: int32Ptr(2)}
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "data", Autoscaling: autoscaling})
+		err := cluster.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.roles[0].autoscaling.maxReplicas: Invalid value: 2"))
+	})
+
+	It("should reject an autoscaling policy with an unknown metric", func() {
+		policies := []coherence.AutoscalingPolicy{{Metric: "Foo", Target: 10}}
+		autoscaling := &coherence.AutoscalingSpec{Enabled: boolPtr(true), MaxReplicas: int32Ptr(5), Policies: policies}
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "data", Autoscaling: autoscaling})
+		err := cluster.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.roles[0].autoscaling.policies[0].metric: Unsupported value: \"Foo\""))
+	})
+
+	It("should reject a CacheSize autoscaling policy without a cache name", func() {
+		policies := []coherence.AutoscalingPolicy{{Metric: coherence.CacheSizeMetric, Target: 0}}
+		autoscaling := &coherence.AutoscalingSpec{Enabled: boolPtr(true), MaxReplicas: int32Ptr(5), Policies: policies}
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "data", Autoscaling: autoscaling})
+		err := cluster.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.roles[0].autoscaling.policies[0].cache: Required value"))
+		Expect(err.Error()).To(ContainSubstring("spec.roles[0].autoscaling.policies[0].target: Invalid value: 0"))
+	})
+
+	It("should reject a scaling schedule with an unknown time zone", func() {
+		schedule := &coherence.ScalingSchedule{TimeZone: stringPtr("Foo/Bar")}
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "data", Scaling: &coherence.ScalingSpec{Schedule: schedule}})
+		err := cluster.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.roles[0].scaling.schedule.timeZone: Invalid value: \"Foo/Bar\""))
+	})
+
+	It("should reject a scaling schedule entry with an invalid cron expression", func() {
+		entries := []coherence.ScheduledReplicas{{Cron: "0 25 * * *", Replicas: 3}}
+		schedule := &coherence.ScalingSchedule{Entries: entries}
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "data", Scaling: &coherence.ScalingSpec{Schedule: schedule}})
+		err := cluster.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.roles[0].scaling.schedule.entries[0].cron: Invalid value: \"0 25 * * *\""))
+	})
+
+	It("should reject a scaling schedule entry with zero replicas", func() {
+		entries := []coherence.ScheduledReplicas{{Cron: "0 7 * * MON-FRI", Replicas: 0}}
+		schedule := &coherence.ScalingSchedule{Entries: entries}
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "data", Scaling: &coherence.ScalingSpec{Schedule: schedule}})
+		err := cluster.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.roles[0].scaling.schedule.entries[0].replicas: Invalid value: 0"))
+	})
+
+	It("should reject a PodDisruptionBudget with both minimum available and maximum unavailable", func() {
+		one := intstr.FromInt(1)
+		budget := &coherence.PodDisruptionBudgetSpec{MinAvailable: &one, MaxUnavailable: &one}
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "data", PodDisruptionBudget: budget})
+		err := cluster.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.roles[0].podDisruptionBudget.maxUnavailable: Forbidden"))
+	})
+
+	It("should reject a PodDisruptionBudget with an invalid percentage", func() {
+		percent := intstr.FromString("150%")
+		budget := &coherence.PodDisruptionBudgetSpec{MinAvailable: &percent}
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "data", PodDisruptionBudget: budget})
+		err := cluster.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.roles[0].podDisruptionBudget.minAvailable: Invalid value: \"150%\""))
+	})
+
+	It("should reject a PodDisruptionBudget with a negative maximum unavailable", func() {
+		negative := intstr.FromInt(-1)
+		budget := &coherence.PodDisruptionBudgetSpec{MaxUnavailable: &negative}
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "data", PodDisruptionBudget: budget})
+		err := cluster.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.roles[0].podDisruptionBudget.maxUnavailable: Invalid value: -1"))
+	})
+
+	It("should accept a PodDisruptionBudget with a percentage", func() {
+		percent := intstr.FromString("25%")
+		budget := &coherence.PodDisruptionBudgetSpec{MaxUnavailable: &percent}
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "data", PodDisruptionBudget: budget})
+		Expect(cluster.Validate()).To(Succeed())
+	})
+
+	It("should reject a start quorum for an unknown role", func() {
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "proxy", StartQuorum: []coherence.StartQuorum{{Role: "data"}}})
+		err := cluster.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.roles[0].startQuorum[0].role: Not found: \"data\""))
+	})
+
+	It("should reject a start quorum that depends on its own role", func() {
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "data", StartQuorum: []coherence.StartQuorum{{Role: "data"}}})
+		err := cluster.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("data -> data"))
+	})
+
+	It("should reject start quorums that form a cycle", func() {
+		cluster := newCluster(
+			coherence.CoherenceRoleSpec{Role: "one", StartQuorum: []coherence.StartQuorum{{Role: "two"}}},
+			coherence.CoherenceRoleSpec{Role: "two", StartQuorum: []coherence.StartQuorum{{Role: "three"}}},
+			coherence.CoherenceRoleSpec{Role: "three", StartQuorum: []coherence.StartQuorum{{Role: "one"}}},
+		)
+		err := cluster.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("start quorum dependencies form a cycle: one -> two -> three -> one"))
+	})
+
+	It("should allow an update that scales a role", func() {
+		previous := newCluster(coherence.CoherenceRoleSpec{Role: "data", Replicas: int32Ptr(3)})
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "data", Replicas: int32Ptr(6)})
+		Expect(cluster.ValidateUpdate(previous)).To(Succeed())
+	})
+
+	It("should allow an update that adds a role with persistence", func() {
+		previous := newCluster(coherence.CoherenceRoleSpec{Role: "data"})
+		cluster := newCluster(
+			coherence.CoherenceRoleSpec{Role: "data"},
+			coherence.CoherenceRoleSpec{Role: "backup", Coherence: &coherence.CoherenceSpec{
+				Persistence: &coherence.PersistentStorageSpec{Enabled: boolPtr(true), PersistentVolumeClaim: pvc("1Gi")},
+			}},
+		)
+		Expect(cluster.ValidateUpdate(previous)).To(Succeed())
+	})
+
+	It("should reject an update that enables persistence on an existing role", func() {
+		previous := newCluster(coherence.CoherenceRoleSpec{Role: "data"})
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "data", Coherence: &coherence.CoherenceSpec{
+			Persistence: &coherence.PersistentStorageSpec{Enabled: boolPtr(true)},
+		}})
+		err := cluster.ValidateUpdate(previous)
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.roles[0].coherence.persistence.enabled: Forbidden"))
+	})
+
+	It("should reject an update that changes the persistence PVC inherited from the defaults", func() {
+		previous := newCluster(coherence.CoherenceRoleSpec{Role: "data"})
+		previous.Spec.Coherence = &coherence.CoherenceSpec{
+			Persistence: &coherence.PersistentStorageSpec{Enabled: boolPtr(true), PersistentVolumeClaim: pvc("1Gi")},
+		}
+		cluster := newCluster(coherence.CoherenceRoleSpec{Role: "data"})
+		cluster.Spec.Coherence = &coherence.CoherenceSpec{
+			Persistence: &coherence.PersistentStorageSpec{Enabled: boolPtr(true), PersistentVolumeClaim: pvc("2Gi")},
+		}
+		err := cluster.ValidateUpdate(previous)
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.roles[0].coherence.persistence.persistentVolumeClaim: Forbidden"))
+	})
+
+	It("should reject an update that changes the volume claim templates", func() {
+		previous := newCluster()
+		cluster := newCluster()
+		cluster.Spec.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{
+			{ObjectMeta: metav1.ObjectMeta{Name: "data"}, Spec: *pvc("1Gi")},
+		}
+		err := cluster.ValidateUpdate(previous)
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.volumeClaimTemplates: Forbidden"))
+	})
+
+	It("should allow any update to a cluster that is being deleted", func() {
+		previous := newCluster()
+		cluster := newCluster()
+		cluster.Spec.Replicas = int32Ptr(-1)
+		now := metav1.Now()
+		cluster.DeletionTimestamp = &now
+		Expect(cluster.ValidateUpdate(previous)).To(Succeed())
+	})
+})
+
+var _ = Describe("Validating CoherenceRole", func() {
+
+	It("should allow a valid role", func() {
+		role := &coherence.CoherenceRole{}
+		role.Spec.Replicas = int32Ptr(1)
+		Expect(role.Validate()).To(Succeed())
+	})
+
+	It("should reject negative replicas", func() {
+		role := &coherence.CoherenceRole{}
+		role.Spec.Replicas = int32Ptr(-1)
+		err := role.Validate()
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.replicas: Invalid value: -1"))
+	})
+
+	It("should reject an update that disables snapshots", func() {
+		previous := &coherence.CoherenceRole{}
+		previous.Spec.Coherence = &coherence.CoherenceSpec{Snapshot: &coherence.PersistentStorageSpec{Enabled: boolPtr(true)}}
+		role := &coherence.CoherenceRole{}
+		role.Spec.Coherence = &coherence.CoherenceSpec{Snapshot: &coherence.PersistentStorageSpec{Enabled: boolPtr(false)}}
+		err := role.ValidateUpdate(previous)
+		Expect(errors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.coherence.snapshot.enabled: Forbidden"))
+	})
+})
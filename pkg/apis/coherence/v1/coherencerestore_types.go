@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CoherenceRestore is the Schema for the coherencerestores API.
+// A CoherenceRestore recovers the partitioned cache services of a Coherence cluster from a persistence snapshot.
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=crestore,categories=coherence
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.cluster",description="The name of the Coherence cluster"
+// +kubebuilder:printcolumn:name="Snapshot",type="string",JSONPath=".spec.snapshot",description="The name of the snapshot to recover"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="The phase of the recovery"
+type CoherenceRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CoherenceRestoreSpec   `json:"spec,omitempty"`
+	Status CoherenceRestoreStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CoherenceRestoreList contains a list of CoherenceRestore
+type CoherenceRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CoherenceRestore `json:"items"`
+}
+
+// CoherenceRestoreSpec defines the desired state of CoherenceRestore
+// +k8s:openapi-gen=true
+type CoherenceRestoreSpec struct {
+	// The name of the CoherenceCluster in the same namespace to recover.
+	Cluster string `json:"cluster"`
+	// The name of the persistence snapshot to recover.
+	Snapshot string `json:"snapshot"`
+	// The names of the partitioned cache services to recover.
+	// If not set all of the cluster's partitioned cache services are recovered.
+	// +optional
+	Services []string `json:"services,omitempty"`
+	// An optional store that the snapshot files of each storage enabled member are copied from before
+	// the snapshot is recovered. This is typically the store that a CoherenceSnapshot copied them to.
+	// +optional
+	Store *SnapshotStoreSpec `json:"store,omitempty"`
+}
+
+// CoherenceRestoreStatus defines the observed state of CoherenceRestore
+// +k8s:openapi-gen=true
+type CoherenceRestoreStatus struct {
+	// The phase of the recovery.
+	// +optional
+	Phase PersistencePhase `json:"phase,omitempty"`
+	// A message describing the reason for the phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// The time that the recovery was started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// The time that the recovery completed or failed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// The status of the recovery of each partitioned cache service.
+	// +optional
+	Services []ServiceOperationStatus `json:"services,omitempty"`
+	// The status of copying the snapshot files of each storage enabled member from the snapshot store.
+	// +optional
+	Members []MemberOperationStatus `json:"members,omitempty"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CoherenceRestore{}, &CoherenceRestoreList{})
+}
@@ -0,0 +1,69 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package v1
+
+// SetDefaults sets the default values for any fields in the CoherenceRole spec that have not been set.
+// The images are the operator's default Coherence and Coherence Utils images, either may be nil.
+func (in *CoherenceRole) SetDefaults(coherenceImage, utilsImage *string) {
+	if in != nil {
+		in.Spec.SetDefaults(coherenceImage, utilsImage)
+	}
+}
+
+// SetDefaults sets the default values for any fields in the CoherenceRoleSpec that have not been set.
+// The scaling policy is only set once the storage enabled flag that decides it has been set.
+// The images are the operator's default Coherence and Coherence Utils images, either may be nil.
+func (in *CoherenceRoleSpec) SetDefaults(coherenceImage, utilsImage *string) {
+	if in == nil {
+		return
+	}
+
+	if in.Role == "" {
+		in.Role = in.GetRoleName()
+	}
+
+	in.setCommonDefaults(coherenceImage, utilsImage)
+	in.setScalingPolicyDefault(in)
+}
+
+// setScalingPolicyDefault sets the scaling policy to the effective policy from GetEffectiveScalingPolicy if neither
+// the role nor the effective spec that it inherits from sets a policy. The default policy depends on whether storage
+// is enabled, so the policy is only set if the effective spec sets the storage enabled flag explicitly, otherwise the
+// policy would be fixed to the storage enabled default even if storage was disabled later.
+func (in *CoherenceRoleSpec) setScalingPolicyDefault(effective *CoherenceRoleSpec) {
+	if effective.Scaling != nil && effective.Scaling.Policy != nil {
+		return
+	}
+	if effective.Coherence == nil || effective.Coherence.StorageEnabled == nil {
+		return
+	}
+	policy := effective.GetEffectiveScalingPolicy()
+	if in.Scaling == nil {
+		in.Scaling = &ScalingSpec{}
+	}
+	in.Scaling.Policy = &policy
+}
+
+// setCommonDefaults sets the default values for fields that do not depend on a specific role.
+func (in *CoherenceRoleSpec) setCommonDefaults(coherenceImage, utilsImage *string) {
+	if in.Replicas == nil {
+		in.SetReplicas(in.GetReplicas())
+	}
+
+	if in.HealthPort == nil || *in.HealthPort <= 0 {
+		port := in.GetHealthPort()
+		in.HealthPort = &port
+	}
+
+	if coherenceImage != nil && *coherenceImage != "" && in.GetCoherenceImage() == nil {
+		in.EnsureCoherenceImage(coherenceImage)
+	}
+
+	if utilsImage != nil && *utilsImage != "" && in.GetCoherenceUtilsImage() == nil {
+		in.EnsureCoherenceUtilsImage(utilsImage)
+	}
+}
@@ -25,6 +25,7 @@ import (
 // +kubebuilder:printcolumn:name="Replicas",type="integer",JSONPath=".spec.replicas",description="The number of Coherence Pods for this role"
 // +kubebuilder:printcolumn:name="Ready",type="integer",JSONPath=".status.readyReplicas",description="The number of ready Coherence Pods for this role"
 // +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.status",description="The status of this role"
+// +kubebuilder:printcolumn:name="Available",type="string",JSONPath=".status.conditions[?(@.type==\"Available\")].status",description="Whether all of the Coherence Pods for this role are ready"
 type CoherenceRole struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -62,6 +63,71 @@ type CoherenceRoleStatus struct {
 	Selector string `json:"selector,omitempty"`
 	// The status of the start quorums for this role.
 	StartQuorum []StartQuorumStatus `json:"startQuorum,omitempty"`
+	// The name of the Pod currently being restarted by a Safe rolling upgrade.
+	// +optional
+	UpgradingPod string `json:"upgradingPod,omitempty"`
+	// The last time that the role was scaled by the autoscaler.
+	// +optional
+	LastScaleTime *metav1.Time `json:"lastScaleTime,omitempty"`
+	// The status of the role's scheduled scaling.
+	// +optional
+	ScheduledScaling *ScheduledScalingStatus `json:"scheduledScaling,omitempty"`
+	// ObservedGeneration is the most recent generation of the role that has been applied by the Operator.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The Coherence cluster membership of the role's Pods, read using Coherence management over ReST.
+	// +optional
+	Membership *MembershipStatus `json:"membership,omitempty"`
+	// The status conditions of the role.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions Conditions `json:"conditions,omitempty"`
+}
+
+// MembershipStatus is the Coherence cluster membership of a role's Pods.
+// +k8s:openapi-gen=true
+type MembershipStatus struct {
+	// The name of the Coherence cluster that the role's Pods have joined.
+	// +optional
+	ClusterName string `json:"clusterName,omitempty"`
+	// The Coherence version of the cluster.
+	// +optional
+	ClusterVersion string `json:"clusterVersion,omitempty"`
+	// The number of members of the Coherence cluster.
+	ClusterSize int32 `json:"clusterSize"`
+	// The number of the role's ready Pods that have joined the Coherence cluster.
+	JoinedMembers int32 `json:"joinedMembers"`
+	// The names of the role's ready Pods that have not joined the Coherence cluster.
+	// +optional
+	NotJoined []string `json:"notJoined,omitempty"`
+}
+
+// ScheduledScalingStatus is the status of the scheduled scaling of a role.
+// +k8s:openapi-gen=true
+type ScheduledScalingStatus struct {
+	// The time that the schedule was last evaluated.
+	// +optional
+	LastEvaluatedTime *metav1.Time `json:"lastEvaluatedTime,omitempty"`
+	// The last scheduled change applied to the role.
+	// +optional
+	Last *ScheduledScalingAction `json:"last,omitempty"`
+	// The next scheduled change to the role.
+	// +optional
+	Next *ScheduledScalingAction `json:"next,omitempty"`
+}
+
+// ScheduledScalingAction is a scheduled change to the number of replicas of a role.
+// +k8s:openapi-gen=true
+type ScheduledScalingAction struct {
+	// The cron expression of the schedule entry.
+	Cron string `json:"cron"`
+	// The number of replicas the role is scaled to.
+	Replicas int32 `json:"replicas"`
+	// The time that the change is due.
+	Time metav1.Time `json:"time"`
 }
 
 func init() {
@@ -86,6 +152,15 @@ func (in *CoherenceRole) GetCoherenceClusterName() string {
 	return name
 }
 
+// SetCondition sets a status condition on the role observed at the role's current generation.
+// Returns true if the role's conditions were changed.
+func (in *CoherenceRole) SetCondition(t ConditionType, status bool, reason, message string) bool {
+	if in == nil {
+		return false
+	}
+	return in.Status.Conditions.SetCondition(NewCondition(t, status, in.Generation, reason, message))
+}
+
 // RoleStatus is the status value for a CoherenceRoleStatus.
 type RoleStatus string
 
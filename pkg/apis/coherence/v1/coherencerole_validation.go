@@ -0,0 +1,354 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package v1
+
+import (
+	"github.com/oracle/coherence-operator/pkg/cron"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The message used when an immutable field of an existing role is changed.
+const immutableFieldMessage = "field cannot be changed once the role has been created as the StatefulSet cannot apply it"
+
+// The message used when a field that requires Coherence management over ReST is set for a role without management.
+const managementRequiredMessage = "may not be set unless Coherence management over ReST is enabled for the role"
+
+// The valid ScalingPolicy values.
+var validScalingPolicies = []string{string(SafeScaling), string(ParallelScaling), string(ParallelUpSafeDownScaling)}
+
+// The valid UpgradePolicy values.
+var validUpgradePolicies = []string{string(RollingUpgrade), string(SafeUpgrade)}
+
+// The valid HAStatus values.
+var validHAStatuses = []string{string(HAStatusEndangered), string(HAStatusNodeSafe), string(HAStatusMachineSafe),
+	string(HAStatusRackSafe), string(HAStatusSiteSafe)}
+
+// The valid AutoscalingMetric values.
+var validAutoscalingMetrics = []string{string(HeapUsageMetric), string(PartitionsPerMemberMetric),
+	string(RequestQueueDepthMetric), string(CacheSizeMetric)}
+
+// Validate validates a CoherenceRole returning an error if the role spec is invalid.
+func (in *CoherenceRole) Validate() error {
+	if in == nil {
+		return nil
+	}
+	specPath := field.NewPath("spec")
+	errs := in.Spec.validate(specPath)
+	errs = append(errs, in.Spec.validateManagementRequired(specPath)...)
+	return toInvalidError("CoherenceRole", in.Name, errs)
+}
+
+// ValidateUpdate validates an update to a CoherenceRole returning an error if the updated
+// role spec is invalid or changes fields that cannot be changed.
+func (in *CoherenceRole) ValidateUpdate(previous *CoherenceRole) error {
+	if in == nil {
+		return nil
+	}
+
+	if in.GetDeletionTimestamp() != nil {
+		// the role is being deleted so allow the update, for example to remove finalizers
+		return nil
+	}
+
+	specPath := field.NewPath("spec")
+	errs := in.Spec.validate(specPath)
+	errs = append(errs, in.Spec.validateManagementRequired(specPath)...)
+	if previous != nil {
+		errs = append(errs, in.Spec.validateImmutableFields(&previous.Spec, specPath)...)
+	}
+
+	return toInvalidError("CoherenceRole", in.Name, errs)
+}
+
+// validate validates a CoherenceRoleSpec.
+func (in *CoherenceRoleSpec) validate(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	errs = append(errs, in.validateReplicas(path)...)
+	errs = append(errs, in.validateScalingPolicy(path)...)
+	errs = append(errs, in.validateUpgradePolicy(path)...)
+	errs = append(errs, in.validateMinimumHAStatus(path)...)
+	errs = append(errs, in.validateAutoscaling(path)...)
+	errs = append(errs, in.validateScalingSchedule(path)...)
+	errs = append(errs, in.validatePodDisruptionBudget(path)...)
+	return errs
+}
+
+// validateReplicas validates that the replicas field, if set, is not negative.
+func (in *CoherenceRoleSpec) validateReplicas(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if in.Replicas != nil && *in.Replicas < 0 {
+		errs = append(errs, field.Invalid(path.Child("replicas"), *in.Replicas, "must be greater than or equal to 0"))
+	}
+	return errs
+}
+
+// validateScalingPolicy validates that the scaling policy, if set, is a known policy.
+func (in *CoherenceRoleSpec) validateScalingPolicy(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if in.Scaling != nil && in.Scaling.Policy != nil {
+		policy := string(*in.Scaling.Policy)
+		valid := false
+		for _, p := range validScalingPolicies {
+			if p == policy {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			errs = append(errs, field.NotSupported(path.Child("scaling", "policy"), policy, validScalingPolicies))
+		}
+	}
+	return errs
+}
+
+// validateUpgradePolicy validates that the upgrade policy, if set, is a known policy.
+func (in *CoherenceRoleSpec) validateUpgradePolicy(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if in.UpgradePolicy != nil {
+		policy := string(*in.UpgradePolicy)
+		valid := false
+		for _, p := range validUpgradePolicies {
+			if p == policy {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			errs = append(errs, field.NotSupported(path.Child("upgradePolicy"), policy, validUpgradePolicies))
+		}
+	}
+	return errs
+}
+
+// validateMinimumHAStatus validates that the minimum HA statuses, if set, are known HA statuses.
+func (in *CoherenceRoleSpec) validateMinimumHAStatus(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if in.Scaling == nil {
+		return errs
+	}
+	scalingPath := path.Child("scaling")
+	if in.Scaling.MinimumHAStatus != nil && !in.Scaling.MinimumHAStatus.IsValid() {
+		status := string(*in.Scaling.MinimumHAStatus)
+		errs = append(errs, field.NotSupported(scalingPath.Child("minimumHAStatus"), status, validHAStatuses))
+	}
+	// validate services in name order so that errors are reported in a consistent order
+	var services []string
+	for service := range in.Scaling.ServiceMinimumHAStatus {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	for _, service := range services {
+		if status := in.Scaling.ServiceMinimumHAStatus[service]; !status.IsValid() {
+			p := scalingPath.Child("serviceMinimumHAStatus").Key(service)
+			errs = append(errs, field.NotSupported(p, string(status), validHAStatuses))
+		}
+	}
+	return errs
+}
+
+// validateManagementRequired validates that management over ReST is enabled if any field that requires it is set.
+// The spec validated must be the effective spec of the role, including any values from the cluster's default role spec.
+func (in *CoherenceRoleSpec) validateManagementRequired(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	errs = append(errs, in.validateMinimumHAStatusManagement(path)...)
+	errs = append(errs, in.validateSnapshotOnDeleteManagement(path)...)
+	errs = append(errs, in.validateAutoscalingManagement(path)...)
+	return errs
+}
+
+// validateMinimumHAStatusManagement validates that management over ReST is enabled if a minimum HA status is set,
+// as the HA status of the role's services can only be obtained using management over ReST.
+func (in *CoherenceRoleSpec) validateMinimumHAStatusManagement(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if in.Scaling == nil || in.IsManagementEnabled() {
+		return errs
+	}
+	scalingPath := path.Child("scaling")
+	if in.Scaling.MinimumHAStatus != nil {
+		errs = append(errs, field.Forbidden(scalingPath.Child("minimumHAStatus"), managementRequiredMessage))
+	}
+	if len(in.Scaling.ServiceMinimumHAStatus) > 0 {
+		errs = append(errs, field.Forbidden(scalingPath.Child("serviceMinimumHAStatus"), managementRequiredMessage))
+	}
+	return errs
+}
+
+// validateSnapshotOnDeleteManagement validates that management over ReST is enabled if a snapshot is to be created
+// when the role is deleted, as the snapshot can only be created using management over ReST.
+func (in *CoherenceRoleSpec) validateSnapshotOnDeleteManagement(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if in.Coherence.IsSnapshotOnDelete() && !in.IsManagementEnabled() {
+		errs = append(errs, field.Forbidden(path.Child("coherence", "snapshotOnDelete"), managementRequiredMessage))
+	}
+	return errs
+}
+
+// validateAutoscalingManagement validates that management over ReST is enabled if autoscaling policies are set,
+// as the values of the policies' metrics can only be read using management over ReST.
+func (in *CoherenceRoleSpec) validateAutoscalingManagement(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if in.Autoscaling != nil && len(in.Autoscaling.Policies) > 0 && !in.IsManagementEnabled() {
+		errs = append(errs, field.Forbidden(path.Child("autoscaling", "policies"), managementRequiredMessage))
+	}
+	return errs
+}
+
+// validateScalingSchedule validates that the scaling schedule, if set, has a known time zone and
+// that each entry has a valid cron expression and scales the role to at least one replica.
+func (in *CoherenceRoleSpec) validateScalingSchedule(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if in.Scaling == nil || in.Scaling.Schedule == nil {
+		return errs
+	}
+
+	schedulePath := path.Child("scaling", "schedule")
+	if _, err := in.Scaling.Schedule.GetLocation(); err != nil {
+		errs = append(errs, field.Invalid(schedulePath.Child("timeZone"), *in.Scaling.Schedule.TimeZone, err.Error()))
+	}
+
+	for i, entry := range in.Scaling.Schedule.Entries {
+		entryPath := schedulePath.Child("entries").Index(i)
+		if _, err := cron.Parse(entry.Cron); err != nil {
+			errs = append(errs, field.Invalid(entryPath.Child("cron"), entry.Cron, err.Error()))
+		}
+		if entry.Replicas < 1 {
+			errs = append(errs, field.Invalid(entryPath.Child("replicas"), entry.Replicas, "must be greater than or equal to 1"))
+		}
+	}
+	return errs
+}
+
+// validatePodDisruptionBudget validates that at most one of the PodDisruptionBudget's minimum available and
+// maximum unavailable fields is set and that the value set is a non-negative number or a percentage.
+func (in *CoherenceRoleSpec) validatePodDisruptionBudget(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if in.PodDisruptionBudget == nil {
+		return errs
+	}
+
+	pdbPath := path.Child("podDisruptionBudget")
+	if in.PodDisruptionBudget.MinAvailable != nil && in.PodDisruptionBudget.MaxUnavailable != nil {
+		errs = append(errs, field.Forbidden(pdbPath.Child("maxUnavailable"), "may not be set when minAvailable is set"))
+	}
+	errs = append(errs, validateIntOrPercent(in.PodDisruptionBudget.MinAvailable, pdbPath.Child("minAvailable"))...)
+	errs = append(errs, validateIntOrPercent(in.PodDisruptionBudget.MaxUnavailable, pdbPath.Child("maxUnavailable"))...)
+	return errs
+}
+
+// validateIntOrPercent validates that a value, if set, is either a non-negative number or a percentage between 0% and 100%.
+func validateIntOrPercent(value *intstr.IntOrString, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if value == nil {
+		return errs
+	}
+
+	switch value.Type {
+	case intstr.Int:
+		if value.IntVal < 0 {
+			errs = append(errs, field.Invalid(path, value.IntVal, "must be greater than or equal to 0"))
+		}
+	case intstr.String:
+		percent, err := strconv.Atoi(strings.TrimSuffix(value.StrVal, "%"))
+		if !strings.HasSuffix(value.StrVal, "%") || err != nil || percent < 0 || percent > 100 {
+			errs = append(errs, field.Invalid(path, value.StrVal, "must be a percentage between 0% and 100%"))
+		}
+	}
+	return errs
+}
+
+// validateAutoscaling validates that the autoscaler replica range and policies, if set, are valid.
+func (in *CoherenceRoleSpec) validateAutoscaling(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if in.Autoscaling == nil {
+		return errs
+	}
+
+	autoscalingPath := path.Child("autoscaling")
+	if in.Autoscaling.MinReplicas != nil && *in.Autoscaling.MinReplicas < 1 {
+		errs = append(errs, field.Invalid(autoscalingPath.Child("minReplicas"), *in.Autoscaling.MinReplicas, "must be greater than or equal to 1"))
+	}
+	if in.Autoscaling.MaxReplicas != nil && *in.Autoscaling.MaxReplicas < in.Autoscaling.GetMinReplicas() {
+		errs = append(errs, field.Invalid(autoscalingPath.Child("maxReplicas"), *in.Autoscaling.MaxReplicas, "must be greater than or equal to minReplicas"))
+	}
+
+	policiesPath := autoscalingPath.Child("policies")
+	for i, policy := range in.Autoscaling.Policies {
+		policyPath := policiesPath.Index(i)
+		valid := false
+		for _, m := range validAutoscalingMetrics {
+			if m == string(policy.Metric) {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			errs = append(errs, field.NotSupported(policyPath.Child("metric"), string(policy.Metric), validAutoscalingMetrics))
+		}
+		if policy.Target <= 0 {
+			errs = append(errs, field.Invalid(policyPath.Child("target"), policy.Target, "must be greater than 0"))
+		}
+		if policy.Metric == CacheSizeMetric && (policy.Cache == nil || *policy.Cache == "") {
+			errs = append(errs, field.Required(policyPath.Child("cache"), "required for the CacheSize metric"))
+		}
+	}
+	return errs
+}
+
+// validateImmutableFields validates that fields that the StatefulSet for a role cannot
+// apply have not been changed from the previous spec.
+func (in *CoherenceRoleSpec) validateImmutableFields(previous *CoherenceRoleSpec, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	if !equality.Semantic.DeepEqual(in.VolumeClaimTemplates, previous.VolumeClaimTemplates) {
+		errs = append(errs, field.Forbidden(path.Child("volumeClaimTemplates"), immutableFieldMessage))
+	}
+
+	var persistence, snapshot, oldPersistence, oldSnapshot *PersistentStorageSpec
+	if in.Coherence != nil {
+		persistence = in.Coherence.Persistence
+		snapshot = in.Coherence.Snapshot
+	}
+	if previous.Coherence != nil {
+		oldPersistence = previous.Coherence.Persistence
+		oldSnapshot = previous.Coherence.Snapshot
+	}
+
+	cohPath := path.Child("coherence")
+	errs = append(errs, persistence.validateImmutableFields(oldPersistence, cohPath.Child("persistence"))...)
+	errs = append(errs, snapshot.validateImmutableFields(oldSnapshot, cohPath.Child("snapshot"))...)
+
+	return errs
+}
+
+// validateImmutableFields validates that the fields of a PersistentStorageSpec that
+// control the PVCs created by the StatefulSet have not been changed.
+func (in *PersistentStorageSpec) validateImmutableFields(previous *PersistentStorageSpec, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	if in.IsEnabled() != previous.IsEnabled() {
+		errs = append(errs, field.Forbidden(path.Child("enabled"), immutableFieldMessage))
+	}
+
+	var pvc, oldPVC interface{}
+	if in != nil && in.PersistentVolumeClaim != nil {
+		pvc = in.PersistentVolumeClaim
+	}
+	if previous != nil && previous.PersistentVolumeClaim != nil {
+		oldPVC = previous.PersistentVolumeClaim
+	}
+
+	if !equality.Semantic.DeepEqual(pvc, oldPVC) {
+		errs = append(errs, field.Forbidden(path.Child("persistentVolumeClaim"), immutableFieldMessage))
+	}
+
+	return errs
+}
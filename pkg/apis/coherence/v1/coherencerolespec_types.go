@@ -77,6 +77,20 @@ type CoherenceRoleSpec struct {
 	// The configuration to control safe scaling.
 	// +optional
 	Scaling *ScalingSpec `json:"scaling,omitempty"`
+	// The configuration of the Operator's built-in autoscaler for the role.
+	// +optional
+	Autoscaling *AutoscalingSpec `json:"autoscaling,omitempty"`
+	// UpgradePolicy describes how the Pods of the role will be restarted when the role's spec is changed.
+	// The default if not specified is Rolling, where the Pods are restarted by the StatefulSet's
+	// rolling update strategy. If set to Safe the Operator restarts the Pods one at a time waiting
+	// for the partitioned cache services to be safe before restarting the next Pod.
+	// +optional
+	UpgradePolicy *UpgradePolicy `json:"upgradePolicy,omitempty"`
+	// The configuration of the PodDisruptionBudget that the Operator creates for the role.
+	// The default if not specified is a PodDisruptionBudget allowing at most one unavailable Pod
+	// for storage enabled roles and no PodDisruptionBudget for storage disabled roles.
+	// +optional
+	PodDisruptionBudget *PodDisruptionBudgetSpec `json:"podDisruptionBudget,omitempty"`
 	// Resources is the optional resource requests and limits for the containers
 	//  ref: http://kubernetes.io/docs/user-guide/compute-resources/
 	//
@@ -272,6 +286,36 @@ func (in *CoherenceRoleSpec) GetEffectiveScalingPolicy() ScalingPolicy {
 	return policy
 }
 
+// Returns the policy to use to restart the Pods of the role when the role's spec is changed.
+func (in *CoherenceRoleSpec) GetEffectiveUpgradePolicy() UpgradePolicy {
+	if in == nil || in.UpgradePolicy == nil {
+		return RollingUpgrade
+	}
+	return *in.UpgradePolicy
+}
+
+// Returns true if the Operator should create a PodDisruptionBudget for the role.
+func (in *CoherenceRoleSpec) IsPodDisruptionBudgetEnabled() bool {
+	if in == nil {
+		return false
+	}
+	return in.PodDisruptionBudget.IsEnabled(in.IsStorageEnabled())
+}
+
+// Returns true if the members of the role are storage enabled.
+// Storage is enabled if the StorageEnabled field is not set or is true.
+func (in *CoherenceRoleSpec) IsStorageEnabled() bool {
+	return in == nil || in.Coherence == nil || in.Coherence.StorageEnabled == nil || *in.Coherence.StorageEnabled
+}
+
+// Returns true if Coherence management over ReST is enabled for the role.
+func (in *CoherenceRoleSpec) IsManagementEnabled() bool {
+	if in == nil || in.Coherence == nil || in.Coherence.Management == nil || in.Coherence.Management.Enabled == nil {
+		return false
+	}
+	return *in.Coherence.Management.Enabled
+}
+
 // Returns the port that the health check endpoint will bind to.
 func (in *CoherenceRoleSpec) GetHealthPort() int32 {
 	if in == nil || in.HealthPort == nil || *in.HealthPort <= 0 {
@@ -366,8 +410,17 @@ func (in *CoherenceRoleSpec) DeepCopyWithDefaults(defaults *CoherenceRoleSpec) *
 		clone.Ports = defaults.Ports
 	}
 
+	// HealthPort is NOT merged
+	if in.HealthPort != nil {
+		clone.HealthPort = in.HealthPort
+	} else {
+		clone.HealthPort = defaults.HealthPort
+	}
+
 	// ReadinessProbe is merged
 	clone.ReadinessProbe = in.ReadinessProbe.DeepCopyWithDefaults(defaults.ReadinessProbe)
+	// LivenessProbe is merged
+	clone.LivenessProbe = in.LivenessProbe.DeepCopyWithDefaults(defaults.LivenessProbe)
 
 	// Application is NOT merged
 	if in.Replicas != nil {
@@ -403,6 +456,22 @@ func (in *CoherenceRoleSpec) DeepCopyWithDefaults(defaults *CoherenceRoleSpec) *
 		}
 	}
 
+	// Scaling is merged
+	clone.Scaling = in.Scaling.DeepCopyWithDefaults(defaults.Scaling)
+
+	// Autoscaling is merged
+	clone.Autoscaling = in.Autoscaling.DeepCopyWithDefaults(defaults.Autoscaling)
+
+	// UpgradePolicy is NOT merged
+	if in.UpgradePolicy != nil {
+		clone.UpgradePolicy = in.UpgradePolicy
+	} else {
+		clone.UpgradePolicy = defaults.UpgradePolicy
+	}
+
+	// PodDisruptionBudget is merged
+	clone.PodDisruptionBudget = in.PodDisruptionBudget.DeepCopyWithDefaults(defaults.PodDisruptionBudget)
+
 	// SecurityContext is NOT merged
 	if in.SecurityContext != nil {
 		clone.SecurityContext = in.SecurityContext
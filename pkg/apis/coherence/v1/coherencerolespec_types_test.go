@@ -310,6 +310,77 @@ var _ = Describe("Testing CoherenceRoleSpec struct", func() {
 			})
 		})
 
+		// ----- HealthPort ---------------------------------------------------------
+
+		Context("HealthPort is not merged", func() {
+			When("the original HealthPort is nil", func() {
+				BeforeEach(func() {
+					defaults = roleSpecTwo.DeepCopy()
+					defaults.HealthPort = int32Ptr(1234)
+					original = roleSpecOne.DeepCopy()
+					original.HealthPort = nil
+				})
+
+				It("clone should be equal to the original with the HealthPort field from the defaults", func() {
+					expected := original.DeepCopy()
+					expected.HealthPort = defaults.HealthPort
+					Expect(clone).To(Equal(expected))
+				})
+			})
+
+			When("the original HealthPort is set", func() {
+				BeforeEach(func() {
+					defaults = roleSpecTwo.DeepCopy()
+					defaults.HealthPort = int32Ptr(1234)
+					original = roleSpecOne.DeepCopy()
+					original.HealthPort = int32Ptr(5678)
+				})
+
+				It("clone should be equal to the original", func() {
+					Expect(clone).To(Equal(original))
+				})
+			})
+		})
+
+		// ----- Scaling ------------------------------------------------------------
+
+		Context("Scaling is merged", func() {
+			var safe = coherence.SafeScaling
+			var parallel = coherence.ParallelScaling
+
+			When("the original Scaling is nil", func() {
+				BeforeEach(func() {
+					defaults = roleSpecTwo.DeepCopy()
+					defaults.Scaling = &coherence.ScalingSpec{Policy: &parallel}
+					original = roleSpecOne.DeepCopy()
+					original.Scaling = nil
+				})
+
+				It("clone should be equal to the original with the Scaling field from the defaults", func() {
+					expected := original.DeepCopy()
+					expected.Scaling = defaults.Scaling
+					Expect(clone).To(Equal(expected))
+				})
+			})
+
+			When("the original Scaling is set and defaults Scaling is set", func() {
+				BeforeEach(func() {
+					defaults = roleSpecTwo.DeepCopy()
+					defaults.Scaling = &coherence.ScalingSpec{Policy: &parallel, Probe: original.GetDefaultScalingProbe()}
+					original = roleSpecOne.DeepCopy()
+					original.Scaling = &coherence.ScalingSpec{Policy: &safe}
+				})
+
+				It("clone should be equal to the merged original and defaults Scaling", func() {
+					expected := original.DeepCopy()
+					expected.Scaling = original.Scaling.DeepCopyWithDefaults(defaults.Scaling)
+					Expect(clone).To(Equal(expected))
+					Expect(*clone.Scaling.Policy).To(Equal(safe))
+					Expect(clone.Scaling.Probe).NotTo(BeNil())
+				})
+			})
+		})
+
 		// ----- Coherence ----------------------------------------------------------
 
 		Context("Coherence is merged", func() {
@@ -1962,4 +2033,48 @@ var _ = Describe("Testing CoherenceRoleSpec struct", func() {
 
 	})
 
+	Context("Checking whether a role is storage enabled", func() {
+		It("should be storage enabled if the role is nil", func() {
+			var role *coherence.CoherenceRoleSpec
+			Expect(role.IsStorageEnabled()).To(BeTrue())
+		})
+
+		It("should be storage enabled if the Coherence spec is nil", func() {
+			role := &coherence.CoherenceRoleSpec{}
+			Expect(role.IsStorageEnabled()).To(BeTrue())
+		})
+
+		It("should be storage enabled if StorageEnabled is nil", func() {
+			role := &coherence.CoherenceRoleSpec{Coherence: &coherence.CoherenceSpec{}}
+			Expect(role.IsStorageEnabled()).To(BeTrue())
+		})
+
+		It("should be storage enabled if StorageEnabled is true", func() {
+			role := &coherence.CoherenceRoleSpec{Coherence: &coherence.CoherenceSpec{StorageEnabled: boolPtr(true)}}
+			Expect(role.IsStorageEnabled()).To(BeTrue())
+		})
+
+		It("should be storage disabled if StorageEnabled is false", func() {
+			role := &coherence.CoherenceRoleSpec{Coherence: &coherence.CoherenceSpec{StorageEnabled: boolPtr(false)}}
+			Expect(role.IsStorageEnabled()).To(BeFalse())
+		})
+	})
+
+	Context("Getting the effective upgrade policy", func() {
+		It("should be Rolling if the role is nil", func() {
+			var role *coherence.CoherenceRoleSpec
+			Expect(role.GetEffectiveUpgradePolicy()).To(Equal(coherence.RollingUpgrade))
+		})
+
+		It("should be Rolling if the UpgradePolicy is nil", func() {
+			role := &coherence.CoherenceRoleSpec{}
+			Expect(role.GetEffectiveUpgradePolicy()).To(Equal(coherence.RollingUpgrade))
+		})
+
+		It("should be the UpgradePolicy if set", func() {
+			policy := coherence.SafeUpgrade
+			role := &coherence.CoherenceRoleSpec{UpgradePolicy: &policy}
+			Expect(role.GetEffectiveUpgradePolicy()).To(Equal(coherence.SafeUpgrade))
+		})
+	})
 })
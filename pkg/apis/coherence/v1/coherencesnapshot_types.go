@@ -0,0 +1,250 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"time"
+)
+
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CoherenceSnapshot is the Schema for the coherencesnapshots API.
+// A CoherenceSnapshot creates a persistence snapshot of the partitioned cache services of a Coherence cluster,
+// either once or repeatedly using a cron schedule.
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=csnap,categories=coherence
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.cluster",description="The name of the Coherence cluster"
+// +kubebuilder:printcolumn:name="Schedule",type="string",JSONPath=".spec.schedule",description="The cron schedule of the snapshots"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="The phase of the most recent snapshot"
+// +kubebuilder:printcolumn:name="Last Snapshot",type="date",JSONPath=".status.lastScheduleTime",description="The time of the most recent snapshot"
+type CoherenceSnapshot struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CoherenceSnapshotSpec   `json:"spec,omitempty"`
+	Status CoherenceSnapshotStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CoherenceSnapshotList contains a list of CoherenceSnapshot
+type CoherenceSnapshotList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CoherenceSnapshot `json:"items"`
+}
+
+// CoherenceSnapshotSpec defines the desired state of CoherenceSnapshot
+// +k8s:openapi-gen=true
+type CoherenceSnapshotSpec struct {
+	// The name of the CoherenceCluster in the same namespace to snapshot.
+	Cluster string `json:"cluster"`
+	// The names of the partitioned cache services to snapshot.
+	// If not set all of the cluster's partitioned cache services are included in the snapshot.
+	// +optional
+	Services []string `json:"services,omitempty"`
+	// Whether to archive each snapshot using the services' configured snapshot archiver once the
+	// snapshot has been created. The default is false.
+	// +optional
+	Archive *bool `json:"archive,omitempty"`
+	// An optional five field cron expression of the form "minute hour day-of-month month day-of-week"
+	// used to create snapshots repeatedly. If not set a single snapshot with the same name as the
+	// CoherenceSnapshot is created. Scheduled snapshots are named using the name of the CoherenceSnapshot
+	// and the time the snapshot was due, for example "nightly-20200131230000".
+	// +optional
+	Schedule *string `json:"schedule,omitempty"`
+	// The name of the IANA time zone used to evaluate the schedule, for example "Europe/London".
+	// If not set the schedule is evaluated in UTC.
+	// +optional
+	TimeZone *string `json:"timeZone,omitempty"`
+	// The number of completed scheduled snapshots to retain. When a scheduled snapshot completes the
+	// oldest snapshots over this number are removed, along with their archives. If not set all
+	// snapshots are retained.
+	// +optional
+	Retain *int32 `json:"retain,omitempty"`
+	// An optional store outside of the cluster that the snapshot files of each storage enabled member
+	// are copied to once a snapshot has been created, so that the snapshot outlives the Pods and
+	// PersistentVolumeClaims of the cluster.
+	// +optional
+	Store *SnapshotStoreSpec `json:"store,omitempty"`
+}
+
+// SnapshotStoreSpec configures a store that snapshot files are copied to and retrieved from.
+// Exactly one of S3 or Directory must be set. The files of each member are stored under the key
+// <prefix>/<cluster>/<snapshot>/<pod name>, along with a manifest of the files and their checksums.
+// +k8s:openapi-gen=true
+type SnapshotStoreSpec struct {
+	// An S3 compatible object store, for example AWS S3 or MinIO.
+	// +optional
+	S3 *S3StoreSpec `json:"s3,omitempty"`
+	// A directory in the Coherence containers to copy snapshots to, typically the mount path of a shared
+	// ReadWriteMany volume added to the storage enabled roles of the cluster.
+	// +optional
+	Directory *string `json:"directory,omitempty"`
+	// An optional prefix for the keys, or paths, of the stored files.
+	// +optional
+	Prefix *string `json:"prefix,omitempty"`
+}
+
+// S3StoreSpec configures an S3 compatible object store.
+// +k8s:openapi-gen=true
+type S3StoreSpec struct {
+	// The URL of the object store, for example https://s3.eu-west-1.amazonaws.com or http://minio:9000.
+	// Buckets are addressed using path style URLs.
+	Endpoint string `json:"endpoint"`
+	// The name of the bucket.
+	Bucket string `json:"bucket"`
+	// The region of the bucket. The default is us-east-1.
+	// +optional
+	Region *string `json:"region,omitempty"`
+	// The name of a Secret in the same namespace with accessKey and secretKey entries
+	// containing the credentials used to access the object store.
+	SecretName string `json:"secretName"`
+}
+
+// CoherenceSnapshotStatus defines the observed state of CoherenceSnapshot
+// +k8s:openapi-gen=true
+type CoherenceSnapshotStatus struct {
+	// The phase of the most recent snapshot.
+	// +optional
+	Phase PersistencePhase `json:"phase,omitempty"`
+	// A message describing the reason for the phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// The time that the most recent snapshot was started.
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+	// The time that the next scheduled snapshot is due.
+	// +optional
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty"`
+	// The snapshots that have been created and not removed, oldest first.
+	// +optional
+	Snapshots []SnapshotStatus `json:"snapshots,omitempty"`
+}
+
+// SnapshotStatus is the status of a single persistence snapshot.
+// +k8s:openapi-gen=true
+type SnapshotStatus struct {
+	// The name of the snapshot.
+	Name string `json:"name"`
+	// The phase of the snapshot.
+	Phase PersistencePhase `json:"phase"`
+	// The time that the snapshot was started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// The time that the snapshot completed or failed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// The status of the snapshot of each partitioned cache service.
+	// +optional
+	Services []ServiceOperationStatus `json:"services,omitempty"`
+	// The status of copying the snapshot files of each storage enabled member to the snapshot store.
+	// +optional
+	Members []MemberOperationStatus `json:"members,omitempty"`
+}
+
+// ServiceOperationStatus is the status of a persistence operation on a single partitioned cache service.
+// +k8s:openapi-gen=true
+type ServiceOperationStatus struct {
+	// The name of the partitioned cache service.
+	Name string `json:"name"`
+	// The phase of the operation for the service.
+	Phase PersistencePhase `json:"phase"`
+	// A message describing the reason for the phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// MemberOperationStatus is the status of copying the snapshot files of a single Coherence member
+// to or from a snapshot store.
+// +k8s:openapi-gen=true
+type MemberOperationStatus struct {
+	// The name of the member's Pod.
+	Pod string `json:"pod"`
+	// The phase of the copy for the member.
+	Phase PersistencePhase `json:"phase"`
+	// A message describing the reason for the phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// PersistencePhase is the phase of a persistence operation.
+type PersistencePhase string
+
+const (
+	// The operation has not yet been started.
+	PersistencePhasePending PersistencePhase = "Pending"
+	// The operation has been started and has not yet completed.
+	PersistencePhaseInProgress PersistencePhase = "InProgress"
+	// A snapshot has been created and is being archived.
+	PersistencePhaseArchiving PersistencePhase = "Archiving"
+	// A snapshot's files are being copied to a snapshot store.
+	PersistencePhaseUploading PersistencePhase = "Uploading"
+	// A snapshot's files are being copied from a snapshot store.
+	PersistencePhaseDownloading PersistencePhase = "Downloading"
+	// The operation completed successfully.
+	PersistencePhaseCompleted PersistencePhase = "Completed"
+	// The operation failed.
+	PersistencePhaseFailed PersistencePhase = "Failed"
+)
+
+// IsFinished returns true if the phase is Completed or Failed.
+func (in PersistencePhase) IsFinished() bool {
+	return in == PersistencePhaseCompleted || in == PersistencePhaseFailed
+}
+
+func init() {
+	SchemeBuilder.Register(&CoherenceSnapshot{}, &CoherenceSnapshotList{})
+}
+
+// IsScheduled returns true if snapshots are created using a cron schedule.
+func (in *CoherenceSnapshotSpec) IsScheduled() bool {
+	return in != nil && in.Schedule != nil && *in.Schedule != ""
+}
+
+// IsArchive returns true if snapshots are archived once they have been created.
+func (in *CoherenceSnapshotSpec) IsArchive() bool {
+	return in != nil && in.Archive != nil && *in.Archive
+}
+
+// GetLocation returns the time zone used to evaluate the schedule.
+func (in *CoherenceSnapshotSpec) GetLocation() (*time.Location, error) {
+	if in == nil || in.TimeZone == nil || *in.TimeZone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(*in.TimeZone)
+}
+
+// GetSnapshot returns the status of the snapshot with the specified name, or nil if there is no such snapshot.
+func (in *CoherenceSnapshotStatus) GetSnapshot(name string) *SnapshotStatus {
+	if in == nil {
+		return nil
+	}
+	for i := range in.Snapshots {
+		if in.Snapshots[i].Name == name {
+			return &in.Snapshots[i]
+		}
+	}
+	return nil
+}
+
+// GetInProgress returns the status of the snapshot that has not yet finished, or nil if all snapshots have finished.
+func (in *CoherenceSnapshotStatus) GetInProgress() *SnapshotStatus {
+	if in == nil {
+		return nil
+	}
+	for i := range in.Snapshots {
+		if !in.Snapshots[i].Phase.IsFinished() {
+			return &in.Snapshots[i]
+		}
+	}
+	return nil
+}
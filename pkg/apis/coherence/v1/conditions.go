@@ -0,0 +1,140 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"time"
+)
+
+// NOTE: This file is used to generate the CRDs use by the Operator. The CRD files should not be manually edited
+// NOTE: json tags are required. Any new fields you add must have json tags for the fields to be serialized.
+
+// ConditionType is the type of a status condition.
+type ConditionType string
+
+const (
+	// ConditionAvailable indicates that all of the desired members are ready.
+	ConditionAvailable ConditionType = "Available"
+	// ConditionProgressing indicates that members are being created, scaled or upgraded.
+	ConditionProgressing ConditionType = "Progressing"
+	// ConditionDegraded indicates that the last reconcile failed.
+	ConditionDegraded ConditionType = "Degraded"
+	// ConditionStatusHA indicates whether the Coherence cluster was Status HA when last checked.
+	ConditionStatusHA ConditionType = "StatusHA"
+	// ConditionQuorumMet indicates whether the start quorum of every role has been met.
+	ConditionQuorumMet ConditionType = "QuorumMet"
+	// ConditionScalingBlocked indicates that a safe scaling operation is waiting for the cluster to be Status HA.
+	ConditionScalingBlocked ConditionType = "ScalingBlocked"
+	// ConditionSplitBrainSuspected indicates that the members of a role report different Coherence cluster sizes.
+	ConditionSplitBrainSuspected ConditionType = "SplitBrainSuspected"
+)
+
+// Condition reasons.
+const (
+	ReasonReplicasReady      = "ReplicasReady"
+	ReasonReplicasNotReady   = "ReplicasNotReady"
+	ReasonCreating           = "Creating"
+	ReasonScaling            = "Scaling"
+	ReasonRollingUpgrade     = "RollingUpgrade"
+	ReasonReconcileFailed    = "ReconcileFailed"
+	ReasonReconcileSucceeded = "ReconcileSucceeded"
+	ReasonStatusHA           = "StatusHA"
+	ReasonNotStatusHA        = "NotStatusHA"
+	ReasonQuorumMet          = "QuorumMet"
+	ReasonWaitingForQuorum   = "WaitingForQuorum"
+	ReasonRolesReady         = "RolesReady"
+	ReasonRolesNotReady      = "RolesNotReady"
+	ReasonRoleFailed         = "RoleFailed"
+	ReasonWaitingForStatusHA = "WaitingForStatusHA"
+	ReasonClusterSizeMatch   = "ClusterSizeMatch"
+	ReasonClusterSizeDiffers = "ClusterSizeDiffers"
+)
+
+// Condition contains details for one aspect of the current state of a resource.
+// The fields match the standard Kubernetes metav1.Condition type so that the
+// conditions can be used by tools such as kubectl wait.
+// +k8s:openapi-gen=true
+type Condition struct {
+	// Type of the condition.
+	Type ConditionType `json:"type"`
+	// Status of the condition, one of True, False or Unknown.
+	Status corev1.ConditionStatus `json:"status"`
+	// ObservedGeneration is the metadata.generation of the resource that the condition was set from.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastTransitionTime is the last time the condition transitioned from one status to another.
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Reason is a CamelCase reason for the condition's last transition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable message indicating details about the transition.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// Conditions is a list of status conditions, with at most one condition of each type.
+type Conditions []Condition
+
+// GetCondition returns the condition with the specified type, or nil if there is no such condition.
+func (in Conditions) GetCondition(t ConditionType) *Condition {
+	for i := range in {
+		if in[i].Type == t {
+			return &in[i]
+		}
+	}
+	return nil
+}
+
+// IsTrue returns true if the condition with the specified type exists and has a True status.
+func (in Conditions) IsTrue(t ConditionType) bool {
+	c := in.GetCondition(t)
+	return c != nil && c.Status == corev1.ConditionTrue
+}
+
+// SetCondition adds or updates the condition with the same type as the specified condition.
+// The LastTransitionTime is only changed if the status of the condition changes.
+// Returns true if the conditions were changed.
+func (in *Conditions) SetCondition(condition Condition) bool {
+	existing := in.GetCondition(condition.Type)
+	if existing == nil {
+		if condition.LastTransitionTime.IsZero() {
+			condition.LastTransitionTime = metav1.NewTime(time.Now())
+		}
+		*in = append(*in, condition)
+		return true
+	}
+
+	if existing.Status == condition.Status && existing.Reason == condition.Reason &&
+		existing.Message == condition.Message && existing.ObservedGeneration == condition.ObservedGeneration {
+		return false
+	}
+
+	if existing.Status != condition.Status {
+		if condition.LastTransitionTime.IsZero() {
+			existing.LastTransitionTime = metav1.NewTime(time.Now())
+		} else {
+			existing.LastTransitionTime = condition.LastTransitionTime
+		}
+	}
+	existing.Status = condition.Status
+	existing.Reason = condition.Reason
+	existing.Message = condition.Message
+	existing.ObservedGeneration = condition.ObservedGeneration
+	return true
+}
+
+// NewCondition creates a Condition with the specified values.
+func NewCondition(t ConditionType, status bool, generation int64, reason, message string) Condition {
+	s := corev1.ConditionFalse
+	if status {
+		s = corev1.ConditionTrue
+	}
+	return Condition{Type: t, Status: s, ObservedGeneration: generation, Reason: reason, Message: message}
+}
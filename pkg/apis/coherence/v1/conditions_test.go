@@ -0,0 +1,140 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package v1
+
+import (
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+	"time"
+)
+
+func TestSetNewCondition(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	conditions := Conditions{}
+	changed := conditions.SetCondition(NewCondition(ConditionAvailable, true, 5, ReasonReplicasReady, "foo"))
+
+	g.Expect(changed).To(BeTrue())
+	g.Expect(len(conditions)).To(Equal(1))
+
+	c := conditions.GetCondition(ConditionAvailable)
+	g.Expect(c).NotTo(BeNil())
+	g.Expect(c.Status).To(Equal(corev1.ConditionTrue))
+	g.Expect(c.ObservedGeneration).To(Equal(int64(5)))
+	g.Expect(c.Reason).To(Equal(ReasonReplicasReady))
+	g.Expect(c.Message).To(Equal("foo"))
+	g.Expect(c.LastTransitionTime.IsZero()).To(BeFalse())
+	g.Expect(conditions.IsTrue(ConditionAvailable)).To(BeTrue())
+}
+
+func TestSetUnchangedCondition(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	conditions := Conditions{}
+	conditions.SetCondition(NewCondition(ConditionAvailable, true, 1, ReasonReplicasReady, "foo"))
+	changed := conditions.SetCondition(NewCondition(ConditionAvailable, true, 1, ReasonReplicasReady, "foo"))
+
+	g.Expect(changed).To(BeFalse())
+	g.Expect(len(conditions)).To(Equal(1))
+}
+
+func TestSetConditionWithSameStatusKeepsTransitionTime(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	transition := metav1.NewTime(time.Now().Add(-time.Hour))
+	conditions := Conditions{
+		{Type: ConditionProgressing, Status: corev1.ConditionTrue, LastTransitionTime: transition, Reason: ReasonCreating},
+	}
+
+	changed := conditions.SetCondition(NewCondition(ConditionProgressing, true, 2, ReasonScaling, "bar"))
+
+	g.Expect(changed).To(BeTrue())
+	c := conditions.GetCondition(ConditionProgressing)
+	g.Expect(c.Reason).To(Equal(ReasonScaling))
+	g.Expect(c.Message).To(Equal("bar"))
+	g.Expect(c.ObservedGeneration).To(Equal(int64(2)))
+	g.Expect(c.LastTransitionTime).To(Equal(transition))
+}
+
+func TestSetConditionWithDifferentStatusUpdatesTransitionTime(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	transition := metav1.NewTime(time.Now().Add(-time.Hour))
+	conditions := Conditions{
+		{Type: ConditionProgressing, Status: corev1.ConditionTrue, LastTransitionTime: transition, Reason: ReasonCreating},
+	}
+
+	changed := conditions.SetCondition(NewCondition(ConditionProgressing, false, 1, ReasonReplicasReady, ""))
+
+	g.Expect(changed).To(BeTrue())
+	c := conditions.GetCondition(ConditionProgressing)
+	g.Expect(c.Status).To(Equal(corev1.ConditionFalse))
+	g.Expect(c.LastTransitionTime.After(transition.Time)).To(BeTrue())
+	g.Expect(conditions.IsTrue(ConditionProgressing)).To(BeFalse())
+}
+
+func TestGetMissingCondition(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	conditions := Conditions{}
+	g.Expect(conditions.GetCondition(ConditionDegraded)).To(BeNil())
+	g.Expect(conditions.IsTrue(ConditionDegraded)).To(BeFalse())
+}
+
+func TestClusterUpdateConditionsWhenAllRolesReady(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cluster := &CoherenceCluster{ObjectMeta: metav1.ObjectMeta{Generation: 3}}
+	cluster.SetRoleStatus("data", true, 3, RoleStatusReady)
+	cluster.SetRoleStatus("proxy", true, 1, RoleStatusReady)
+	cluster.Status.Roles = 2
+
+	cluster.UpdateConditions()
+
+	conditions := cluster.Status.Conditions
+	g.Expect(conditions.IsTrue(ConditionAvailable)).To(BeTrue())
+	g.Expect(conditions.IsTrue(ConditionProgressing)).To(BeFalse())
+	g.Expect(conditions.IsTrue(ConditionDegraded)).To(BeFalse())
+	g.Expect(conditions.GetCondition(ConditionAvailable).ObservedGeneration).To(Equal(int64(3)))
+}
+
+func TestClusterUpdateConditionsWhenRoleNotReady(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cluster := &CoherenceCluster{}
+	cluster.SetRoleStatus("data", true, 3, RoleStatusReady)
+	cluster.SetRoleStatus("proxy", false, 0, RoleStatusCreated)
+	cluster.Status.Roles = 2
+
+	cluster.UpdateConditions()
+
+	conditions := cluster.Status.Conditions
+	g.Expect(conditions.IsTrue(ConditionAvailable)).To(BeFalse())
+	g.Expect(conditions.IsTrue(ConditionProgressing)).To(BeTrue())
+	g.Expect(conditions.IsTrue(ConditionDegraded)).To(BeFalse())
+	g.Expect(conditions.GetCondition(ConditionAvailable).Message).To(Equal("1 of 2 roles are ready"))
+}
+
+func TestClusterUpdateConditionsWhenRoleFailed(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cluster := &CoherenceCluster{}
+	cluster.SetRoleStatus("data", true, 3, RoleStatusReady)
+	cluster.SetRoleStatus("proxy", false, 0, RoleStatusFailed)
+	cluster.Status.Roles = 2
+
+	cluster.UpdateConditions()
+
+	conditions := cluster.Status.Conditions
+	g.Expect(conditions.IsTrue(ConditionAvailable)).To(BeFalse())
+	g.Expect(conditions.IsTrue(ConditionProgressing)).To(BeFalse())
+	g.Expect(conditions.IsTrue(ConditionDegraded)).To(BeTrue())
+	g.Expect(conditions.GetCondition(ConditionDegraded).Reason).To(Equal(ReasonRoleFailed))
+	g.Expect(conditions.GetCondition(ConditionDegraded).Message).To(Equal("failed roles: proxy"))
+}
@@ -0,0 +1,365 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package archive
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// OperationArchive copies the snapshot files of a member to the store.
+	OperationArchive = "archive"
+	// OperationRetrieve copies the snapshot files of a member from the store to the snapshot directory.
+	OperationRetrieve = "retrieve"
+	// OperationRemove removes archived snapshot files from the store.
+	OperationRemove = "remove"
+
+	// The default Coherence snapshot directory.
+	DefaultSnapshotDir = "/snapshot"
+	// The name of the manifest that lists the archived files of a member.
+	manifestName = "manifest.json"
+	// The prefix of the directory that files are retrieved into before they are moved into place.
+	retrievePrefix = ".retrieve-"
+)
+
+// Manifest lists the snapshot files archived for a member. The manifest is written after all of the
+// files have been stored so a member's archive is only complete if its manifest exists.
+type Manifest struct {
+	Cluster  string    `json:"cluster"`
+	Snapshot string    `json:"snapshot"`
+	Member   string    `json:"member"`
+	Created  time.Time `json:"created"`
+	Files    []File    `json:"files"`
+}
+
+// File is an archived snapshot file.
+type File struct {
+	// The slash separated path of the file relative to the cluster's snapshot directory,
+	// of the form <service>/<snapshot>/...
+	Path string `json:"path"`
+	// The size of the file in bytes.
+	Size int64 `json:"size"`
+	// The hex encoded SHA-256 checksum of the file.
+	SHA256 string `json:"sha256"`
+}
+
+// Run performs an archive operation using the store specified in the configuration. The manifest of the
+// member's archive is returned for the archive and retrieve operations.
+func Run(operation string, cfg Config) (*Manifest, error) {
+	store, err := NewStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	switch operation {
+	case OperationArchive:
+		return Archive(store, cfg)
+	case OperationRetrieve:
+		return Retrieve(store, cfg)
+	case OperationRemove:
+		return nil, Remove(store, cfg)
+	default:
+		return nil, fmt.Errorf("invalid operation %q, expected one of %s, %s or %s", operation, OperationArchive, OperationRetrieve, OperationRemove)
+	}
+}
+
+// Archive copies the files of a member's snapshot to the store, followed by the manifest of the files.
+// Coherence keeps the files of a snapshot in <snapshot dir>/<cluster>/<service>/<snapshot> directories.
+func Archive(store Store, cfg Config) (*Manifest, error) {
+	if err := validate(cfg, true); err != nil {
+		return nil, err
+	}
+
+	clusterDir := filepath.Join(cfg.getSnapshotDir(), cfg.Cluster)
+	files, err := findSnapshotFiles(clusterDir, cfg.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("there are no files for snapshot %s in %s", cfg.Snapshot, clusterDir)
+	}
+
+	// remove any existing manifest first so that a partially replaced archive is not mistaken for a complete one
+	memberKey := cfg.memberKey()
+	if err = store.Delete(memberKey + "/" + manifestName); err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{Cluster: cfg.Cluster, Snapshot: cfg.Snapshot, Member: cfg.Member, Created: time.Now().UTC()}
+	keys := map[string]bool{}
+	for _, rel := range files {
+		f, err := putFile(store, memberKey+"/"+rel, filepath.Join(clusterDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, err
+		}
+		f.Path = rel
+		manifest.Files = append(manifest.Files, f)
+		keys[memberKey+"/"+rel] = true
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err = store.Put(memberKey+"/"+manifestName, bytes.NewReader(data), int64(len(data)), hashHex(data)); err != nil {
+		return nil, err
+	}
+	keys[memberKey+"/"+manifestName] = true
+
+	// remove any files left from an earlier archive of a snapshot with the same name
+	existing, err := store.List(memberKey + "/")
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range existing {
+		if !keys[key] {
+			if err = store.Delete(key); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return manifest, nil
+}
+
+// Retrieve copies the archived files of a member's snapshot from the store to the snapshot directory,
+// verifying the size and checksum of each file. The files are copied to a temporary directory and only
+// replace any existing files for the snapshot once every file has been verified.
+func Retrieve(store Store, cfg Config) (*Manifest, error) {
+	if err := validate(cfg, true); err != nil {
+		return nil, err
+	}
+
+	manifest, err := getManifest(store, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterDir := filepath.Join(cfg.getSnapshotDir(), cfg.Cluster)
+	staging := filepath.Join(clusterDir, retrievePrefix+cfg.Snapshot)
+	if err = os.RemoveAll(staging); err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	memberKey := cfg.memberKey()
+	dirs := map[string]bool{}
+	for _, f := range manifest.Files {
+		parts := strings.SplitN(f.Path, "/", 3)
+		if !isLocalPath(f.Path) || len(parts) != 3 || parts[1] != cfg.Snapshot {
+			return nil, fmt.Errorf("invalid file %q in the manifest of snapshot %s", f.Path, cfg.Snapshot)
+		}
+		if err = getFile(store, memberKey+"/"+f.Path, filepath.Join(staging, filepath.FromSlash(f.Path)), f); err != nil {
+			return nil, err
+		}
+		dirs[parts[0]] = true
+	}
+
+	for service := range dirs {
+		target := filepath.Join(clusterDir, service, cfg.Snapshot)
+		if err = os.RemoveAll(target); err != nil {
+			return nil, err
+		}
+		if err = os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+			return nil, err
+		}
+		if err = os.Rename(filepath.Join(staging, service, cfg.Snapshot), target); err != nil {
+			return nil, err
+		}
+	}
+	return manifest, nil
+}
+
+// Remove removes the archived files of a member's snapshot from the store, or the archived files of every
+// member if no member is specified. Manifests are removed first so that a partially removed archive is
+// not mistaken for a complete one.
+func Remove(store Store, cfg Config) error {
+	if err := validate(cfg, false); err != nil {
+		return err
+	}
+
+	prefix := cfg.snapshotKey() + "/"
+	if cfg.Member != "" {
+		prefix = cfg.memberKey() + "/"
+	}
+	keys, err := store.List(prefix)
+	if err != nil {
+		return err
+	}
+
+	var files []string
+	for _, key := range keys {
+		if path.Base(key) != manifestName {
+			files = append(files, key)
+		} else if err = store.Delete(key); err != nil {
+			return err
+		}
+	}
+	for _, key := range files {
+		if err = store.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getManifest reads the manifest of a member's archive.
+func getManifest(store Store, cfg Config) (*Manifest, error) {
+	r, err := store.Get(cfg.memberKey() + "/" + manifestName)
+	if err == ErrNotFound {
+		return nil, fmt.Errorf("snapshot %s of cluster %s has not been archived for member %s", cfg.Snapshot, cfg.Cluster, cfg.Member)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	manifest := &Manifest{}
+	if err = json.NewDecoder(r).Decode(manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest for snapshot %s: %s", cfg.Snapshot, err.Error())
+	}
+	return manifest, nil
+}
+
+// findSnapshotFiles returns the slash separated paths, relative to the cluster directory, of the files in
+// each service's directory for a snapshot.
+func findSnapshotFiles(clusterDir, snapshot string) ([]string, error) {
+	services, err := ioutil.ReadDir(clusterDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, service := range services {
+		dir := filepath.Join(clusterDir, service.Name(), snapshot)
+		if info, err := os.Stat(dir); err != nil || !service.IsDir() || !info.IsDir() {
+			continue
+		}
+		err = filepath.Walk(dir, func(name string, info os.FileInfo, err error) error {
+			if err != nil || !info.Mode().IsRegular() {
+				return err
+			}
+			rel, err := filepath.Rel(clusterDir, name)
+			if err == nil {
+				files = append(files, filepath.ToSlash(rel))
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// putFile calculates the checksum of a file and copies the file to the store.
+func putFile(store Store, key, name string) (File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return File{}, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return File{}, err
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return File{}, err
+	}
+
+	sum := hex.EncodeToString(h.Sum(nil))
+	if err = store.Put(key, io.LimitReader(f, size), size, sum); err != nil {
+		return File{}, err
+	}
+	return File{Size: size, SHA256: sum}, nil
+}
+
+// getFile copies a file from the store, verifying that its size and checksum match the manifest.
+func getFile(store Store, key, name string, expected File) error {
+	r, err := store.Get(key)
+	if err == ErrNotFound {
+		return fmt.Errorf("archived file %s is missing", expected.Path)
+	}
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if err = os.MkdirAll(filepath.Dir(name), os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, h), r)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	switch {
+	case err != nil:
+		return err
+	case size != expected.Size:
+		return fmt.Errorf("archived file %s has size %d, expected %d", expected.Path, size, expected.Size)
+	case hex.EncodeToString(h.Sum(nil)) != expected.SHA256:
+		return fmt.Errorf("archived file %s does not match its checksum", expected.Path)
+	}
+	return nil
+}
+
+// validate checks that the configuration specifies a snapshot, and a member if one is required.
+func validate(cfg Config, memberRequired bool) error {
+	switch {
+	case cfg.Cluster == "" || cfg.Snapshot == "":
+		return fmt.Errorf("a cluster and a snapshot must be specified")
+	case memberRequired && cfg.Member == "":
+		return fmt.Errorf("a member must be specified")
+	case !isLocalPath(cfg.Cluster) || !isLocalPath(cfg.Snapshot) || (cfg.Member != "" && !isLocalPath(cfg.Member)):
+		return fmt.Errorf("invalid cluster, snapshot or member name")
+	}
+	return nil
+}
+
+// getSnapshotDir returns the Coherence snapshot directory.
+func (in Config) getSnapshotDir() string {
+	if in.SnapshotDir == "" {
+		return DefaultSnapshotDir
+	}
+	return in.SnapshotDir
+}
+
+// snapshotKey returns the key prefix of the archived files of every member for the snapshot.
+func (in Config) snapshotKey() string {
+	return path.Join(strings.Trim(in.Prefix, "/"), in.Cluster, in.Snapshot)
+}
+
+// memberKey returns the key prefix of the archived files of the member.
+func (in Config) memberKey() string {
+	return in.snapshotKey() + "/" + in.Member
+}
+
+// isLocalPath returns true if p is a clean, relative, slash separated path that does not refer to a parent directory.
+func isLocalPath(p string) bool {
+	return p != "" && p != "." && p != ".." && !path.IsAbs(p) && path.Clean(p) == p &&
+		!strings.HasPrefix(p, "../") && !strings.Contains(p, "\\")
+}
@@ -0,0 +1,207 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package archive
+
+import (
+	. "github.com/onsi/gomega"
+	"github.com/oracle/coherence-operator/pkg/fakes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// snapshotFiles are the files of snapshot "snap" for member "member-0" of cluster "test".
+var snapshotFiles = map[string]string{
+	"PartitionedCache/snap/0-1-abc/data": "partition one",
+	"PartitionedCache/snap/0-2-abc/data": "partition two",
+	"OtherCache/snap/meta.properties":    "meta",
+}
+
+func newTestConfig(g *GomegaWithT) Config {
+	dir, err := ioutil.TempDir("", "snapshot")
+	g.Expect(err).NotTo(HaveOccurred())
+	return Config{Cluster: "test", Snapshot: "snap", Member: "member-0", SnapshotDir: dir, Prefix: "backups"}
+}
+
+func writeFiles(g *GomegaWithT, dir string, files map[string]string) {
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		g.Expect(os.MkdirAll(filepath.Dir(file), os.ModePerm)).To(Succeed())
+		g.Expect(ioutil.WriteFile(file, []byte(content), 0600)).To(Succeed())
+	}
+}
+
+func readFile(g *GomegaWithT, dir, name string) string {
+	data, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	g.Expect(err).NotTo(HaveOccurred())
+	return string(data)
+}
+
+// assertRoundTrip archives the snapshot files, removes them and retrieves them again.
+func assertRoundTrip(g *GomegaWithT, cfg Config) {
+	clusterDir := filepath.Join(cfg.SnapshotDir, cfg.Cluster)
+	writeFiles(g, clusterDir, snapshotFiles)
+	writeFiles(g, clusterDir, map[string]string{"PartitionedCache/other/data": "another snapshot"})
+
+	manifest, err := Run(OperationArchive, cfg)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(manifest.Files).To(HaveLen(len(snapshotFiles)))
+	for _, f := range manifest.Files {
+		g.Expect(snapshotFiles).To(HaveKey(f.Path))
+		g.Expect(f.Size).To(BeNumerically("==", len(snapshotFiles[f.Path])))
+	}
+
+	g.Expect(os.RemoveAll(filepath.Join(clusterDir, "PartitionedCache", "snap"))).To(Succeed())
+	writeFiles(g, clusterDir, map[string]string{"OtherCache/snap/meta.properties": "changed"})
+
+	_, err = Run(OperationRetrieve, cfg)
+	g.Expect(err).NotTo(HaveOccurred())
+	for name, content := range snapshotFiles {
+		g.Expect(readFile(g, clusterDir, name)).To(Equal(content))
+	}
+	g.Expect(readFile(g, clusterDir, "PartitionedCache/other/data")).To(Equal("another snapshot"))
+	_, err = os.Stat(filepath.Join(clusterDir, retrievePrefix+cfg.Snapshot))
+	g.Expect(os.IsNotExist(err)).To(BeTrue())
+}
+
+func TestArchiveAndRetrieveUsingDirectoryStore(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cfg := newTestConfig(g)
+	defer os.RemoveAll(cfg.SnapshotDir)
+	cfg.Directory = filepath.Join(cfg.SnapshotDir, "archive")
+
+	assertRoundTrip(g, cfg)
+
+	keys, err := NewDirectoryStore(cfg.Directory).List("")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(keys).To(ConsistOf(
+		"backups/test/snap/member-0/manifest.json",
+		"backups/test/snap/member-0/OtherCache/snap/meta.properties",
+		"backups/test/snap/member-0/PartitionedCache/snap/0-1-abc/data",
+		"backups/test/snap/member-0/PartitionedCache/snap/0-2-abc/data"))
+}
+
+func TestArchiveAndRetrieveUsingS3Store(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cfg := newTestConfig(g)
+	defer os.RemoveAll(cfg.SnapshotDir)
+	server := fakes.NewFakeS3Server(testAccessKey, testBucket)
+	defer server.Close()
+	cfg.S3 = &S3Config{Endpoint: server.URL(), Bucket: testBucket, AccessKey: testAccessKey, SecretKey: testSecretKey}
+
+	assertRoundTrip(g, cfg)
+	g.Expect(server.Keys(testBucket)).To(ContainElement("backups/test/snap/member-0/manifest.json"))
+}
+
+func TestArchiveWithoutSnapshotFilesFails(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cfg := newTestConfig(g)
+	defer os.RemoveAll(cfg.SnapshotDir)
+	cfg.Directory = filepath.Join(cfg.SnapshotDir, "archive")
+
+	_, err := Run(OperationArchive, cfg)
+	g.Expect(err).To(MatchError(ContainSubstring("there are no files for snapshot snap")))
+}
+
+func TestArchiveRemovesFilesOfEarlierArchive(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cfg := newTestConfig(g)
+	defer os.RemoveAll(cfg.SnapshotDir)
+	cfg.Directory = filepath.Join(cfg.SnapshotDir, "archive")
+	store := NewDirectoryStore(cfg.Directory)
+
+	writeFiles(g, filepath.Join(cfg.Directory, "backups", "test", "snap", "member-0"), map[string]string{"OldCache/snap/data": "old"})
+	writeFiles(g, filepath.Join(cfg.SnapshotDir, "test"), snapshotFiles)
+
+	_, err := Archive(store, cfg)
+	g.Expect(err).NotTo(HaveOccurred())
+	keys, err := store.List("backups/test/snap/member-0/OldCache")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(keys).To(BeEmpty())
+}
+
+func TestRetrieveWithCorruptFileFails(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cfg := newTestConfig(g)
+	defer os.RemoveAll(cfg.SnapshotDir)
+	server := fakes.NewFakeS3Server(testAccessKey, testBucket)
+	defer server.Close()
+	cfg.S3 = &S3Config{Endpoint: server.URL(), Bucket: testBucket, AccessKey: testAccessKey, SecretKey: testSecretKey}
+
+	clusterDir := filepath.Join(cfg.SnapshotDir, cfg.Cluster)
+	writeFiles(g, clusterDir, snapshotFiles)
+	_, err := Run(OperationArchive, cfg)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	server.PutObject(testBucket, "backups/test/snap/member-0/PartitionedCache/snap/0-1-abc/data", []byte("partition 1ne"))
+	writeFiles(g, clusterDir, map[string]string{"PartitionedCache/snap/0-1-abc/data": "local"})
+
+	_, err = Run(OperationRetrieve, cfg)
+	g.Expect(err).To(MatchError("archived file PartitionedCache/snap/0-1-abc/data does not match its checksum"))
+	// the existing local files are left unchanged
+	g.Expect(readFile(g, clusterDir, "PartitionedCache/snap/0-1-abc/data")).To(Equal("local"))
+}
+
+func TestRetrieveWithoutManifestFails(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cfg := newTestConfig(g)
+	defer os.RemoveAll(cfg.SnapshotDir)
+	cfg.Directory = filepath.Join(cfg.SnapshotDir, "archive")
+
+	_, err := Run(OperationRetrieve, cfg)
+	g.Expect(err).To(MatchError("snapshot snap of cluster test has not been archived for member member-0"))
+}
+
+func TestRetrieveRejectsManifestOutsideSnapshot(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cfg := newTestConfig(g)
+	defer os.RemoveAll(cfg.SnapshotDir)
+	cfg.Directory = filepath.Join(cfg.SnapshotDir, "archive")
+
+	manifest := `{"files": [{"path": "../../etc/passwd", "size": 1, "sha256": ""}]}`
+	writeFiles(g, filepath.Join(cfg.Directory, "backups", "test", "snap", "member-0"), map[string]string{manifestName: manifest})
+
+	_, err := Run(OperationRetrieve, cfg)
+	g.Expect(err).To(MatchError(ContainSubstring("invalid file")))
+}
+
+func TestRemoveAllMembers(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cfg := newTestConfig(g)
+	defer os.RemoveAll(cfg.SnapshotDir)
+	cfg.Directory = filepath.Join(cfg.SnapshotDir, "archive")
+
+	writeFiles(g, filepath.Join(cfg.SnapshotDir, "test"), snapshotFiles)
+	_, err := Run(OperationArchive, cfg)
+	g.Expect(err).NotTo(HaveOccurred())
+	cfg.Member = "member-1"
+	_, err = Run(OperationArchive, cfg)
+	g.Expect(err).NotTo(HaveOccurred())
+	writeFiles(g, filepath.Join(cfg.Directory, "backups", "test", "other"), map[string]string{"data": "other snapshot"})
+
+	cfg.Member = ""
+	_, err = Run(OperationRemove, cfg)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	keys, err := NewDirectoryStore(cfg.Directory).List("")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(keys).To(Equal([]string{"backups/test/other/data"}))
+}
+
+func TestRunValidatesConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	_, err := Run(OperationArchive, Config{Cluster: "test", Snapshot: "snap", Member: "m"})
+	g.Expect(err).To(MatchError("no snapshot store has been specified"))
+	_, err = Run("copy", Config{Directory: "/tmp"})
+	g.Expect(err).To(MatchError(ContainSubstring("invalid operation")))
+	_, err = Run(OperationArchive, Config{Cluster: "test", Snapshot: "snap", Directory: "/tmp"})
+	g.Expect(err).To(MatchError("a member must be specified"))
+	_, err = Run(OperationArchive, Config{Cluster: "test", Snapshot: "../snap", Member: "m", Directory: "/tmp"})
+	g.Expect(err).To(MatchError("invalid cluster, snapshot or member name"))
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package archive
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DirectoryStore is a Store that keeps files in a directory, typically on a shared PersistentVolume.
+type DirectoryStore struct {
+	root string
+}
+
+// NewDirectoryStore creates a DirectoryStore rooted at the specified directory.
+func NewDirectoryStore(root string) *DirectoryStore {
+	return &DirectoryStore{root: root}
+}
+
+// Put writes the content to a temporary file that is renamed once all of the content has been written.
+func (d *DirectoryStore) Put(key string, r io.Reader, size int64, _ string) error {
+	name, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(name), os.ModePerm); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(name), ".upload-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, r)
+	if e := tmp.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return fmt.Errorf("wrote %d bytes to %s, expected %d", n, key, size)
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+func (d *DirectoryStore) Get(key string) (io.ReadCloser, error) {
+	name, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (d *DirectoryStore) Delete(key string) error {
+	name, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(name); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (d *DirectoryStore) List(prefix string) ([]string, error) {
+	var keys []string
+	err := filepath.Walk(d.root, func(name string, info os.FileInfo, err error) error {
+		switch {
+		case os.IsNotExist(err):
+			return nil
+		case err != nil:
+			return err
+		case info.IsDir() || strings.HasPrefix(info.Name(), ".upload-"):
+			return nil
+		}
+		rel, err := filepath.Rel(d.root, name)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	sort.Strings(keys)
+	return keys, err
+}
+
+// path returns the name of the file for a key, which must not refer to a file outside of the root directory.
+func (d *DirectoryStore) path(key string) (string, error) {
+	if !isLocalPath(key) {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	return filepath.Join(d.root, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,278 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package archive
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// The region used to sign requests if none is configured.
+	defaultRegion = "us-east-1"
+	// The timeout for a single request to the object store.
+	s3Timeout = time.Minute * 10
+	// The SigV4 signing algorithm.
+	signingAlgorithm = "AWS4-HMAC-SHA256"
+	// The hex encoded SHA-256 checksum of an empty payload.
+	emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	amzDateFormat  = "20060102T150405Z"
+	amzShortFormat = "20060102"
+)
+
+// S3Store is a Store that keeps files in a bucket of an S3 compatible object store, such as AWS S3
+// or MinIO. Buckets are addressed using path style URLs and requests are signed using AWS Signature
+// Version 4 with the payload checksum, so the object store verifies the content that it receives.
+type S3Store struct {
+	client   *http.Client
+	endpoint *url.URL
+	cfg      S3Config
+	now      func() time.Time
+}
+
+// NewS3Store creates an S3Store from the specified configuration.
+func NewS3Store(cfg S3Config) (*S3Store, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("an S3 store requires an endpoint and a bucket")
+	}
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q, the scheme must be http or https", cfg.Endpoint)
+	}
+	if cfg.Region == "" {
+		cfg.Region = defaultRegion
+	}
+	return &S3Store{client: &http.Client{Timeout: s3Timeout}, endpoint: u, cfg: cfg, now: time.Now}, nil
+}
+
+func (s *S3Store) Put(key string, r io.Reader, size int64, sha256 string) error {
+	req, err := s.newRequest(http.MethodPut, key, nil, r, sha256)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	_, err = s.do(req, http.StatusOK)
+	return err
+}
+
+func (s *S3Store) Get(key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(http.MethodGet, key, nil, nil, emptySHA256)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		_ = resp.Body.Close()
+		return nil, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, responseError(req, resp)
+	}
+	return resp.Body, nil
+}
+
+func (s *S3Store) Delete(key string) error {
+	req, err := s.newRequest(http.MethodDelete, key, nil, nil, emptySHA256)
+	if err != nil {
+		return err
+	}
+	_, err = s.do(req, http.StatusNoContent, http.StatusOK, http.StatusNotFound)
+	return err
+}
+
+// listBucketResult is the response to a ListObjectsV2 request.
+type listBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *S3Store) List(prefix string) ([]string, error) {
+	var keys []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		req, err := s.newRequest(http.MethodGet, "", query, nil, emptySHA256)
+		if err != nil {
+			return nil, err
+		}
+		body, err := s.do(req, http.StatusOK)
+		if err != nil {
+			return nil, err
+		}
+		result := listBucketResult{}
+		if err = xml.Unmarshal(body, &result); err != nil {
+			return nil, err
+		}
+		for _, c := range result.Contents {
+			keys = append(keys, c.Key)
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			sort.Strings(keys)
+			return keys, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// newRequest creates a signed request for an object in the bucket, or for the bucket itself if the key is empty.
+func (s *S3Store) newRequest(method, key string, query url.Values, body io.Reader, payloadHash string) (*http.Request, error) {
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.cfg.Bucket
+	if key != "" {
+		u.Path += "/" + key
+	}
+	// the path sent must be encoded in the same way as the path that is signed
+	u.RawPath = uriEncode(u.Path, false)
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	signV4(req, s.cfg, payloadHash, s.now())
+	return req, nil
+}
+
+// do sends a request and returns the response body if the response has one of the expected status codes.
+func (s *S3Store) do(req *http.Request, expected ...int) ([]byte, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range expected {
+		if resp.StatusCode == status {
+			defer resp.Body.Close()
+			return ioutil.ReadAll(resp.Body)
+		}
+	}
+	return nil, responseError(req, resp)
+}
+
+// s3Error is the error document returned by an S3 compatible object store.
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// responseError returns an error describing an unexpected response, closing the response body.
+func responseError(req *http.Request, resp *http.Response) error {
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	e := s3Error{}
+	if xml.Unmarshal(body, &e) == nil && e.Code != "" {
+		return fmt.Errorf("%s %s returned status %d: %s %s", req.Method, req.URL.Path, resp.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Errorf("%s %s returned status %d", req.Method, req.URL.Path, resp.StatusCode)
+}
+
+// signV4 signs a request using AWS Signature Version 4, signing the host header, any headers
+// already set on the request and the x-amz-date and x-amz-content-sha256 headers.
+func signV4(req *http.Request, cfg S3Config, payloadHash string, t time.Time) {
+	t = t.UTC()
+	req.Header.Set("x-amz-date", t.Format(amzDateFormat))
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	canonicalHeaders := strings.Builder{}
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{t.Format(amzShortFormat), cfg.Region, "s3", "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{signingAlgorithm, t.Format(amzDateFormat), scope, hashHex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+cfg.SecretKey), t.Format(amzShortFormat))
+	key = hmacSHA256(key, cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signingAlgorithm, cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery returns the SigV4 canonical form of a query string.
+func canonicalQuery(query url.Values) string {
+	var params []string
+	for name, values := range query {
+		for _, value := range values {
+			params = append(params, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// uriEncode encodes a string as required by SigV4, leaving unreserved characters unchanged and,
+// unless encodeSlash is true, leaving slashes unchanged.
+func uriEncode(s string, encodeSlash bool) string {
+	b := strings.Builder{}
+	for _, c := range []byte(s) {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteString(fmt.Sprintf("%%%02X", c))
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hashHex(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
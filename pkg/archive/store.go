@@ -0,0 +1,79 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+// The archive package copies the persistence snapshot files of a Coherence member to and from a store outside
+// of the member's volumes, such as an S3 compatible object store or a directory on a shared volume, so that
+// snapshots outlive the Pods and PersistentVolumeClaims of a cluster.
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrNotFound is returned by a Store when a key does not exist.
+var ErrNotFound = errors.New("not found")
+
+// Store is a location that archived snapshot files are stored in. Keys are slash separated paths.
+type Store interface {
+	// Put stores the size bytes read from r with the specified key. The sha256 is the hex encoded
+	// SHA-256 checksum of the content, which the store may use to verify the content it receives.
+	Put(key string, r io.Reader, size int64, sha256 string) error
+	// Get returns the content stored with the specified key, or ErrNotFound.
+	Get(key string) (io.ReadCloser, error)
+	// Delete removes the content stored with the specified key. Deleting a key that does not exist is not an error.
+	Delete(key string) error
+	// List returns the keys that start with the specified prefix.
+	List(prefix string) ([]string, error)
+}
+
+// Config is the configuration of a snapshot archive operation.
+type Config struct {
+	// The name of the Coherence cluster.
+	Cluster string `json:"cluster"`
+	// The name of the snapshot.
+	Snapshot string `json:"snapshot"`
+	// The name of the member whose snapshot files are archived or retrieved, typically the Pod name.
+	// If empty a remove operation removes the files of every member.
+	Member string `json:"member,omitempty"`
+	// The Coherence snapshot directory, the default is /snapshot.
+	SnapshotDir string `json:"snapshotDir,omitempty"`
+	// An optional prefix for the keys of the archived files.
+	Prefix string `json:"prefix,omitempty"`
+	// The S3 compatible object store to use.
+	S3 *S3Config `json:"s3,omitempty"`
+	// The directory to use as the store, typically the mount path of a shared volume.
+	Directory string `json:"directory,omitempty"`
+}
+
+// S3Config is the configuration of an S3 compatible object store.
+type S3Config struct {
+	// The URL of the object store, for example https://s3.eu-west-1.amazonaws.com or http://minio:9000.
+	Endpoint string `json:"endpoint"`
+	// The name of the bucket.
+	Bucket string `json:"bucket"`
+	// The region of the bucket, the default is us-east-1.
+	Region string `json:"region,omitempty"`
+	// The access key ID used to sign requests.
+	AccessKey string `json:"accessKey"`
+	// The secret access key used to sign requests.
+	SecretKey string `json:"secretKey"`
+}
+
+// NewStore creates the Store specified by a Config.
+func NewStore(cfg Config) (Store, error) {
+	switch {
+	case cfg.S3 != nil && cfg.Directory != "":
+		return nil, fmt.Errorf("only one of an S3 store or a directory store may be specified")
+	case cfg.S3 != nil:
+		return NewS3Store(*cfg.S3)
+	case cfg.Directory != "":
+		return NewDirectoryStore(cfg.Directory), nil
+	default:
+		return nil, fmt.Errorf("no snapshot store has been specified")
+	}
+}
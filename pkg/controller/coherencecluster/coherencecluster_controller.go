@@ -15,16 +15,16 @@ import (
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
 	coherence "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
 	"github.com/oracle/coherence-operator/pkg/controller/coherencerole"
+	"github.com/oracle/coherence-operator/pkg/controller/events"
 	"github.com/oracle/coherence-operator/pkg/flags"
+	"github.com/oracle/coherence-operator/pkg/metrics"
 	"github.com/oracle/coherence-operator/pkg/operator"
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
-	"k8s.io/client-go/tools/record"
 	"os"
-	"reflect"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -33,6 +33,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -48,13 +49,7 @@ const (
 	updateFailedEventMessage string = "update CoherenceRole %s in CoherenceCluster %s failed\n%s"
 	deleteEventMessage       string = "deleted CoherenceRole %s in CoherenceCluster %s successful"
 	deleteFailedEventMessage string = "delete CoherenceRole %s in CoherenceCluster %s failed\n%s"
-
-	eventReasonCreated      string = "SuccessfulCreate"
-	eventReasonFailedCreate string = "FailedCreate"
-	eventReasonUpdated      string = "SuccessfulUpdate"
-	eventReasonFailedUpdate string = "FailedUpdate"
-	eventReasonDeleted      string = "SuccessfulDelete"
-	eventReasonFailedDelete string = "FailedDelete"
+	quorumMetMessage         string = "the start quorum of every role in CoherenceCluster %s has been met"
 
 	versionEnv     = "VERSION_FULL"
 	versionUnknown = "UNKNOWN"
@@ -82,21 +77,23 @@ func newReconciler(mgr manager.Manager, opFlags *flags.CoherenceOperatorFlags) *
 	}
 
 	return &ReconcileCoherenceCluster{
-		client:        mgr.GetClient(),
-		scheme:        mgr.GetScheme(),
-		events:        mgr.GetEventRecorderFor(controllerName),
-		resourceLocks: make(map[types.NamespacedName]bool),
-		mutex:         sync.Mutex{},
-		version:       version,
-		opFlags:       opFlags,
-		mgr:           mgr,
+		client:  mgr.GetClient(),
+		scheme:  mgr.GetScheme(),
+		events:  events.NewRecorder(mgr.GetEventRecorderFor(controllerName)),
+		mutex:   sync.Mutex{},
+		version: version,
+		opFlags: opFlags,
+		mgr:     mgr,
 	}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler.
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
+func add(mgr manager.Manager, r *ReconcileCoherenceCluster) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, controller.Options{
+		Reconciler:              metrics.InstrumentReconciler(controllerName, r),
+		MaxConcurrentReconciles: r.opFlags.GetMaxConcurrentReconciles(),
+	})
 	if err != nil {
 		return err
 	}
@@ -117,15 +114,14 @@ var _ reconcile.Reconciler = &ReconcileCoherenceCluster{}
 type ReconcileCoherenceCluster struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the api server
-	client        client.Client
-	scheme        *runtime.Scheme
-	events        record.EventRecorder
-	resourceLocks map[types.NamespacedName]bool
-	mutex         sync.Mutex
-	version       string
-	opFlags       *flags.CoherenceOperatorFlags
-	mgr           manager.Manager
-	initialized   bool
+	client      client.Client
+	scheme      *runtime.Scheme
+	events      *events.Recorder
+	mutex       sync.Mutex
+	version     string
+	opFlags     *flags.CoherenceOperatorFlags
+	mgr         manager.Manager
+	initialized bool
 }
 
 // Set the initialized flag for this controller.
@@ -135,36 +131,6 @@ func (r *ReconcileCoherenceCluster) SetInitialized(i bool) {
 	}
 }
 
-// Attempt to lock the requested resource.
-func (r *ReconcileCoherenceCluster) lock(request reconcile.Request) bool {
-	if r == nil {
-		return false
-	}
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-
-	_, found := r.resourceLocks[request.NamespacedName]
-	if found {
-		log.Info("CoherenceCluster " + request.Namespace + "/" + request.Name + " is locked")
-		return false
-	}
-
-	r.resourceLocks[request.NamespacedName] = true
-	log.Info("Acquired lock for CoherenceCluster " + request.Namespace + "/" + request.Name)
-	return true
-}
-
-// Unlock the requested resource
-func (r *ReconcileCoherenceCluster) unlock(request reconcile.Request) {
-	if r != nil {
-		r.mutex.Lock()
-		defer r.mutex.Unlock()
-
-		log.Info("Released lock for CoherenceCluster " + request.Namespace + "/" + request.Name)
-		delete(r.resourceLocks, request.NamespacedName)
-	}
-}
-
 // Reconcile reads that state of a CoherenceCluster object and makes changes based on the state read
 // and what is in the CoherenceCluster.Spec.
 // Note:
@@ -177,15 +143,8 @@ func (r *ReconcileCoherenceCluster) Reconcile(request reconcile.Request) (reconc
 		return reconcile.Result{}, err
 	}
 
-	// Attempt to lock the requested resource. If the resource is locked then another
-	// request for the same resource is already in progress so requeue this one.
-	if ok := r.lock(request); !ok {
-		logger.Info("CoherenceCluster " + request.Namespace + "/" + request.Name + " is already locked, re-queuing request")
-		return reconcile.Result{Requeue: true, RequeueAfter: 0}, nil
-	}
-	// Make sure that the request is unlocked when this method exits
-	defer r.unlock(request)
-
+	// The controller's work queue never hands the same request to more than one worker at a time, so
+	// different CoherenceClusters may be reconciled in parallel but a CoherenceCluster is never reconciled concurrently.
 	return r.reconcileInternal(request)
 }
 
@@ -202,7 +161,7 @@ func (r *ReconcileCoherenceCluster) reconcileInternal(request reconcile.Request)
 	cluster := &coherence.CoherenceCluster{}
 	err := r.client.Get(context.TODO(), request.NamespacedName, cluster)
 	if err != nil {
-		if errors.IsNotFound(err) || cluster.GetDeletionTimestamp() != nil {
+		if errors.IsNotFound(err) {
 			logger.Info("CoherenceCluster deleted")
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
@@ -218,6 +177,23 @@ func (r *ReconcileCoherenceCluster) reconcileInternal(request reconcile.Request)
 		return reconcile.Result{}, err
 	}
 
+	if cluster.GetDeletionTimestamp() != nil {
+		if coherence.HasFinalizer(cluster) {
+			// The cluster is being deleted so tear down its roles in a safe order before removing the finalizer
+			return r.finalizeCluster(cluster, logger)
+		}
+		logger.Info("CoherenceCluster deleted")
+		return reconcile.Result{}, nil
+	}
+
+	// Ensure the cluster has the finalizer so that its roles are torn down safely when it is deleted
+	if !coherence.HasFinalizer(cluster) {
+		controllerutil.AddFinalizer(cluster, coherence.CoherenceFinalizer)
+		if err = r.client.Update(context.TODO(), cluster); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
 	clusterName := cluster.GetName()
 
 	existingRoles := make(map[string]coherence.CoherenceRole)
@@ -240,6 +216,9 @@ func (r *ReconcileCoherenceCluster) reconcileInternal(request reconcile.Request)
 
 	log.Info("Desired role names: '"+strings.Join(desiredRoleNames, "', '")+"'", "Namespace", request.Namespace, "Name", request.Name)
 
+	// the reasons that any roles are waiting for their start quorum
+	var waiting []string
+
 	// Process the inserts and updates in the order they are specified in the cluster spec
 	for _, roleName := range desiredRoleNames {
 		log.Info("Reconciling role", "Namespace", request.Namespace, "Name", request.Name, "Role", roleName)
@@ -281,12 +260,28 @@ func (r *ReconcileCoherenceCluster) reconcileInternal(request reconcile.Request)
 				// The log will give the reason why so the customer can see what conditions are being waited on.
 				log.Info("Cannot create role - "+reason, "Namespace", request.Namespace, "Name", request.Name, "Role", roleName)
 				status = coherence.RoleStatusWaiting
+				waiting = append(waiting, fmt.Sprintf("role %s: %s", roleName, reason))
 			}
 
 			cluster.SetRoleStatus(role.Role, false, 0, status)
 		}
 	}
 
+	metrics.SetRolesWaitingForStartQuorum(cluster.Namespace, cluster.Name, len(waiting))
+	if len(waiting) > 0 {
+		msg := strings.Join(waiting, "; ")
+		if cluster.SetCondition(coherence.ConditionQuorumMet, false, coherence.ReasonWaitingForQuorum, msg) {
+			r.events.Event(cluster, events.QuorumWaiting, msg)
+		}
+	} else {
+		if c := cluster.Status.Conditions.GetCondition(coherence.ConditionQuorumMet); c != nil && c.Status == v1.ConditionFalse {
+			// the roles have been waiting for their start quorum since the condition became false
+			metrics.ObserveStartQuorumWait(time.Since(c.LastTransitionTime.Time))
+			r.events.Event(cluster, events.QuorumMet, fmt.Sprintf(quorumMetMessage, cluster.Name))
+		}
+		cluster.SetCondition(coherence.ConditionQuorumMet, true, coherence.ReasonQuorumMet, "")
+	}
+
 	return r.updateClusterStatus(cluster, int32(len(desiredRoles)))
 }
 
@@ -302,6 +297,8 @@ func (r *ReconcileCoherenceCluster) updateClusterStatus(cluster *coherence.Coher
 	// Update status in the re-fetched cluster
 	cluster.Status.DeepCopyInto(&clusterStatus.Status)
 	clusterStatus.Status.Roles = roleCount
+	clusterStatus.Status.ObservedGeneration = cluster.Generation
+	clusterStatus.UpdateConditions()
 
 	// Update the new status in k8s
 	if err := r.client.Status().Update(context.TODO(), clusterStatus); err != nil {
@@ -385,6 +382,7 @@ func (r *ReconcileCoherenceCluster) createRole(p params) error {
 	labels[coherence.CoherenceRoleLabel] = p.desiredRole.GetRoleName()
 	labels[coherence.CoherenceOperatorVersionLabel] = r.version
 	role.SetLabels(labels)
+	role.SetFinalizers([]string{coherence.CoherenceFinalizer})
 
 	// Set CoherenceCluster instance as the owner and controller of the CoherenceRole structure
 	if err := controllerutil.SetControllerReference(p.cluster, role, r.scheme); err != nil {
@@ -394,13 +392,13 @@ func (r *ReconcileCoherenceCluster) createRole(p params) error {
 	// Create the CoherenceRole resource in k8s which will be detected by the role controller
 	if err := r.client.Create(context.TODO(), role); err != nil {
 		msg := fmt.Sprintf(createEventFailedMessage, role.Name, p.cluster.Name, err.Error())
-		r.events.Event(p.cluster, v1.EventTypeNormal, eventReasonFailedCreate, msg)
+		r.events.Event(p.cluster, events.FailedCreate, msg)
 		return err
 	}
 
 	// send a successful creation event
 	msg := fmt.Sprintf(createEventMessage, role.Name, p.cluster.Name)
-	r.events.Event(p.cluster, v1.EventTypeNormal, eventReasonCreated, msg)
+	r.events.Event(p.cluster, events.Created, msg)
 
 	return nil
 }
@@ -409,7 +407,19 @@ func (r *ReconcileCoherenceCluster) createRole(p params) error {
 func (r *ReconcileCoherenceCluster) updateRole(p params) (reconcile.Result, error) {
 	logger := p.reqLogger.WithValues("Role", p.existingRole.GetName())
 
-	if reflect.DeepEqual(p.existingRole.Spec, p.desiredRole) {
+	desiredHash, err := coherence.ComputeHash(p.desiredRole)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// the existing role's spec is hashed rather than relying on a stored hash so that a role
+	// edited directly is still restored to the spec from the cluster
+	existingHash, err := coherence.ComputeHash(p.existingRole.Spec)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if existingHash == desiredHash {
 		// nothing to do
 		logger.Info("Existing Role is at the desired spec")
 		return reconcile.Result{}, nil
@@ -420,16 +430,16 @@ func (r *ReconcileCoherenceCluster) updateRole(p params) (reconcile.Result, erro
 
 	// Create the CoherenceRole resource in k8s which will be detected by the role controller
 	p.existingRole.Spec = p.desiredRole
-	err := r.client.Update(context.TODO(), &p.existingRole)
+	err = r.client.Update(context.TODO(), &p.existingRole)
 
 	if err == nil {
 		// send a successful update event
 		msg := fmt.Sprintf(updateEventMessage, p.existingRole.Name, p.cluster.Name)
-		r.events.Event(p.cluster, v1.EventTypeNormal, eventReasonUpdated, msg)
+		r.events.Event(p.cluster, events.Updated, msg)
 	} else {
 		// send a failed update event
 		msg := fmt.Sprintf(updateFailedEventMessage, p.existingRole.Name, p.cluster.Name, err.Error())
-		r.events.Event(p.cluster, v1.EventTypeNormal, eventReasonFailedUpdate, msg)
+		r.events.Event(p.cluster, events.FailedUpdate, msg)
 	}
 
 	return reconcile.Result{}, err
@@ -444,13 +454,13 @@ func (r *ReconcileCoherenceCluster) deleteRole(p params) error {
 	err := r.client.Delete(context.TODO(), &p.existingRole)
 	if err != nil {
 		msg := fmt.Sprintf(deleteFailedEventMessage, p.existingRole.Name, p.cluster.Name, err.Error())
-		r.events.Event(p.cluster, v1.EventTypeNormal, eventReasonFailedDelete, msg)
+		r.events.Event(p.cluster, events.FailedDelete, msg)
 		return err
 	}
 
 	// send a successful deletion event
 	msg := fmt.Sprintf(deleteEventMessage, p.existingRole.Name, p.cluster.Name)
-	r.events.Event(p.cluster, v1.EventTypeNormal, eventReasonDeleted, msg)
+	r.events.Event(p.cluster, events.Deleted, msg)
 
 	return nil
 }
@@ -566,6 +576,71 @@ func (r *ReconcileCoherenceCluster) findExistingRoles(clusterName string, namesp
 	return nil
 }
 
+// finalizeCluster performs the ordered teardown of a deleted cluster.
+// The storage disabled roles are deleted first. Once they have gone the storage enabled roles are deleted
+// one at a time, the role controller safely scaling down each role before it is removed. When all of
+// the roles have gone the finalizer is removed allowing Kubernetes to delete the cluster.
+func (r *ReconcileCoherenceCluster) finalizeCluster(cluster *coherence.CoherenceCluster, logger logr.Logger) (reconcile.Result, error) {
+	logger.Info("Finalizing deleted CoherenceCluster")
+
+	existingRoles := make(map[string]coherence.CoherenceRole)
+	if err := r.findExistingRoles(cluster.Name, cluster.Namespace, existingRoles); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if len(existingRoles) == 0 {
+		logger.Info("Removing finalizer from deleted CoherenceCluster")
+		controllerutil.RemoveFinalizer(cluster, coherence.CoherenceFinalizer)
+		if err := r.client.Update(context.TODO(), cluster); err != nil && !errors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+		metrics.DeleteClusterMetrics(cluster.Namespace, cluster.Name)
+		return reconcile.Result{}, nil
+	}
+
+	var storageDisabled, storageEnabled []coherence.CoherenceRole
+	var names []string
+	for name := range existingRoles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		role := existingRoles[name]
+		if role.Spec.IsStorageEnabled() {
+			storageEnabled = append(storageEnabled, role)
+		} else {
+			storageDisabled = append(storageDisabled, role)
+		}
+	}
+
+	var toDelete []coherence.CoherenceRole
+	if len(storageDisabled) > 0 {
+		// storage disabled roles can all be removed in parallel
+		toDelete = storageDisabled
+	} else {
+		// storage enabled roles are removed one at a time so that the cluster can remain StatusHA
+		toDelete = storageEnabled[:1]
+		for _, role := range storageEnabled {
+			if role.GetDeletionTimestamp() != nil {
+				toDelete = nil
+				break
+			}
+		}
+	}
+
+	for _, role := range toDelete {
+		if role.GetDeletionTimestamp() == nil {
+			if err := r.deleteRole(params{cluster: cluster, existingRole: role, reqLogger: logger}); err != nil && !errors.IsNotFound(err) {
+				return reconcile.Result{}, err
+			}
+		}
+	}
+
+	// requeue the request to wait for the deleted roles to be removed
+	logger.Info(fmt.Sprintf("Waiting for %d CoherenceRoles to be removed from deleted CoherenceCluster", len(existingRoles)))
+	return reconcile.Result{Requeue: true, RequeueAfter: time.Second * 10}, nil
+}
+
 func (r *ReconcileCoherenceCluster) deleteAllRoles(request reconcile.Request, logger logr.Logger) {
 	logger.Info(fmt.Sprintf("Ensuring all roles are deleted for cluster %s/%s", request.Namespace, request.Name))
 
@@ -0,0 +1,197 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package coherencecluster
+
+import (
+	"context"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	coherence "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/flags"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"time"
+
+	stubs "github.com/oracle/coherence-operator/pkg/fakes"
+)
+
+var _ = Describe("coherencecluster_controller finalizer", func() {
+	const (
+		testNamespace   = "test-namespace"
+		testClusterName = "test-cluster"
+	)
+
+	var (
+		mgr      *stubs.FakeManager
+		cluster  *coherence.CoherenceCluster
+		existing []runtime.Object
+		result   stubs.ReconcileResult
+		err      error
+	)
+
+	JustBeforeEach(func() {
+		mgr, err = stubs.NewFakeManager(existing...)
+		Expect(err).NotTo(HaveOccurred())
+
+		_ = mgr.Client.Create(context.TODO(), cluster)
+
+		request := reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: testNamespace,
+				Name:      testClusterName,
+			},
+		}
+
+		controller := newReconciler(mgr, &flags.CoherenceOperatorFlags{})
+		// skip initialization for unit tests
+		controller.SetInitialized(true)
+
+		r, err := controller.Reconcile(request)
+		result = stubs.ReconcileResult{Result: r, Error: err}
+	})
+
+	newCluster := func(deleted bool) *coherence.CoherenceCluster {
+		c := &coherence.CoherenceCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:  testNamespace,
+				Name:       testClusterName,
+				Finalizers: []string{coherence.CoherenceFinalizer},
+			},
+		}
+		if deleted {
+			now := metav1.Now()
+			c.SetDeletionTimestamp(&now)
+		}
+		return c
+	}
+
+	newRole := func(name string, storageEnabled bool) *coherence.CoherenceRole {
+		return &coherence.CoherenceRole{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testNamespace,
+				Name:      testClusterName + "-" + name,
+				Labels: map[string]string{
+					coherence.CoherenceClusterLabel: testClusterName,
+					coherence.CoherenceRoleLabel:    name,
+				},
+			},
+			Spec: coherence.CoherenceRoleSpec{
+				Role:      name,
+				Coherence: &coherence.CoherenceSpec{StorageEnabled: pointer.BoolPtr(storageEnabled)},
+			},
+		}
+	}
+
+	getCluster := func() *coherence.CoherenceCluster {
+		c := &coherence.CoherenceCluster{}
+		err := mgr.Client.Get(context.TODO(), types.NamespacedName{Namespace: testNamespace, Name: testClusterName}, c)
+		Expect(err).NotTo(HaveOccurred())
+		return c
+	}
+
+	roleExists := func(name string) bool {
+		list, err := mgr.GetCoherenceRoles(testNamespace)
+		Expect(err).NotTo(HaveOccurred())
+		for _, role := range list.Items {
+			if role.Name == testClusterName+"-"+name {
+				return true
+			}
+		}
+		return false
+	}
+
+	When("a CoherenceCluster without the finalizer is added", func() {
+		BeforeEach(func() {
+			cluster = newCluster(false)
+			cluster.SetFinalizers(nil)
+			existing = nil
+		})
+
+		It("should add the finalizer to the cluster", func() {
+			Expect(coherence.HasFinalizer(getCluster())).To(BeTrue())
+		})
+
+		It("should add the finalizer to the new role", func() {
+			name := cluster.Spec.CoherenceRoleSpec.GetFullRoleName(cluster)
+			role := mgr.AssertCoherenceRoleExists(testNamespace, name)
+			Expect(coherence.HasFinalizer(role)).To(BeTrue())
+		})
+	})
+
+	When("a deleted CoherenceCluster has storage enabled and storage disabled roles", func() {
+		BeforeEach(func() {
+			cluster = newCluster(true)
+			existing = []runtime.Object{
+				newRole("data", true),
+				newRole("proxy", false),
+				newRole("web", false),
+			}
+		})
+
+		It("should not return error", func() {
+			Expect(result.Error).To(BeNil())
+		})
+
+		It("should re-queue the request", func() {
+			Expect(result.Result).To(Equal(reconcile.Result{Requeue: true, RequeueAfter: time.Second * 10}))
+		})
+
+		It("should delete the storage disabled roles", func() {
+			Expect(roleExists("proxy")).To(BeFalse())
+			Expect(roleExists("web")).To(BeFalse())
+		})
+
+		It("should not delete the storage enabled role", func() {
+			Expect(roleExists("data")).To(BeTrue())
+		})
+
+		It("should not remove the finalizer", func() {
+			Expect(coherence.HasFinalizer(getCluster())).To(BeTrue())
+		})
+	})
+
+	When("a deleted CoherenceCluster has only storage enabled roles", func() {
+		BeforeEach(func() {
+			cluster = newCluster(true)
+			existing = []runtime.Object{
+				newRole("one", true),
+				newRole("two", true),
+			}
+		})
+
+		It("should re-queue the request", func() {
+			Expect(result.Result).To(Equal(reconcile.Result{Requeue: true, RequeueAfter: time.Second * 10}))
+		})
+
+		It("should delete only one storage enabled role", func() {
+			Expect(roleExists("one")).To(BeFalse())
+			Expect(roleExists("two")).To(BeTrue())
+		})
+	})
+
+	When("a deleted CoherenceCluster has no roles", func() {
+		BeforeEach(func() {
+			cluster = newCluster(true)
+			existing = nil
+		})
+
+		It("should not return error", func() {
+			Expect(result.Error).To(BeNil())
+		})
+
+		It("should not re-queue the request", func() {
+			Expect(result.Result).To(Equal(reconcile.Result{}))
+		})
+
+		It("should remove the finalizer", func() {
+			Expect(coherence.HasFinalizer(getCluster())).To(BeFalse())
+		})
+	})
+})
@@ -12,6 +12,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	coherence "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/controller/events"
 	"github.com/oracle/coherence-operator/pkg/flags"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -93,7 +94,7 @@ var _ = Describe("coherencecluster_controller", func() {
 				event := mgr.AssertEvent()
 
 				Expect(event.Type).To(Equal(corev1.EventTypeNormal))
-				Expect(event.Reason).To(Equal(eventReasonCreated))
+				Expect(event.Reason).To(Equal(string(events.Created)))
 				Expect(event.Message).To(Equal(msg))
 
 				mgr.AssertNoRemainingEvents()
@@ -102,6 +103,15 @@ var _ = Describe("coherencecluster_controller", func() {
 			It("should create the WKA service", func() {
 				mgr.AssertWkaService(testNamespace, cluster)
 			})
+
+			It("should set the cluster's status conditions", func() {
+				c := &coherence.CoherenceCluster{}
+				err := mgr.Client.Get(context.TODO(), types.NamespacedName{Namespace: testNamespace, Name: testClusterName}, c)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c.Status.Conditions.IsTrue(coherence.ConditionQuorumMet)).To(BeTrue())
+				Expect(c.Status.Conditions.IsTrue(coherence.ConditionProgressing)).To(BeTrue())
+				Expect(c.Status.Conditions.IsTrue(coherence.ConditionAvailable)).To(BeFalse())
+			})
 		})
 	})
 
@@ -139,7 +149,7 @@ var _ = Describe("coherencecluster_controller", func() {
 				event := mgr.AssertEvent()
 
 				Expect(event.Type).To(Equal(corev1.EventTypeNormal))
-				Expect(event.Reason).To(Equal(eventReasonCreated))
+				Expect(event.Reason).To(Equal(string(events.Created)))
 				Expect(event.Message).To(Equal(msg))
 
 				mgr.AssertNoRemainingEvents()
@@ -203,11 +213,11 @@ var _ = Describe("coherencecluster_controller", func() {
 				eventTwo := mgr.AssertEvent()
 
 				Expect(eventOne.Type).To(Equal(corev1.EventTypeNormal))
-				Expect(eventOne.Reason).To(Equal(eventReasonCreated))
+				Expect(eventOne.Reason).To(Equal(string(events.Created)))
 				Expect(eventOne.Message).To(Equal(msgOne))
 
 				Expect(eventTwo.Type).To(Equal(corev1.EventTypeNormal))
-				Expect(eventTwo.Reason).To(Equal(eventReasonCreated))
+				Expect(eventTwo.Reason).To(Equal(string(events.Created)))
 				Expect(eventTwo.Message).To(Equal(msgTwo))
 
 				mgr.AssertNoRemainingEvents()
@@ -339,7 +349,7 @@ var _ = Describe("coherencecluster_controller", func() {
 					event := mgr.AssertEvent()
 
 					Expect(event.Type).To(Equal(corev1.EventTypeNormal))
-					Expect(event.Reason).To(Equal(eventReasonUpdated))
+					Expect(event.Reason).To(Equal(string(events.Updated)))
 					Expect(event.Message).To(Equal(msg))
 
 					mgr.AssertNoRemainingEvents()
@@ -414,7 +424,7 @@ var _ = Describe("coherencecluster_controller", func() {
 					event := mgr.AssertEvent()
 
 					Expect(event.Type).To(Equal(corev1.EventTypeNormal))
-					Expect(event.Reason).To(Equal(eventReasonUpdated))
+					Expect(event.Reason).To(Equal(string(events.Updated)))
 					Expect(event.Message).To(Equal(msg))
 
 					mgr.AssertNoRemainingEvents()
@@ -489,7 +499,7 @@ var _ = Describe("coherencecluster_controller", func() {
 					event := mgr.AssertEvent()
 
 					Expect(event.Type).To(Equal(corev1.EventTypeNormal))
-					Expect(event.Reason).To(Equal(eventReasonDeleted))
+					Expect(event.Reason).To(Equal(string(events.Deleted)))
 					Expect(event.Message).To(Equal(msg))
 
 					mgr.AssertNoRemainingEvents()
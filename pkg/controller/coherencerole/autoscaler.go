@@ -0,0 +1,309 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package coherencerole
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/controller/events"
+	mgmt "github.com/oracle/coherence-operator/pkg/management"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"math"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"time"
+)
+
+// The tolerance of the ratio of a metric's value to its target within which the role will not be scaled.
+const autoscalingTolerance = 0.1
+
+// The timeout for Coherence management requests made when reading autoscaling metrics.
+const autoscalingMetricsTimeout = time.Second * 30
+
+// autoscale evaluates the autoscaling policies of a role that is at its desired size and, if the role
+// needs to be scaled, updates the replicas of the role. The role is then scaled by the reconcile of the
+// updated role in the same way as a role scaled using kubectl, with scaling down always using safe scaling.
+// The request is re-queued so that the policies are evaluated again after the autoscaling interval.
+func (r *ReconcileCoherenceRole) autoscale(role *coh.CoherenceRole, sts *appsv1.StatefulSet, logger logr.Logger) (reconcile.Result, error) {
+	autoscaling := role.Spec.Autoscaling
+	result := reconcile.Result{Requeue: true, RequeueAfter: autoscaling.GetInterval()}
+
+	current := role.Spec.GetReplicas()
+	if sts.Status.ReadyReplicas != current {
+		// wait for the role to be stable before evaluating the metrics
+		return result, nil
+	}
+
+	var values []float64
+	if len(autoscaling.Policies) > 0 {
+		var err error
+		checker := ScalableChecker{Client: r.client, Config: r.mgr.GetConfig()}
+		if values, err = checker.GetAutoscalingMetrics(role, sts); err != nil {
+			logger.Info("Unable to read autoscaling metrics: " + err.Error())
+			r.events.Eventf(role, events.AutoscalingMetricsFailed, autoscaleMetricsFailedMessage, role.Name, err.Error())
+			return result, nil
+		}
+	}
+
+	desired := desiredReplicas(autoscaling, current, values)
+	if desired == current {
+		return result, nil
+	}
+
+	if desired < current && role.Status.LastScaleTime != nil {
+		if time.Since(role.Status.LastScaleTime.Time) < autoscaling.GetScaleDownDelay() {
+			logger.Info(fmt.Sprintf("Autoscaler delaying scale down from %d to %d as the role was recently scaled", current, desired))
+			return result, nil
+		}
+	}
+
+	logger.Info(fmt.Sprintf("Autoscaling role from %d to %d", current, desired))
+	role.Spec.SetReplicas(desired)
+	if err := r.client.Update(context.TODO(), role); err != nil {
+		return r.handleErrAndRequeue(err, role, fmt.Sprintf(failedToAutoscaleRole, role.Name, current, desired, err.Error()), logger)
+	}
+
+	now := metav1.Now()
+	role.Status.LastScaleTime = &now
+	if err := r.client.Status().Update(context.TODO(), role); err != nil {
+		log.Error(err, "failed to update role status")
+	}
+
+	msg := fmt.Sprintf(autoscaleMessage, role.Name, current, desired)
+	r.events.Event(role, events.Autoscaled, msg)
+
+	return result, nil
+}
+
+// desiredReplicas calculates the number of replicas for a role from the values of the autoscaling policy metrics.
+// Each policy gives a number of replicas proportional to the ratio of its metric's value to its target and the
+// largest of these, limited to the autoscaling replica range, is returned.
+func desiredReplicas(autoscaling *coh.AutoscalingSpec, current int32, values []float64) int32 {
+	desired := current
+	if len(values) > 0 {
+		desired = 0
+		for i, value := range values {
+			replicas := current
+			ratio := value / float64(autoscaling.Policies[i].Target)
+			if math.Abs(ratio-1.0) > autoscalingTolerance {
+				replicas = int32(math.Ceil(ratio * float64(current)))
+			}
+			if replicas > desired {
+				desired = replicas
+			}
+		}
+	}
+
+	if min := autoscaling.GetMinReplicas(); desired < min {
+		desired = min
+	}
+	if max := autoscaling.GetMaxReplicas(); desired > max {
+		desired = max
+	}
+	return desired
+}
+
+// GetAutoscalingMetrics uses Coherence management over ReST on one of the role's ready Pods to read
+// the values of the metrics of the role's autoscaling policies.
+func (in *ScalableChecker) GetAutoscalingMetrics(role *coh.CoherenceRole, sts *appsv1.StatefulSet) ([]float64, error) {
+	port, enabled := GetManagementPort(role)
+	if !enabled {
+		return nil, fmt.Errorf("management over ReST is not enabled for CoherenceRole %s", role.Name)
+	}
+
+	pods, err := listPods(in.Client, role, sts)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := in.GetManagementTLSConfig(role)
+	if err != nil {
+		return nil, err
+	}
+
+	err = fmt.Errorf("no ready Pods found for StatefulSet %s", sts.Name)
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning || !IsPodReady(pod) {
+			continue
+		}
+
+		cl := in.NewManagementClient(pod, port, tlsConfig, autoscalingMetricsTimeout)
+
+		var values []float64
+		if values, err = readAutoscalingMetrics(cl, role.Spec.GetRoleName(), role.Spec.Autoscaling.Policies); err == nil {
+			log.Info(fmt.Sprintf("Read autoscaling metrics from Pod %s %v", pod.Name, values))
+			return values, nil
+		}
+		log.Info(fmt.Sprintf("Reading autoscaling metrics from Pod %s error %s", pod.Name, err.Error()))
+	}
+
+	return nil, err
+}
+
+// readAutoscalingMetrics uses Coherence management over ReST to read the value of the metric of each autoscaling policy.
+// The values are the average per member of the role, except for PartitionsPerMember, which is the average per
+// storage enabled member of the service.
+func readAutoscalingMetrics(cl *mgmt.Client, roleName string, policies []coh.AutoscalingPolicy) ([]float64, error) {
+	members, err := cl.GetMembers(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+
+	var roleMembers []mgmt.MemberData
+	nodeIDs := make(map[string]bool)
+	for _, member := range members.Items {
+		if member.RoleName == roleName {
+			roleMembers = append(roleMembers, member)
+			nodeIDs[member.NodeID] = true
+		}
+	}
+	if len(roleMembers) == 0 {
+		return nil, fmt.Errorf("no cluster members found with role %s", roleName)
+	}
+
+	values := make([]float64, len(policies))
+	for i, policy := range policies {
+		var value float64
+		switch policy.Metric {
+		case coh.HeapUsageMetric:
+			value = heapUsage(roleMembers)
+		case coh.PartitionsPerMemberMetric:
+			value, err = partitionsPerMember(cl, policy.Service)
+		case coh.RequestQueueDepthMetric:
+			value, err = requestQueueDepth(cl, policy.Service, nodeIDs)
+		case coh.CacheSizeMetric:
+			value, err = cacheSize(cl, policy.Service, policy.Cache, nodeIDs)
+		default:
+			err = fmt.Errorf("unknown autoscaling metric %s", policy.Metric)
+		}
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+
+	return values, nil
+}
+
+// heapUsage returns the average percentage of the maximum heap used by the members.
+func heapUsage(members []mgmt.MemberData) float64 {
+	var total float64
+	count := 0
+	for _, member := range members {
+		if member.MemoryMaxMB > 0 {
+			total += float64(member.MemoryMaxMB-member.MemoryAvailableMB) * 100.0 / float64(member.MemoryMaxMB)
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return total / float64(count)
+}
+
+// partitionsPerMember returns the number of partitions per storage enabled member of the service, or the
+// highest number of partitions per storage enabled member of all of the partitioned cache services.
+func partitionsPerMember(cl *mgmt.Client, service *string) (float64, error) {
+	services, err := partitionedServices(cl, service)
+	if err != nil {
+		return 0, err
+	}
+
+	var value float64
+	for _, name := range services {
+		partitions, err := cl.GetPartitionAssignment(context.TODO(), name)
+		if err != nil {
+			return 0, err
+		}
+		if partitions.ServiceNodeCount > 0 {
+			value = math.Max(value, float64(partitions.PartitionCount)/float64(partitions.ServiceNodeCount))
+		}
+	}
+	return value, nil
+}
+
+// requestQueueDepth returns the average task backlog of the service on the role's members, or the
+// highest average task backlog of all of the partitioned cache services.
+func requestQueueDepth(cl *mgmt.Client, service *string, nodeIDs map[string]bool) (float64, error) {
+	services, err := partitionedServices(cl, service)
+	if err != nil {
+		return 0, err
+	}
+
+	var value float64
+	for _, name := range services {
+		members, err := cl.GetServiceMembers(context.TODO(), name)
+		if err != nil {
+			return 0, err
+		}
+
+		total := 0
+		count := 0
+		for _, member := range members.Items {
+			if nodeIDs[member.NodeID] {
+				total += member.TaskBacklog
+				count++
+			}
+		}
+		if count > 0 {
+			value = math.Max(value, float64(total)/float64(count))
+		}
+	}
+	return value, nil
+}
+
+// cacheSize returns the average number of entries of the cache held by each of the role's members.
+func cacheSize(cl *mgmt.Client, service, cache *string, nodeIDs map[string]bool) (float64, error) {
+	if cache == nil || *cache == "" {
+		return 0, fmt.Errorf("the CacheSize metric requires a cache name")
+	}
+
+	serviceName := ""
+	if service != nil {
+		serviceName = *service
+	}
+
+	members, err := cl.GetCacheMembers(context.TODO(), serviceName, *cache)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, member := range members.Items {
+		// front tier entries are copies of entries held in the back tier so are not counted
+		if nodeIDs[member.NodeID] && member.Tier != "front" {
+			total += member.Size
+		}
+	}
+	return float64(total) / float64(len(nodeIDs)), nil
+}
+
+// partitionedServices returns the specified service name or, if no name is specified,
+// the names of all of the partitioned cache services.
+func partitionedServices(cl *mgmt.Client, service *string) ([]string, error) {
+	if service != nil && *service != "" {
+		return []string{*service}, nil
+	}
+
+	services, err := cl.GetServices(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	found := make(map[string]bool)
+	for _, s := range services.Items {
+		if s.Type == mgmt.DistributedCacheType && !found[s.Name] {
+			found[s.Name] = true
+			names = append(names, s.Name)
+		}
+	}
+	return names, nil
+}
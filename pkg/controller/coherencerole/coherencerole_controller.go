@@ -8,24 +8,26 @@ package coherencerole
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"github.com/go-logr/logr"
 	"github.com/go-test/deep"
-	helmctl "github.com/operator-framework/operator-sdk/pkg/helm/controller"
-	"github.com/operator-framework/operator-sdk/pkg/helm/release"
-	"github.com/operator-framework/operator-sdk/pkg/helm/watches"
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
 	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/controller/events"
 	"github.com/oracle/coherence-operator/pkg/flags"
+	"github.com/oracle/coherence-operator/pkg/metrics"
+	"github.com/oracle/coherence-operator/pkg/resources"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/client-go/tools/record"
 	"os"
 	"reflect"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -45,31 +47,48 @@ const (
 	// The name of this controller. This is used in events, log messages, etc.
 	controllerName = "coherencerole.controller"
 
-	invalidRoleEventMessage      string = "invalid CoherenceRole '%s' cannot find parent CoherenceCluster '%s'"
-	createMessage                string = "created CoherenceInternal '%s' from CoherenceRole '%s' successful"
-	createFailedMessage          string = "create CoherenceInternal '%s' from CoherenceRole '%s' failed\n%s"
-	updateMessage                string = "updated CoherenceInternal %s from CoherenceRole %s successful"
-	updateFailedMessage          string = "update CoherenceInternal %s from CoherenceRole %s failed\n%s"
-	scaleToZeroFailed            string = "scale of CoherenceRole %s to zero failed\n%s"
-	failedToGetHelmValuesMessage string = "failed to get Helm values for CoherenceRole %s due to error\n%s"
-	failedToGetParentCluster     string = "failed to get parent CoherenceCluster %s for CoherenceRole %s due to error\n%s"
-	failedToReconcileRole        string = "failed to reconcile CoherenceRole %s due to error\n%s"
-	failedToScaleRole            string = "failed to scale CoherenceRole %s from %d to %d due to error\n%s"
-
-	eventReasonFailed  string = "failed"
-	eventReasonCreated string = "SuccessfulCreate"
-	eventReasonUpdated string = "SuccessfulUpdate"
-	eventReasonScale   string = "Scaling"
+	invalidRoleEventMessage       string = "invalid CoherenceRole '%s' cannot find parent CoherenceCluster '%s'"
+	createMessage                 string = "created StatefulSet '%s' from CoherenceRole '%s' successful"
+	createFailedMessage           string = "create StatefulSet '%s' from CoherenceRole '%s' failed\n%s"
+	updateMessage                 string = "updated StatefulSet %s from CoherenceRole %s successful"
+	updateFailedMessage           string = "update StatefulSet %s from CoherenceRole %s failed\n%s"
+	scaleToZeroFailed             string = "scale of CoherenceRole %s to zero failed\n%s"
+	failedToGetStatefulSetMessage string = "failed to get StatefulSet for CoherenceRole %s due to error\n%s"
+	failedToMigrateRoleMessage    string = "failed to migrate CoherenceInternal for CoherenceRole %s due to error\n%s"
+	failedToAddFinalizerMessage   string = "failed to add finalizer to CoherenceRole %s due to error\n%s"
+	failedToFinalizeRoleMessage   string = "failed to finalize deleted CoherenceRole %s due to error\n%s"
+	failedToSnapshotRoleMessage   string = "failed to create persistence snapshot %s for deleted CoherenceRole %s due to error\n%s"
+	snapshotSkippedMessage        string = "skipped the persistence snapshot of deleted CoherenceRole %s as management over ReST is not enabled"
+	failedToGetParentCluster      string = "failed to get parent CoherenceCluster %s for CoherenceRole %s due to error\n%s"
+	failedToReconcileRole         string = "failed to reconcile CoherenceRole %s due to error\n%s"
+	failedToScaleRole             string = "failed to scale CoherenceRole %s from %d to %d due to error\n%s"
+	failedToUpgradeRole           string = "failed to restart Pod %s for rolling upgrade of CoherenceRole %s due to error\n%s"
+	restartPodMessage             string = "deleted Pod %s in CoherenceRole %s for safe rolling upgrade"
+	failedToAutoscaleRole         string = "failed to autoscale CoherenceRole %s from %d to %d due to error\n%s"
+	autoscaleMessage              string = "autoscaled CoherenceRole %s from %d to %d"
+	autoscaleMetricsFailedMessage string = "failed to read the autoscaling metrics of CoherenceRole %s due to error\n%s"
+	failedToScheduleScaleRole     string = "failed to apply scaling schedule of CoherenceRole %s due to error\n%s"
+	scheduledScaleMessage         string = "scheduled scaling of CoherenceRole %s from %d to %d (%s)"
+	failedToUpdateBudgetMessage   string = "failed to update PodDisruptionBudget of CoherenceRole %s due to error\n%s"
+	specResetMessage              string = "CoherenceRole %s spec was different to its CoherenceCluster %s role spec and has been reset"
+	upgradeStartedMessage         string = "started rolling upgrade of CoherenceRole %s"
+	upgradeCompletedMessage       string = "completed rolling upgrade of CoherenceRole %s"
+	scaledMessage                 string = "scaled StatefulSet %s in CoherenceRole %s from %d to %d"
+	failedToScaleMessage          string = "failed to scale StatefulSet %s in CoherenceRole %s from %d to %d"
+	scaleBlockedMessage           string = "waiting for StatusHA to scale CoherenceRole %s from %d to %d"
 
 	// The template used to create the CoherenceRole.Status.Selector
 	selectorTemplate = "coherenceCluster=%s,coherenceRole=%s"
 
 	statusHaRetryEnv = "STATUS_HA_RETRY"
 
+	// The field manager name used when applying a role's resources.
+	fieldOwner = "coherence-operator"
+
 	// The name of the Coherence container in the Coherence Pods
-	CoherenceContainerName = "coherence"
+	CoherenceContainerName = resources.CoherenceContainerName
 	// The name of the Coherence Utils container in the Coherence Pods
-	CoherenceUtilsContainerName = "coherence-k8s-utils"
+	CoherenceUtilsContainerName = resources.CoherenceUtilsContainerName
 )
 
 var log = logf.Log.WithName(controllerName)
@@ -106,10 +125,9 @@ func newReconciler(mgr manager.Manager, opFlags *flags.CoherenceOperatorFlags) *
 		client:        mgr.GetClient(),
 		scheme:        scheme,
 		gvk:           gvk,
-		events:        mgr.GetEventRecorderFor(controllerName),
+		events:        events.NewRecorder(mgr.GetEventRecorderFor(controllerName)),
 		statusHARetry: retry,
 		mgr:           mgr,
-		resourceLocks: make(map[types.NamespacedName]bool),
 		mutex:         sync.Mutex{},
 		opFlags:       opFlags,
 	}
@@ -118,7 +136,10 @@ func newReconciler(mgr manager.Manager, opFlags *flags.CoherenceOperatorFlags) *
 // add adds a new Controller to mgr with r as the reconcile.Reconciler.
 func add(mgr manager.Manager, r *ReconcileCoherenceRole) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, controller.Options{
+		Reconciler:              metrics.InstrumentReconciler(controllerName, r),
+		MaxConcurrentReconciles: r.opFlags.GetMaxConcurrentReconciles(),
+	})
 	if err != nil {
 		return err
 	}
@@ -129,10 +150,10 @@ func add(mgr manager.Manager, r *ReconcileCoherenceRole) error {
 		return err
 	}
 
-	// Watch for changes to secondary resource - in this case we watch the StatefulSet created by the Helm chart
+	// Watch for changes to secondary resource - in this case we watch the StatefulSet created for the role
 	err = c.Watch(&source.Kind{Type: &appsv1.StatefulSet{}}, &handler.EnqueueRequestForOwner{
 		IsController: true,
-		OwnerType:    r.CreateEmptyHelmValues(nil),
+		OwnerType:    &coh.CoherenceRole{},
 	})
 	if err != nil {
 		return err
@@ -145,21 +166,28 @@ func add(mgr manager.Manager, r *ReconcileCoherenceRole) error {
 // If the reconcile.Reconciler API was to change then we'd get a compile error here.
 var _ reconcile.Reconciler = &ReconcileCoherenceRole{}
 
-// ReconcileCoherenceRole reconciles a CoherenceRole object and related CoherenceInternal
-// Helm values resources.
+// ReconcileCoherenceRole reconciles a CoherenceRole object and the StatefulSet,
+// Services and ConfigMaps that make up the role.
 type ReconcileCoherenceRole struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the api server
 	client        client.Client
 	scheme        *runtime.Scheme
 	gvk           schema.GroupVersionKind
-	events        record.EventRecorder
+	events        *events.Recorder
 	statusHARetry time.Duration
 	mgr           manager.Manager
-	resourceLocks map[types.NamespacedName]bool
 	mutex         sync.Mutex
 	opFlags       *flags.CoherenceOperatorFlags
 	initialized   bool
+	// scriptsMutex guards scripts separately from mutex, which is held while EnsureInitialized
+	// reconciles the existing roles, and so while those roles load the scripts.
+	scriptsMutex sync.Mutex
+	scripts      map[string]string
+	// membershipMutex guards splitObserved, the roles whose members reported different cluster sizes at the
+	// latest membership check.
+	membershipMutex sync.Mutex
+	splitObserved   map[string]bool
 }
 
 // Set the initialized flag for this controller.
@@ -169,36 +197,6 @@ func (r *ReconcileCoherenceRole) SetInitialized(i bool) {
 	}
 }
 
-// Attempt to lock the requested resource.
-func (r *ReconcileCoherenceRole) lock(request reconcile.Request) bool {
-	if r == nil {
-		return false
-	}
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-
-	_, found := r.resourceLocks[request.NamespacedName]
-	if found {
-		log.Info("CoherenceRole " + request.Namespace + "/" + request.Name + " is locked")
-		return false
-	}
-
-	r.resourceLocks[request.NamespacedName] = true
-	log.Info("Acquired lock for CoherenceRole " + request.Namespace + "/" + request.Name)
-	return true
-}
-
-// Unlock the requested resource
-func (r *ReconcileCoherenceRole) unlock(request reconcile.Request) {
-	if r != nil {
-		r.mutex.Lock()
-		defer r.mutex.Unlock()
-
-		log.Info("Released lock for CoherenceRole " + request.Namespace + "/" + request.Name)
-		delete(r.resourceLocks, request.NamespacedName)
-	}
-}
-
 // Reconcile reads that state of a CoherenceRole object and makes changes based on the state read
 // and what is in the CoherenceRole.Spec.
 // Note:
@@ -211,15 +209,8 @@ func (r *ReconcileCoherenceRole) Reconcile(request reconcile.Request) (reconcile
 		return reconcile.Result{}, err
 	}
 
-	// Attempt to lock the requested resource. If the resource is locked then another
-	// request for the same resource is already in progress so requeue this one.
-	if ok := r.lock(request); !ok {
-		logger.Info("CoherenceRole " + request.Namespace + "/" + request.Name + " is already locked, re-queuing request")
-		return reconcile.Result{Requeue: true, RequeueAfter: 0}, nil
-	}
-	// Make sure that the request is unlocked when this method exits
-	defer r.unlock(request)
-
+	// The controller's work queue never hands the same request to more than one worker at a time, so
+	// different CoherenceRoles may be reconciled in parallel but a CoherenceRole is never reconciled concurrently.
 	return r.reconcileInternal(request)
 }
 
@@ -242,18 +233,31 @@ func (r *ReconcileCoherenceRole) reconcileInternal(request reconcile.Request) (r
 		return r.handleErrAndRequeue(err, nil, fmt.Sprintf(failedToReconcileRole, role.Name, err), logger)
 	}
 
+	if found && role.GetDeletionTimestamp() != nil && coh.HasFinalizer(role) {
+		// The role is being deleted so tear down its members in a safe order before removing the finalizer
+		return r.finalizeRole(role, logger)
+	}
+
 	if !found || role.GetDeletionTimestamp() != nil {
 		logger.Info("CoherenceRole deleted")
-		// Request object not found (could have been deleted after reconcile request) or this is a delete notification.
-		// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
-		// Ensure that the CoherenceInternal for this role is deleted.
-		// It should be cleaned-up by k8s garbage collection but belt and braces as we've seen when
-		// upgrading or reinstalling the Operator existing clusters are not always cleaned automatically
-		r.deleteCoherenceInternal(request, logger)
+		// Request object not found (could have been deleted after reconcile request) or this is a delete notification
+		// for a role that has already been finalized. Owned objects are automatically garbage collected.
+		// Ensure that any CoherenceInternal left by a previous Operator version for this role is deleted.
+		if _, err := r.deleteCoherenceInternal(request.Namespace, request.Name, metav1.DeletePropagationBackground, logger); err != nil {
+			logger.Error(err, "failed to delete CoherenceInternal")
+		}
 
 		return reconcile.Result{Requeue: false}, nil
 	}
 
+	// Ensure the role has the finalizer so that it is torn down safely when deleted
+	if !coh.HasFinalizer(role) {
+		controllerutil.AddFinalizer(role, coh.CoherenceFinalizer)
+		if err = r.client.Update(context.TODO(), role); err != nil {
+			return r.handleErrAndRequeue(err, nil, fmt.Sprintf(failedToAddFinalizerMessage, role.Name, err.Error()), logger)
+		}
+	}
+
 	clusterName := role.GetCoherenceClusterName()
 
 	// Fetch the owning CoherenceCluster
@@ -266,25 +270,36 @@ func (r *ReconcileCoherenceRole) reconcileInternal(request reconcile.Request) (r
 		return r.handleErrAndRequeue(err, role, fmt.Sprintf(failedToGetParentCluster, clusterName, role.Name, err.Error()), logger)
 	}
 
-	// find the existing Helm values structure in k8s (this will be an unstructured.Unstructured)
-	// it may not exist if this is a create request
-	helmValues, err := r.GetExistingHelmValues(role)
+	// Roles created by previous Operator versions were installed by the Helm operator from a CoherenceInternal.
+	// The CoherenceInternal is deleted leaving its resources in place for this controller to take over.
+	migrated, err := r.deleteCoherenceInternal(role.Namespace, role.Name, metav1.DeletePropagationOrphan, logger)
+	if err != nil {
+		return r.handleErrAndRequeue(err, role, fmt.Sprintf(failedToMigrateRoleMessage, role.Name, err.Error()), logger)
+	}
+	if migrated {
+		// requeue to give garbage collection time to remove the CoherenceInternal owner references
+		return reconcile.Result{Requeue: true, RequeueAfter: time.Second * 5}, nil
+	}
+
+	// find the existing StatefulSet for the role, it may not exist if this is a create request
+	sts, err := r.findStatefulSet(role)
 	replicas := role.Spec.GetReplicas()
 
 	switch {
-	case replicas <= 0 && err == nil && helmValues.GetDeletionTimestamp() == nil:
-		// Scaling down to zero so delete the helm values which will cause the StatefulSet to be deleted
-		return r.scaleDownToZero(cluster, role, helmValues)
-	case replicas <= 0 && err == nil && helmValues.GetDeletionTimestamp() != nil:
-		// Helm values already deleted but not yet gone
+	case replicas <= 0 && err == nil && sts.GetDeletionTimestamp() == nil:
+		// Scaling down to zero so delete the StatefulSet and the role's other resources
+		return r.scaleDownToZero(cluster, role, sts)
+	case replicas <= 0 && err == nil && sts.GetDeletionTimestamp() != nil:
+		// StatefulSet already deleted but not yet gone
 		if err = r.updateStatus(role, nil, cluster); err != nil {
 			// failed to update the CoherenceRole's status
 			log.Error(err, "failed to update role status", "Namespace", role.Namespace, "Name", role.Name)
 			return reconcile.Result{Requeue: true, RequeueAfter: time.Second * 5}, nil
 		}
+
 		return reconcile.Result{}, nil
 	case replicas <= 0 && err != nil && errors.IsNotFound(err):
-		// Helm values has been deleted
+		// StatefulSet has been deleted
 		if err = r.updateStatus(role, nil, cluster); err != nil {
 			// failed to update the CoherenceRole's status
 			log.Error(err, "failed to update role status", "Namespace", role.Namespace, "Name", role.Name)
@@ -292,18 +307,18 @@ func (r *ReconcileCoherenceRole) reconcileInternal(request reconcile.Request) (r
 		}
 		return reconcile.Result{}, nil
 	case replicas > 0 && err != nil && errors.IsNotFound(err):
-		// Helm values was not found so this is an insert of a new role
+		// StatefulSet was not found so this is an insert of a new role
 		return r.createRole(cluster, role)
 	case err != nil:
 		// the error is a real error
-		return r.handleErrAndRequeue(err, role, fmt.Sprintf(failedToGetHelmValuesMessage, role.Name, err.Error()), logger)
+		return r.handleErrAndRequeue(err, role, fmt.Sprintf(failedToGetStatefulSetMessage, role.Name, err.Error()), logger)
 	default:
-		// The Helm values was found so this is an update
-		return r.updateRole(cluster, role, helmValues)
+		// The StatefulSet was found so this is an update
+		return r.updateRole(cluster, role, sts)
 	}
 }
 
-// createRole creates a new Helm values structure in k8s, which will in turn trigger a Helm install.
+// getRole obtains the CoherenceRole with the specified namespace and name.
 func (r *ReconcileCoherenceRole) getRole(namespace, name string) (*coh.CoherenceRole, bool, error) {
 	role := &coh.CoherenceRole{}
 	err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name}, role)
@@ -318,7 +333,7 @@ func (r *ReconcileCoherenceRole) getRole(namespace, name string) (*coh.Coherence
 	}
 }
 
-// createRole creates a new Helm values structure in k8s, which will in turn trigger a Helm install.
+// createRole applies the StatefulSet, Services and ConfigMaps for a new role.
 func (r *ReconcileCoherenceRole) createRole(cluster *coh.CoherenceCluster, role *coh.CoherenceRole) (reconcile.Result, error) {
 	if role.Spec.GetReplicas() <= 0 {
 		// nothing to do as the desired replica count is zero
@@ -326,62 +341,30 @@ func (r *ReconcileCoherenceRole) createRole(cluster *coh.CoherenceCluster, role
 	}
 
 	logger := log.WithValues("Namespace", role.Namespace, "Name", role.Name)
-	logger.Info("Creating Coherence Role Helm values")
+	logger.Info("Creating Coherence Role resources")
 
-	// create the CoherenceInternal for the role
+	// create the CoherenceInternalSpec for the role
 	ci := coh.NewCoherenceInternalSpec(cluster, role)
-	// Ensure that the CoherenceInternal has images set
+	// Ensure that the CoherenceInternalSpec has images set
 	// If the CoherenceInternalSpec does not have a Coherence or Coherence Utils images specified we set the defaults here.
 	// This ensures that the image is fixed to either that specified in the cluster spec or to the current default
-	// and means that the Helm controller does not do a rolling upgrade of the Pods if the Operator is upgraded.
+	// and means that we do not do a rolling upgrade of the Pods if the Operator is upgraded.
 	r.EnsureImages(ci, logger)
 
-	// define a new Helm values map
-	spec, err := coh.CoherenceInternalSpecAsMapFromSpec(ci)
-	if err != nil {
-		// this error would only occur if there was a json marshalling issue which would be unlikely
+	if err := r.applyRole(role, ci); err != nil {
 		return r.handleErrAndRequeue(err, nil, fmt.Sprintf(createFailedMessage, role.Name, role.Name, err), logger)
 	}
 
-	helmValues := r.CreateHelmValues(cluster, role, spec)
-	labels := helmValues.GetLabels()
-	labels[coh.CoherenceOperatorVersionLabel] = role.GetLabels()[coh.CoherenceOperatorVersionLabel]
-	helmValues.SetLabels(labels)
-
-	// Set this CoherenceRole instance as the owner and controller of the Helm values structure
-	if err := controllerutil.SetControllerReference(role, helmValues, r.scheme); err != nil {
-		return r.handleErrAndRequeue(err, nil, fmt.Sprintf(createFailedMessage, helmValues.GetName(), role.Name, err), logger)
-	}
-
-	// Clean-up previous Helm v3 state if any exists, we've seen orphaned state causing issues in testing
-	hsList := corev1.SecretList{}
-	err = r.client.List(context.TODO(), &hsList, client.InNamespace(role.Namespace))
-	if err == nil {
-		prefix := fmt.Sprintf("sh.helm.release.v1.%s.", role.Name)
-		for _, hs := range hsList.Items {
-			if strings.HasPrefix(hs.Name, prefix) {
-				// Helm state exists so delete it
-				logger.Info(fmt.Sprintf("Deleting existing Helm state for role %s in secret %s", role.Name, hs.Name))
-				_ = r.client.Delete(context.TODO(), &hs)
-			}
-		}
-	}
-
-	// Create the CoherenceInternal resource in k8s which will be detected
-	// by the Helm operator and trigger a Helm install
-
-	d, _ := json.Marshal(helmValues)
-	logger.V(2).Info("Creating CoherenceInternal\n------------\n" + string(d) + "\n------------\n")
-
-	if err := r.client.Create(context.TODO(), helmValues); err != nil {
-		return r.handleErrAndRequeue(err, nil, fmt.Sprintf(createFailedMessage, helmValues.GetName(), role.Name, err), logger)
-	}
-
 	// update this CoherenceRole's status
 	role.Status.Status = coh.RoleStatusCreated
 	role.Status.Replicas = role.Spec.GetReplicas()
 	role.Status.Selector = fmt.Sprintf(selectorTemplate, cluster.Name, role.Spec.GetRoleName())
-	err = r.client.Status().Update(context.TODO(), role)
+	role.Status.ObservedGeneration = role.Generation
+	msg := fmt.Sprintf("creating %d replicas", role.Status.Replicas)
+	role.SetCondition(coh.ConditionAvailable, false, coh.ReasonCreating, msg)
+	role.SetCondition(coh.ConditionProgressing, true, coh.ReasonCreating, msg)
+	role.SetCondition(coh.ConditionDegraded, false, coh.ReasonReconcileSucceeded, "")
+	err := r.client.Status().Update(context.TODO(), role)
 	if err != nil {
 		// failed to update the CoherenceRole's status
 		// ToDo - handle this properly by re-queuing the request and then in the reconcile method properly handle setting status even if the role is in the desired state
@@ -389,17 +372,24 @@ func (r *ReconcileCoherenceRole) createRole(cluster *coh.CoherenceCluster, role
 	}
 
 	// send a successful creation event
-	msg := fmt.Sprintf(createMessage, helmValues.GetName(), role.Name)
-	r.events.Event(role, corev1.EventTypeNormal, eventReasonCreated, msg)
+	msg = fmt.Sprintf(createMessage, role.Name, role.Name)
+	r.events.Event(role, events.Created, msg)
 
 	return reconcile.Result{Requeue: false}, nil
 }
 
-// updateRole updates an existing CoherenceInternal which will in turn trigger a Helm update.
-func (r *ReconcileCoherenceRole) updateRole(cluster *coh.CoherenceCluster, role *coh.CoherenceRole, helmValues *unstructured.Unstructured) (reconcile.Result, error) {
+// updateRole scales or upgrades the resources of an existing role.
+func (r *ReconcileCoherenceRole) updateRole(cluster *coh.CoherenceCluster, role *coh.CoherenceRole, sts *appsv1.StatefulSet) (reconcile.Result, error) {
 	logger := log.WithValues("Namespace", role.Namespace, "Name", role.Name)
 	logger.Info("Reconciling existing Coherence Role")
 
+	if role.Spec.Scaling.HasSchedule() {
+		// apply any scheduled change to the role's replicas before it is synchronised with the cluster
+		if err := r.applySchedule(role, logger); err != nil {
+			return r.handleErrAndRequeue(err, nil, fmt.Sprintf(failedToScheduleScaleRole, role.Name, err), logger)
+		}
+	}
+
 	clusterRole := cluster.GetRole(role.Spec.GetRoleName())
 	clusterReplicas := clusterRole.GetReplicas()
 	roleReplicas := role.Spec.GetReplicas()
@@ -407,7 +397,7 @@ func (r *ReconcileCoherenceRole) updateRole(cluster *coh.CoherenceCluster, role
 	effectiveRole := clusterRole.DeepCopyWithDefaults(&cluster.Spec.CoherenceRoleSpec)
 	effectiveRole.SetReplicas(effectiveRole.GetReplicas())
 
-	if !reflect.DeepEqual(effectiveRole, &role.Spec) {
+	if !isSameSpec(effectiveRole, &role.Spec) {
 		// Role spec is not the same as the cluster's role spec - likely caused by a scale but could have
 		// been caused by a direct update to the CoherenceRole, even though we really discourage that.
 
@@ -416,6 +406,7 @@ func (r *ReconcileCoherenceRole) updateRole(cluster *coh.CoherenceCluster, role
 			diff := deep.Equal(effectiveRole, &role.Spec)
 			logger.Info("CoherenceCluster role spec is different to CoherenceRole spec and will be reset to match the cluster - diff:\n" + strings.Join(diff, "\n"))
 			effectiveRole.DeepCopyInto(&role.Spec)
+			r.events.Event(role, events.SpecReset, fmt.Sprintf(specResetMessage, role.Name, cluster.Name))
 		} else {
 			// Update the cluster's Replicas count to match the role, which will cause this update to come around again.
 			clusterRole.SetReplicas(roleReplicas)
@@ -423,33 +414,64 @@ func (r *ReconcileCoherenceRole) updateRole(cluster *coh.CoherenceCluster, role
 			logger.Info(fmt.Sprintf("Reconciling existing Coherence Role - updating cluster's role Replicas from %d to %d", clusterReplicas, clusterRole.GetReplicas()))
 			err := r.client.Update(context.TODO(), cluster)
 			if err != nil {
-				return r.handleErrAndRequeue(err, nil, fmt.Sprintf(updateFailedMessage, helmValues.GetName(), role.Name, err), logger)
+				return r.handleErrAndRequeue(err, nil, fmt.Sprintf(updateFailedMessage, sts.Name, role.Name, err), logger)
 			}
 		}
 	}
 
 	desiredReplicas := role.Spec.GetReplicas()
 
-	// convert the unstructured data to a CoherenceInternal that we can deal with better
-	existing, err := r.toCoherenceInternal(helmValues)
+	// obtain the spec that the existing StatefulSet was last applied from
+	existing, err := resources.GetAppliedSpec(sts.GetAnnotations())
 	if err != nil {
-		return r.handleErrAndRequeue(err, nil, fmt.Sprintf(updateFailedMessage, helmValues.GetName(), role.Name, err), logger)
+		return r.handleErrAndRequeue(err, nil, fmt.Sprintf(updateFailedMessage, sts.Name, role.Name, err), logger)
 	}
 
-	sts, err := r.findStatefulSet(role)
-	if err != nil && !errors.IsNotFound(err) {
-		return r.handleErrAndRequeue(err, role, fmt.Sprintf(updateFailedMessage, helmValues.GetName(), role.Name, err), logger)
+	legacy := existing == nil
+	if legacy {
+		// A StatefulSet created by a previous Operator version does not have the spec annotation so the
+		// existing spec is derived from the StatefulSet.
+		existing = r.CreateDesiredRole(cluster, role, &coh.CoherenceInternalSpec{}, sts)
+		existing.Replicas = sts.Spec.Replicas
+	}
+
+	currentReplicas := existing.GetReplicas()
+	desiredRole := r.CreateDesiredRole(cluster, role, existing, sts)
+	isUpgrade := r.isUpgrade(sts, existing, desiredRole)
+
+	if legacy && !isUpgrade {
+		// The StatefulSet is only rolled if this Operator version would generate a different Pod template,
+		// otherwise the role's resources are re-applied at the current size so that the StatefulSet gets the
+		// spec annotation without restarting its Pods.
+		changed, err := isPodTemplateChanged(sts, desiredRole)
+		if err != nil {
+			return r.handleErrAndRequeue(err, nil, fmt.Sprintf(updateFailedMessage, sts.Name, role.Name, err), logger)
+		}
+		if changed {
+			logger.Info("The Pod template generated for the StatefulSet created by a previous Operator version is different, the Pods will be restarted")
+			isUpgrade = true
+		} else {
+			logger.Info("Re-applying the resources of a StatefulSet created by a previous Operator version")
+			current := desiredRole.DeepCopy()
+			current.Replicas = &currentReplicas
+			if err := r.applyRole(role, current); err != nil {
+				return r.handleErrAndRequeue(err, nil, fmt.Sprintf(updateFailedMessage, sts.Name, role.Name, err), logger)
+			}
+			// requeue the request so that it is reconciled against the annotated StatefulSet
+			return reconcile.Result{Requeue: true}, nil
+		}
 	}
 
-	if errors.IsNotFound(err) {
-		// The StatefulSet is not found, it could be being created or recovered
-		logger.Info(fmt.Sprintf("Re-queing update request. Could not find StatefulSet for CoherenceRole %s", role.Name))
-		return reconcile.Result{Requeue: true, RequeueAfter: time.Second * 10}, nil
+	recordUpgradeProgress(role, sts)
+
+	if !isUpgrade && role.Spec.GetEffectiveUpgradePolicy() == coh.SafeUpgrade {
+		// complete any Operator driven rolling upgrade before scaling or updating the status
+		if inProgress, result, err := r.safeUpgrade(role, sts, logger); inProgress || err != nil {
+			return result, err
+		}
 	}
 
-	currentReplicas := existing.Spec.GetReplicas()
-	desiredRole := r.CreateDesiredRole(cluster, role, &existing.Spec, sts)
-	isUpgrade := r.isUpgrade(&existing.Spec, desiredRole)
+	result := reconcile.Result{Requeue: false}
 
 	switch {
 	case currentReplicas < desiredReplicas:
@@ -459,7 +481,7 @@ func (r *ReconcileCoherenceRole) updateRole(cluster *coh.CoherenceCluster, role
 		// if scaling up and upgrading then upgrade first and scale second
 		// otherwise we'd have to upgrade all the scaled up members
 		if isUpgrade {
-			err := r.upgrade(role, helmValues, currentReplicas, desiredRole)
+			err := r.upgrade(role, currentReplicas, desiredRole)
 			if err == nil {
 				// Requeue so that we then scale up after the upgrade.
 				// We do things this way because the upgrade is still happening asynchronously
@@ -468,11 +490,11 @@ func (r *ReconcileCoherenceRole) updateRole(cluster *coh.CoherenceCluster, role
 				// in a stable state when the scale up will then happen.
 				return reconcile.Result{Requeue: true}, nil
 			}
-			return r.handleErrAndRequeue(err, nil, fmt.Sprintf(updateFailedMessage, helmValues.GetName(), role.Name, err), logger)
+			return r.handleErrAndRequeue(err, nil, fmt.Sprintf(updateFailedMessage, sts.Name, role.Name, err), logger)
 		}
 
 		logger.Info(fmt.Sprintf("Request to scale up from %d to %d", currentReplicas, desiredReplicas))
-		return r.scale(role, helmValues, existing, desiredReplicas, currentReplicas, sts)
+		return r.scale(role, existing, desiredReplicas, currentReplicas, sts)
 	case currentReplicas > desiredReplicas:
 		logger.Info("Reconciling existing Coherence Role: case currentReplicas > desiredReplicas")
 		// Scaling DOWN
@@ -480,7 +502,7 @@ func (r *ReconcileCoherenceRole) updateRole(cluster *coh.CoherenceCluster, role
 		// if scaling down and upgrading then scale down first and upgrade second
 		// so that we do not have to upgrade the members we are scaling down
 		logger.Info(fmt.Sprintf("Request to scale down from %d to %d", currentReplicas, desiredReplicas))
-		result, err := r.scale(role, helmValues, existing, desiredReplicas, currentReplicas, sts)
+		result, err := r.scale(role, existing, desiredReplicas, currentReplicas, sts)
 
 		if err == nil && isUpgrade {
 			// requeue the request so that we then upgrade
@@ -494,10 +516,11 @@ func (r *ReconcileCoherenceRole) updateRole(cluster *coh.CoherenceCluster, role
 	case isUpgrade:
 		logger.Info("Reconciling existing Coherence Role: case isUpgrade")
 		// no scaling, just a rolling upgrade
-		if err := r.upgrade(role, helmValues, currentReplicas, desiredRole); err != nil {
-			return r.handleErrAndRequeue(err, nil, fmt.Sprintf(updateFailedMessage, helmValues.GetName(), role.Name, err), logger)
+		if err := r.upgrade(role, currentReplicas, desiredRole); err != nil {
+			return r.handleErrAndRequeue(err, nil, fmt.Sprintf(updateFailedMessage, sts.Name, role.Name, err), logger)
 		}
-		return reconcile.Result{Requeue: false}, nil
+		// with the Safe upgrade policy requeue the request so that the Operator restarts the Pods
+		return reconcile.Result{Requeue: role.Spec.GetEffectiveUpgradePolicy() == coh.SafeUpgrade}, nil
 	case sts != nil:
 		logger.Info("Reconciling existing Coherence Role: case sts != nil")
 		// nothing to do to update or scale
@@ -509,19 +532,40 @@ func (r *ReconcileCoherenceRole) updateRole(cluster *coh.CoherenceCluster, role
 			log.Error(err, "failed to update role status", "Namespace", role.Namespace, "Name", role.Name)
 			return reconcile.Result{Requeue: true, RequeueAfter: time.Second * 5}, nil
 		}
+
+		locked, err := r.updateDisruptionBudget(role, existing, sts, logger)
+		if err != nil {
+			return r.handleErrAndRequeue(err, nil, fmt.Sprintf(failedToUpdateBudgetMessage, role.Name, err), logger)
+		}
+		if locked {
+			// re-queue the request so that the PodDisruptionBudget is unlocked once the role is StatusHA
+			result = reconcile.Result{Requeue: true, RequeueAfter: r.statusHARetry}
+		}
+
+		// check the role's Pods have actually joined the Coherence cluster
+		result = EarliestRequeue(result, r.updateMembership(role, sts, logger))
+
+		if role.Spec.Autoscaling.IsEnabled() {
+			// the role is at its desired size so evaluate whether it should be autoscaled
+			autoscaled, err := r.autoscale(role, sts, logger)
+			if err != nil {
+				return autoscaled, err
+			}
+			result = EarliestRequeue(result, autoscaled)
+		}
 	}
 
 	logger.Info("Finished reconciling existing Coherence Role")
-	return reconcile.Result{Requeue: false}, nil
+	return requeueForSchedule(role, result), nil
 }
 
 // scaleDownToZero is called in response to the replica count of a role being set to zero.
-func (r *ReconcileCoherenceRole) scaleDownToZero(cluster *coh.CoherenceCluster, role *coh.CoherenceRole, cohInt *unstructured.Unstructured) (reconcile.Result, error) {
+func (r *ReconcileCoherenceRole) scaleDownToZero(cluster *coh.CoherenceCluster, role *coh.CoherenceRole, sts *appsv1.StatefulSet) (reconcile.Result, error) {
 	logger := log.WithValues("Namespace", role.Namespace, "Name", role.Name)
 	logger.Info("Scaling existing Coherence Role to zero")
 
-	// Delete the CoherenceInternal causing a Helm delete of the Pods
-	if err := r.client.Delete(context.TODO(), cohInt); err != nil {
+	// Delete the StatefulSet, Services and ConfigMaps causing a delete of the Pods
+	if err := r.deleteResources(sts); err != nil {
 		return r.handleErrAndRequeue(err, role, fmt.Sprintf(scaleToZeroFailed, role.Name, err), logger)
 	}
 
@@ -536,47 +580,92 @@ func (r *ReconcileCoherenceRole) scaleDownToZero(cluster *coh.CoherenceCluster,
 
 	// send a successful update event
 	msg := fmt.Sprintf(updateMessage, role.Name, role.Name)
-	r.events.Event(role, corev1.EventTypeNormal, eventReasonUpdated, msg)
+	r.events.Event(role, events.Updated, msg)
 
 	return reconcile.Result{Requeue: false}, nil
 }
 
 // isUpgrade determines whether the current spec differs to the desired spec ignoring differences to the Replicas field.
-func (r *ReconcileCoherenceRole) isUpgrade(current *coh.CoherenceInternalSpec, desired *coh.CoherenceInternalSpec) bool {
-	clone := current.DeepCopy()
-	clone.Replicas = desired.Replicas
+// The hash of the desired spec is compared to the hash annotation on the StatefulSet, or to the hash of the
+// current spec if the StatefulSet does not have the annotation.
+func (r *ReconcileCoherenceRole) isUpgrade(sts *appsv1.StatefulSet, current *coh.CoherenceInternalSpec, desired *coh.CoherenceInternalSpec) bool {
+	desiredHash, err := resources.SpecHash(desired)
+	if err != nil {
+		log.Error(err, "failed to compute hash of desired role spec")
+		return true
+	}
+
+	currentHash := coh.GetSpecHash(sts)
+	if currentHash == "" {
+		if currentHash, err = resources.SpecHash(current); err != nil {
+			log.Error(err, "failed to compute hash of current role spec")
+			return true
+		}
+	}
+
+	return currentHash != desiredHash
+}
 
-	return !reflect.DeepEqual(clone, desired)
+// isPodTemplateChanged determines whether the Pod template that would be generated from the desired spec differs
+// from the Pod template of an existing StatefulSet. Fields that are not set in the generated template, such as
+// those defaulted by the API server, are ignored.
+func isPodTemplateChanged(sts *appsv1.StatefulSet, desired *coh.CoherenceInternalSpec) (bool, error) {
+	generated, err := resources.NewStatefulSet(sts.Namespace, desired)
+	if err != nil {
+		return false, err
+	}
+	return !equality.Semantic.DeepDerivative(generated.Spec.Template, sts.Spec.Template), nil
+}
+
+// isSameSpec determines whether two specs are the same by comparing their hashes.
+func isSameSpec(a, b interface{}) bool {
+	hashA, err := coh.ComputeHash(a)
+	if err != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	hashB, err := coh.ComputeHash(b)
+	if err != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return hashA == hashB
+}
+
+// EarliestRequeue returns whichever of two reconcile results re-queues the request soonest.
+func EarliestRequeue(a, b reconcile.Result) reconcile.Result {
+	switch {
+	case !a.Requeue:
+		return b
+	case !b.Requeue:
+		return a
+	case b.RequeueAfter < a.RequeueAfter:
+		return b
+	default:
+		return a
+	}
 }
 
 // upgrade triggers a rolling upgrade of the role
-func (r *ReconcileCoherenceRole) upgrade(role *coh.CoherenceRole, existingRole *unstructured.Unstructured, replicas int32, desiredRole *coh.CoherenceInternalSpec) error {
+func (r *ReconcileCoherenceRole) upgrade(role *coh.CoherenceRole, replicas int32, desiredRole *coh.CoherenceInternalSpec) error {
 	// Rolling upgrade
 	reqLogger := log.WithValues("Namespace", role.Namespace, "Name", role.Name)
 	reqLogger.Info("Rolling upgrade of existing Role")
 
-	spec, err := coh.CoherenceInternalSpecAsMapFromSpec(desiredRole)
-	if err != nil {
-		return err
-	}
-
-	// update the CoherenceInternal, this should trigger an update of the Helm install
+	// apply the desired spec at the current size, the StatefulSet controller will then roll the Pods
 	desiredRole.Replicas = &replicas
-	existingRole.Object["spec"] = spec
-
-	if err = r.client.Update(context.TODO(), existingRole); err != nil {
+	if err := r.applyRole(role, desiredRole); err != nil {
 		return err
 	}
 
 	// Update this CoherenceRole's status
 	role.Status.Status = coh.RoleStatusRollingUpgrade
-	if err = r.client.Update(context.TODO(), role); err != nil {
+	role.SetCondition(coh.ConditionProgressing, true, coh.ReasonRollingUpgrade, "")
+	role.Status.ObservedGeneration = role.Generation
+	if err := r.client.Status().Update(context.TODO(), role); err != nil {
 		reqLogger.Error(err, "failed to update Status")
 	}
 
-	// send a successful update event
-	msg := fmt.Sprintf(updateMessage, role.Name, role.Name)
-	r.events.Event(role, corev1.EventTypeNormal, eventReasonUpdated, msg)
+	// send an upgrade started event
+	r.events.Event(role, events.UpgradeStarted, fmt.Sprintf(upgradeStartedMessage, role.Name))
 
 	return nil
 }
@@ -596,12 +685,20 @@ func (r *ReconcileCoherenceRole) updateStatus(role *coh.CoherenceRole, sts *apps
 			log.Error(err, "failed to update role status", "Namespace", role.Namespace, "Name", role.Name, "Cluster", cluster.Name)
 			return err
 		}
-	} else if role.Status.CurrentReplicas != sts.Status.Replicas || role.Status.ReadyReplicas != sts.Status.ReadyReplicas {
+	} else if changed := r.updateConditions(role, sts); changed || role.Status.ObservedGeneration != role.Generation ||
+		role.Status.CurrentReplicas != sts.Status.Replicas || role.Status.ReadyReplicas != sts.Status.ReadyReplicas ||
+		(role.Status.Status == coh.RoleStatusRollingUpgrade && isRolloutComplete(sts, role.Spec.GetReplicas())) {
 		// Update this CoherenceRole's status
+		role.Status.ObservedGeneration = role.Generation
 		role.Status.CurrentReplicas = sts.Status.CurrentReplicas
 		role.Status.ReadyReplicas = sts.Status.ReadyReplicas
 
-		if sts.Status.ReadyReplicas == role.Spec.GetReplicas() {
+		upgrading := role.Status.Status == coh.RoleStatusRollingUpgrade
+		switch {
+		case upgrading && isRolloutComplete(sts, role.Spec.GetReplicas()):
+			r.events.Event(role, events.UpgradeCompleted, fmt.Sprintf(upgradeCompletedMessage, role.Name))
+			role.Status.Status = coh.RoleStatusReady
+		case !upgrading && sts.Status.ReadyReplicas == role.Spec.GetReplicas():
 			role.Status.Status = coh.RoleStatusReady
 		}
 
@@ -622,6 +719,7 @@ func (r *ReconcileCoherenceRole) updateStatus(role *coh.CoherenceRole, sts *apps
 
 	ready := role.Status.Status == coh.RoleStatusReady
 	clusterToUpdate.SetRoleStatus(role.Spec.Role, ready, role.Status.ReadyReplicas, role.Status.Status)
+	clusterToUpdate.UpdateConditions()
 
 	log.Info("Updating role's status in parent cluster", "Namespace", role.Namespace, "Name", role.Name, "Cluster", cluster.Name)
 
@@ -634,6 +732,33 @@ func (r *ReconcileCoherenceRole) updateStatus(role *coh.CoherenceRole, sts *apps
 	return err
 }
 
+// isRolloutComplete returns true once the StatefulSet controller has observed the latest StatefulSet spec and every
+// replica has been updated to the latest revision and is ready. The current revision of a StatefulSet with the OnDelete
+// update strategy is never moved on to the update revision, so it is only compared for the RollingUpdate strategy.
+func isRolloutComplete(sts *appsv1.StatefulSet, replicas int32) bool {
+	if sts.Status.ObservedGeneration < sts.Generation || sts.Status.UpdatedReplicas != replicas || sts.Status.ReadyReplicas != replicas {
+		return false
+	}
+	return sts.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType || sts.Status.CurrentRevision == sts.Status.UpdateRevision
+}
+
+// updateConditions sets the role's Available, Progressing and Degraded conditions from the status of the StatefulSet.
+// Returns true if any of the role's conditions were changed.
+func (r *ReconcileCoherenceRole) updateConditions(role *coh.CoherenceRole, sts *appsv1.StatefulSet) bool {
+	desired := role.Spec.GetReplicas()
+	msg := fmt.Sprintf("%d of %d replicas are ready", sts.Status.ReadyReplicas, desired)
+
+	var changed bool
+	if sts.Status.ReadyReplicas == desired {
+		changed = role.SetCondition(coh.ConditionAvailable, true, coh.ReasonReplicasReady, msg)
+		changed = role.SetCondition(coh.ConditionProgressing, false, coh.ReasonReplicasReady, msg) || changed
+	} else {
+		changed = role.SetCondition(coh.ConditionAvailable, false, coh.ReasonReplicasNotReady, msg)
+		changed = role.SetCondition(coh.ConditionProgressing, true, coh.ReasonReplicasNotReady, msg) || changed
+	}
+	return role.SetCondition(coh.ConditionDegraded, false, coh.ReasonReconcileSucceeded, "") || changed
+}
+
 // findStatefulSet finds the StatefulSet associated to the role.
 func (r *ReconcileCoherenceRole) findStatefulSet(role *coh.CoherenceRole) (*appsv1.StatefulSet, error) {
 	sts := &appsv1.StatefulSet{}
@@ -644,61 +769,99 @@ func (r *ReconcileCoherenceRole) findStatefulSet(role *coh.CoherenceRole) (*apps
 	return sts, nil
 }
 
-// GetExistingHelmValues gets an existing unstructured Helm values from k8s for a given CoherenceRole
-func (r *ReconcileCoherenceRole) GetExistingHelmValues(role *coh.CoherenceRole) (*unstructured.Unstructured, error) {
-	cohInt := r.CreateEmptyHelmValues(role)
-	err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: role.Namespace, Name: role.Name}, cohInt)
-	return cohInt, err
-}
-
-// CreateHelmValues creates an unstructured Helm values struct.
-func (r *ReconcileCoherenceRole) CreateHelmValues(cluster *coh.CoherenceCluster, role *coh.CoherenceRole, spec map[string]interface{}) *unstructured.Unstructured {
-	cohInternal := r.CreateEmptyHelmValues(role)
-	cohInternal.Object["spec"] = spec
+// applyRole uses server-side apply to create or update the StatefulSet, Services and ConfigMaps for a role. The
+// PodDisruptionBudget is managed separately by updateDisruptionBudget.
+func (r *ReconcileCoherenceRole) applyRole(role *coh.CoherenceRole, spec *coh.CoherenceInternalSpec) error {
+	scripts, err := r.getScripts()
+	if err != nil {
+		return err
+	}
 
-	// Set the labels for the CoherenceInternal
-	labels := make(map[string]string)
-	labels[coh.CoherenceClusterLabel] = cluster.Name
-	labels[coh.CoherenceRoleLabel] = role.Spec.GetRoleName()
-	cohInternal.SetLabels(labels)
+	objects, err := resources.New(role.Namespace, spec, scripts)
+	if err != nil {
+		return err
+	}
 
-	return cohInternal
-}
+	version := role.GetLabels()[coh.CoherenceOperatorVersionLabel]
+	for _, o := range objects {
+		m, err := meta.Accessor(o)
+		if err != nil {
+			return err
+		}
 
-// CreateEmptyHelmValues creates an empty (no Spec) unstructured Helm values.
-func (r *ReconcileCoherenceRole) CreateEmptyHelmValues(role *coh.CoherenceRole) *unstructured.Unstructured {
-	cohInternal := &unstructured.Unstructured{}
+		if version != "" {
+			labels := m.GetLabels()
+			labels[coh.CoherenceOperatorVersionLabel] = version
+			m.SetLabels(labels)
+		}
 
-	cohInternal.SetGroupVersionKind(r.gvk)
+		// Set this CoherenceRole instance as the owner and controller of the resource
+		if err := controllerutil.SetControllerReference(role, m, r.scheme); err != nil {
+			return err
+		}
 
-	if role != nil {
-		cohInternal.SetNamespace(role.Namespace)
-		cohInternal.SetName(role.Name)
+		if err := r.client.Patch(context.TODO(), o, client.Apply, client.FieldOwner(fieldOwner), client.ForceOwnership); err != nil {
+			return err
+		}
 	}
 
-	return cohInternal
+	return nil
 }
 
-// toCoherenceInternal converts an unstructured Helm values struct to a real CoherenceInternal struct.
-func (r *ReconcileCoherenceRole) toCoherenceInternal(state *unstructured.Unstructured) (*coh.CoherenceInternal, error) {
-	b, err := state.MarshalJSON()
-	if err != nil {
-		return nil, err
+// deleteResources deletes the StatefulSet, Services, ConfigMaps and PodDisruptionBudget for a role.
+func (r *ReconcileCoherenceRole) deleteResources(sts *appsv1.StatefulSet) error {
+	labels := client.MatchingLabels{resources.CoherenceDeploymentLabel: sts.Name}
+
+	if err := r.client.Delete(context.TODO(), sts); err != nil && !errors.IsNotFound(err) {
+		return err
 	}
 
-	cohInternal := &coh.CoherenceInternal{}
-	gvk := &schema.GroupVersionKind{
-		Group:   r.gvk.Group,
-		Kind:    r.gvk.Kind,
-		Version: r.gvk.Version,
+	services := corev1.ServiceList{}
+	if err := r.client.List(context.TODO(), &services, client.InNamespace(sts.Namespace), labels); err != nil {
+		return err
+	}
+	for i := range services.Items {
+		if err := r.client.Delete(context.TODO(), &services.Items[i]); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
 	}
 
-	_, _, err = unstructured.UnstructuredJSONScheme.Decode(b, gvk, cohInternal)
-	if err != nil {
-		return nil, err
+	configMaps := corev1.ConfigMapList{}
+	if err := r.client.List(context.TODO(), &configMaps, client.InNamespace(sts.Namespace), labels); err != nil {
+		return err
+	}
+	for i := range configMaps.Items {
+		if err := r.client.Delete(context.TODO(), &configMaps.Items[i]); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
 	}
 
-	return cohInternal, nil
+	budgets := policyv1beta1.PodDisruptionBudgetList{}
+	if err := r.client.List(context.TODO(), &budgets, client.InNamespace(sts.Namespace), labels); err != nil {
+		return err
+	}
+	for i := range budgets.Items {
+		if err := r.client.Delete(context.TODO(), &budgets.Items[i]); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getScripts returns the scripts to add to each role's scripts ConfigMap, loading them on first use.
+func (r *ReconcileCoherenceRole) getScripts() (map[string]string, error) {
+	r.scriptsMutex.Lock()
+	defer r.scriptsMutex.Unlock()
+
+	if r.scripts == nil {
+		scripts, err := resources.LoadScripts(r.opFlags.ScriptsDir)
+		if err != nil {
+			return nil, err
+		}
+		r.scripts = scripts
+	}
+	return r.scripts, nil
 }
 
 // handleErrAndRequeue is the common error handler
@@ -723,13 +886,14 @@ func (r *ReconcileCoherenceRole) failed(err error, role *coh.CoherenceRole, msg
 	if role != nil {
 		// update the status to failed.
 		role.Status.Status = coh.RoleStatusFailed
+		role.SetCondition(coh.ConditionDegraded, true, coh.ReasonReconcileFailed, msg)
 		if e := r.client.Status().Update(context.TODO(), role); e != nil {
 			// There isn't much we can do, we're already handling an error
 			logger.Error(err, "failed to update role status")
 		}
 
 		// send a failure event
-		r.events.Event(role, corev1.EventTypeNormal, eventReasonFailed, msg)
+		r.events.Event(role, events.ReconcileFailed, msg)
 	}
 
 	if requeue {
@@ -797,14 +961,48 @@ func (r *ReconcileCoherenceRole) CreateDesiredRole(cluster *coh.CoherenceCluster
 	return desiredRole
 }
 
-func (r *ReconcileCoherenceRole) deleteCoherenceInternal(request reconcile.Request, logger logr.Logger) {
+// deleteCoherenceInternal deletes a CoherenceInternal created by a previous Operator version along with
+// any Helm release state. The finalizer added by the Helm operator is removed first as the Helm operator
+// no longer runs. Returns true if a CoherenceInternal was found.
+func (r *ReconcileCoherenceRole) deleteCoherenceInternal(namespace, name string, propagation metav1.DeletionPropagation, logger logr.Logger) (bool, error) {
 	ci := &unstructured.Unstructured{}
 	ci.SetGroupVersionKind(r.gvk)
-	ci.SetNamespace(request.Namespace)
-	ci.SetName(request.Name)
 
-	logger.Info(fmt.Sprintf("Ensuring CoherenceInternal '%s/%s' is deleted", request.Namespace, request.Name))
-	_ = r.client.Delete(context.TODO(), ci)
+	err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name}, ci)
+	switch {
+	case err != nil && (errors.IsNotFound(err) || meta.IsNoMatchError(err)):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+
+	logger.Info(fmt.Sprintf("Deleting CoherenceInternal '%s/%s'", namespace, name))
+
+	if len(ci.GetFinalizers()) > 0 {
+		ci.SetFinalizers(nil)
+		if err := r.client.Update(context.TODO(), ci); err != nil && !errors.IsNotFound(err) {
+			return true, err
+		}
+	}
+
+	if err := r.client.Delete(context.TODO(), ci, client.PropagationPolicy(propagation)); err != nil && !errors.IsNotFound(err) {
+		return true, err
+	}
+
+	// Clean-up the Helm v3 release state
+	secrets := corev1.SecretList{}
+	if err := r.client.List(context.TODO(), &secrets, client.InNamespace(namespace)); err != nil {
+		return true, err
+	}
+	prefix := fmt.Sprintf("sh.helm.release.v1.%s.", name)
+	for i := range secrets.Items {
+		if strings.HasPrefix(secrets.Items[i].Name, prefix) {
+			logger.Info(fmt.Sprintf("Deleting Helm state for role %s in secret %s", name, secrets.Items[i].Name))
+			_ = r.client.Delete(context.TODO(), &secrets.Items[i])
+		}
+	}
+
+	return true, nil
 }
 
 func (r *ReconcileCoherenceRole) EnsureInitialized(logger logr.Logger) error {
@@ -823,15 +1021,11 @@ func (r *ReconcileCoherenceRole) EnsureInitialized(logger logr.Logger) error {
 	}
 
 	r.initialized = true
-
-	// Start the Helm controller
-	logger.Info("Starting Helm controller")
-	return r.setupHelm(r.mgr)
+	return nil
 }
 
 // Produces pseudo reconcile requests for all of the existing CoherenceRoles in the watch namespace
-// We typically call this function first before the Helm operator has stated to ensure that everything
-// is in the required state before the Helm operator gets a chance to think it has to update installs.
+// We typically call this function first to ensure that everything is in the required state.
 func (r *ReconcileCoherenceRole) reconcileExistingRoles() error {
 	log.Info("Reconciling all existing CoherenceRoles")
 
@@ -868,35 +1062,3 @@ func (r *ReconcileCoherenceRole) reconcileExistingRoles() error {
 
 	return nil
 }
-
-func (r *ReconcileCoherenceRole) setupHelm(mgr manager.Manager) error {
-	namespace, err := k8sutil.GetWatchNamespace()
-	if err != nil {
-		return err
-	}
-
-	// Setup Helm controller
-	watchList, err := watches.Load(r.opFlags.WatchesFile)
-	if err != nil {
-		log.Error(err, "failed to load Helm watches")
-		return err
-	}
-
-	fmt.Println(watchList)
-	for _, w := range watchList {
-		fmt.Println(w)
-		err := helmctl.Add(mgr, helmctl.WatchOptions{
-			Namespace:               namespace,
-			GVK:                     w.GroupVersionKind,
-			ManagerFactory:          release.NewManagerFactory(mgr, w.ChartDir),
-			ReconcilePeriod:         r.opFlags.ReconcilePeriod,
-			WatchDependentResources: w.WatchDependentResources,
-		})
-		if err != nil {
-			log.Error(err, "failed to add Helm watche")
-			return err
-		}
-	}
-
-	return nil
-}
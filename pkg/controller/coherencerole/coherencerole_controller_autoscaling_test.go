@@ -0,0 +1,272 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package coherencerole
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	coherence "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/controller/events"
+	stubs "github.com/oracle/coherence-operator/pkg/fakes"
+	mgmt "github.com/oracle/coherence-operator/pkg/management"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("coherencerole_controller autoscaling tests", func() {
+	const (
+		testNamespace   = "coherence-test"
+		testClusterName = "test-cluster"
+		roleName        = "storage"
+		fullRoleName    = testClusterName + "-" + roleName
+	)
+
+	var (
+		mgr         *stubs.FakeManager
+		role        *coherence.CoherenceRole
+		statefulSet *appsv1.StatefulSet
+		result      stubs.ReconcileResult
+	)
+
+	JustBeforeEach(func() {
+		var err error
+		mgr, err = stubs.NewFakeManager()
+		Expect(err).NotTo(HaveOccurred())
+
+		_ = mgr.Client.Create(context.TODO(), role)
+
+		controller := newReconciler(mgr, NewTestFlags())
+		// skip initialization for unit tests
+		controller.SetInitialized(true)
+
+		r, err := controller.autoscale(role, statefulSet, log)
+		result = stubs.ReconcileResult{Result: r, Error: err}
+	})
+
+	BeforeEach(func() {
+		role = &coherence.CoherenceRole{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: fullRoleName},
+			Spec: coherence.CoherenceRoleSpec{
+				Role:     roleName,
+				Replicas: pointer.Int32Ptr(1),
+				Autoscaling: &coherence.AutoscalingSpec{
+					Enabled:         pointer.BoolPtr(true),
+					MinReplicas:     pointer.Int32Ptr(3),
+					MaxReplicas:     pointer.Int32Ptr(5),
+					IntervalSeconds: pointer.Int32Ptr(10),
+				},
+			},
+		}
+
+		statefulSet = &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: fullRoleName},
+			Status:     appsv1.StatefulSetStatus{Replicas: 1, ReadyReplicas: 1},
+		}
+	})
+
+	When("the role has fewer replicas than the autoscaling minimum", func() {
+		It("should re-queue the request after the autoscaling interval", func() {
+			Expect(result.Error).NotTo(HaveOccurred())
+			Expect(result.Result.Requeue).To(BeTrue())
+			Expect(result.Result.RequeueAfter).To(Equal(time.Second * 10))
+		})
+
+		It("should scale the role to the minimum replicas", func() {
+			r := GetRole(mgr, testNamespace, fullRoleName)
+			Expect(r.Spec.GetReplicas()).To(Equal(int32(3)))
+			Expect(r.Status.LastScaleTime).NotTo(BeNil())
+		})
+
+		It("should fire a scaling event", func() {
+			event := mgr.AssertEvent()
+			Expect(event.Reason).To(Equal(string(events.Autoscaled)))
+			Expect(event.Message).To(Equal(fmt.Sprintf(autoscaleMessage, fullRoleName, 1, 3)))
+			mgr.AssertNoRemainingEvents()
+		})
+	})
+
+	When("the role has more replicas than the autoscaling maximum", func() {
+		BeforeEach(func() {
+			role.Spec.Replicas = pointer.Int32Ptr(6)
+			statefulSet.Status = appsv1.StatefulSetStatus{Replicas: 6, ReadyReplicas: 6}
+		})
+
+		It("should scale the role to the maximum replicas", func() {
+			Expect(GetRole(mgr, testNamespace, fullRoleName).Spec.GetReplicas()).To(Equal(int32(5)))
+		})
+
+		When("the role was recently scaled", func() {
+			BeforeEach(func() {
+				now := metav1.Now()
+				role.Status.LastScaleTime = &now
+			})
+
+			It("should not scale the role down", func() {
+				Expect(GetRole(mgr, testNamespace, fullRoleName).Spec.GetReplicas()).To(Equal(int32(6)))
+				mgr.AssertNoRemainingEvents()
+			})
+		})
+	})
+
+	When("the autoscaling metrics cannot be read", func() {
+		BeforeEach(func() {
+			role.Spec.Replicas = pointer.Int32Ptr(3)
+			role.Spec.Autoscaling.Policies = []coherence.AutoscalingPolicy{{Metric: coherence.HeapUsageMetric, Target: 70}}
+			statefulSet.Status = appsv1.StatefulSetStatus{Replicas: 3, ReadyReplicas: 3}
+		})
+
+		It("should not scale the role", func() {
+			Expect(result.Error).NotTo(HaveOccurred())
+			Expect(result.Result.Requeue).To(BeTrue())
+			Expect(GetRole(mgr, testNamespace, fullRoleName).Spec.GetReplicas()).To(Equal(int32(3)))
+		})
+
+		It("should fire a warning event", func() {
+			event := mgr.AssertEvent()
+			Expect(event.Type).To(Equal(corev1.EventTypeWarning))
+			Expect(event.Reason).To(Equal(string(events.AutoscalingMetricsFailed)))
+			cause := fmt.Sprintf("management over ReST is not enabled for CoherenceRole %s", fullRoleName)
+			Expect(event.Message).To(Equal(fmt.Sprintf(autoscaleMetricsFailedMessage, fullRoleName, cause)))
+			mgr.AssertNoRemainingEvents()
+		})
+	})
+
+	When("the role is not ready", func() {
+		BeforeEach(func() {
+			statefulSet.Status = appsv1.StatefulSetStatus{Replicas: 1, ReadyReplicas: 0}
+		})
+
+		It("should not scale the role", func() {
+			Expect(result.Result.Requeue).To(BeTrue())
+			Expect(GetRole(mgr, testNamespace, fullRoleName).Spec.GetReplicas()).To(Equal(int32(1)))
+			mgr.AssertNoRemainingEvents()
+		})
+	})
+})
+
+var _ = Describe("autoscaling desired replicas", func() {
+	autoscaling := &coherence.AutoscalingSpec{
+		Enabled:     pointer.BoolPtr(true),
+		MinReplicas: pointer.Int32Ptr(2),
+		MaxReplicas: pointer.Int32Ptr(10),
+		Policies: []coherence.AutoscalingPolicy{
+			{Metric: coherence.HeapUsageMetric, Target: 50},
+			{Metric: coherence.RequestQueueDepthMetric, Target: 100},
+		},
+	}
+
+	It("should not scale when the metrics are within the tolerance of their targets", func() {
+		Expect(desiredReplicas(autoscaling, 4, []float64{52, 95})).To(Equal(int32(4)))
+	})
+
+	It("should scale up in proportion to the metric", func() {
+		Expect(desiredReplicas(autoscaling, 4, []float64{75, 50})).To(Equal(int32(6)))
+	})
+
+	It("should use the largest number of replicas from the policies", func() {
+		Expect(desiredReplicas(autoscaling, 4, []float64{75, 200})).To(Equal(int32(8)))
+	})
+
+	It("should scale down in proportion to the metric", func() {
+		Expect(desiredReplicas(autoscaling, 6, []float64{25, 10})).To(Equal(int32(3)))
+	})
+
+	It("should not scale above the maximum replicas", func() {
+		Expect(desiredReplicas(autoscaling, 8, []float64{100, 100})).To(Equal(int32(10)))
+	})
+
+	It("should not scale below the minimum replicas", func() {
+		Expect(desiredReplicas(autoscaling, 3, []float64{1, 1})).To(Equal(int32(2)))
+	})
+})
+
+var _ = Describe("reading autoscaling metrics", func() {
+	var (
+		server   *httptest.Server
+		policies []coherence.AutoscalingPolicy
+		values   []float64
+		err      error
+	)
+
+	responses := map[string]interface{}{
+		"/management/coherence/cluster/members": mgmt.MembersData{Items: []mgmt.MemberData{
+			{NodeID: "1", RoleName: "storage", MemoryMaxMB: 1000, MemoryAvailableMB: 400},
+			{NodeID: "2", RoleName: "storage", MemoryMaxMB: 1000, MemoryAvailableMB: 200},
+			{NodeID: "3", RoleName: "proxy", MemoryMaxMB: 1000, MemoryAvailableMB: 900},
+		}},
+		"/management/coherence/cluster/services": mgmt.ServicesData{Items: []mgmt.ServiceData{
+			{Name: "PartitionedCache", Type: mgmt.DistributedCacheType},
+			{Name: "Proxy", Type: "Proxy"},
+		}},
+		"/management/coherence/cluster/services/PartitionedCache/partition": mgmt.PartitionData{
+			PartitionCount: 257, ServiceNodeCount: 2,
+		},
+		"/management/coherence/cluster/services/PartitionedCache/members": mgmt.ServiceMembersData{Items: []mgmt.ServiceMemberData{
+			{NodeID: "1", TaskBacklog: 10},
+			{NodeID: "2", TaskBacklog: 30},
+			{NodeID: "3", TaskBacklog: 100},
+		}},
+		"/management/coherence/cluster/caches/test/members": mgmt.CacheMembersData{Items: []mgmt.CacheMemberData{
+			{NodeID: "1", Tier: "back", Size: 100},
+			{NodeID: "2", Tier: "back", Size: 300},
+			{NodeID: "3", Tier: "front", Size: 50},
+		}},
+	}
+
+	JustBeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			data, found := responses[req.URL.Path]
+			if !found {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(data)
+		}))
+
+		values, err = readAutoscalingMetrics(mgmt.NewClient(server.Client(), server.URL), "storage", policies)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	When("reading each metric", func() {
+		BeforeEach(func() {
+			policies = []coherence.AutoscalingPolicy{
+				{Metric: coherence.HeapUsageMetric, Target: 50},
+				{Metric: coherence.PartitionsPerMemberMetric, Target: 50},
+				{Metric: coherence.RequestQueueDepthMetric, Target: 50},
+				{Metric: coherence.CacheSizeMetric, Cache: pointer.StringPtr("test"), Target: 50},
+			}
+		})
+
+		It("should return the average values for the role's members", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(values).To(Equal([]float64{70, 128.5, 20, 200}))
+		})
+	})
+
+	When("a query fails", func() {
+		BeforeEach(func() {
+			policies = []coherence.AutoscalingPolicy{
+				{Metric: coherence.CacheSizeMetric, Cache: pointer.StringPtr("unknown"), Target: 50},
+			}
+		})
+
+		It("should return an error", func() {
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
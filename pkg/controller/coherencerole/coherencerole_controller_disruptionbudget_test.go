@@ -0,0 +1,190 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package coherencerole
+
+import (
+	"context"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	coherence "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	stubs "github.com/oracle/coherence-operator/pkg/fakes"
+	appsv1 "k8s.io/api/apps/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
+)
+
+var _ = Describe("coherencerole_controller PodDisruptionBudget tests", func() {
+	const (
+		testNamespace   = "coherence-test"
+		testClusterName = "test-cluster"
+		roleName        = "storage"
+		fullRoleName    = testClusterName + "-" + roleName
+	)
+
+	var (
+		mgr         *stubs.FakeManager
+		existing    []runtime.Object
+		role        *coherence.CoherenceRole
+		statefulSet *appsv1.StatefulSet
+		controller  *ReconcileCoherenceRole
+		spec        *coherence.CoherenceInternalSpec
+		locked      bool
+		err         error
+	)
+
+	JustBeforeEach(func() {
+		mgr, err = stubs.NewFakeManager(existing...)
+		Expect(err).NotTo(HaveOccurred())
+
+		controller = newReconciler(mgr, NewTestFlags())
+		// skip initialization for unit tests
+		controller.SetInitialized(true)
+
+		spec = &coherence.CoherenceInternalSpec{FullnameOverride: fullRoleName, Cluster: testClusterName}
+		role.Spec.DeepCopyInto(&spec.CoherenceRoleSpec)
+
+		locked, err = controller.updateDisruptionBudget(role, spec, statefulSet, log)
+	})
+
+	getBudget := func() (*policyv1beta1.PodDisruptionBudget, error) {
+		pdb := &policyv1beta1.PodDisruptionBudget{}
+		e := mgr.Client.Get(context.TODO(), types.NamespacedName{Namespace: testNamespace, Name: fullRoleName}, pdb)
+		return pdb, e
+	}
+
+	newBudget := func(maxUnavailable int) *policyv1beta1.PodDisruptionBudget {
+		value := intstr.FromInt(maxUnavailable)
+		gvk := coherence.SchemeGroupVersion.WithKind("CoherenceRole")
+		return &policyv1beta1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       testNamespace,
+				Name:            fullRoleName,
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(role, gvk)},
+			},
+			Spec: policyv1beta1.PodDisruptionBudgetSpec{MaxUnavailable: &value},
+		}
+	}
+
+	BeforeEach(func() {
+		existing = []runtime.Object{}
+
+		role = &coherence.CoherenceRole{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: fullRoleName, UID: "test-uid"},
+			Spec: coherence.CoherenceRoleSpec{
+				Role:     roleName,
+				Replicas: pointer.Int32Ptr(3),
+			},
+		}
+
+		statefulSet = &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: fullRoleName},
+			Spec: appsv1.StatefulSetSpec{
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"coherenceDeployment": fullRoleName},
+				},
+			},
+			Status: appsv1.StatefulSetStatus{Replicas: 3, ReadyReplicas: 3},
+		}
+	})
+
+	When("the role does not have a PodDisruptionBudget", func() {
+		It("should create the PodDisruptionBudget", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(locked).To(BeFalse())
+
+			pdb, e := getBudget()
+			Expect(e).NotTo(HaveOccurred())
+			Expect(pdb.Spec.MaxUnavailable).To(Equal(&intstr.IntOrString{Type: intstr.Int, IntVal: 1}))
+			Expect(metav1.IsControlledBy(pdb, role)).To(BeTrue())
+		})
+	})
+
+	When("the role is not StatusHA", func() {
+		BeforeEach(func() {
+			// management is not enabled so a minimum HA status can never be satisfied
+			status := coherence.HAStatusNodeSafe
+			role.Spec.Scaling = &coherence.ScalingSpec{MinimumHAStatus: &status}
+			existing = append(existing, newBudget(1))
+		})
+
+		It("should lock the PodDisruptionBudget", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(locked).To(BeTrue())
+
+			pdb, e := getBudget()
+			Expect(e).NotTo(HaveOccurred())
+			Expect(pdb.Spec.MinAvailable).To(BeNil())
+			Expect(pdb.Spec.MaxUnavailable).To(Equal(&intstr.IntOrString{Type: intstr.Int, IntVal: 0}))
+		})
+
+		It("should not unlock the PodDisruptionBudget when the role's resources are applied", func() {
+			Expect(controller.applyRole(role, spec)).To(Succeed())
+
+			pdb, e := getBudget()
+			Expect(e).NotTo(HaveOccurred())
+			Expect(pdb.Spec.MaxUnavailable).To(Equal(&intstr.IntOrString{Type: intstr.Int, IntVal: 0}))
+		})
+
+		When("the role has a single replica", func() {
+			BeforeEach(func() {
+				role.Spec.Replicas = pointer.Int32Ptr(1)
+			})
+
+			It("should not lock the PodDisruptionBudget", func() {
+				Expect(locked).To(BeFalse())
+				pdb, e := getBudget()
+				Expect(e).NotTo(HaveOccurred())
+				Expect(pdb.Spec.MaxUnavailable).To(Equal(&intstr.IntOrString{Type: intstr.Int, IntVal: 1}))
+			})
+		})
+	})
+
+	When("the PodDisruptionBudget is locked and the role is StatusHA", func() {
+		BeforeEach(func() {
+			existing = append(existing, newBudget(0))
+		})
+
+		It("should unlock the PodDisruptionBudget", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(locked).To(BeFalse())
+
+			pdb, e := getBudget()
+			Expect(e).NotTo(HaveOccurred())
+			Expect(pdb.Spec.MaxUnavailable).To(Equal(&intstr.IntOrString{Type: intstr.Int, IntVal: 1}))
+		})
+	})
+
+	When("the PodDisruptionBudget has been disabled", func() {
+		BeforeEach(func() {
+			role.Spec.PodDisruptionBudget = &coherence.PodDisruptionBudgetSpec{Enabled: pointer.BoolPtr(false)}
+			existing = append(existing, newBudget(1))
+		})
+
+		It("should delete the PodDisruptionBudget", func() {
+			Expect(err).NotTo(HaveOccurred())
+			_, e := getBudget()
+			Expect(errors.IsNotFound(e)).To(BeTrue())
+		})
+	})
+
+	When("the role is storage disabled", func() {
+		BeforeEach(func() {
+			role.Spec.Coherence = &coherence.CoherenceSpec{StorageEnabled: pointer.BoolPtr(false)}
+		})
+
+		It("should not create a PodDisruptionBudget", func() {
+			Expect(err).NotTo(HaveOccurred())
+			_, e := getBudget()
+			Expect(errors.IsNotFound(e)).To(BeTrue())
+		})
+	})
+})
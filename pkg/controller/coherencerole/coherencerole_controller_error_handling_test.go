@@ -9,14 +9,14 @@ package coherencerole
 import (
 	"context"
 	"fmt"
-	"github.com/oracle/coherence-operator/pkg/flags"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	coherence "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/controller/events"
 	stubs "github.com/oracle/coherence-operator/pkg/fakes"
 
 	. "github.com/onsi/ginkgo"
@@ -35,14 +35,13 @@ var _ = Describe("coherencerole_controller", func() {
 	)
 
 	var (
-		mgr         *stubs.FakeManager
-		cluster     *coherence.CoherenceCluster
-		roleNew     *coherence.CoherenceRole
-		roleCurrent *coherence.CoherenceRole
-		existing    []runtime.Object
-		result      stubs.ReconcileResult
-		errors      stubs.ClientErrors
-		err         error
+		mgr      *stubs.FakeManager
+		cluster  *coherence.CoherenceCluster
+		roleNew  *coherence.CoherenceRole
+		existing []runtime.Object
+		result   stubs.ReconcileResult
+		errors   stubs.ClientErrors
+		err      error
 
 		controller *ReconcileCoherenceRole
 
@@ -57,7 +56,7 @@ var _ = Describe("coherencerole_controller", func() {
 	JustBeforeEach(func() {
 		mgr, err = stubs.NewFakeManager(existing...)
 		Expect(err).NotTo(HaveOccurred())
-		controller = newReconciler(mgr, &flags.CoherenceOperatorFlags{})
+		controller = newReconciler(mgr, NewTestFlags())
 		// skip initialization for unit tests
 		controller.SetInitialized(true)
 
@@ -71,17 +70,6 @@ var _ = Describe("coherencerole_controller", func() {
 			_ = mgr.Client.Create(context.TODO(), roleNew)
 		}
 
-		if roleCurrent != nil {
-			spec := coherence.NewCoherenceInternalSpec(cluster, roleCurrent)
-			specMap, err := coherence.CoherenceInternalSpecAsMapFromSpec(spec)
-			Expect(err).NotTo(HaveOccurred())
-
-			cohIntern := controller.CreateHelmValues(cluster, roleNew, specMap)
-
-			err = mgr.Client.Create(context.TODO(), cohIntern)
-			Expect(err).NotTo(HaveOccurred())
-		}
-
 		request := reconcile.Request{
 			NamespacedName: types.NamespacedName{
 				Namespace: namespace,
@@ -122,8 +110,8 @@ var _ = Describe("coherencerole_controller", func() {
 				mgr.AssertNoRemainingEvents()
 			})
 
-			It("should not create any CoherenceInternals", func() {
-				mgr.AssertCoherenceInternals(namespace, 0)
+			It("should not create any StatefulSets", func() {
+				mgr.AssertStatefulSets(namespace, 0)
 			})
 		})
 	})
@@ -153,8 +141,8 @@ var _ = Describe("coherencerole_controller", func() {
 				msg := fmt.Sprintf(invalidRoleEventMessage, fullRoleName, clusterName)
 				event := mgr.AssertEvent()
 
-				Expect(event.Type).To(Equal(corev1.EventTypeNormal))
-				Expect(event.Reason).To(Equal(eventReasonFailed))
+				Expect(event.Type).To(Equal(corev1.EventTypeWarning))
+				Expect(event.Reason).To(Equal(string(events.ReconcileFailed)))
 				Expect(event.Message).To(Equal(msg))
 
 				mgr.AssertNoRemainingEvents()
@@ -165,8 +153,8 @@ var _ = Describe("coherencerole_controller", func() {
 				Expect(role.Status.Status).To(Equal(coherence.RoleStatusFailed))
 			})
 
-			It("should not create any CoherenceInternals", func() {
-				mgr.AssertCoherenceInternals(namespace, 0)
+			It("should not create any StatefulSets", func() {
+				mgr.AssertStatefulSets(namespace, 0)
 			})
 		})
 	})
@@ -200,21 +188,21 @@ var _ = Describe("coherencerole_controller", func() {
 				msg := fmt.Sprintf(failedToGetParentCluster, clusterName, fullRoleName, err.Error())
 				event := mgr.AssertEvent()
 
-				Expect(event.Type).To(Equal(corev1.EventTypeNormal))
-				Expect(event.Reason).To(Equal(eventReasonFailed))
+				Expect(event.Type).To(Equal(corev1.EventTypeWarning))
+				Expect(event.Reason).To(Equal(string(events.ReconcileFailed)))
 				Expect(event.Message).To(Equal(msg))
 
 				mgr.AssertNoRemainingEvents()
 			})
 
-			It("should not create any CoherenceInternals", func() {
-				mgr.AssertCoherenceInternals(namespace, 0)
+			It("should not create any StatefulSets", func() {
+				mgr.AssertStatefulSets(namespace, 0)
 			})
 		})
 	})
 
-	When("the k8s client returns an error getting the Helm values for a CoherenceRole", func() {
-		var err error = stubs.FakeError{Msg: "error getting values"}
+	When("the k8s client returns an error getting the StatefulSet for a CoherenceRole", func() {
+		var err error = stubs.FakeError{Msg: "error getting StatefulSet"}
 
 		BeforeEach(func() {
 			cluster = defaultCluster
@@ -228,7 +216,7 @@ var _ = Describe("coherencerole_controller", func() {
 
 			errors.AddGetError(stubs.ErrorIf{
 				KeyIs:  &types.NamespacedName{Namespace: namespace, Name: fullRoleName},
-				TypeIs: &unstructured.Unstructured{},
+				TypeIs: &appsv1.StatefulSet{},
 			}, err)
 		})
 
@@ -242,18 +230,18 @@ var _ = Describe("coherencerole_controller", func() {
 			})
 
 			It("should fire a failed event", func() {
-				msg := fmt.Sprintf(failedToGetHelmValuesMessage, fullRoleName, err.Error())
+				msg := fmt.Sprintf(failedToGetStatefulSetMessage, fullRoleName, err.Error())
 				event := mgr.AssertEvent()
 
-				Expect(event.Type).To(Equal(corev1.EventTypeNormal))
-				Expect(event.Reason).To(Equal(eventReasonFailed))
+				Expect(event.Type).To(Equal(corev1.EventTypeWarning))
+				Expect(event.Reason).To(Equal(string(events.ReconcileFailed)))
 				Expect(event.Message).To(Equal(msg))
 
 				mgr.AssertNoRemainingEvents()
 			})
 
-			It("should not create any CoherenceInternals", func() {
-				mgr.AssertCoherenceInternals(namespace, 0)
+			It("should not create any StatefulSets", func() {
+				mgr.AssertStatefulSets(namespace, 0)
 			})
 		})
 	})
@@ -0,0 +1,270 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package coherencerole
+
+import (
+	"context"
+	"fmt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	coherence "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/controller/events"
+	stubs "github.com/oracle/coherence-operator/pkg/fakes"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"time"
+)
+
+// These tests use fakes and stubs for the k8s and operator-sdk so that the
+// tests will run without requiring a k8s cluster.
+var _ = Describe("coherencerole_controller finalizer tests", func() {
+	const (
+		testNamespace   = "coherence-test"
+		testClusterName = "test-cluster"
+		roleName        = "storage"
+		fullRoleName    = testClusterName + "-" + roleName
+	)
+
+	var (
+		mgr         *stubs.FakeManager
+		role        *coherence.CoherenceRole
+		statefulSet *appsv1.StatefulSet
+		result      stubs.ReconcileResult
+		err         error
+	)
+
+	JustBeforeEach(func() {
+		mgr, err = stubs.NewFakeManager()
+		Expect(err).NotTo(HaveOccurred())
+
+		if role != nil {
+			_ = mgr.Client.Create(context.TODO(), role)
+		}
+
+		if statefulSet != nil {
+			_ = mgr.Client.Create(context.TODO(), statefulSet)
+		}
+
+		request := reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: testNamespace,
+				Name:      fullRoleName,
+			},
+		}
+
+		controller := newReconciler(mgr, NewTestFlags())
+		// skip initialization for unit tests
+		controller.SetInitialized(true)
+
+		r, err := controller.Reconcile(request)
+		result = stubs.ReconcileResult{Result: r, Error: err}
+	})
+
+	newRole := func(spec coherence.CoherenceRoleSpec, deleted bool) *coherence.CoherenceRole {
+		r := &coherence.CoherenceRole{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:  testNamespace,
+				Name:       fullRoleName,
+				Labels:     map[string]string{coherence.CoherenceClusterLabel: testClusterName},
+				Finalizers: []string{coherence.CoherenceFinalizer},
+			},
+			Spec: spec,
+		}
+		if deleted {
+			now := metav1.Now()
+			r.SetDeletionTimestamp(&now)
+		}
+		return r
+	}
+
+	newStatefulSet := func(replicas int32) *appsv1.StatefulSet {
+		return &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testNamespace,
+				Name:      fullRoleName,
+			},
+			Spec: appsv1.StatefulSetSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"coherenceDeployment": fullRoleName},
+				},
+			},
+		}
+	}
+
+	assertStatefulSetDeleted := func() {
+		err := mgr.Client.Get(context.TODO(), types.NamespacedName{Namespace: testNamespace, Name: fullRoleName}, &appsv1.StatefulSet{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	}
+
+	When("a CoherenceRole without the finalizer is reconciled", func() {
+		BeforeEach(func() {
+			role = newRole(coherence.CoherenceRoleSpec{Role: roleName}, false)
+			role.SetFinalizers(nil)
+			statefulSet = nil
+		})
+
+		It("should add the finalizer to the role", func() {
+			Expect(coherence.HasFinalizer(GetRole(mgr, testNamespace, fullRoleName))).To(BeTrue())
+		})
+	})
+
+	When("a deleted CoherenceRole has no StatefulSet", func() {
+		BeforeEach(func() {
+			role = newRole(coherence.CoherenceRoleSpec{Role: roleName}, true)
+			statefulSet = nil
+		})
+
+		It("should not return error", func() {
+			Expect(result.Error).To(BeNil())
+		})
+
+		It("should not re-queue the request", func() {
+			Expect(result.Result).To(Equal(reconcile.Result{}))
+		})
+
+		It("should remove the finalizer", func() {
+			Expect(coherence.HasFinalizer(GetRole(mgr, testNamespace, fullRoleName))).To(BeFalse())
+		})
+	})
+
+	When("a deleted storage enabled CoherenceRole has more than one member", func() {
+		BeforeEach(func() {
+			role = newRole(coherence.CoherenceRoleSpec{Role: roleName}, true)
+			statefulSet = newStatefulSet(3)
+		})
+
+		It("should not return error", func() {
+			Expect(result.Error).To(BeNil())
+		})
+
+		It("should re-queue the request", func() {
+			Expect(result.Result).To(Equal(reconcile.Result{Requeue: true, RequeueAfter: time.Minute}))
+		})
+
+		It("should scale down the StatefulSet by one", func() {
+			sts := mgr.AssertStatefulSetExists(testNamespace, fullRoleName)
+			Expect(*sts.Spec.Replicas).To(Equal(int32(2)))
+		})
+
+		It("should fire a scale event", func() {
+			event := mgr.AssertEvent()
+			Expect(event.Reason).To(Equal(string(events.Scaled)))
+			Expect(event.Message).To(Equal(fmt.Sprintf("scaled StatefulSet %s in CoherenceRole %s from 3 to 2", fullRoleName, fullRoleName)))
+			mgr.AssertNoRemainingEvents()
+		})
+
+		It("should not remove the finalizer", func() {
+			Expect(coherence.HasFinalizer(GetRole(mgr, testNamespace, fullRoleName))).To(BeTrue())
+		})
+	})
+
+	When("a deleted storage enabled CoherenceRole has one member", func() {
+		BeforeEach(func() {
+			role = newRole(coherence.CoherenceRoleSpec{Role: roleName}, true)
+			statefulSet = newStatefulSet(1)
+		})
+
+		It("should not return error", func() {
+			Expect(result.Error).To(BeNil())
+		})
+
+		It("should not re-queue the request", func() {
+			Expect(result.Result).To(Equal(reconcile.Result{}))
+		})
+
+		It("should delete the StatefulSet", func() {
+			assertStatefulSetDeleted()
+		})
+
+		It("should remove the finalizer", func() {
+			Expect(coherence.HasFinalizer(GetRole(mgr, testNamespace, fullRoleName))).To(BeFalse())
+		})
+	})
+
+	When("a deleted storage disabled CoherenceRole has more than one member", func() {
+		BeforeEach(func() {
+			spec := coherence.CoherenceRoleSpec{
+				Role:      roleName,
+				Coherence: &coherence.CoherenceSpec{StorageEnabled: pointer.BoolPtr(false)},
+			}
+			role = newRole(spec, true)
+			statefulSet = newStatefulSet(3)
+		})
+
+		It("should not re-queue the request", func() {
+			Expect(result.Result).To(Equal(reconcile.Result{}))
+		})
+
+		It("should delete the StatefulSet", func() {
+			assertStatefulSetDeleted()
+		})
+
+		It("should remove the finalizer", func() {
+			Expect(coherence.HasFinalizer(GetRole(mgr, testNamespace, fullRoleName))).To(BeFalse())
+		})
+	})
+
+	When("a deleted CoherenceRole requires a snapshot", func() {
+		BeforeEach(func() {
+			spec := coherence.CoherenceRoleSpec{
+				Role: roleName,
+				Coherence: &coherence.CoherenceSpec{
+					SnapshotOnDelete: pointer.BoolPtr(true),
+					Management:       &coherence.PortSpecWithSSL{Enabled: pointer.BoolPtr(true)},
+				},
+			}
+			role = newRole(spec, true)
+			statefulSet = newStatefulSet(3)
+		})
+
+		It("should re-queue the request", func() {
+			Expect(result.Result).To(Equal(reconcile.Result{Requeue: true}))
+		})
+
+		It("should record the snapshot name on the role", func() {
+			Expect(GetRole(mgr, testNamespace, fullRoleName).GetAnnotations()).To(HaveKey(snapshotAnnotation))
+		})
+
+		It("should not scale down the StatefulSet", func() {
+			sts := mgr.AssertStatefulSetExists(testNamespace, fullRoleName)
+			Expect(*sts.Spec.Replicas).To(Equal(int32(3)))
+		})
+	})
+
+	When("a deleted CoherenceRole requires a snapshot but management is not enabled", func() {
+		BeforeEach(func() {
+			spec := coherence.CoherenceRoleSpec{
+				Role:      roleName,
+				Coherence: &coherence.CoherenceSpec{SnapshotOnDelete: pointer.BoolPtr(true)},
+			}
+			role = newRole(spec, true)
+			statefulSet = newStatefulSet(3)
+		})
+
+		It("should not record a snapshot name on the role", func() {
+			Expect(GetRole(mgr, testNamespace, fullRoleName).GetAnnotations()).NotTo(HaveKey(snapshotAnnotation))
+		})
+
+		It("should fire a snapshot skipped event", func() {
+			event := mgr.AssertEvent()
+			Expect(event.Type).To(Equal(corev1.EventTypeWarning))
+			Expect(event.Reason).To(Equal(string(events.SnapshotSkipped)))
+			Expect(event.Message).To(Equal(fmt.Sprintf(snapshotSkippedMessage, fullRoleName)))
+		})
+
+		It("should continue the teardown by scaling down the StatefulSet", func() {
+			sts := mgr.AssertStatefulSetExists(testNamespace, fullRoleName)
+			Expect(*sts.Spec.Replicas).To(Equal(int32(2)))
+		})
+	})
+})
@@ -0,0 +1,322 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package coherencerole
+
+import (
+	"encoding/json"
+	"fmt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	coherence "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	stubs "github.com/oracle/coherence-operator/pkg/fakes"
+	mgmt "github.com/oracle/coherence-operator/pkg/management"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"strconv"
+	"sync"
+)
+
+var _ = Describe("coherencerole_controller cluster membership tests", func() {
+	const (
+		testNamespace   = "coherence-test"
+		testClusterName = "test-cluster"
+		roleName        = "storage"
+		fullRoleName    = testClusterName + "-" + roleName
+		nodeName        = "node-1"
+	)
+
+	var (
+		server       *httptest.Server
+		lock         sync.Mutex
+		clusterSizes []int
+		calls        int
+		members      []mgmt.MemberData
+		role         *coherence.CoherenceRole
+		statefulSet  *appsv1.StatefulSet
+		pods         []runtime.Object
+		membership   *coherence.MembershipStatus
+		sizes        map[string]int32
+		err          error
+	)
+
+	newPod := func(name, ip string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: name, Labels: map[string]string{"coherenceRole": roleName}},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				PodIP:      ip,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		}
+	}
+
+	newMember := func(name string) mgmt.MemberData {
+		return mgmt.MemberData{MemberName: name, RoleName: roleName, MachineName: nodeName}
+	}
+
+	BeforeEach(func() {
+		calls = 0
+		clusterSizes = []int{3, 3, 3}
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			defer lock.Unlock()
+
+			var data interface{}
+			switch r.URL.Path {
+			case "/management/coherence/cluster":
+				// each Pod is queried in turn so each request returns the cluster size seen by the next Pod
+				data = mgmt.ClusterData{ClusterName: testClusterName, Version: "14.1.1.0.0", ClusterSize: clusterSizes[calls%len(clusterSizes)]}
+				calls++
+			case "/management/coherence/cluster/members":
+				data = mgmt.MembersData{Items: members}
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			b, _ := json.Marshal(data)
+			_, _ = w.Write(b)
+		}))
+
+		u, e := url.Parse(server.URL)
+		Expect(e).NotTo(HaveOccurred())
+		port, e := strconv.Atoi(u.Port())
+		Expect(e).NotTo(HaveOccurred())
+
+		pods = nil
+		members = nil
+		for i := 0; i < 3; i++ {
+			name := fmt.Sprintf("%s-%d", fullRoleName, i)
+			pods = append(pods, newPod(name, u.Hostname()))
+			members = append(members, newMember(name))
+		}
+
+		role = &coherence.CoherenceRole{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: fullRoleName},
+			Spec: coherence.CoherenceRoleSpec{
+				Role:     roleName,
+				Replicas: pointer.Int32Ptr(3),
+				Coherence: &coherence.CoherenceSpec{
+					Management: &coherence.PortSpecWithSSL{
+						Enabled: pointer.BoolPtr(true),
+						Port:    pointer.Int32Ptr(int32(port)),
+					},
+				},
+			},
+		}
+
+		statefulSet = &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: fullRoleName},
+			Spec: appsv1.StatefulSetSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"coherenceRole": roleName}},
+			},
+			Status: appsv1.StatefulSetStatus{Replicas: 3, ReadyReplicas: 3, UpdatedReplicas: 3},
+		}
+	})
+
+	JustBeforeEach(func() {
+		mgr, e := stubs.NewFakeManager(pods...)
+		Expect(e).NotTo(HaveOccurred())
+		checker := &ScalableChecker{Client: mgr.Client}
+		membership, sizes, err = checker.GetMembership(role, statefulSet)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	When("all of the role's Pods have joined the cluster", func() {
+		It("should count every Pod as a joined member", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(membership).To(Equal(&coherence.MembershipStatus{
+				ClusterName:    testClusterName,
+				ClusterVersion: "14.1.1.0.0",
+				ClusterSize:    3,
+				JoinedMembers:  3,
+			}))
+			Expect(sizes).To(HaveLen(3))
+		})
+
+		It("should not suspect a split cluster", func() {
+			changed, split := updateMembershipStatus(role, membership, sizes, false)
+			Expect(changed).To(BeTrue())
+			Expect(split).To(BeFalse())
+			c := role.Status.Conditions.GetCondition(coherence.ConditionSplitBrainSuspected)
+			Expect(c).NotTo(BeNil())
+			Expect(c.Status).To(Equal(corev1.ConditionFalse))
+			Expect(c.Reason).To(Equal(coherence.ReasonClusterSizeMatch))
+			Expect(role.Status.Membership).To(Equal(membership))
+
+			// the status is unchanged when the membership is unchanged
+			changed, _ = updateMembershipStatus(role, membership.DeepCopy(), sizes, false)
+			Expect(changed).To(BeFalse())
+		})
+	})
+
+	When("a ready Pod is not a member of the cluster", func() {
+		BeforeEach(func() {
+			members = members[:2]
+		})
+
+		It("should report the Pod as not joined", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(membership.JoinedMembers).To(Equal(int32(2)))
+			Expect(membership.NotJoined).To(Equal([]string{fullRoleName + "-2"}))
+		})
+	})
+
+	When("a member with the Pod's name is on a different machine", func() {
+		BeforeEach(func() {
+			members[1].MachineName = "node-2"
+		})
+
+		It("should report the Pod as not joined", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(membership.JoinedMembers).To(Equal(int32(2)))
+			Expect(membership.NotJoined).To(Equal([]string{fullRoleName + "-1"}))
+		})
+	})
+
+	When("a Pod is not ready", func() {
+		BeforeEach(func() {
+			pods[2].(*corev1.Pod).Status.Conditions[0].Status = corev1.ConditionFalse
+		})
+
+		It("should only check the ready Pods", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(membership.JoinedMembers).To(Equal(int32(2)))
+			Expect(membership.NotJoined).To(BeEmpty())
+			Expect(sizes).To(HaveLen(2))
+		})
+	})
+
+	When("the members report different cluster sizes", func() {
+		BeforeEach(func() {
+			clusterSizes = []int{3, 3, 2}
+		})
+
+		It("should not suspect a split cluster at the first check", func() {
+			Expect(err).NotTo(HaveOccurred())
+			changed, split := updateMembershipStatus(role, membership, sizes, false)
+			Expect(changed).To(BeTrue())
+			Expect(split).To(BeTrue())
+			Expect(role.Status.Conditions.GetCondition(coherence.ConditionSplitBrainSuspected)).To(BeNil())
+		})
+
+		It("should suspect a split cluster when the sizes also differed at the previous check", func() {
+			Expect(err).NotTo(HaveOccurred())
+			changed, split := updateMembershipStatus(role, membership, sizes, true)
+			Expect(changed).To(BeTrue())
+			Expect(split).To(BeTrue())
+			c := role.Status.Conditions.GetCondition(coherence.ConditionSplitBrainSuspected)
+			Expect(c).NotTo(BeNil())
+			Expect(c.Status).To(Equal(corev1.ConditionTrue))
+			Expect(c.Reason).To(Equal(coherence.ReasonClusterSizeDiffers))
+			Expect(c.Message).To(ContainSubstring("=2"))
+		})
+	})
+
+	Context("checking the membership from the reconciler", func() {
+		var (
+			controller *ReconcileCoherenceRole
+			result     reconcile.Result
+		)
+
+		check := func() {
+			lock.Lock()
+			calls = 0
+			lock.Unlock()
+			result = controller.updateMembership(role, statefulSet, log)
+		}
+
+		JustBeforeEach(func() {
+			objects := append([]runtime.Object{role}, pods...)
+			mgr, e := stubs.NewFakeManager(objects...)
+			Expect(e).NotTo(HaveOccurred())
+			controller = newReconciler(mgr, NewTestFlags())
+		})
+
+		When("the members report different cluster sizes at consecutive checks", func() {
+			BeforeEach(func() {
+				clusterSizes = []int{3, 3, 2}
+			})
+
+			It("should only suspect a split cluster at the second check", func() {
+				check()
+				Expect(result.RequeueAfter).To(Equal(membershipCheckInterval))
+				Expect(role.Status.Conditions.IsTrue(coherence.ConditionSplitBrainSuspected)).To(BeFalse())
+
+				check()
+				Expect(role.Status.Conditions.IsTrue(coherence.ConditionSplitBrainSuspected)).To(BeTrue())
+			})
+		})
+
+		When("the members report different cluster sizes at one check only", func() {
+			BeforeEach(func() {
+				clusterSizes = []int{3, 3, 2}
+			})
+
+			It("should not suspect a split cluster", func() {
+				check()
+				lock.Lock()
+				clusterSizes = []int{3, 3, 3}
+				lock.Unlock()
+				check()
+				c := role.Status.Conditions.GetCondition(coherence.ConditionSplitBrainSuspected)
+				Expect(c).NotTo(BeNil())
+				Expect(c.Status).To(Equal(corev1.ConditionFalse))
+			})
+		})
+
+		When("not every replica is ready", func() {
+			BeforeEach(func() {
+				clusterSizes = []int{3, 3, 2}
+				statefulSet.Status.ReadyReplicas = 2
+			})
+
+			It("should not check the membership", func() {
+				check()
+				check()
+				Expect(result.RequeueAfter).To(Equal(membershipCheckInterval))
+				Expect(calls).To(BeZero())
+				Expect(role.Status.Conditions.GetCondition(coherence.ConditionSplitBrainSuspected)).To(BeNil())
+			})
+		})
+
+		When("a rolling upgrade is in progress", func() {
+			BeforeEach(func() {
+				clusterSizes = []int{3, 3, 2}
+				statefulSet.Status.UpdatedReplicas = 2
+			})
+
+			It("should not check the membership", func() {
+				check()
+				check()
+				Expect(calls).To(BeZero())
+				Expect(role.Status.Conditions.GetCondition(coherence.ConditionSplitBrainSuspected)).To(BeNil())
+			})
+		})
+	})
+
+	When("management over ReST is not enabled", func() {
+		BeforeEach(func() {
+			role.Spec.Coherence.Management.Enabled = pointer.BoolPtr(false)
+		})
+
+		It("should return an error", func() {
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
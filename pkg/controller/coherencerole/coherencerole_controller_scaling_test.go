@@ -8,7 +8,6 @@ package coherencerole
 
 import (
 	"context"
-	"github.com/oracle/coherence-operator/pkg/flags"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -66,7 +65,7 @@ var _ = Describe("coherencerole_controller scaling tests", func() {
 			},
 		}
 
-		controller := newReconciler(mgr, &flags.CoherenceOperatorFlags{})
+		controller := newReconciler(mgr, NewTestFlags())
 		// skip initialization for unit tests
 		controller.SetInitialized(true)
 
@@ -94,8 +93,8 @@ var _ = Describe("coherencerole_controller scaling tests", func() {
 				Expect(found).To(BeFalse())
 			})
 
-			It("should not create any CoherenceInternals", func() {
-				mgr.AssertCoherenceInternals(testNamespace, 0)
+			It("should not create any StatefulSets", func() {
+				mgr.AssertStatefulSets(testNamespace, 0)
 			})
 		})
 	})
@@ -0,0 +1,192 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package coherencerole
+
+import (
+	"context"
+	"fmt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	coherence "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/controller/events"
+	stubs "github.com/oracle/coherence-operator/pkg/fakes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"time"
+)
+
+var _ = Describe("coherencerole_controller scheduled scaling tests", func() {
+	const (
+		testNamespace   = "coherence-test"
+		testClusterName = "test-cluster"
+		roleName        = "storage"
+		fullRoleName    = testClusterName + "-" + roleName
+		everyMinute     = "* * * * *"
+		neverDue        = "0 0 30 2 *"
+	)
+
+	var (
+		mgr  *stubs.FakeManager
+		role *coherence.CoherenceRole
+		err  error
+	)
+
+	JustBeforeEach(func() {
+		mgr, err = stubs.NewFakeManager()
+		Expect(err).NotTo(HaveOccurred())
+
+		_ = mgr.Client.Create(context.TODO(), role)
+
+		controller := newReconciler(mgr, NewTestFlags())
+		// skip initialization for unit tests
+		controller.SetInitialized(true)
+
+		err = controller.applySchedule(role, log)
+	})
+
+	BeforeEach(func() {
+		role = &coherence.CoherenceRole{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: fullRoleName},
+			Spec: coherence.CoherenceRoleSpec{
+				Role:     roleName,
+				Replicas: pointer.Int32Ptr(3),
+				Scaling: &coherence.ScalingSpec{
+					Schedule: &coherence.ScalingSchedule{
+						Entries: []coherence.ScheduledReplicas{{Cron: everyMinute, Replicas: 5}},
+					},
+				},
+			},
+		}
+	})
+
+	When("the schedule has not been evaluated before", func() {
+		It("should not return an error", func() {
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should not scale the role", func() {
+			Expect(GetRole(mgr, testNamespace, fullRoleName).Spec.GetReplicas()).To(Equal(int32(3)))
+			mgr.AssertNoRemainingEvents()
+		})
+
+		It("should set the next scheduled change in the status", func() {
+			status := GetRole(mgr, testNamespace, fullRoleName).Status.ScheduledScaling
+			Expect(status).NotTo(BeNil())
+			Expect(status.LastEvaluatedTime).NotTo(BeNil())
+			Expect(status.Last).To(BeNil())
+			Expect(status.Next).NotTo(BeNil())
+			Expect(status.Next.Cron).To(Equal(everyMinute))
+			Expect(status.Next.Replicas).To(Equal(int32(5)))
+			Expect(status.Next.Time.After(time.Now())).To(BeTrue())
+		})
+	})
+
+	When("a scheduled change has become due since the schedule was last evaluated", func() {
+		BeforeEach(func() {
+			evaluated := metav1.NewTime(time.Now().Add(-2 * time.Minute))
+			role.Status.ScheduledScaling = &coherence.ScheduledScalingStatus{LastEvaluatedTime: &evaluated}
+		})
+
+		It("should scale the role", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(GetRole(mgr, testNamespace, fullRoleName).Spec.GetReplicas()).To(Equal(int32(5)))
+		})
+
+		It("should set the last and next scheduled changes in the status", func() {
+			status := GetRole(mgr, testNamespace, fullRoleName).Status.ScheduledScaling
+			Expect(status).NotTo(BeNil())
+			Expect(status.Last).NotTo(BeNil())
+			Expect(status.Last.Cron).To(Equal(everyMinute))
+			Expect(status.Last.Replicas).To(Equal(int32(5)))
+			Expect(status.Last.Time.After(time.Now())).To(BeFalse())
+			Expect(status.Next).NotTo(BeNil())
+		})
+
+		It("should fire a scaling event", func() {
+			event := mgr.AssertEvent()
+			Expect(event.Reason).To(Equal(string(events.ScheduledScale)))
+			Expect(event.Message).To(Equal(fmt.Sprintf(scheduledScaleMessage, fullRoleName, 3, 5, everyMinute)))
+			mgr.AssertNoRemainingEvents()
+		})
+	})
+
+	When("the role already has the replicas of the due scheduled change", func() {
+		BeforeEach(func() {
+			role.Spec.Replicas = pointer.Int32Ptr(5)
+			evaluated := metav1.NewTime(time.Now().Add(-2 * time.Minute))
+			role.Status.ScheduledScaling = &coherence.ScheduledScalingStatus{LastEvaluatedTime: &evaluated}
+		})
+
+		It("should record the change in the status without scaling", func() {
+			r := GetRole(mgr, testNamespace, fullRoleName)
+			Expect(r.Spec.GetReplicas()).To(Equal(int32(5)))
+			Expect(r.Status.ScheduledScaling.Last).NotTo(BeNil())
+			mgr.AssertNoRemainingEvents()
+		})
+	})
+
+	When("no scheduled change is due", func() {
+		BeforeEach(func() {
+			role.Spec.Scaling.Schedule.Entries = []coherence.ScheduledReplicas{{Cron: neverDue, Replicas: 5}}
+			evaluated := metav1.NewTime(time.Now().Add(-2 * time.Minute))
+			role.Status.ScheduledScaling = &coherence.ScheduledScalingStatus{LastEvaluatedTime: &evaluated}
+		})
+
+		It("should not scale the role", func() {
+			Expect(err).NotTo(HaveOccurred())
+			r := GetRole(mgr, testNamespace, fullRoleName)
+			Expect(r.Spec.GetReplicas()).To(Equal(int32(3)))
+			Expect(r.Status.ScheduledScaling.Last).To(BeNil())
+			Expect(r.Status.ScheduledScaling.Next).To(BeNil())
+			mgr.AssertNoRemainingEvents()
+		})
+	})
+})
+
+var _ = Describe("coherencerole_controller scheduled scaling re-queue tests", func() {
+	var role *coherence.CoherenceRole
+
+	BeforeEach(func() {
+		role = &coherence.CoherenceRole{
+			Spec: coherence.CoherenceRoleSpec{
+				Scaling: &coherence.ScalingSpec{
+					Schedule: &coherence.ScalingSchedule{
+						Entries: []coherence.ScheduledReplicas{{Cron: "0 * * * *", Replicas: 5}},
+					},
+				},
+			},
+			Status: coherence.CoherenceRoleStatus{
+				ScheduledScaling: &coherence.ScheduledScalingStatus{
+					Next: &coherence.ScheduledScalingAction{Cron: "0 * * * *", Replicas: 5, Time: metav1.NewTime(time.Now().Add(time.Hour))},
+				},
+			},
+		}
+	})
+
+	It("should re-queue a request that would not be re-queued", func() {
+		result := requeueForSchedule(role, reconcile.Result{})
+		Expect(result.Requeue).To(BeTrue())
+		Expect(result.RequeueAfter).To(BeNumerically("~", time.Hour, time.Minute))
+	})
+
+	It("should re-queue a request that would be re-queued after the next change", func() {
+		result := requeueForSchedule(role, reconcile.Result{Requeue: true, RequeueAfter: 2 * time.Hour})
+		Expect(result.Requeue).To(BeTrue())
+		Expect(result.RequeueAfter).To(BeNumerically("~", time.Hour, time.Minute))
+	})
+
+	It("should not change a request that would be re-queued before the next change", func() {
+		original := reconcile.Result{Requeue: true, RequeueAfter: time.Minute}
+		Expect(requeueForSchedule(role, original)).To(Equal(original))
+	})
+
+	It("should not change a request for a role without a schedule", func() {
+		role.Spec.Scaling = nil
+		Expect(requeueForSchedule(role, reconcile.Result{})).To(Equal(reconcile.Result{}))
+	})
+})
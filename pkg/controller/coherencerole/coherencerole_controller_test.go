@@ -10,7 +10,9 @@ import (
 	"context"
 	"fmt"
 	"github.com/go-test/deep"
+	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
 	"github.com/oracle/coherence-operator/pkg/flags"
+	"github.com/oracle/coherence-operator/pkg/resources"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -18,9 +20,11 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"os"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	coherence "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/controller/events"
 	stubs "github.com/oracle/coherence-operator/pkg/fakes"
 
 	. "github.com/onsi/ginkgo"
@@ -61,7 +65,7 @@ var _ = Describe("coherencerole_controller", func() {
 	JustBeforeEach(func() {
 		mgr, err = stubs.NewFakeManager(existing...)
 		Expect(err).NotTo(HaveOccurred())
-		controller = newReconciler(mgr, &flags.CoherenceOperatorFlags{})
+		controller = newReconciler(mgr, NewTestFlags())
 		// skip initialization for unit tests
 		controller.initialized = true
 
@@ -73,18 +77,10 @@ var _ = Describe("coherencerole_controller", func() {
 			_ = mgr.Client.Create(context.TODO(), roleNew)
 		}
 
-		if roleCurrent != nil {
-			spec := coherence.NewCoherenceInternalSpec(cluster, roleCurrent)
-			specMap, err := coherence.CoherenceInternalSpecAsMapFromSpec(spec)
-			Expect(err).NotTo(HaveOccurred())
-
-			cohIntern := controller.CreateHelmValues(cluster, roleNew, specMap)
-
-			err = mgr.Client.Create(context.TODO(), cohIntern)
-			Expect(err).NotTo(HaveOccurred())
-		}
-
 		if statefulSet != nil {
+			if roleCurrent != nil {
+				WithAppliedSpec(statefulSet, coherence.NewCoherenceInternalSpec(cluster, roleCurrent))
+			}
 			_ = mgr.Client.Create(context.TODO(), statefulSet)
 		}
 
@@ -124,8 +120,8 @@ var _ = Describe("coherencerole_controller", func() {
 				Expect(found).To(BeFalse())
 			})
 
-			It("should not create any CoherenceInternals", func() {
-				mgr.AssertCoherenceInternals(testNamespace, 0)
+			It("should not create any StatefulSets", func() {
+				mgr.AssertStatefulSets(testNamespace, 0)
 			})
 		})
 	})
@@ -164,8 +160,8 @@ var _ = Describe("coherencerole_controller", func() {
 				Expect(found).To(BeFalse())
 			})
 
-			It("should not create any CoherenceInternals", func() {
-				mgr.AssertCoherenceInternals(testNamespace, 0)
+			It("should not create any StatefulSets", func() {
+				mgr.AssertStatefulSets(testNamespace, 0)
 			})
 		})
 	})
@@ -174,7 +170,7 @@ var _ = Describe("coherencerole_controller", func() {
 		BeforeEach(func() {
 			cluster = defaultCluster
 			roleNew = &coherence.CoherenceRole{
-				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: fullRoleName},
+				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: fullRoleName, Generation: 2},
 				Spec: coherence.CoherenceRoleSpec{
 					Role: roleName,
 				},
@@ -195,22 +191,40 @@ var _ = Describe("coherencerole_controller", func() {
 				event := mgr.AssertEvent()
 
 				Expect(event.Type).To(Equal(corev1.EventTypeNormal))
-				Expect(event.Reason).To(Equal(eventReasonCreated))
+				Expect(event.Reason).To(Equal(string(events.Created)))
 				Expect(event.Message).To(Equal(msg))
 
 				mgr.AssertNoRemainingEvents()
 			})
 
-			It("should create a CoherenceInternal", func() {
-				u, err := mgr.AssertCoherenceInternalExists(testNamespace, fullRoleName)
-				Expect(err).NotTo(HaveOccurred())
-				roleSpec := UnstructuredToCoherenceInternalSpec(u)
+			It("should create a StatefulSet", func() {
+				sts := mgr.AssertStatefulSetExists(testNamespace, fullRoleName)
+				roleSpec := AppliedSpec(sts)
 				expected := coherence.NewCoherenceInternalSpec(cluster, roleNew)
 				expected.EnsureCoherenceImage(flags.GetDefaultCoherenceImage())
 				expected.EnsureCoherenceUtilsImage(flags.GetDefaultCoherenceUtilsImage())
 
 				Expect(roleSpec).To(Equal(expected))
 			})
+
+			It("should set the CoherenceRole's Progressing condition", func() {
+				role := mgr.AssertCoherenceRoleExists(testNamespace, fullRoleName)
+				Expect(role.Status.Conditions.IsTrue(coherence.ConditionProgressing)).To(BeTrue())
+				Expect(role.Status.Conditions.IsTrue(coherence.ConditionAvailable)).To(BeFalse())
+				Expect(role.Status.Conditions.GetCondition(coherence.ConditionProgressing).Reason).To(Equal(coherence.ReasonCreating))
+			})
+
+			It("should set the CoherenceRole's observed generation", func() {
+				role := mgr.AssertCoherenceRoleExists(testNamespace, fullRoleName)
+				Expect(role.Status.ObservedGeneration).To(Equal(int64(2)))
+			})
+
+			It("should annotate the StatefulSet with the spec hash", func() {
+				sts := mgr.AssertStatefulSetExists(testNamespace, fullRoleName)
+				hash, err := resources.SpecHash(AppliedSpec(sts))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(coherence.GetSpecHash(sts)).To(Equal(hash))
+			})
 		})
 	})
 
@@ -261,6 +275,9 @@ var _ = Describe("coherencerole_controller", func() {
 			statefulSet = &appsv1.StatefulSet{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: fullRoleName},
 				Spec: appsv1.StatefulSetSpec{
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"coherenceDeployment": fullRoleName},
+					},
 					Template: corev1.PodTemplateSpec{
 						Spec: corev1.PodSpec{
 							InitContainers: []corev1.Container{
@@ -295,21 +312,20 @@ var _ = Describe("coherencerole_controller", func() {
 				Expect(result.Result).To(Equal(reconcile.Result{}))
 			})
 
-			It("should fire an update event", func() {
-				msg := fmt.Sprintf(updateMessage, roleNew.Name, roleNew.Name)
+			It("should fire an upgrade started event", func() {
+				msg := fmt.Sprintf(upgradeStartedMessage, roleNew.Name)
 				event := mgr.AssertEvent()
 
 				Expect(event.Type).To(Equal(corev1.EventTypeNormal))
-				Expect(event.Reason).To(Equal(eventReasonUpdated))
+				Expect(event.Reason).To(Equal(string(events.UpgradeStarted)))
 				Expect(event.Message).To(Equal(msg))
 
 				mgr.AssertNoRemainingEvents()
 			})
 
-			It("should update the CoherenceInternal", func() {
-				u, err := mgr.AssertCoherenceInternalExists(testNamespace, fullRoleName)
-				Expect(err).NotTo(HaveOccurred())
-				roleSpec := UnstructuredToCoherenceInternalSpec(u)
+			It("should update the StatefulSet", func() {
+				sts := mgr.AssertStatefulSetExists(testNamespace, fullRoleName)
+				roleSpec := AppliedSpec(sts)
 				expected := coherence.NewCoherenceInternalSpec(cluster, roleNew)
 				expected.EnsureCoherenceImage(flags.GetDefaultCoherenceImage())
 				expected.EnsureCoherenceUtilsImage(flags.GetDefaultCoherenceUtilsImage())
@@ -385,6 +401,9 @@ var _ = Describe("coherencerole_controller", func() {
 			statefulSet = &appsv1.StatefulSet{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: fullRoleName},
 				Spec: appsv1.StatefulSetSpec{
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"coherenceDeployment": fullRoleName},
+					},
 					Template: corev1.PodTemplateSpec{
 						Spec: corev1.PodSpec{
 							InitContainers: []corev1.Container{
@@ -424,8 +443,146 @@ var _ = Describe("coherencerole_controller", func() {
 				Expect(found).To(BeFalse())
 			})
 
-			It("should create one CoherenceInternals", func() {
-				mgr.AssertCoherenceInternals(testNamespace, 1)
+			It("should have one StatefulSet", func() {
+				mgr.AssertStatefulSets(testNamespace, 1)
+			})
+		})
+	})
+
+	When("a CoherenceRole is being upgraded by the StatefulSet controller", func() {
+		var replicas int32 = 3
+		var image = "foo/bar:1.0"
+
+		BeforeEach(func() {
+			spec := coherence.CoherenceRoleSpec{
+				Role:           roleName,
+				Replicas:       &replicas,
+				CoherenceUtils: &coherence.ImageSpec{Image: &image},
+				Coherence:      &coherence.CoherenceSpec{ImageSpec: coherence.ImageSpec{Image: &image}},
+			}
+
+			roleCurrent = &coherence.CoherenceRole{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: fullRoleName},
+				Spec:       spec,
+			}
+			roleNew = roleCurrent.DeepCopy()
+			roleNew.Status = coherence.CoherenceRoleStatus{
+				Status:          coherence.RoleStatusRollingUpgrade,
+				Replicas:        replicas,
+				CurrentReplicas: replicas,
+				ReadyReplicas:   replicas,
+			}
+
+			cluster = defaultCluster.DeepCopy()
+			cluster.Spec.Roles = []coherence.CoherenceRoleSpec{spec}
+
+			statefulSet = &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: fullRoleName},
+				Spec: appsv1.StatefulSetSpec{
+					Replicas: &replicas,
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"coherenceDeployment": fullRoleName},
+					},
+				},
+				Status: appsv1.StatefulSetStatus{
+					Replicas:        replicas,
+					ReadyReplicas:   replicas,
+					CurrentReplicas: 1,
+					UpdatedReplicas: 2,
+					CurrentRevision: "rev-1",
+					UpdateRevision:  "rev-2",
+				},
+			}
+		})
+
+		When("every replica is ready but not every replica has been updated", func() {
+			It("should not complete the upgrade", func() {
+				Expect(result.Error).To(BeNil())
+				mgr.AssertNoRemainingEvents()
+				Expect(GetRole(mgr, testNamespace, fullRoleName).Status.Status).To(Equal(coherence.RoleStatusRollingUpgrade))
+			})
+		})
+
+		When("every replica has been updated to the latest revision", func() {
+			BeforeEach(func() {
+				statefulSet.Status.CurrentReplicas = replicas
+				statefulSet.Status.UpdatedReplicas = replicas
+				statefulSet.Status.CurrentRevision = "rev-2"
+			})
+
+			It("should complete the upgrade", func() {
+				Expect(result.Error).To(BeNil())
+				event := mgr.AssertEvent()
+				Expect(event.Reason).To(Equal(string(events.UpgradeCompleted)))
+				Expect(GetRole(mgr, testNamespace, fullRoleName).Status.Status).To(Equal(coherence.RoleStatusReady))
+			})
+		})
+	})
+
+	When("a CoherenceRole has a StatefulSet created by a previous Operator version", func() {
+		var image = "foo/bar:1.0"
+		var replicas int32 = 3
+
+		BeforeEach(func() {
+			roleNew = &coherence.CoherenceRole{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: fullRoleName},
+				Spec: coherence.CoherenceRoleSpec{
+					Role:           roleName,
+					Replicas:       &replicas,
+					CoherenceUtils: &coherence.ImageSpec{Image: &image},
+					Coherence:      &coherence.CoherenceSpec{ImageSpec: coherence.ImageSpec{Image: &image}},
+				},
+				Status: coherence.CoherenceRoleStatus{Status: coherence.RoleStatusReady, Replicas: replicas},
+			}
+
+			cluster = defaultCluster.DeepCopy()
+			cluster.Spec.Roles = []coherence.CoherenceRoleSpec{roleNew.Spec}
+			// the StatefulSet does not have the applied spec annotation
+			roleCurrent = nil
+		})
+
+		When("the Pod template is the same as the Pod template that would be generated", func() {
+			BeforeEach(func() {
+				sts, err := resources.NewStatefulSet(testNamespace, coherence.NewCoherenceInternalSpec(cluster, roleNew))
+				Expect(err).NotTo(HaveOccurred())
+				sts.SetAnnotations(nil)
+				sts.Status = appsv1.StatefulSetStatus{Replicas: replicas, ReadyReplicas: replicas, CurrentReplicas: replicas}
+				statefulSet = sts
+			})
+
+			It("should re-queue the request", func() {
+				Expect(result.Error).To(BeNil())
+				Expect(result.Result).To(Equal(reconcile.Result{Requeue: true}))
+			})
+
+			It("should not start a rolling upgrade", func() {
+				mgr.AssertNoRemainingEvents()
+				role := &coherence.CoherenceRole{}
+				Expect(mgr.Client.Get(context.TODO(), types.NamespacedName{Namespace: testNamespace, Name: fullRoleName}, role)).To(Succeed())
+				Expect(role.Status.Status).To(Equal(coherence.RoleStatusReady))
+			})
+
+			It("should annotate the StatefulSet with the applied spec", func() {
+				sts := mgr.AssertStatefulSetExists(testNamespace, fullRoleName)
+				Expect(AppliedSpec(sts)).NotTo(BeNil())
+				Expect(sts.Spec.Replicas).To(Equal(&replicas))
+			})
+		})
+
+		When("the Pod template is different to the Pod template that would be generated", func() {
+			BeforeEach(func() {
+				sts, err := resources.NewStatefulSet(testNamespace, coherence.NewCoherenceInternalSpec(cluster, roleNew))
+				Expect(err).NotTo(HaveOccurred())
+				sts.SetAnnotations(nil)
+				sts.Spec.Template.Spec.Containers[0].Image = "foo/bar:0.9"
+				sts.Status = appsv1.StatefulSetStatus{Replicas: replicas, ReadyReplicas: replicas, CurrentReplicas: replicas}
+				statefulSet = sts
+			})
+
+			It("should start a rolling upgrade", func() {
+				Expect(result.Error).To(BeNil())
+				event := mgr.AssertEvent()
+				Expect(event.Reason).To(Equal(string(events.UpgradeStarted)))
 			})
 		})
 	})
@@ -482,6 +639,9 @@ var _ = Describe("coherencerole_controller", func() {
 			statefulSet = &appsv1.StatefulSet{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: fullRoleName},
 				Spec: appsv1.StatefulSetSpec{
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"coherenceDeployment": fullRoleName},
+					},
 					Template: corev1.PodTemplateSpec{
 						Spec: corev1.PodSpec{
 							InitContainers: []corev1.Container{
@@ -521,8 +681,8 @@ var _ = Describe("coherencerole_controller", func() {
 				Expect(found).To(BeFalse())
 			})
 
-			It("should create one CoherenceInternals", func() {
-				mgr.AssertCoherenceInternals(testNamespace, 1)
+			It("should have one StatefulSet", func() {
+				mgr.AssertStatefulSets(testNamespace, 1)
 			})
 
 			It("should update the CoherenceRole's status replicas", func() {
@@ -535,6 +695,13 @@ var _ = Describe("coherencerole_controller", func() {
 				role := mgr.AssertCoherenceRoleExists(testNamespace, fullRoleName)
 				Expect(role.Status.Status).To(Equal(coherence.RoleStatusReady))
 			})
+
+			It("should set the CoherenceRole's Available condition", func() {
+				role := mgr.AssertCoherenceRoleExists(testNamespace, fullRoleName)
+				Expect(role.Status.Conditions.IsTrue(coherence.ConditionAvailable)).To(BeTrue())
+				Expect(role.Status.Conditions.IsTrue(coherence.ConditionProgressing)).To(BeFalse())
+				Expect(role.Status.Conditions.IsTrue(coherence.ConditionDegraded)).To(BeFalse())
+			})
 		})
 	})
 
@@ -587,6 +754,9 @@ var _ = Describe("coherencerole_controller", func() {
 			statefulSet = &appsv1.StatefulSet{
 				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: fullRoleName},
 				Spec: appsv1.StatefulSetSpec{
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"coherenceDeployment": fullRoleName},
+					},
 					Template: corev1.PodTemplateSpec{
 						Spec: corev1.PodSpec{
 							InitContainers: []corev1.Container{
@@ -626,8 +796,8 @@ var _ = Describe("coherencerole_controller", func() {
 				Expect(found).To(BeFalse())
 			})
 
-			It("should create one CoherenceInternals", func() {
-				mgr.AssertCoherenceInternals(testNamespace, 1)
+			It("should have one StatefulSet", func() {
+				mgr.AssertStatefulSets(testNamespace, 1)
 			})
 
 			It("should update the CoherenceRole's status replica counts", func() {
@@ -640,6 +810,59 @@ var _ = Describe("coherencerole_controller", func() {
 				role := mgr.AssertCoherenceRoleExists(testNamespace, fullRoleName)
 				Expect(role.Status.Status).To(Equal(coherence.RoleStatusCreated))
 			})
+
+			It("should set the CoherenceRole's Progressing condition", func() {
+				role := mgr.AssertCoherenceRoleExists(testNamespace, fullRoleName)
+				Expect(role.Status.Conditions.IsTrue(coherence.ConditionAvailable)).To(BeFalse())
+				Expect(role.Status.Conditions.IsTrue(coherence.ConditionProgressing)).To(BeTrue())
+				Expect(role.Status.Conditions.GetCondition(coherence.ConditionAvailable).Message).To(Equal("2 of 3 replicas are ready"))
+			})
 		})
 	})
 })
+
+var _ = Describe("coherencerole_controller initialization", func() {
+	const testNamespace = "coherence-test"
+
+	var (
+		mgr      *stubs.FakeManager
+		result   chan error
+		previous string
+		found    bool
+	)
+
+	BeforeEach(func() {
+		previous, found = os.LookupEnv(k8sutil.WatchNamespaceEnvVar)
+		Expect(os.Setenv(k8sutil.WatchNamespaceEnvVar, testNamespace)).To(Succeed())
+
+		role := &coherence.CoherenceRole{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "test-cluster-storage"},
+			Spec:       coherence.CoherenceRoleSpec{Role: "storage"},
+		}
+
+		cluster := &coherence.CoherenceCluster{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "test-cluster"},
+		}
+
+		var err error
+		mgr, err = stubs.NewFakeManager(cluster, role)
+		Expect(err).NotTo(HaveOccurred())
+		controller := newReconciler(mgr, NewTestFlags())
+
+		result = make(chan error, 1)
+		go func() { result <- controller.EnsureInitialized(log) }()
+	})
+
+	AfterEach(func() {
+		if found {
+			_ = os.Setenv(k8sutil.WatchNamespaceEnvVar, previous)
+		} else {
+			_ = os.Unsetenv(k8sutil.WatchNamespaceEnvVar)
+		}
+	})
+
+	It("should reconcile an existing role without deadlocking", func() {
+		Eventually(result, "10s").Should(Receive(BeNil()))
+		mgr.AssertStatefulSetExists(testNamespace, "test-cluster-storage")
+	})
+})
@@ -0,0 +1,259 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package coherencerole
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	coherence "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	stubs "github.com/oracle/coherence-operator/pkg/fakes"
+	mgmt "github.com/oracle/coherence-operator/pkg/management"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var _ = Describe("management over ReST with SSL", func() {
+	const (
+		testNamespace = "coherence-test"
+		secretName    = "management-ssl"
+	)
+
+	var (
+		server    *httptest.Server
+		existing  []runtime.Object
+		role      *coherence.CoherenceRole
+		pod       corev1.Pod
+		port      int32
+		checker   *ScalableChecker
+		tlsConfig *tls.Config
+		err       error
+	)
+
+	// newClientCert creates a PEM encoded self-signed client certificate and private key.
+	newClientCert := func() ([]byte, []byte) {
+		key, e := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(e).NotTo(HaveOccurred())
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "coherence-operator"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}
+		der, e := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		Expect(e).NotTo(HaveOccurred())
+		keyDer, e := x509.MarshalECPrivateKey(key)
+		Expect(e).NotTo(HaveOccurred())
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	}
+
+	// newDNSServerCert creates a CA certificate and a server certificate signed by the CA that is only issued
+	// for the specified DNS name, as member certificates normally are, returning the PEM encoded CA certificate.
+	newDNSServerCert := func(dnsName string) ([]byte, tls.Certificate) {
+		caKey, e := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(e).NotTo(HaveOccurred())
+		caTemplate := &x509.Certificate{
+			SerialNumber:          big.NewInt(2),
+			Subject:               pkix.Name{CommonName: "coherence-ca"},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign,
+		}
+		caDer, e := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+		Expect(e).NotTo(HaveOccurred())
+		caCert, e := x509.ParseCertificate(caDer)
+		Expect(e).NotTo(HaveOccurred())
+
+		key, e := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(e).NotTo(HaveOccurred())
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(3),
+			Subject:      pkix.Name{CommonName: dnsName},
+			DNSNames:     []string{dnsName},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}
+		der, e := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		Expect(e).NotTo(HaveOccurred())
+		cert := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDer}), cert
+	}
+
+	// startServer starts a mutual TLS server that only accepts requests with the client certificate, using the
+	// server certificate if one is specified, and creates the Pod and SSL Secret used to connect to it.
+	startServer := func(serverCert *tls.Certificate, ca []byte) {
+		cert, key := newClientCert()
+		pool := x509.NewCertPool()
+		Expect(pool.AppendCertsFromPEM(cert)).To(BeTrue())
+
+		server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/ha" {
+				return
+			}
+			_, _ = w.Write([]byte(`{"clusterName":"test-cluster"}`))
+		}))
+		server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+		if serverCert != nil {
+			server.TLS.Certificates = []tls.Certificate{*serverCert}
+		}
+		server.StartTLS()
+
+		u, e := url.Parse(server.URL)
+		Expect(e).NotTo(HaveOccurred())
+		p, e := strconv.Atoi(u.Port())
+		Expect(e).NotTo(HaveOccurred())
+		port = int32(p)
+		pod = corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "test-cluster-storage-0"},
+			Status:     corev1.PodStatus{PodIP: u.Hostname()},
+		}
+
+		if ca == nil {
+			ca = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: secretName},
+			Data:       map[string][]byte{mgmt.CACertKey: ca, mgmt.ClientCertKey: cert, mgmt.ClientKeyKey: key},
+		}
+		existing = []runtime.Object{secret}
+	}
+
+	BeforeEach(func() {
+		// the default test server certificate is issued for the server's IP address
+		startServer(nil, nil)
+
+		role = &coherence.CoherenceRole{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "test-cluster-storage"},
+			Spec: coherence.CoherenceRoleSpec{
+				Coherence: &coherence.CoherenceSpec{
+					Management: &coherence.PortSpecWithSSL{
+						Enabled: pointer.BoolPtr(true),
+						SSL: &coherence.SSLSpec{
+							Enabled:           pointer.BoolPtr(true),
+							Secrets:           pointer.StringPtr(secretName),
+							RequireClientCert: pointer.BoolPtr(true),
+						},
+					},
+				},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		mgr, e := stubs.NewFakeManager(existing...)
+		Expect(e).NotTo(HaveOccurred())
+		checker = &ScalableChecker{Client: mgr.Client}
+		tlsConfig, err = checker.GetManagementTLSConfig(role)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should connect to management over ReST using the client certificate in the role's SSL Secret", func() {
+		Expect(err).NotTo(HaveOccurred())
+		cl := checker.NewManagementClient(pod, port, tlsConfig, time.Second*10)
+		data, e := cl.GetCluster(context.TODO())
+		Expect(e).NotTo(HaveOccurred())
+		Expect(data.ClusterName).To(Equal("test-cluster"))
+	})
+
+	It("should check StatusHA using an https probe with the client certificate", func() {
+		Expect(err).NotTo(HaveOccurred())
+		probe := &coherence.ScalingProbe{Handler: corev1.Handler{HTTPGet: &corev1.HTTPGetAction{
+			Scheme: corev1.URISchemeHTTPS,
+			Path:   "/ha",
+			Port:   intstr.FromInt(int(port)),
+		}}}
+
+		ha, e := checker.CanScale(pod, probe, tlsConfig)
+		Expect(e).NotTo(HaveOccurred())
+		Expect(ha).To(BeTrue())
+
+		ha, _ = checker.CanScale(pod, probe, nil)
+		Expect(ha).To(BeFalse())
+	})
+
+	When("the member certificate is only issued for the Pod's DNS name", func() {
+		BeforeEach(func() {
+			server.Close()
+			ca, cert := newDNSServerCert("test-cluster-storage-0.test-cluster-storage-headless.coherence-test.svc")
+			startServer(&cert, ca)
+			pod.Spec = corev1.PodSpec{Hostname: "test-cluster-storage-0", Subdomain: "test-cluster-storage-headless"}
+		})
+
+		It("should verify the certificate against the Pod's DNS name when connecting to management over ReST", func() {
+			Expect(err).NotTo(HaveOccurred())
+			cl := checker.NewManagementClient(pod, port, tlsConfig, time.Second*10)
+			data, e := cl.GetCluster(context.TODO())
+			Expect(e).NotTo(HaveOccurred())
+			Expect(data.ClusterName).To(Equal("test-cluster"))
+		})
+
+		It("should verify the certificate against the Pod's DNS name in an https probe", func() {
+			Expect(err).NotTo(HaveOccurred())
+			probe := &coherence.ScalingProbe{Handler: corev1.Handler{HTTPGet: &corev1.HTTPGetAction{
+				Scheme: corev1.URISchemeHTTPS,
+				Path:   "/ha",
+				Port:   intstr.FromInt(int(port)),
+			}}}
+
+			ha, e := checker.CanScale(pod, probe, tlsConfig)
+			Expect(e).NotTo(HaveOccurred())
+			Expect(ha).To(BeTrue())
+		})
+
+		It("should not connect to a Pod without a DNS name", func() {
+			Expect(err).NotTo(HaveOccurred())
+			pod.Spec = corev1.PodSpec{}
+			cl := checker.NewManagementClient(pod, port, tlsConfig, time.Second*10)
+			_, e := cl.GetCluster(context.TODO())
+			Expect(e).To(HaveOccurred())
+		})
+	})
+
+	When("the SSL Secret does not exist", func() {
+		BeforeEach(func() {
+			existing = nil
+		})
+
+		It("should return an error", func() {
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("SSL is not enabled", func() {
+		BeforeEach(func() {
+			role.Spec.Coherence.Management.SSL.Enabled = pointer.BoolPtr(false)
+		})
+
+		It("should use plain http", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tlsConfig).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1,360 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package coherencerole
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	coherence "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/controller/events"
+	stubs "github.com/oracle/coherence-operator/pkg/fakes"
+	mgmt "github.com/oracle/coherence-operator/pkg/management"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"net/http"
+	"net/http/httptest"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"strings"
+)
+
+// These tests use fakes and stubs for the k8s and operator-sdk so that the
+// tests will run without requiring a k8s cluster.
+var _ = Describe("coherencerole_controller safe upgrade tests", func() {
+	const (
+		testNamespace   = "coherence-test"
+		testClusterName = "test-cluster"
+		roleName        = "storage"
+		fullRoleName    = testClusterName + "-" + roleName
+		oldRevision     = "revision-1"
+		newRevision     = "revision-2"
+	)
+
+	var (
+		mgr         *stubs.FakeManager
+		role        *coherence.CoherenceRole
+		statefulSet *appsv1.StatefulSet
+		pods        []runtime.Object
+		inProgress  bool
+		result      stubs.ReconcileResult
+		err         error
+	)
+
+	JustBeforeEach(func() {
+		mgr, err = stubs.NewFakeManager(pods...)
+		Expect(err).NotTo(HaveOccurred())
+
+		_ = mgr.Client.Create(context.TODO(), role)
+		_ = mgr.Client.Create(context.TODO(), statefulSet)
+
+		controller := newReconciler(mgr, NewTestFlags())
+		// skip initialization for unit tests
+		controller.SetInitialized(true)
+
+		var r reconcile.Result
+		inProgress, r, err = controller.safeUpgrade(role, statefulSet, log)
+		result = stubs.ReconcileResult{Result: r, Error: err}
+	})
+
+	newPod := func(ordinal int, revision string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testNamespace,
+				Name:      fmt.Sprintf("%s-%d", fullRoleName, ordinal),
+				Labels: map[string]string{
+					"coherenceDeployment":           fullRoleName,
+					appsv1.StatefulSetRevisionLabel: revision,
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		}
+	}
+
+	podExists := func(ordinal int) bool {
+		name := fmt.Sprintf("%s-%d", fullRoleName, ordinal)
+		err := mgr.Client.Get(context.TODO(), types.NamespacedName{Namespace: testNamespace, Name: name}, &corev1.Pod{})
+		if errors.IsNotFound(err) {
+			return false
+		}
+		Expect(err).NotTo(HaveOccurred())
+		return true
+	}
+
+	BeforeEach(func() {
+		policy := coherence.SafeUpgrade
+		role = &coherence.CoherenceRole{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: fullRoleName},
+			Spec: coherence.CoherenceRoleSpec{
+				Role:          roleName,
+				UpgradePolicy: &policy,
+				// an empty probe is always StatusHA
+				Scaling: &coherence.ScalingSpec{Probe: &coherence.ScalingProbe{}},
+			},
+		}
+
+		replicas := int32(3)
+		statefulSet = &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: fullRoleName},
+			Spec: appsv1.StatefulSetSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"coherenceDeployment": fullRoleName},
+				},
+			},
+			Status: appsv1.StatefulSetStatus{
+				Replicas:        replicas,
+				ReadyReplicas:   replicas,
+				CurrentRevision: oldRevision,
+				UpdateRevision:  newRevision,
+			},
+		}
+	})
+
+	When("all Pods are at the old revision", func() {
+		BeforeEach(func() {
+			pods = []runtime.Object{newPod(0, oldRevision), newPod(1, oldRevision), newPod(2, oldRevision)}
+		})
+
+		It("should be in progress", func() {
+			Expect(inProgress).To(BeTrue())
+			Expect(result.Error).To(BeNil())
+			Expect(result.Result.Requeue).To(BeTrue())
+		})
+
+		It("should delete only the Pod with the highest ordinal", func() {
+			Expect(podExists(0)).To(BeTrue())
+			Expect(podExists(1)).To(BeTrue())
+			Expect(podExists(2)).To(BeFalse())
+		})
+
+		It("should record the Pod being restarted in the role status", func() {
+			r := GetRole(mgr, testNamespace, fullRoleName)
+			Expect(r.Status.UpgradingPod).To(Equal(fullRoleName + "-2"))
+			Expect(r.Status.Status).To(Equal(coherence.RoleStatusRollingUpgrade))
+		})
+
+		It("should fire a Pod restarted event", func() {
+			event := mgr.AssertEvent()
+			Expect(event.Reason).To(Equal(string(events.UpgradePodRestarted)))
+			Expect(event.Message).To(Equal(fmt.Sprintf(restartPodMessage, fullRoleName+"-2", fullRoleName)))
+			mgr.AssertNoRemainingEvents()
+		})
+	})
+
+	When("the Pod being restarted has not been re-created", func() {
+		BeforeEach(func() {
+			role.Status.UpgradingPod = fullRoleName + "-2"
+			pods = []runtime.Object{newPod(0, oldRevision), newPod(1, oldRevision)}
+		})
+
+		It("should be in progress", func() {
+			Expect(inProgress).To(BeTrue())
+			Expect(result.Result.Requeue).To(BeTrue())
+		})
+
+		It("should not delete any Pods", func() {
+			Expect(podExists(0)).To(BeTrue())
+			Expect(podExists(1)).To(BeTrue())
+		})
+	})
+
+	When("the Pod being restarted has been removed by a scale down", func() {
+		BeforeEach(func() {
+			replicas := int32(2)
+			statefulSet.Spec.Replicas = &replicas
+			statefulSet.Status.Replicas = replicas
+			statefulSet.Status.ReadyReplicas = replicas
+			role.Status.UpgradingPod = fullRoleName + "-2"
+			pods = []runtime.Object{newPod(0, oldRevision), newPod(1, oldRevision)}
+		})
+
+		It("should delete the next Pod", func() {
+			Expect(inProgress).To(BeTrue())
+			Expect(result.Error).To(BeNil())
+			Expect(podExists(0)).To(BeTrue())
+			Expect(podExists(1)).To(BeFalse())
+			Expect(GetRole(mgr, testNamespace, fullRoleName).Status.UpgradingPod).To(Equal(fullRoleName + "-1"))
+		})
+	})
+
+	When("the Pod being restarted is not ready", func() {
+		BeforeEach(func() {
+			role.Status.UpgradingPod = fullRoleName + "-2"
+			restarted := newPod(2, newRevision)
+			restarted.Status.Conditions = nil
+			pods = []runtime.Object{newPod(0, oldRevision), newPod(1, oldRevision), restarted}
+		})
+
+		It("should not delete any Pods", func() {
+			Expect(inProgress).To(BeTrue())
+			Expect(podExists(0)).To(BeTrue())
+			Expect(podExists(1)).To(BeTrue())
+			Expect(podExists(2)).To(BeTrue())
+		})
+	})
+
+	When("the Pod being restarted is ready", func() {
+		BeforeEach(func() {
+			role.Status.UpgradingPod = fullRoleName + "-2"
+			pods = []runtime.Object{newPod(0, oldRevision), newPod(1, oldRevision), newPod(2, newRevision)}
+		})
+
+		It("should delete the next Pod", func() {
+			Expect(inProgress).To(BeTrue())
+			Expect(podExists(0)).To(BeTrue())
+			Expect(podExists(1)).To(BeFalse())
+			Expect(GetRole(mgr, testNamespace, fullRoleName).Status.UpgradingPod).To(Equal(fullRoleName + "-1"))
+		})
+	})
+
+	When("all Pods are at the new revision", func() {
+		BeforeEach(func() {
+			role.Status.UpgradingPod = fullRoleName + "-0"
+			pods = []runtime.Object{newPod(0, newRevision), newPod(1, newRevision), newPod(2, newRevision)}
+		})
+
+		It("should not be in progress", func() {
+			Expect(inProgress).To(BeFalse())
+			Expect(result.Error).To(BeNil())
+		})
+
+		It("should clear the Pod being restarted from the role status", func() {
+			Expect(GetRole(mgr, testNamespace, fullRoleName).Status.UpgradingPod).To(BeEmpty())
+		})
+	})
+
+	When("the StatefulSet does not have an update revision", func() {
+		BeforeEach(func() {
+			statefulSet.Status.UpdateRevision = ""
+			pods = []runtime.Object{newPod(0, oldRevision)}
+		})
+
+		It("should not be in progress", func() {
+			Expect(inProgress).To(BeFalse())
+			Expect(podExists(0)).To(BeTrue())
+		})
+	})
+})
+
+var _ = Describe("partition HA status checks", func() {
+	var (
+		server     *httptest.Server
+		partitions map[string]mgmt.PartitionData
+		scaling    *coherence.ScalingSpec
+		balanced   bool
+		safe       bool
+		err        error
+	)
+
+	JustBeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			var data interface{}
+			if strings.HasSuffix(req.URL.Path, "/services") {
+				services := mgmt.ServicesData{}
+				for name := range partitions {
+					services.Items = append(services.Items, mgmt.ServiceData{Name: name, Type: mgmt.DistributedCacheType})
+				}
+				data = services
+			} else {
+				name := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/management/coherence/cluster/services/"), "/partition")
+				data = partitions[name]
+			}
+			_ = json.NewEncoder(w).Encode(data)
+		}))
+
+		safe, err = isPartitionSafe(context.TODO(), mgmt.NewClient(server.Client(), server.URL), scaling, balanced)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	BeforeEach(func() {
+		balanced = false
+		scaling = nil
+		partitions = map[string]mgmt.PartitionData{
+			"PartitionedCache": {HAStatus: "MACHINE-SAFE", HAStatusCode: 2},
+			"LowPriorityCache": {HAStatus: "NODE-SAFE", HAStatusCode: 1},
+		}
+	})
+
+	When("no minimum HA status is configured", func() {
+		It("should be safe as all services are at least NODE-SAFE", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(safe).To(BeTrue())
+		})
+	})
+
+	When("the minimum HA status is MACHINE-SAFE", func() {
+		BeforeEach(func() {
+			status := coherence.HAStatusMachineSafe
+			scaling = &coherence.ScalingSpec{MinimumHAStatus: &status}
+		})
+
+		It("should not be safe as a service is only NODE-SAFE", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(safe).To(BeFalse())
+		})
+	})
+
+	When("the minimum HA status is MACHINE-SAFE with a lower override for a service", func() {
+		BeforeEach(func() {
+			status := coherence.HAStatusMachineSafe
+			scaling = &coherence.ScalingSpec{
+				MinimumHAStatus:        &status,
+				ServiceMinimumHAStatus: map[string]coherence.HAStatus{"LowPriorityCache": coherence.HAStatusNodeSafe},
+			}
+		})
+
+		It("should be safe", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(safe).To(BeTrue())
+		})
+	})
+
+	When("the HA status name is not reported", func() {
+		BeforeEach(func() {
+			status := coherence.HAStatusMachineSafe
+			scaling = &coherence.ScalingSpec{MinimumHAStatus: &status}
+			partitions = map[string]mgmt.PartitionData{"PartitionedCache": {HAStatusCode: 3}}
+		})
+
+		It("should use the HA status code", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(safe).To(BeTrue())
+		})
+	})
+
+	When("partitions are still being transferred", func() {
+		BeforeEach(func() {
+			partitions["PartitionedCache"] = mgmt.PartitionData{HAStatus: "MACHINE-SAFE", HAStatusCode: 2, RemainingDistributionCount: 10}
+		})
+
+		It("should be safe if the services do not need to be balanced", func() {
+			Expect(safe).To(BeTrue())
+		})
+
+		When("the services must be balanced", func() {
+			BeforeEach(func() {
+				balanced = true
+			})
+
+			It("should not be safe", func() {
+				Expect(safe).To(BeFalse())
+			})
+		})
+	})
+})
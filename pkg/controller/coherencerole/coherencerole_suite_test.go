@@ -7,11 +7,17 @@
 package coherencerole
 
 import (
-	"encoding/json"
+	"context"
 	. "github.com/onsi/ginkgo"
 	"github.com/onsi/ginkgo/reporters"
 	. "github.com/onsi/gomega"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	stubs "github.com/oracle/coherence-operator/pkg/fakes"
+	"github.com/oracle/coherence-operator/pkg/flags"
+	"github.com/oracle/coherence-operator/pkg/resources"
+	"github.com/oracle/coherence-operator/test/e2e/helper"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"path/filepath"
 
 	coherence "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
 
@@ -24,14 +30,31 @@ func TestCoherenceRoleControler(t *testing.T) {
 	RunSpecsWithDefaultAndCustomReporters(t, "CoherenceRole Controller Suite", []Reporter{junitReporter})
 }
 
-// UnstructuredToCoherenceInternalSpec converts an Unstructured to a CoherenceInternalSpec.
-// The "spec" field of the Unstructured is marshalled to json and then
-// un-marshalled back to a CoherenceInternalSpec.
-func UnstructuredToCoherenceInternalSpec(u *unstructured.Unstructured) *coherence.CoherenceInternalSpec {
-	spec := &coherence.CoherenceInternalSpec{}
-	data, err := json.Marshal(u.Object["spec"])
-	Expect(err).ToNot(HaveOccurred())
-	err = json.Unmarshal(data, spec)
+// AppliedSpec returns the CoherenceInternalSpec that a StatefulSet was applied from.
+func AppliedSpec(sts *appsv1.StatefulSet) *coherence.CoherenceInternalSpec {
+	spec, err := resources.GetAppliedSpec(sts.Annotations)
 	Expect(err).ToNot(HaveOccurred())
+	Expect(spec).ToNot(BeNil())
 	return spec
 }
+
+// WithAppliedSpec sets the annotation on a StatefulSet holding the CoherenceInternalSpec it was applied from.
+func WithAppliedSpec(sts *appsv1.StatefulSet, spec *coherence.CoherenceInternalSpec) {
+	applied, err := resources.NewStatefulSet(sts.Namespace, spec)
+	Expect(err).ToNot(HaveOccurred())
+	sts.Annotations = applied.Annotations
+}
+
+// NewTestFlags returns the Operator flags to use to create a controller under test.
+func NewTestFlags() *flags.CoherenceOperatorFlags {
+	root, err := helper.FindProjectRootDir()
+	Expect(err).ToNot(HaveOccurred())
+	return &flags.CoherenceOperatorFlags{ScriptsDir: filepath.Join(root, "helm-charts", "coherence", "scripts")}
+}
+
+// GetRole returns the CoherenceRole with the specified namespace and name from the fake manager's client.
+func GetRole(mgr *stubs.FakeManager, namespace, name string) *coherence.CoherenceRole {
+	role := &coherence.CoherenceRole{}
+	Expect(mgr.Client.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name}, role)).To(Succeed())
+	return role
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package coherencerole
+
+import (
+	"context"
+	"github.com/go-logr/logr"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/resources"
+	appsv1 "k8s.io/api/apps/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// updateDisruptionBudget keeps the PodDisruptionBudget of a role that is at its desired size in step with the
+// role's spec, creating it if it is missing or deleting it if it is no longer enabled. While the role is not
+// StatusHA the PodDisruptionBudget is locked so that none of the role's Pods can be evicted, for example by a
+// node drain. Returns true if the PodDisruptionBudget is locked so that the request can be re-queued to unlock
+// the PodDisruptionBudget once the role is StatusHA again.
+func (r *ReconcileCoherenceRole) updateDisruptionBudget(role *coh.CoherenceRole, spec *coh.CoherenceInternalSpec, sts *appsv1.StatefulSet, logger logr.Logger) (bool, error) {
+	existing := &policyv1beta1.PodDisruptionBudget{}
+	name := resources.GetPodDisruptionBudgetName(spec)
+	err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: role.Namespace, Name: name}, existing)
+	found := err == nil
+	if err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+
+	desired := resources.NewPodDisruptionBudget(role.Namespace, spec)
+	if desired == nil {
+		if found && metav1.IsControlledBy(existing, role) {
+			logger.Info("Deleting PodDisruptionBudget " + name + " as it is not enabled for the role")
+			if err := r.client.Delete(context.TODO(), existing); err != nil && !errors.IsNotFound(err) {
+				return false, err
+			}
+		}
+		return false, nil
+	}
+
+	checker := ScalableChecker{Client: r.client, Config: r.mgr.GetConfig()}
+	locked := spec.GetReplicas() > 1 && !checker.IsStatusHA(role, sts)
+	if locked {
+		resources.LockPodDisruptionBudget(desired)
+	}
+
+	if !found {
+		logger.Info("Creating PodDisruptionBudget " + name)
+		if err := controllerutil.SetControllerReference(role, desired, r.scheme); err != nil {
+			return locked, err
+		}
+		return locked, r.client.Create(context.TODO(), desired)
+	}
+
+	if reflect.DeepEqual(existing.Spec.MinAvailable, desired.Spec.MinAvailable) &&
+		reflect.DeepEqual(existing.Spec.MaxUnavailable, desired.Spec.MaxUnavailable) {
+		return locked, nil
+	}
+
+	if locked {
+		logger.Info("Locking PodDisruptionBudget " + name + " as the role is not StatusHA")
+	} else {
+		logger.Info("Updating PodDisruptionBudget " + name)
+	}
+	existing.Spec.MinAvailable = desired.Spec.MinAvailable
+	existing.Spec.MaxUnavailable = desired.Spec.MaxUnavailable
+	return locked, r.client.Update(context.TODO(), existing)
+}
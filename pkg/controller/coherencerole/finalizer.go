@@ -0,0 +1,207 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package coherencerole
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/controller/events"
+	mgmt "github.com/oracle/coherence-operator/pkg/management"
+	"github.com/oracle/coherence-operator/pkg/metrics"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"time"
+)
+
+const (
+	// The annotation added to a deleted role holding the name of the persistence snapshot created before teardown.
+	snapshotAnnotation = "coherence.oracle.com/snapshot"
+
+	// The timeout for Coherence management requests made when creating a snapshot.
+	snapshotTimeout = time.Minute * 5
+)
+
+// finalizeRole performs the ordered teardown of a deleted role.
+// A storage enabled role is scaled down one member at a time, waiting for the cluster to be StatusHA before
+// each member is removed, after optionally creating a persistence snapshot. Once the role is down to a single
+// member (or immediately for a storage disabled role) the role's resources are deleted and the finalizer is
+// removed allowing Kubernetes to delete the role.
+func (r *ReconcileCoherenceRole) finalizeRole(role *coh.CoherenceRole, logger logr.Logger) (reconcile.Result, error) {
+	logger.Info("Finalizing deleted CoherenceRole")
+
+	// Take over the resources of any CoherenceInternal left by a previous Operator version
+	// so that they are not deleted by garbage collection before they have been safely scaled down.
+	if _, err := r.deleteCoherenceInternal(role.Namespace, role.Name, metav1.DeletePropagationOrphan, logger); err != nil {
+		return r.handleErrAndRequeue(err, role, fmt.Sprintf(failedToFinalizeRoleMessage, role.Name, err.Error()), logger)
+	}
+
+	sts, err := r.findStatefulSet(role)
+	switch {
+	case err != nil && errors.IsNotFound(err):
+		// there are no members left to tear down
+		return r.removeFinalizer(role, logger)
+	case err != nil:
+		return r.handleErrAndRequeue(err, role, fmt.Sprintf(failedToFinalizeRoleMessage, role.Name, err.Error()), logger)
+	case sts.GetDeletionTimestamp() != nil:
+		// the StatefulSet has already been deleted
+		return r.removeFinalizer(role, logger)
+	}
+
+	replicas := int32(1)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+
+	if role.Spec.IsStorageEnabled() && replicas > 0 {
+		if role.Spec.Coherence.IsSnapshotOnDelete() && role.GetAnnotations()[snapshotAnnotation] == "" {
+			if role.Spec.IsManagementEnabled() {
+				return r.snapshotRole(role, sts, logger)
+			}
+			// the snapshot can never be created so continue the teardown rather than blocking the deletion
+			msg := fmt.Sprintf(snapshotSkippedMessage, role.Name)
+			logger.Info(msg)
+			r.events.Event(role, events.SnapshotSkipped, msg)
+		}
+
+		if replicas > 1 {
+			return r.scaleDownForDelete(role, sts, replicas, logger)
+		}
+	}
+
+	logger.Info("Deleting resources for deleted CoherenceRole")
+	if err := r.deleteResources(sts); err != nil {
+		return r.handleErrAndRequeue(err, role, fmt.Sprintf(failedToFinalizeRoleMessage, role.Name, err.Error()), logger)
+	}
+
+	return r.removeFinalizer(role, logger)
+}
+
+// scaleDownForDelete removes a single member from a deleted storage enabled role if the cluster is StatusHA
+// and then requeues the request to remove the next member.
+func (r *ReconcileCoherenceRole) scaleDownForDelete(role *coh.CoherenceRole, sts *appsv1.StatefulSet, current int32, logger logr.Logger) (reconcile.Result, error) {
+	if sts.Status.Replicas > current {
+		// the previous scale down has not yet completed
+		logger.Info(fmt.Sprintf("Role %s is still scaling down to %d - re-queuing delete request", role.Name, current))
+		return reconcile.Result{Requeue: true, RequeueAfter: r.statusHARetry}, nil
+	}
+
+	checker := ScalableChecker{Client: r.client, Config: r.mgr.GetConfig()}
+	if !checker.IsStatusHA(role, sts) {
+		logger.Info(fmt.Sprintf("Role %s is not StatusHA - re-queuing delete request", role.Name))
+		r.setScalingBlocked(role, current, current-1)
+		return reconcile.Result{Requeue: true, RequeueAfter: r.statusHARetry}, nil
+	}
+
+	replicas := current - 1
+	logger.Info(fmt.Sprintf("Role %s is StatusHA, safely scaling deleted role from %d to %d", role.Name, current, replicas))
+
+	sts.Spec.Replicas = &replicas
+	if err := r.client.Update(context.TODO(), sts); err != nil {
+		return r.handleErrAndRequeue(err, role, fmt.Sprintf(failedToScaleRole, role.Name, current, replicas, err.Error()), logger)
+	}
+
+	// send a successful scale event
+	r.events.Event(role, events.Scaled, fmt.Sprintf(scaledMessage, sts.Name, role.Name, current, replicas))
+
+	// scaled by one - requeue the request to remove the next member
+	return reconcile.Result{Requeue: true, RequeueAfter: time.Minute}, nil
+}
+
+// snapshotRole creates a persistence snapshot of the role's partitioned cache services and records the
+// snapshot name in an annotation on the role so that the snapshot is only created once.
+func (r *ReconcileCoherenceRole) snapshotRole(role *coh.CoherenceRole, sts *appsv1.StatefulSet, logger logr.Logger) (reconcile.Result, error) {
+	name := fmt.Sprintf("%s-%s", role.Name, time.Now().UTC().Format("20060102150405"))
+	logger.Info(fmt.Sprintf("Creating persistence snapshot %s for deleted CoherenceRole", name))
+
+	checker := ScalableChecker{Client: r.client, Config: r.mgr.GetConfig()}
+	if err := checker.CreateSnapshot(role, sts, name); err != nil {
+		return r.handleErrAndRequeue(err, role, fmt.Sprintf(failedToSnapshotRoleMessage, name, role.Name, err.Error()), logger)
+	}
+
+	annotations := role.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[snapshotAnnotation] = name
+	role.SetAnnotations(annotations)
+
+	if err := r.client.Update(context.TODO(), role); err != nil {
+		return r.handleErrAndRequeue(err, nil, fmt.Sprintf(failedToFinalizeRoleMessage, role.Name, err.Error()), logger)
+	}
+
+	// requeue the request to continue the teardown of the role
+	return reconcile.Result{Requeue: true}, nil
+}
+
+// removeFinalizer removes the finalizer from a deleted role allowing Kubernetes to delete it.
+func (r *ReconcileCoherenceRole) removeFinalizer(role *coh.CoherenceRole, logger logr.Logger) (reconcile.Result, error) {
+	logger.Info("Removing finalizer from deleted CoherenceRole")
+
+	controllerutil.RemoveFinalizer(role, coh.CoherenceFinalizer)
+	if err := r.client.Update(context.TODO(), role); err != nil && !errors.IsNotFound(err) {
+		return r.handleErrAndRequeue(err, nil, fmt.Sprintf(failedToFinalizeRoleMessage, role.Name, err.Error()), logger)
+	}
+
+	metrics.DeleteRoleMetrics(role.Namespace, role.Name)
+	r.setSplitObserved(role, false)
+	return reconcile.Result{Requeue: false}, nil
+}
+
+// CreateSnapshot uses Coherence management over ReST on one of the role's running Pods to create a persistence
+// snapshot with the specified name for each of the cluster's partitioned cache services.
+// If the role has no running Pods there is no data to snapshot and no error is returned.
+func (in *ScalableChecker) CreateSnapshot(role *coh.CoherenceRole, sts *appsv1.StatefulSet, name string) error {
+	port, enabled := GetManagementPort(role)
+	if !enabled {
+		return fmt.Errorf("management over ReST is not enabled for CoherenceRole %s", role.Name)
+	}
+
+	pods, err := listPods(in.Client, role, sts)
+	if err != nil {
+		return err
+	}
+
+	tlsConfig, err := in.GetManagementTLSConfig(role)
+	if err != nil {
+		return err
+	}
+
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+
+		cl := in.NewManagementClient(pod, port, tlsConfig, snapshotTimeout)
+
+		services, err := cl.GetServices(context.TODO())
+		if err != nil {
+			return err
+		}
+
+		created := make(map[string]bool)
+		for _, service := range services.Items {
+			if service.Type != mgmt.DistributedCacheType || created[service.Name] {
+				continue
+			}
+			log.Info(fmt.Sprintf("Creating snapshot %s for service %s using Pod %s", name, service.Name, pod.Name))
+			if err := cl.CreateSnapshot(context.TODO(), service.Name, name); err != nil {
+				return err
+			}
+			created[service.Name] = true
+		}
+		return nil
+	}
+
+	log.Info(fmt.Sprintf("Cannot find any running Pods for StatefulSet %s - skipping snapshot %s", sts.Name, name))
+	return nil
+}
@@ -0,0 +1,48 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package coherencerole
+
+import (
+	"context"
+	"crypto/tls"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	mgmt "github.com/oracle/coherence-operator/pkg/management"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"time"
+)
+
+// GetManagementTLSConfig returns the TLS configuration that the Operator uses to connect to the SSL enabled
+// endpoints of a role's Pods, created from the SSL configuration and Secret of the role's management over ReST
+// endpoint. Returns nil if management over ReST does not have SSL enabled.
+func (in *ScalableChecker) GetManagementTLSConfig(role *coh.CoherenceRole) (*tls.Config, error) {
+	spec := role.Spec.Coherence
+	if spec == nil || spec.Management == nil || spec.Management.SSL == nil {
+		return nil, nil
+	}
+
+	ssl := spec.Management.SSL
+	var secret *corev1.Secret
+	if ssl.Enabled != nil && *ssl.Enabled && ssl.Secrets != nil && *ssl.Secrets != "" {
+		secret = &corev1.Secret{}
+		err := in.Client.Get(context.TODO(), types.NamespacedName{Namespace: role.Namespace, Name: *ssl.Secrets}, secret)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return mgmt.NewTLSConfig(ssl, secret)
+}
+
+// NewManagementClient creates a client for the Coherence management over ReST endpoint of a Pod on the specified
+// container port. The client uses https if the TLS configuration is not nil, verifying the Pod's certificate
+// against the Pod's stable DNS name.
+func (in *ScalableChecker) NewManagementClient(pod corev1.Pod, port int32, tlsConfig *tls.Config, timeout time.Duration) *mgmt.Client {
+	host := in.GetPodHostName(pod)
+	p := int32(in.TranslatePort(mgmt.PortName, int(port)))
+	cfg := mgmt.NewPodTLSConfig(tlsConfig, pod)
+	return mgmt.NewClient(mgmt.NewHTTPClient(timeout, cfg), mgmt.EndpointURL(host, p, cfg != nil))
+}
@@ -0,0 +1,208 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package coherencerole
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/controller/events"
+	mgmt "github.com/oracle/coherence-operator/pkg/management"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The interval at which the Coherence cluster membership of a role is checked.
+const membershipCheckInterval = time.Minute
+
+// The timeout for the Coherence management requests made to each Pod when checking the cluster membership of a role.
+const membershipTimeout = time.Second * 10
+
+// updateMembership checks which of the ready Pods of a role have actually joined the Coherence cluster and whether
+// the role's members agree on the size of the cluster, updating the role's membership status and SplitBrainSuspected
+// condition. A Pod can be ready without having joined the cluster, for example when a WKA misconfiguration has split
+// the cluster. The membership is only checked when every replica of the role is ready and no scaling or rolling
+// upgrade is in progress, as members that are joining or leaving the cluster briefly report different cluster sizes.
+// The request is re-queued so that the membership is checked again after the check interval.
+func (r *ReconcileCoherenceRole) updateMembership(role *coh.CoherenceRole, sts *appsv1.StatefulSet, logger logr.Logger) reconcile.Result {
+	if _, enabled := GetManagementPort(role); !enabled || sts.Status.ReadyReplicas == 0 {
+		r.setSplitObserved(role, false)
+		return reconcile.Result{Requeue: false}
+	}
+
+	result := reconcile.Result{Requeue: true, RequeueAfter: membershipCheckInterval}
+
+	replicas := role.Spec.GetReplicas()
+	if sts.Status.Replicas != replicas || role.Status.UpgradingPod != "" || !isRolloutComplete(sts, replicas) {
+		logger.Info("Not checking Coherence cluster membership while the role's Pods are changing")
+		r.setSplitObserved(role, false)
+		return result
+	}
+
+	checker := ScalableChecker{Client: r.client, Config: r.mgr.GetConfig()}
+	membership, sizes, err := checker.GetMembership(role, sts)
+	if err != nil {
+		logger.Info("Unable to read Coherence cluster membership: " + err.Error())
+		return result
+	}
+
+	suspected := role.Status.Conditions.IsTrue(coh.ConditionSplitBrainSuspected)
+	changed, split := updateMembershipStatus(role, membership, sizes, r.isSplitObserved(role))
+	r.setSplitObserved(role, split)
+	if changed {
+		if !suspected && role.Status.Conditions.IsTrue(coh.ConditionSplitBrainSuspected) {
+			r.events.Event(role, events.SplitBrainSuspected, role.Status.Conditions.GetCondition(coh.ConditionSplitBrainSuspected).Message)
+		}
+		if err := r.client.Status().Update(context.TODO(), role); err != nil {
+			log.Error(err, "failed to update role status", "Namespace", role.Namespace, "Name", role.Name)
+		}
+	}
+	return result
+}
+
+// isSplitObserved returns true if the previous membership check of the role found that its members reported
+// different cluster sizes.
+func (r *ReconcileCoherenceRole) isSplitObserved(role *coh.CoherenceRole) bool {
+	r.membershipMutex.Lock()
+	defer r.membershipMutex.Unlock()
+	return r.splitObserved[role.Namespace+"/"+role.Name]
+}
+
+// setSplitObserved records whether the latest membership check of the role found that its members reported
+// different cluster sizes.
+func (r *ReconcileCoherenceRole) setSplitObserved(role *coh.CoherenceRole, split bool) {
+	r.membershipMutex.Lock()
+	defer r.membershipMutex.Unlock()
+	key := role.Namespace + "/" + role.Name
+	if !split {
+		delete(r.splitObserved, key)
+		return
+	}
+	if r.splitObserved == nil {
+		r.splitObserved = make(map[string]bool)
+	}
+	r.splitObserved[key] = true
+}
+
+// updateMembershipStatus sets the role's membership status and determines whether the role's members report
+// different cluster sizes. The SplitBrainSuspected condition is only set once the sizes have also differed at the
+// previous check, so a member that was joining or leaving the cluster when the sizes were read is not reported.
+// Returns true if the role's status was changed and true if the members report different cluster sizes.
+func updateMembershipStatus(role *coh.CoherenceRole, membership *coh.MembershipStatus, sizes map[string]int32, splitBefore bool) (bool, bool) {
+	changed := !reflect.DeepEqual(role.Status.Membership, membership)
+	role.Status.Membership = membership
+
+	var names []string
+	for name := range sizes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var reported []string
+	split := false
+	for _, name := range names {
+		reported = append(reported, fmt.Sprintf("%s=%d", name, sizes[name]))
+		split = split || sizes[name] != sizes[names[0]]
+	}
+
+	if split {
+		if !splitBefore {
+			return changed, true
+		}
+		msg := "members report different cluster sizes: " + strings.Join(reported, ", ")
+		return role.SetCondition(coh.ConditionSplitBrainSuspected, true, coh.ReasonClusterSizeDiffers, msg) || changed, true
+	}
+	msg := fmt.Sprintf("%d members report a cluster size of %d", len(names), membership.ClusterSize)
+	return role.SetCondition(coh.ConditionSplitBrainSuspected, false, coh.ReasonClusterSizeMatch, msg) || changed, false
+}
+
+// GetMembership uses Coherence management over ReST on each of the role's ready Pods to read the cluster size seen
+// by that Pod, returned keyed by Pod name. The cluster and its members are read from the first Pod that responds and
+// each ready Pod is matched to a member with the same member name, role name and machine name to find the Pods that
+// have joined the cluster.
+func (in *ScalableChecker) GetMembership(role *coh.CoherenceRole, sts *appsv1.StatefulSet) (*coh.MembershipStatus, map[string]int32, error) {
+	port, enabled := GetManagementPort(role)
+	if !enabled {
+		return nil, nil, fmt.Errorf("management over ReST is not enabled for CoherenceRole %s", role.Name)
+	}
+
+	pods, err := listPods(in.Client, role, sts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tlsConfig, err := in.GetManagementTLSConfig(role)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var membership *coh.MembershipStatus
+	var members []mgmt.MemberData
+	var ready []corev1.Pod
+	sizes := make(map[string]int32)
+
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning || !IsPodReady(pod) {
+			continue
+		}
+		ready = append(ready, pod)
+
+		cl := in.NewManagementClient(pod, port, tlsConfig, membershipTimeout)
+		ctx, cancel := context.WithTimeout(context.Background(), membershipTimeout)
+		cluster, err := cl.GetCluster(ctx)
+		if err == nil && membership == nil {
+			var data *mgmt.MembersData
+			if data, err = cl.GetMembers(ctx); err == nil {
+				members = data.Items
+				membership = &coh.MembershipStatus{
+					ClusterName:    cluster.ClusterName,
+					ClusterVersion: cluster.Version,
+					ClusterSize:    int32(cluster.ClusterSize),
+				}
+			}
+		}
+		cancel()
+
+		if err != nil {
+			log.Info(fmt.Sprintf("Unable to read Coherence cluster from Pod %s: %s", pod.Name, err.Error()))
+			continue
+		}
+		sizes[pod.Name] = int32(cluster.ClusterSize)
+	}
+
+	if membership == nil {
+		return nil, nil, fmt.Errorf("unable to read the Coherence cluster from any ready Pod of StatefulSet %s", sts.Name)
+	}
+
+	roleName := role.Spec.GetRoleName()
+	for _, pod := range ready {
+		if IsClusterMember(pod, roleName, members) {
+			membership.JoinedMembers++
+		} else {
+			membership.NotJoined = append(membership.NotJoined, pod.Name)
+		}
+	}
+	return membership, sizes, nil
+}
+
+// IsClusterMember returns true if one of the members is the member started in the Pod. The Operator configures the
+// member name of a Coherence member as the Pod name and the machine name as the name of the Pod's node.
+func IsClusterMember(pod corev1.Pod, roleName string, members []mgmt.MemberData) bool {
+	for _, member := range members {
+		if member.MemberName == pod.Name && member.RoleName == roleName &&
+			(pod.Spec.NodeName == "" || member.MachineName == pod.Spec.NodeName) {
+			return true
+		}
+	}
+	return false
+}
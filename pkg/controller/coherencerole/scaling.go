@@ -8,12 +8,14 @@ package coherencerole
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/controller/events"
 	mgmt "github.com/oracle/coherence-operator/pkg/management"
+	"github.com/oracle/coherence-operator/pkg/metrics"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/rest"
 	"k8s.io/kubernetes/pkg/probe"
@@ -29,27 +31,32 @@ import (
 )
 
 // scale will scale a role up or down
-func (r *ReconcileCoherenceRole) scale(role *coh.CoherenceRole, cohInternal *unstructured.Unstructured, existing *coh.CoherenceInternal, desired int32, current int32, sts *appsv1.StatefulSet) (reconcile.Result, error) {
+func (r *ReconcileCoherenceRole) scale(role *coh.CoherenceRole, existing *coh.CoherenceInternalSpec, desired int32, current int32, sts *appsv1.StatefulSet) (reconcile.Result, error) {
+	if desired < current && role.Spec.Autoscaling.IsEnabled() {
+		// a role that is autoscaled is always scaled down safely regardless of its scaling policy
+		return r.safeScale(role, existing, desired, current, sts)
+	}
+
 	policy := role.Spec.GetEffectiveScalingPolicy()
 
 	switch policy {
 	case coh.SafeScaling:
-		return r.safeScale(role, cohInternal, existing, desired, current, sts)
+		return r.safeScale(role, existing, desired, current, sts)
 	case coh.ParallelScaling:
-		return r.parallelScale(role, cohInternal, existing, desired, current)
+		return r.parallelScale(role, existing, desired, current)
 	case coh.ParallelUpSafeDownScaling:
 		if desired > current {
-			return r.parallelScale(role, cohInternal, existing, desired, current)
+			return r.parallelScale(role, existing, desired, current)
 		}
-		return r.safeScale(role, cohInternal, existing, desired, current, sts)
+		return r.safeScale(role, existing, desired, current, sts)
 	default:
 		// shouldn't get here, but better safe than sorry
-		return r.safeScale(role, cohInternal, existing, desired, current, sts)
+		return r.safeScale(role, existing, desired, current, sts)
 	}
 }
 
 // safeScale will scale a role up or down by one and requeue the request.
-func (r *ReconcileCoherenceRole) safeScale(role *coh.CoherenceRole, cohInternal *unstructured.Unstructured, existing *coh.CoherenceInternal, desired int32, current int32, sts *appsv1.StatefulSet) (reconcile.Result, error) {
+func (r *ReconcileCoherenceRole) safeScale(role *coh.CoherenceRole, existing *coh.CoherenceInternalSpec, desired int32, current int32, sts *appsv1.StatefulSet) (reconcile.Result, error) {
 	logger := log.WithValues("Namespace", role.Name, "Name", role.Name)
 
 	if sts.Status.ReadyReplicas != current {
@@ -60,6 +67,9 @@ func (r *ReconcileCoherenceRole) safeScale(role *coh.CoherenceRole, cohInternal
 	ha := current == 1 || checker.IsStatusHA(role, sts)
 
 	if ha {
+		role.SetCondition(coh.ConditionStatusHA, true, coh.ReasonStatusHA, "")
+		role.SetCondition(coh.ConditionScalingBlocked, false, coh.ReasonStatusHA, "")
+
 		var replicas int32
 
 		if desired > current {
@@ -71,7 +81,7 @@ func (r *ReconcileCoherenceRole) safeScale(role *coh.CoherenceRole, cohInternal
 		logger.Info(fmt.Sprintf("Role %s is StatusHA, safely scaling from %d to %d (final desired replicas %d)", role.Name, current, replicas, desired))
 
 		// use the parallel method to just scale by one
-		_, err := r.parallelScale(role, cohInternal, existing, replicas, current)
+		_, err := r.parallelScale(role, existing, replicas, current)
 		if err == nil {
 			if replicas == desired {
 				// we're at the desired size so finished scaling
@@ -86,16 +96,18 @@ func (r *ReconcileCoherenceRole) safeScale(role *coh.CoherenceRole, cohInternal
 
 	// Not StatusHA - wait one minute
 	logger.Info(fmt.Sprintf("Role %s is not StatusHA - re-queing scaling request", role.Name))
+	r.setScalingBlocked(role, current, desired)
 	return reconcile.Result{Requeue: true, RequeueAfter: r.statusHARetry}, nil
 }
 
 // parallelScale will scale the role by the required amount in one request.
-func (r *ReconcileCoherenceRole) parallelScale(role *coh.CoherenceRole, cohInternal *unstructured.Unstructured, existing *coh.CoherenceInternal, desired int32, current int32) (reconcile.Result, error) {
-	// update the CoherenceInternal, this should trigger an update of the Helm install to scale the StatefulSet
-
+func (r *ReconcileCoherenceRole) parallelScale(role *coh.CoherenceRole, existing *coh.CoherenceInternalSpec, desired int32, current int32) (reconcile.Result, error) {
 	// Update this CoherenceRole's status
 	role.Status.Status = coh.RoleStatusScaling
 	role.Status.Replicas = desired
+	role.Status.ObservedGeneration = role.Generation
+	role.SetCondition(coh.ConditionProgressing, true, coh.ReasonScaling, fmt.Sprintf("scaling from %d to %d", current, desired))
+	role.SetCondition(coh.ConditionAvailable, false, coh.ReasonScaling, fmt.Sprintf("scaling from %d to %d", current, desired))
 	err := r.client.Status().Update(context.TODO(), role)
 	if err != nil {
 		// failed to update the CoherenceRole's status
@@ -103,24 +115,41 @@ func (r *ReconcileCoherenceRole) parallelScale(role *coh.CoherenceRole, cohInter
 		log.Error(err, "failed to update role status")
 	}
 
-	existing.Spec.Replicas = &desired
-	cohInternal.Object["spec"] = existing.Spec
-	err = r.client.Update(context.TODO(), cohInternal)
+	// re-apply the existing spec with the new replica count to scale the StatefulSet
+	spec := existing.DeepCopy()
+	spec.Replicas = &desired
+	err = r.applyRole(role, spec)
 	if err != nil {
 		// send a failed scale event
-		msg := fmt.Sprintf("failed to scale Helm install %s in CoherenceRole %s from %d to %d", role.Name, role.Name, current, desired)
-		r.events.Event(role, corev1.EventTypeNormal, "SuccessfulScale", msg)
+		r.events.Event(role, events.FailedScale, fmt.Sprintf(failedToScaleMessage, role.Name, role.Name, current, desired))
 
 		return reconcile.Result{}, err
 	}
 
+	metrics.ObserveScale(string(role.Spec.GetEffectiveScalingPolicy()), current, desired)
+
 	// send a successful scale event
-	msg := fmt.Sprintf("scaled Helm install %s in CoherenceRole %s from %d to %d", role.Name, role.Name, current, desired)
-	r.events.Event(role, corev1.EventTypeNormal, eventReasonScale, msg)
+	r.events.Event(role, events.Scaled, fmt.Sprintf(scaledMessage, role.Name, role.Name, current, desired))
 
 	return reconcile.Result{}, nil
 }
 
+// setScalingBlocked updates the role's status conditions to show that scaling is waiting for the cluster to be StatusHA.
+func (r *ReconcileCoherenceRole) setScalingBlocked(role *coh.CoherenceRole, current, desired int32) {
+	msg := fmt.Sprintf("waiting for StatusHA to scale from %d to %d", current, desired)
+	changed := role.SetCondition(coh.ConditionStatusHA, false, coh.ReasonNotStatusHA, "")
+	if role.SetCondition(coh.ConditionScalingBlocked, true, coh.ReasonWaitingForStatusHA, msg) {
+		// only raise an event when scaling first becomes blocked, not each time the request is re-queued
+		r.events.Event(role, events.ScaleBlockedNotStatusHA, fmt.Sprintf(scaleBlockedMessage, role.Name, current, desired))
+		changed = true
+	}
+	if changed {
+		if err := r.client.Status().Update(context.TODO(), role); err != nil {
+			log.Error(err, "failed to update role status")
+		}
+	}
+}
+
 type ScalableChecker struct {
 	Client         client.Client
 	Config         *rest.Config
@@ -157,7 +186,13 @@ func (in *ScalableChecker) TranslatePort(name string, port int) int {
 }
 
 // IsStatusHA will return true if the cluster represented by the role is StatusHA.
+// If the role has a minimum HA status configured the HA status of the partitioned cache services
+// is checked using Coherence management over ReST instead of the StatusHA probe.
 func (in *ScalableChecker) IsStatusHA(role *coh.CoherenceRole, sts *appsv1.StatefulSet) bool {
+	if role.Spec.Scaling.HasMinimumHAStatus() {
+		return in.IsMinimumHAStatus(role, sts)
+	}
+
 	list := corev1.PodList{}
 
 	if log.Enabled() {
@@ -184,13 +219,21 @@ func (in *ScalableChecker) IsStatusHA(role *coh.CoherenceRole, sts *appsv1.State
 
 	scalingProbe := role.Spec.GetScalingProbe()
 
+	var tlsConfig *tls.Config
+	if scalingProbe.HTTPGet != nil && scalingProbe.HTTPGet.Scheme == corev1.URISchemeHTTPS {
+		if tlsConfig, err = in.GetManagementTLSConfig(role); err != nil {
+			log.Error(err, "Error getting the management TLS configuration for CoherenceRole "+role.Name)
+			return false
+		}
+	}
+
 	for _, pod := range list.Items {
 		if pod.Status.Phase == "Running" {
 			if log.Enabled() {
 				log.Info("Checking pod " + pod.Name + " for StatusHA")
 			}
 
-			ha, err := in.CanScale(pod, scalingProbe)
+			ha, err := in.CanScale(pod, scalingProbe, tlsConfig)
 			if err == nil {
 				log.Info(fmt.Sprintf("Checked pod %s for StatusHA (%t)", pod.Name, ha))
 				return ha
@@ -204,18 +247,32 @@ func (in *ScalableChecker) IsStatusHA(role *coh.CoherenceRole, sts *appsv1.State
 	return false
 }
 
-// Determine whether a role allowed to scale using the configured probe.
-func (in *ScalableChecker) CanScale(pod corev1.Pod, handler *coh.ScalingProbe) (bool, error) {
+// Determine whether a role allowed to scale using the configured probe. If the TLS configuration is not nil
+// it is used by an https probe, otherwise an https probe does not verify the Pod's certificate.
+func (in *ScalableChecker) CanScale(pod corev1.Pod, handler *coh.ScalingProbe, tlsConfig *tls.Config) (bool, error) {
+	var (
+		probeType string
+		ha        bool
+		err       error
+	)
+
+	start := time.Now()
 	switch {
 	case handler.Exec != nil:
-		return in.ExecIsPodStatusHA(pod, handler)
+		probeType = metrics.ProbeExec
+		ha, err = in.ExecIsPodStatusHA(pod, handler)
 	case handler.HTTPGet != nil:
-		return in.HTTPIsPodStatusHA(pod, handler)
+		probeType = metrics.ProbeHTTP
+		ha, err = in.HTTPIsPodStatusHA(pod, handler, tlsConfig)
 	case handler.TCPSocket != nil:
-		return in.TCPIsPodStatusHA(pod, handler)
+		probeType = metrics.ProbeTCP
+		ha, err = in.TCPIsPodStatusHA(pod, handler)
 	default:
 		return true, nil
 	}
+
+	metrics.ObserveStatusHACheck(probeType, start, ha, err)
+	return ha, err
 }
 
 func (in *ScalableChecker) ExecIsPodStatusHA(pod corev1.Pod, handler *coh.ScalingProbe) (bool, error) {
@@ -239,7 +296,7 @@ func (in *ScalableChecker) ExecIsPodStatusHA(pod corev1.Pod, handler *coh.Scalin
 	return exitCode == 0, nil
 }
 
-func (in *ScalableChecker) HTTPIsPodStatusHA(pod corev1.Pod, handler *coh.ScalingProbe) (bool, error) {
+func (in *ScalableChecker) HTTPIsPodStatusHA(pod corev1.Pod, handler *coh.ScalingProbe, tlsConfig *tls.Config) (bool, error) {
 	var (
 		scheme corev1.URIScheme
 		host   string
@@ -289,7 +346,17 @@ func (in *ScalableChecker) HTTPIsPodStatusHA(pod corev1.Pod, handler *coh.Scalin
 		}
 	}
 
-	p := httpprobe.New(true)
+	var p httpprobe.Prober
+	if scheme == corev1.URISchemeHTTPS && tlsConfig != nil {
+		// the Pod's certificate is verified in the same way as by the management over ReST client
+		cfg := tlsConfig.Clone()
+		if action.Host == "" {
+			cfg = mgmt.NewPodTLSConfig(tlsConfig, pod)
+		}
+		p = httpprobe.NewWithTLSConfig(cfg, true)
+	} else {
+		p = httpprobe.New(true)
+	}
 	result, s, err := p.Probe(u, header, handler.GetTimeout())
 
 	log.Info(fmt.Sprintf("StatusHA check URL: %s result=%s msg=%s error=%s", u.String(), result, s, err))
@@ -0,0 +1,110 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package coherencerole
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/controller/events"
+	"github.com/oracle/coherence-operator/pkg/cron"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"time"
+)
+
+// applySchedule evaluates the role's scaling schedule. If a scheduled change has become due since the schedule
+// was last evaluated the role's replicas are updated to the replicas of the most recent due change, so that the
+// role is then scaled by the scale method using the role's scaling policy. The role's scheduled scaling status
+// is updated to show the last change applied and the next change due.
+func (r *ReconcileCoherenceRole) applySchedule(role *coh.CoherenceRole, logger logr.Logger) error {
+	schedule := role.Spec.Scaling.Schedule
+	loc, err := schedule.GetLocation()
+	if err != nil {
+		logger.Error(err, "Invalid scaling schedule time zone")
+		return nil
+	}
+
+	now := time.Now().In(loc)
+	status := role.Status.ScheduledScaling.DeepCopy()
+	if status == nil {
+		status = &coh.ScheduledScalingStatus{}
+	}
+
+	var due, next *coh.ScheduledScalingAction
+	for _, entry := range schedule.Entries {
+		s, err := cron.Parse(entry.Cron)
+		if err != nil {
+			logger.Error(err, "Invalid scaling schedule entry "+entry.Cron)
+			continue
+		}
+
+		if status.LastEvaluatedTime != nil {
+			// find the most recent time this entry was due since the schedule was last evaluated
+			var last time.Time
+			for t := s.Next(status.LastEvaluatedTime.In(loc)); !t.IsZero() && !t.After(now); t = s.Next(t) {
+				last = t
+			}
+			if !last.IsZero() && (due == nil || last.After(due.Time.Time)) {
+				due = &coh.ScheduledScalingAction{Cron: entry.Cron, Replicas: entry.Replicas, Time: metav1.NewTime(last)}
+			}
+		}
+
+		if t := s.Next(now); !t.IsZero() && (next == nil || t.Before(next.Time.Time)) {
+			next = &coh.ScheduledScalingAction{Cron: entry.Cron, Replicas: entry.Replicas, Time: metav1.NewTime(t)}
+		}
+	}
+
+	if status.LastEvaluatedTime != nil && due == nil && isSameSpec(status.Next, next) {
+		// nothing has changed since the schedule was last evaluated
+		return nil
+	}
+
+	evaluated := metav1.NewTime(now)
+	status.LastEvaluatedTime = &evaluated
+	status.Next = next
+	if due != nil {
+		status.Last = due
+	}
+
+	role.Status.ScheduledScaling = status
+	if err := r.client.Status().Update(context.TODO(), role); err != nil {
+		return err
+	}
+
+	current := role.Spec.GetReplicas()
+	if due == nil || due.Replicas == current {
+		return nil
+	}
+
+	logger.Info(fmt.Sprintf("Scheduled scaling %q of role from %d to %d", due.Cron, current, due.Replicas))
+	role.Spec.SetReplicas(due.Replicas)
+	if err := r.client.Update(context.TODO(), role); err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf(scheduledScaleMessage, role.Name, current, due.Replicas, due.Cron)
+	r.events.Event(role, events.ScheduledScale, msg)
+
+	return nil
+}
+
+// requeueForSchedule ensures that a request for a role with a scaling schedule is re-queued
+// no later than the time that the next scheduled change is due.
+func requeueForSchedule(role *coh.CoherenceRole, result reconcile.Result) reconcile.Result {
+	if !role.Spec.Scaling.HasSchedule() || role.Status.ScheduledScaling == nil || role.Status.ScheduledScaling.Next == nil {
+		return result
+	}
+
+	after := time.Until(role.Status.ScheduledScaling.Next.Time.Time)
+	if after < time.Second {
+		after = time.Second
+	}
+
+	return EarliestRequeue(result, reconcile.Result{Requeue: true, RequeueAfter: after})
+}
@@ -0,0 +1,314 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package coherencerole
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/controller/events"
+	mgmt "github.com/oracle/coherence-operator/pkg/management"
+	"github.com/oracle/coherence-operator/pkg/metrics"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The timeout for Coherence management requests made when checking whether partitions are safe.
+const partitionCheckTimeout = time.Second * 30
+
+// recordUpgradeProgress records the number of the role's Pods that the StatefulSet controller reports have not
+// yet been updated to the latest revision, which covers rolling upgrades using either upgrade policy.
+func recordUpgradeProgress(role *coh.CoherenceRole, sts *appsv1.StatefulSet) {
+	outdated := sts.Status.Replicas - sts.Status.UpdatedReplicas
+	if outdated < 0 {
+		outdated = 0
+	}
+	metrics.SetRollingUpgradeOutdatedPods(role.Namespace, role.Name, int(outdated))
+}
+
+// safeUpgrade performs a single step of an Operator driven rolling upgrade of a role with the Safe upgrade policy.
+// The role's StatefulSet uses the OnDelete update strategy so Pods are only re-created at the new revision after
+// they have been deleted. One Pod is deleted at a time and the next Pod is not deleted until the restarted Pod is
+// ready and every partitioned cache service is at least the role's minimum HA status (NODE-SAFE by default) with
+// no remaining partition transfers.
+// Returns true if the upgrade is still in progress, in which case the request should be re-queued using the result.
+func (r *ReconcileCoherenceRole) safeUpgrade(role *coh.CoherenceRole, sts *appsv1.StatefulSet, logger logr.Logger) (bool, reconcile.Result, error) {
+	revision := sts.Status.UpdateRevision
+	if revision == "" {
+		// the StatefulSet controller has not yet set a revision so there is nothing to roll
+		return false, reconcile.Result{}, nil
+	}
+
+	if sts.Status.ObservedGeneration < sts.Generation {
+		// the StatefulSet controller has not yet processed the latest StatefulSet spec
+		return true, reconcile.Result{Requeue: true, RequeueAfter: time.Second * 5}, nil
+	}
+
+	pods, err := listPods(r.client, role, sts)
+	if err != nil {
+		return true, reconcile.Result{Requeue: true, RequeueAfter: r.statusHARetry}, err
+	}
+
+	var outdated []corev1.Pod
+	var restarting *corev1.Pod
+	for i := range pods {
+		if pods[i].Name == role.Status.UpgradingPod {
+			restarting = &pods[i]
+		}
+		if pods[i].Labels[appsv1.StatefulSetRevisionLabel] != revision {
+			outdated = append(outdated, pods[i])
+		}
+	}
+
+	if role.Status.UpgradingPod != "" && restarting == nil && sts.Spec.Replicas != nil && podOrdinal(role.Status.UpgradingPod) >= int(*sts.Spec.Replicas) {
+		// the role has been scaled down since the Pod was deleted so it will never be re-created
+		logger.Info(fmt.Sprintf("Pod %s being restarted has been removed by a scale down", role.Status.UpgradingPod))
+		role.Status.UpgradingPod = ""
+		if err := r.client.Status().Update(context.TODO(), role); err != nil {
+			return true, reconcile.Result{Requeue: true}, err
+		}
+	}
+
+	if role.Status.UpgradingPod != "" {
+		if restarting == nil || restarting.Labels[appsv1.StatefulSetRevisionLabel] != revision || !IsPodReady(*restarting) {
+			logger.Info(fmt.Sprintf("Waiting for Pod %s to be restarted and ready", role.Status.UpgradingPod))
+			return true, reconcile.Result{Requeue: true, RequeueAfter: r.statusHARetry}, nil
+		}
+	}
+
+	if len(outdated) == 0 {
+		if role.Status.UpgradingPod != "" {
+			logger.Info("Safe rolling upgrade of CoherenceRole complete")
+			role.Status.UpgradingPod = ""
+			if err := r.client.Status().Update(context.TODO(), role); err != nil {
+				return true, reconcile.Result{Requeue: true}, err
+			}
+		}
+		return false, reconcile.Result{}, nil
+	}
+
+	checker := ScalableChecker{Client: r.client, Config: r.mgr.GetConfig()}
+	if !checker.IsPartitionSafe(role, sts) {
+		logger.Info(fmt.Sprintf("Role %s partitions are not safe - re-queuing rolling upgrade request", role.Name))
+		msg := "waiting for partitioned services to be safe before restarting the next Pod"
+		if role.SetCondition(coh.ConditionProgressing, true, coh.ReasonRollingUpgrade, msg) {
+			if err := r.client.Status().Update(context.TODO(), role); err != nil {
+				logger.Error(err, "failed to update role status")
+			}
+		}
+		return true, reconcile.Result{Requeue: true, RequeueAfter: r.statusHARetry}, nil
+	}
+
+	// restart the Pod with the highest ordinal first, the same order used by the StatefulSet controller
+	sort.Slice(outdated, func(i, j int) bool {
+		return podOrdinal(outdated[i].Name) > podOrdinal(outdated[j].Name)
+	})
+	pod := outdated[0]
+
+	logger.Info(fmt.Sprintf("Partitions are safe, deleting Pod %s for rolling upgrade", pod.Name))
+	if err := r.client.Delete(context.TODO(), &pod); err != nil && !errors.IsNotFound(err) {
+		result, err := r.handleErrAndRequeue(err, role, fmt.Sprintf(failedToUpgradeRole, pod.Name, role.Name, err.Error()), logger)
+		return true, result, err
+	}
+
+	metrics.IncRollingUpgradePodRestarts(role.Namespace, role.Name)
+
+	role.Status.Status = coh.RoleStatusRollingUpgrade
+	role.Status.UpgradingPod = pod.Name
+	role.SetCondition(coh.ConditionProgressing, true, coh.ReasonRollingUpgrade, fmt.Sprintf("restarting Pod %s", pod.Name))
+	if err := r.client.Status().Update(context.TODO(), role); err != nil {
+		logger.Error(err, "failed to update role status")
+	}
+
+	msg := fmt.Sprintf(restartPodMessage, pod.Name, role.Name)
+	r.events.Event(role, events.UpgradePodRestarted, msg)
+
+	return true, reconcile.Result{Requeue: true, RequeueAfter: r.statusHARetry}, nil
+}
+
+// IsPartitionSafe uses Coherence management over ReST on one of the role's ready Pods to determine whether
+// every partitioned cache service is at least the role's minimum HA status with no remaining partition transfers.
+// If management over ReST is not enabled for the role the role's StatusHA probe is used instead.
+func (in *ScalableChecker) IsPartitionSafe(role *coh.CoherenceRole, sts *appsv1.StatefulSet) bool {
+	if _, enabled := GetManagementPort(role); !enabled {
+		log.Info(fmt.Sprintf("Management over ReST is not enabled for CoherenceRole %s - using StatusHA probe", role.Name))
+		return in.IsStatusHA(role, sts)
+	}
+	return in.checkPartitions(context.TODO(), role, sts, role.Spec.Scaling, true)
+}
+
+// IsMinimumHAStatus uses Coherence management over ReST on one of the role's ready Pods to determine whether
+// every partitioned cache service is at least the role's minimum HA status.
+// If management over ReST is not enabled for the role the HA status cannot be determined so false is returned.
+func (in *ScalableChecker) IsMinimumHAStatus(role *coh.CoherenceRole, sts *appsv1.StatefulSet) bool {
+	if _, enabled := GetManagementPort(role); !enabled {
+		log.Info(fmt.Sprintf("Management over ReST is not enabled for CoherenceRole %s - cannot check minimum HA status", role.Name))
+		return false
+	}
+	return in.checkPartitions(context.TODO(), role, sts, role.Spec.Scaling, false)
+}
+
+// IsEvictionSafe uses Coherence management over ReST on one of the role's ready Pods to determine whether
+// one of the role's Pods can be evicted, which is when no partitioned cache service is ENDANGERED and there
+// are no remaining partition transfers.
+// If management over ReST is not enabled for the role the role's StatusHA probe is used instead.
+// If no answer is obtained before the context is done the eviction is not safe so false is returned.
+func (in *ScalableChecker) IsEvictionSafe(ctx context.Context, role *coh.CoherenceRole, sts *appsv1.StatefulSet) bool {
+	if _, enabled := GetManagementPort(role); !enabled {
+		log.Info(fmt.Sprintf("Management over ReST is not enabled for CoherenceRole %s - using StatusHA probe", role.Name))
+		// the StatusHA probe has its own timeouts so it is run in the background and abandoned if the context is done
+		ha := make(chan bool, 1)
+		go func() { ha <- in.IsStatusHA(role, sts) }()
+		select {
+		case safe := <-ha:
+			return safe
+		case <-ctx.Done():
+			log.Info(fmt.Sprintf("Timed out checking StatusHA of CoherenceRole %s: %s", role.Name, ctx.Err().Error()))
+			return false
+		}
+	}
+	// with no scaling spec every service must be at least NODE-SAFE, i.e. not ENDANGERED
+	return in.checkPartitions(ctx, role, sts, nil, true)
+}
+
+// checkPartitions checks the partitioned cache services using Coherence management over ReST on the first
+// ready Pod that responds using the minimum HA status configured in the scaling spec. If balanced is true every
+// service must also have no remaining partition transfers. The Pods are no longer tried once the context is done.
+func (in *ScalableChecker) checkPartitions(ctx context.Context, role *coh.CoherenceRole, sts *appsv1.StatefulSet, scaling *coh.ScalingSpec, balanced bool) bool {
+	port, _ := GetManagementPort(role)
+
+	pods, err := listPods(in.Client, role, sts)
+	if err != nil {
+		log.Error(err, "Error getting list of Pods for StatefulSet "+sts.Name)
+		return false
+	}
+
+	if len(pods) == 0 {
+		log.Info("Cannot find any Pods for StatefulSet " + sts.Name + " - assuming partitions are safe")
+		return true
+	}
+
+	tlsConfig, err := in.GetManagementTLSConfig(role)
+	if err != nil {
+		log.Error(err, "Error getting the management TLS configuration for CoherenceRole "+role.Name)
+		return false
+	}
+
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning || !IsPodReady(pod) {
+			continue
+		}
+		if ctx.Err() != nil {
+			log.Info(fmt.Sprintf("Stopped checking StatefulSet %s for partition safety: %s", sts.Name, ctx.Err().Error()))
+			return false
+		}
+
+		cl := in.NewManagementClient(pod, port, tlsConfig, partitionCheckTimeout)
+
+		safe, err := isPartitionSafe(ctx, cl, scaling, balanced)
+		if err == nil {
+			log.Info(fmt.Sprintf("Checked Pod %s for partition safety (%t)", pod.Name, safe))
+			return safe
+		}
+		log.Info(fmt.Sprintf("Checked Pod %s for partition safety error %s", pod.Name, err.Error()))
+	}
+
+	return false
+}
+
+// isPartitionSafe uses Coherence management over ReST to determine whether every partitioned cache service
+// is at least the minimum HA status configured for the service in the scaling spec. If balanced is true
+// every service must also have no remaining partition transfers.
+func isPartitionSafe(ctx context.Context, cl *mgmt.Client, scaling *coh.ScalingSpec, balanced bool) (bool, error) {
+	services, err := cl.GetServices(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	checked := make(map[string]bool)
+	for _, service := range services.Items {
+		if service.Type != mgmt.DistributedCacheType || checked[service.Name] {
+			continue
+		}
+		checked[service.Name] = true
+
+		partitions, err := cl.GetPartitionAssignment(ctx, service.Name)
+		if err != nil {
+			return false, err
+		}
+
+		minimum := scaling.GetMinimumHAStatus(service.Name)
+		if !minimum.IsSatisfiedBy(partitions.HAStatus, partitions.HAStatusCode) {
+			log.Info(fmt.Sprintf("Service %s is not safe: HAStatus=%s HAStatusCode=%d required=%s",
+				service.Name, partitions.HAStatus, partitions.HAStatusCode, minimum))
+			return false, nil
+		}
+		if balanced && partitions.RemainingDistributionCount != 0 {
+			log.Info(fmt.Sprintf("Service %s is not safe: RemainingDistributionCount=%d",
+				service.Name, partitions.RemainingDistributionCount))
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// GetManagementPort returns the Coherence management over ReST port for a role and whether management is enabled.
+func GetManagementPort(role *coh.CoherenceRole) (int32, bool) {
+	if !role.Spec.IsManagementEnabled() {
+		return 0, false
+	}
+	spec := role.Spec.Coherence
+	if spec.Management.Port != nil {
+		return *spec.Management.Port, true
+	}
+	return mgmt.DefaultPort, true
+}
+
+// listPods returns the Pods belonging to a role's StatefulSet.
+func listPods(c client.Client, role *coh.CoherenceRole, sts *appsv1.StatefulSet) ([]corev1.Pod, error) {
+	list := corev1.PodList{}
+	labels := client.MatchingLabels{}
+	for k, v := range sts.Spec.Selector.MatchLabels {
+		labels[k] = v
+	}
+	if err := c.List(context.TODO(), &list, client.InNamespace(role.Namespace), labels); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// IsPodReady returns true if the Pod has a Ready condition with a True status.
+func IsPodReady(pod corev1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// podOrdinal returns the ordinal of a StatefulSet Pod from the suffix of its name, or -1 if the name has no ordinal.
+func podOrdinal(name string) int {
+	i := strings.LastIndex(name, "-")
+	if i < 0 {
+		return -1
+	}
+	ordinal, err := strconv.Atoi(name[i+1:])
+	if err != nil {
+		return -1
+	}
+	return ordinal
+}
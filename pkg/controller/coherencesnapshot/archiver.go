@@ -0,0 +1,299 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package coherencesnapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-logr/logr"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/archive"
+	"github.com/oracle/coherence-operator/pkg/controller/coherencerole"
+	mgmt "github.com/oracle/coherence-operator/pkg/management"
+	"github.com/oracle/coherence-operator/pkg/resources"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// The snapshot-archiver utility that the utils init container copies into the Coherence container.
+	archiverCommand = "/utils/snapshot-archiver"
+	// The timeout for copying the snapshot files of a single member.
+	archiverTimeout = time.Minute * 30
+
+	// The keys of the credentials in the Secret of an S3 snapshot store.
+	accessKeyName = "accessKey"
+	secretKeyName = "secretKey"
+)
+
+// execFunction executes a command in a Pod, returning the exit code, stdout and stderr.
+type execFunction func(req *mgmt.ExecRequest, config *rest.Config) (int, string, string, error)
+
+// archiver copies snapshot files to and from a snapshot store by running the snapshot-archiver
+// in the Coherence container of each storage enabled member of a cluster. The snapshot-archiver runs
+// asynchronously so that a long copy does not block the controller's workers; the controllers poll
+// the runs each time they reconcile.
+type archiver struct {
+	client client.Client
+	config *rest.Config
+	exec   execFunction
+	mutex  sync.Mutex
+	runs   map[string]*archiverRun
+}
+
+// archiverRun is a snapshot-archiver operation running in a Pod. The result fields are only set
+// once the done channel is closed.
+type archiverRun struct {
+	done     chan struct{}
+	exitCode int
+	stderr   string
+	err      error
+}
+
+// newConfig creates the snapshot-archiver configuration for a snapshot store, reading the
+// credentials of an S3 store from its Secret.
+func (a *archiver) newConfig(namespace, cluster, snapshot string, store *coh.SnapshotStoreSpec) (archive.Config, error) {
+	cfg := archive.Config{Cluster: cluster, Snapshot: snapshot}
+	if store.Prefix != nil {
+		cfg.Prefix = *store.Prefix
+	}
+	if store.Directory != nil {
+		cfg.Directory = *store.Directory
+	}
+	if store.S3 == nil {
+		return cfg, nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := a.client.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: store.S3.SecretName}, secret); err != nil {
+		return cfg, fmt.Errorf("failed to get the snapshot store Secret %s: %s", store.S3.SecretName, err.Error())
+	}
+	cfg.S3 = &archive.S3Config{
+		Endpoint:  store.S3.Endpoint,
+		Bucket:    store.S3.Bucket,
+		AccessKey: string(secret.Data[accessKeyName]),
+		SecretKey: string(secret.Data[secretKeyName]),
+	}
+	if store.S3.Region != nil {
+		cfg.S3.Region = *store.S3.Region
+	}
+	return cfg, nil
+}
+
+// storagePods returns the Pods of the storage enabled roles of a cluster.
+func (a *archiver) storagePods(namespace, cluster string) ([]corev1.Pod, error) {
+	roles := coh.CoherenceRoleList{}
+	err := a.client.List(context.TODO(), &roles, client.InNamespace(namespace), client.MatchingLabels{coh.CoherenceClusterLabel: cluster})
+	if err != nil {
+		return nil, err
+	}
+
+	var pods []corev1.Pod
+	for _, role := range roles.Items {
+		if !role.Spec.IsStorageEnabled() {
+			continue
+		}
+		list := corev1.PodList{}
+		err := a.client.List(context.TODO(), &list, client.InNamespace(namespace), client.MatchingLabels{resources.CoherenceDeploymentLabel: role.Name})
+		if err != nil {
+			return nil, err
+		}
+		pods = append(pods, list.Items...)
+	}
+	return pods, nil
+}
+
+// copyFiles moves the copy of each member's snapshot files on to its next phase, running the snapshot-archiver
+// operation in each ready member that has not yet finished. The members are initialised from the cluster's
+// storage enabled Pods when the copy starts. Returns the aggregate phase of the copy.
+func (a *archiver) copyFiles(namespace string, members *[]coh.MemberOperationStatus, operation string, cfg archive.Config, logger logr.Logger) (coh.PersistencePhase, error) {
+	pods, err := a.storagePods(namespace, cfg.Cluster)
+	if err != nil {
+		return coh.PersistencePhaseInProgress, err
+	}
+
+	if *members == nil {
+		if len(pods) == 0 {
+			return coh.PersistencePhaseInProgress, fmt.Errorf("CoherenceCluster %s has no storage enabled Pods", cfg.Cluster)
+		}
+		for _, pod := range pods {
+			*members = append(*members, coh.MemberOperationStatus{Pod: pod.Name, Phase: coh.PersistencePhasePending})
+		}
+	}
+
+	ready := make(map[string]corev1.Pod)
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodRunning && coherencerole.IsPodReady(pod) {
+			ready[pod.Name] = pod
+		}
+	}
+
+	phase := coh.PersistencePhaseCompleted
+	for i := range *members {
+		member := &(*members)[i]
+		cfg.Member = member.Pod
+		switch member.Phase {
+		case coh.PersistencePhasePending:
+			pod, found := ready[member.Pod]
+			if found {
+				logger.Info(fmt.Sprintf("Running snapshot-archiver %s for snapshot %s in Pod %s", operation, cfg.Snapshot, pod.Name))
+				a.start(pod, operation, cfg)
+				member.Phase = coh.PersistencePhaseInProgress
+				member.Message = ""
+			} else {
+				member.Message = fmt.Sprintf("waiting for Pod %s to be ready", member.Pod)
+			}
+		case coh.PersistencePhaseInProgress:
+			a.updateMember(namespace, member, operation, cfg)
+		}
+
+		switch {
+		case !member.Phase.IsFinished():
+			phase = coh.PersistencePhaseInProgress
+		case member.Phase == coh.PersistencePhaseFailed && phase != coh.PersistencePhaseInProgress:
+			phase = coh.PersistencePhaseFailed
+		}
+	}
+	return phase, nil
+}
+
+// updateMember updates a member whose copy is in progress with the result of its snapshot-archiver run once
+// the run has finished. The member's copy fails if the snapshot-archiver fails, any other error, or a run that
+// is no longer known, for example because the Operator has restarted, returns the member to pending so that
+// the operation is retried.
+func (a *archiver) updateMember(namespace string, member *coh.MemberOperationStatus, operation string, cfg archive.Config) {
+	run, found := a.poll(namespace, operation, cfg)
+	switch {
+	case !found:
+		member.Phase = coh.PersistencePhasePending
+		member.Message = fmt.Sprintf("the snapshot-archiver %s is no longer running in Pod %s", operation, member.Pod)
+	case run == nil:
+		// the snapshot-archiver is still running
+	case run.err != nil:
+		member.Phase = coh.PersistencePhasePending
+		member.Message = run.err.Error()
+	case run.exitCode != 0:
+		member.Phase = coh.PersistencePhaseFailed
+		member.Message = fmt.Sprintf("snapshot-archiver %s exited with code %d: %s", operation, run.exitCode, strings.TrimSpace(run.stderr))
+	default:
+		member.Phase = coh.PersistencePhaseCompleted
+		member.Message = ""
+	}
+}
+
+// start runs a snapshot-archiver operation in a Pod in a new goroutine, unless the same operation is
+// already running.
+func (a *archiver) start(pod corev1.Pod, operation string, cfg archive.Config) {
+	key := runKey(pod.Namespace, operation, cfg)
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.runs == nil {
+		a.runs = make(map[string]*archiverRun)
+	}
+	if _, found := a.runs[key]; found {
+		return
+	}
+
+	run := &archiverRun{done: make(chan struct{})}
+	a.runs[key] = run
+	go func() {
+		defer close(run.done)
+		run.exitCode, run.stderr, run.err = a.run(pod, operation, cfg)
+	}()
+}
+
+// poll returns the run of a snapshot-archiver operation if it has finished, removing it, or nil if it is
+// still running. Returns false if the operation is not known.
+func (a *archiver) poll(namespace, operation string, cfg archive.Config) (*archiverRun, bool) {
+	key := runKey(namespace, operation, cfg)
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	run, found := a.runs[key]
+	if !found {
+		return nil, false
+	}
+	select {
+	case <-run.done:
+		delete(a.runs, key)
+		return run, true
+	default:
+		return nil, true
+	}
+}
+
+// runKey returns the key of a snapshot-archiver operation in the runs of an archiver.
+func runKey(namespace, operation string, cfg archive.Config) string {
+	return strings.Join([]string{namespace, cfg.Cluster, cfg.Snapshot, operation, cfg.Member}, "/")
+}
+
+// run runs a snapshot-archiver operation in the Coherence container of a Pod, passing the configuration on stdin
+// so that store credentials do not appear in the command line. Returns the exit code and stderr of the
+// snapshot-archiver, or an error if it could not be run.
+func (a *archiver) run(pod corev1.Pod, operation string, cfg archive.Config) (int, string, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return 0, "", err
+	}
+
+	req := &mgmt.ExecRequest{
+		Pod:       pod.Name,
+		Container: resources.CoherenceContainerName,
+		Namespace: pod.Namespace,
+		Command:   []string{archiverCommand, operation},
+		Arg:       []string{string(data)},
+		Timeout:   archiverTimeout,
+	}
+	exitCode, _, stderr, err := a.exec(req, a.config)
+	return exitCode, stderr, err
+}
+
+// remove removes the stored files of every member for a snapshot by running the snapshot-archiver
+// in a ready storage enabled Pod of the cluster. Returns true once the files have been removed, or
+// false while the snapshot-archiver is still running.
+func (a *archiver) remove(namespace string, cfg archive.Config) (bool, error) {
+	run, found := a.poll(namespace, archive.OperationRemove, cfg)
+	switch {
+	case found && run == nil:
+		return false, nil
+	case found && run.err == nil && run.exitCode != 0:
+		return false, fmt.Errorf("snapshot-archiver %s exited with code %d: %s", archive.OperationRemove, run.exitCode, strings.TrimSpace(run.stderr))
+	case found:
+		return run.err == nil, run.err
+	}
+
+	pods, err := a.storagePods(namespace, cfg.Cluster)
+	if err != nil {
+		return false, err
+	}
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning || !coherencerole.IsPodReady(pod) {
+			continue
+		}
+		a.start(pod, archive.OperationRemove, cfg)
+		return false, nil
+	}
+	return false, fmt.Errorf("CoherenceCluster %s has no ready storage enabled Pods", cfg.Cluster)
+}
+
+// failedMembers returns the names of the Pods that a copy failed for.
+func failedMembers(members []coh.MemberOperationStatus) []string {
+	var names []string
+	for _, member := range members {
+		if member.Phase == coh.PersistencePhaseFailed {
+			names = append(names, member.Pod)
+		}
+	}
+	return names
+}
@@ -0,0 +1,206 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package coherencesnapshot
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/archive"
+	"github.com/oracle/coherence-operator/pkg/controller/coherencerole"
+	"github.com/oracle/coherence-operator/pkg/controller/events"
+	mgmt "github.com/oracle/coherence-operator/pkg/management"
+	"github.com/oracle/coherence-operator/pkg/metrics"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	"strings"
+)
+
+const (
+	// The name of the CoherenceRestore controller. This is used in events, log messages, etc.
+	restoreControllerName = "coherencerestore.controller"
+
+	restoreCompletedMessage string = "recovered CoherenceCluster %s from snapshot %s"
+	restoreFailedMessage    string = "failed to recover CoherenceCluster %s from snapshot %s for services %s"
+	downloadFailedMessage   string = "failed to copy snapshot %s of CoherenceCluster %s from the snapshot store to Pods %s"
+)
+
+// newRestoreReconciler returns a new reconcile.Reconciler.
+func newRestoreReconciler(mgr manager.Manager) *ReconcileCoherenceRestore {
+	return &ReconcileCoherenceRestore{
+		client:   mgr.GetClient(),
+		events:   events.NewRecorder(mgr.GetEventRecorderFor(restoreControllerName)),
+		checker:  &coherencerole.ScalableChecker{Client: mgr.GetClient(), Config: mgr.GetConfig()},
+		archiver: &archiver{client: mgr.GetClient(), config: mgr.GetConfig(), exec: mgmt.PodExec},
+	}
+}
+
+// addRestore adds a new Controller to mgr with r as the reconcile.Reconciler.
+func addRestore(mgr manager.Manager, r *ReconcileCoherenceRestore) error {
+	// Create a new controller
+	c, err := controller.New(restoreControllerName, mgr, controller.Options{Reconciler: metrics.InstrumentReconciler(restoreControllerName, r)})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource CoherenceRestore
+	return c.Watch(&source.Kind{Type: &coh.CoherenceRestore{}}, &handler.EnqueueRequestForObject{})
+}
+
+// blank assignment to verify that ReconcileCoherenceRestore implements reconcile.Reconciler.
+// If the reconcile.Reconciler API was to change then we'd get a compile error here.
+var _ reconcile.Reconciler = &ReconcileCoherenceRestore{}
+
+// ReconcileCoherenceRestore reconciles a CoherenceRestore object by recovering a Coherence cluster
+// from a persistence snapshot using Coherence management over ReST.
+type ReconcileCoherenceRestore struct {
+	client   client.Client
+	events   *events.Recorder
+	checker  *coherencerole.ScalableChecker
+	archiver *archiver
+}
+
+// Reconcile reads that state of a CoherenceRestore object and recovers the services in the CoherenceRestore.Spec
+// from the snapshot, recording the progress of the recovery in the CoherenceRestore.Status.
+// A CoherenceRestore is only processed once, after the recovery has completed or failed it is ignored.
+func (r *ReconcileCoherenceRestore) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	logger := log.WithValues("Namespace", request.Namespace, "Name", request.Name)
+
+	restore := &coh.CoherenceRestore{}
+	if err := r.client.Get(context.TODO(), request.NamespacedName, restore); err != nil {
+		if errors.IsNotFound(err) {
+			// the CoherenceRestore has been deleted
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	if restore.GetDeletionTimestamp() != nil || restore.Status.Phase.IsFinished() {
+		return reconcile.Result{}, nil
+	}
+
+	status := restore.Status.DeepCopy()
+	result, err := r.reconcileRestore(restore, status, logger)
+
+	if !reflect.DeepEqual(status, &restore.Status) {
+		restore.Status = *status
+		if e := r.client.Status().Update(context.TODO(), restore); e != nil {
+			return reconcile.Result{}, e
+		}
+	}
+	return result, err
+}
+
+// reconcileRestore moves the recovery of each service on to its next phase: a pending recovery is started
+// and the recovery completes once the service is idle. If a snapshot store is specified the snapshot files
+// of each member are copied from the store before any service is recovered. The specified status is updated.
+func (r *ReconcileCoherenceRestore) reconcileRestore(restore *coh.CoherenceRestore, status *coh.CoherenceRestoreStatus, logger logr.Logger) (reconcile.Result, error) {
+	if status.Phase == "" {
+		status.Phase = coh.PersistencePhasePending
+	}
+
+	if restore.Spec.Store != nil && (status.Phase == coh.PersistencePhasePending || status.Phase == coh.PersistencePhaseDownloading) {
+		if status.StartTime == nil {
+			now := metav1.Now()
+			status.StartTime = &now
+		}
+		status.Phase = coh.PersistencePhaseDownloading
+		phase, err := r.downloadSnapshot(restore, status, logger)
+		switch {
+		case err != nil:
+			status.Message = err.Error()
+			return reconcile.Result{Requeue: true, RequeueAfter: pollInterval}, nil
+		case phase == coh.PersistencePhaseInProgress:
+			status.Message = ""
+			return reconcile.Result{Requeue: true, RequeueAfter: pollInterval}, nil
+		case phase == coh.PersistencePhaseFailed:
+			status.Phase = coh.PersistencePhaseFailed
+			r.finishRestore(restore, status)
+			return reconcile.Result{}, nil
+		}
+	}
+
+	ep, err := findEndpoint(r.checker, restore.Namespace, restore.Spec.Cluster)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if ep == nil {
+		status.Message = noEndpointMessage(restore.Spec.Cluster)
+		return reconcile.Result{Requeue: true, RequeueAfter: endpointRetry}, nil
+	}
+
+	if status.Services == nil {
+		services, err := newServiceStatuses(ep, restore.Spec.Services)
+		if err != nil {
+			status.Message = err.Error()
+			return reconcile.Result{Requeue: true, RequeueAfter: pollInterval}, nil
+		}
+		if status.StartTime == nil {
+			now := metav1.Now()
+			status.StartTime = &now
+		}
+		status.Services = services
+	}
+
+	for i := range status.Services {
+		service := &status.Services[i]
+		switch service.Phase {
+		case coh.PersistencePhasePending:
+			logger.Info(fmt.Sprintf("Recovering snapshot %s for service %s", restore.Spec.Snapshot, service.Name))
+			startOperation(service, coh.PersistencePhaseInProgress, func() error {
+				return ep.client.RecoverSnapshot(context.TODO(), service.Name, restore.Spec.Snapshot)
+			})
+		case coh.PersistencePhaseInProgress:
+			if data := getIdlePersistence(ep, service); data != nil {
+				service.Phase = coh.PersistencePhaseCompleted
+			}
+		}
+	}
+
+	status.Phase = aggregatePhase(status.Services)
+	status.Message = ""
+	if !status.Phase.IsFinished() {
+		return reconcile.Result{Requeue: true, RequeueAfter: pollInterval}, nil
+	}
+
+	r.finishRestore(restore, status)
+	return reconcile.Result{}, nil
+}
+
+// downloadSnapshot copies the snapshot files of each storage enabled member from the snapshot store.
+// Returns the aggregate phase of the copy.
+func (r *ReconcileCoherenceRestore) downloadSnapshot(restore *coh.CoherenceRestore, status *coh.CoherenceRestoreStatus, logger logr.Logger) (coh.PersistencePhase, error) {
+	cfg, err := r.archiver.newConfig(restore.Namespace, restore.Spec.Cluster, restore.Spec.Snapshot, restore.Spec.Store)
+	if err != nil {
+		return coh.PersistencePhaseInProgress, err
+	}
+	return r.archiver.copyFiles(restore.Namespace, &status.Members, archive.OperationRetrieve, cfg, logger)
+}
+
+// finishRestore records the completion of a finished recovery and raises an event.
+func (r *ReconcileCoherenceRestore) finishRestore(restore *coh.CoherenceRestore, status *coh.CoherenceRestoreStatus) {
+	now := metav1.Now()
+	status.CompletionTime = &now
+	switch {
+	case status.Phase != coh.PersistencePhaseFailed:
+		r.events.Event(restore, events.Completed, fmt.Sprintf(restoreCompletedMessage, restore.Spec.Cluster, restore.Spec.Snapshot))
+		return
+	case len(failedMembers(status.Members)) > 0:
+		status.Message = fmt.Sprintf(downloadFailedMessage, restore.Spec.Snapshot, restore.Spec.Cluster, strings.Join(failedMembers(status.Members), ","))
+	default:
+		status.Message = fmt.Sprintf(restoreFailedMessage, restore.Spec.Cluster, restore.Spec.Snapshot, strings.Join(failedServices(status.Services), ","))
+	}
+	r.events.Event(restore, events.Failed, status.Message)
+}
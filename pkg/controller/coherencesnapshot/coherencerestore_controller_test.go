@@ -0,0 +1,195 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package coherencesnapshot
+
+import (
+	"context"
+	"fmt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	coherence "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/archive"
+	stubs "github.com/oracle/coherence-operator/pkg/fakes"
+	"io/ioutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("coherencerestore_controller", func() {
+	const restoreName = "test-restore"
+
+	var (
+		mgr        *stubs.FakeManager
+		management *fakeManagement
+		restore    *coherence.CoherenceRestore
+		existing   []runtime.Object
+		archiveDir string
+		controller *ReconcileCoherenceRestore
+		result     reconcile.Result
+		err        error
+	)
+
+	reconcileRestore := func() {
+		request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: restoreName}}
+		result, err = controller.Reconcile(request)
+	}
+
+	getStatus := func() coherence.CoherenceRestoreStatus {
+		r := &coherence.CoherenceRestore{}
+		e := mgr.Client.Get(context.TODO(), types.NamespacedName{Namespace: testNamespace, Name: restoreName}, r)
+		Expect(e).NotTo(HaveOccurred())
+		return r.Status
+	}
+
+	BeforeEach(func() {
+		management = newFakeManagement("PartitionedCache")
+		existing = []runtime.Object{newManagedRole(), newReadyPod()}
+		restore = &coherence.CoherenceRestore{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: restoreName},
+			Spec:       coherence.CoherenceRestoreSpec{Cluster: testClusterName, Snapshot: "test-snapshot"},
+		}
+	})
+
+	JustBeforeEach(func() {
+		mgr, err = stubs.NewFakeManager(append(existing, restore)...)
+		Expect(err).NotTo(HaveOccurred())
+		controller = newRestoreReconciler(mgr)
+		useFakeManagement(management, controller.checker)
+		if archiveDir != "" {
+			useFakeArchiver(controller.archiver, archiveDir)
+		}
+		reconcileRestore()
+	})
+
+	AfterEach(func() {
+		management.server.Close()
+	})
+
+	When("a restore is created", func() {
+		It("should recover the snapshot for each partitioned cache service", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Requeue).To(BeTrue())
+			Expect(management.Requests()).To(Equal([]string{"POST /PartitionedCache/persistence/snapshots/test-snapshot/recover"}))
+
+			status := getStatus()
+			Expect(status.Phase).To(Equal(coherence.PersistencePhaseInProgress))
+			Expect(status.StartTime).NotTo(BeNil())
+		})
+
+		It("should complete the restore once the services are idle", func() {
+			reconcileRestore()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Requeue).To(BeFalse())
+
+			status := getStatus()
+			Expect(status.Phase).To(Equal(coherence.PersistencePhaseCompleted))
+			Expect(status.CompletionTime).NotTo(BeNil())
+		})
+
+		It("should not recover the snapshot again", func() {
+			reconcileRestore()
+			reconcileRestore()
+			Expect(management.Requests()).To(HaveLen(1))
+		})
+	})
+
+	When("the snapshot does not exist", func() {
+		BeforeEach(func() {
+			management.status = http.StatusNotFound
+		})
+
+		It("should fail the restore", func() {
+			Expect(err).NotTo(HaveOccurred())
+			status := getStatus()
+			Expect(status.Phase).To(Equal(coherence.PersistencePhaseFailed))
+			Expect(status.Services[0].Message).To(ContainSubstring("returned status 404"))
+		})
+	})
+
+	When("the snapshot is copied from a snapshot store", func() {
+		var server *stubs.FakeS3Server
+
+		BeforeEach(func() {
+			server = stubs.NewFakeS3Server(testAccessKey, testBucket)
+			archiveDir, err = ioutil.TempDir("", "snapshot")
+			Expect(err).NotTo(HaveOccurred())
+			restore.Spec.Store = newS3Store(server)
+			existing = append(existing, newStoreSecret())
+		})
+
+		AfterEach(func() {
+			server.Close()
+			_ = os.RemoveAll(archiveDir)
+			archiveDir = ""
+		})
+
+		When("the snapshot has been stored", func() {
+			BeforeEach(func() {
+				writeSnapshotFile(archiveDir, "PartitionedCache", "test-snapshot", "partition data")
+				cfg := archive.Config{
+					Cluster:     testClusterName,
+					Snapshot:    "test-snapshot",
+					Member:      testRoleName + "-0",
+					SnapshotDir: filepath.Join(archiveDir, testRoleName+"-0"),
+					S3:          &archive.S3Config{Endpoint: server.URL(), Bucket: testBucket, AccessKey: testAccessKey},
+				}
+				_, err = archive.Run(archive.OperationArchive, cfg)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(os.RemoveAll(filepath.Join(archiveDir, testRoleName+"-0"))).To(Succeed())
+			})
+
+			It("should copy the snapshot files to each member before recovering the snapshot", func() {
+				Expect(getStatus().Phase).To(Equal(coherence.PersistencePhaseDownloading))
+				waitForArchiver(controller.archiver)
+				reconcileRestore()
+				reconcileRestore()
+				Expect(err).NotTo(HaveOccurred())
+
+				data, e := ioutil.ReadFile(filepath.Join(archiveDir, testRoleName+"-0", testClusterName, "PartitionedCache", "test-snapshot", "data"))
+				Expect(e).NotTo(HaveOccurred())
+				Expect(string(data)).To(Equal("partition data"))
+
+				status := getStatus()
+				Expect(status.Phase).To(Equal(coherence.PersistencePhaseCompleted))
+				Expect(status.Members).To(Equal([]coherence.MemberOperationStatus{
+					{Pod: testRoleName + "-0", Phase: coherence.PersistencePhaseCompleted}}))
+				Expect(management.Requests()).To(Equal([]string{"POST /PartitionedCache/persistence/snapshots/test-snapshot/recover"}))
+			})
+		})
+
+		When("the snapshot has not been stored", func() {
+			It("should fail the restore without recovering the snapshot", func() {
+				waitForArchiver(controller.archiver)
+				reconcileRestore()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Requeue).To(BeFalse())
+				status := getStatus()
+				Expect(status.Phase).To(Equal(coherence.PersistencePhaseFailed))
+				Expect(status.Message).To(Equal(fmt.Sprintf(downloadFailedMessage, "test-snapshot", testClusterName, testRoleName+"-0")))
+				Expect(management.Requests()).To(BeEmpty())
+			})
+		})
+	})
+
+	When("the services are still recovering", func() {
+		BeforeEach(func() {
+			management.idle = false
+		})
+
+		It("should wait for the services to be idle", func() {
+			reconcileRestore()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(reconcile.Result{Requeue: true, RequeueAfter: pollInterval}))
+			Expect(getStatus().Phase).To(Equal(coherence.PersistencePhaseInProgress))
+		})
+	})
+})
@@ -0,0 +1,387 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package coherencesnapshot
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/archive"
+	"github.com/oracle/coherence-operator/pkg/controller/coherencerole"
+	"github.com/oracle/coherence-operator/pkg/controller/events"
+	"github.com/oracle/coherence-operator/pkg/cron"
+	"github.com/oracle/coherence-operator/pkg/flags"
+	mgmt "github.com/oracle/coherence-operator/pkg/management"
+	"github.com/oracle/coherence-operator/pkg/metrics"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	"strings"
+	"time"
+)
+
+const (
+	// The name of this controller. This is used in events, log messages, etc.
+	controllerName = "coherencesnapshot.controller"
+
+	// The time format used in the names of scheduled snapshots.
+	snapshotTimeFormat = "20060102150405"
+
+	snapshotCompletedMessage string = "created snapshot %s of CoherenceCluster %s"
+	snapshotFailedMessage    string = "failed to create snapshot %s of CoherenceCluster %s for services %s"
+	uploadFailedMessage      string = "failed to copy snapshot %s of CoherenceCluster %s to the snapshot store from Pods %s"
+	snapshotRemovedMessage   string = "removed snapshot %s of CoherenceCluster %s"
+	invalidScheduleMessage   string = "invalid snapshot schedule: %s"
+)
+
+var log = logf.Log.WithName(controllerName)
+
+// Add creates the CoherenceSnapshot and CoherenceRestore Controllers and adds them to the Manager.
+// The Manager will set fields on the Controllers and Start them when the Manager is Started.
+func Add(mgr manager.Manager, _ *flags.CoherenceOperatorFlags) error {
+	if err := add(mgr, newReconciler(mgr)); err != nil {
+		return err
+	}
+	return addRestore(mgr, newRestoreReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler.
+func newReconciler(mgr manager.Manager) *ReconcileCoherenceSnapshot {
+	return &ReconcileCoherenceSnapshot{
+		client:   mgr.GetClient(),
+		events:   events.NewRecorder(mgr.GetEventRecorderFor(controllerName)),
+		checker:  &coherencerole.ScalableChecker{Client: mgr.GetClient(), Config: mgr.GetConfig()},
+		archiver: &archiver{client: mgr.GetClient(), config: mgr.GetConfig(), exec: mgmt.PodExec},
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler.
+func add(mgr manager.Manager, r *ReconcileCoherenceSnapshot) error {
+	// Create a new controller
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: metrics.InstrumentReconciler(controllerName, r)})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource CoherenceSnapshot
+	return c.Watch(&source.Kind{Type: &coh.CoherenceSnapshot{}}, &handler.EnqueueRequestForObject{})
+}
+
+// blank assignment to verify that ReconcileCoherenceSnapshot implements reconcile.Reconciler.
+// If the reconcile.Reconciler API was to change then we'd get a compile error here.
+var _ reconcile.Reconciler = &ReconcileCoherenceSnapshot{}
+
+// ReconcileCoherenceSnapshot reconciles a CoherenceSnapshot object by creating persistence snapshots
+// of a Coherence cluster using Coherence management over ReST.
+type ReconcileCoherenceSnapshot struct {
+	client   client.Client
+	events   *events.Recorder
+	checker  *coherencerole.ScalableChecker
+	archiver *archiver
+}
+
+// Reconcile reads that state of a CoherenceSnapshot object and creates, archives and removes snapshots
+// based on the CoherenceSnapshot.Spec, recording the progress of the snapshots in the CoherenceSnapshot.Status.
+func (r *ReconcileCoherenceSnapshot) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	logger := log.WithValues("Namespace", request.Namespace, "Name", request.Name)
+
+	snapshot := &coh.CoherenceSnapshot{}
+	if err := r.client.Get(context.TODO(), request.NamespacedName, snapshot); err != nil {
+		if errors.IsNotFound(err) {
+			// the CoherenceSnapshot has been deleted
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	if snapshot.GetDeletionTimestamp() != nil {
+		return reconcile.Result{}, nil
+	}
+
+	status := snapshot.Status.DeepCopy()
+	result, err := r.reconcileSnapshot(snapshot, status, logger)
+
+	if !reflect.DeepEqual(status, &snapshot.Status) {
+		snapshot.Status = *status
+		if e := r.client.Status().Update(context.TODO(), snapshot); e != nil {
+			return reconcile.Result{}, e
+		}
+	}
+	return result, err
+}
+
+// reconcileSnapshot starts the next snapshot if one is due and progresses the snapshot in progress,
+// updating the specified status.
+func (r *ReconcileCoherenceSnapshot) reconcileSnapshot(snapshot *coh.CoherenceSnapshot, status *coh.CoherenceSnapshotStatus, logger logr.Logger) (reconcile.Result, error) {
+	now := time.Now()
+	result := reconcile.Result{}
+
+	current := status.GetInProgress()
+	if current != nil && status.NextScheduleTime != nil {
+		// re-queue for the next scheduled snapshot in case the current snapshot finishes
+		result = reconcile.Result{Requeue: true, RequeueAfter: time.Until(status.NextScheduleTime.Time)}
+	}
+	if current == nil && snapshot.Spec.IsScheduled() {
+		due, next, err := evaluateSchedule(snapshot, now)
+		if err != nil {
+			status.Phase = coh.PersistencePhaseFailed
+			status.Message = fmt.Sprintf(invalidScheduleMessage, err.Error())
+			return reconcile.Result{}, nil
+		}
+
+		status.NextScheduleTime = nil
+		if !next.IsZero() {
+			t := metav1.NewTime(next)
+			status.NextScheduleTime = &t
+			result = reconcile.Result{Requeue: true, RequeueAfter: time.Until(next)}
+		}
+
+		if !due.IsZero() {
+			name := fmt.Sprintf("%s-%s", snapshot.Name, due.Format(snapshotTimeFormat))
+			current = startSnapshot(status, name, due)
+		}
+	} else if current == nil && len(status.Snapshots) == 0 {
+		current = startSnapshot(status, snapshot.Name, now)
+	}
+
+	if current == nil {
+		// there is no snapshot in progress or due
+		return result, nil
+	}
+
+	ep, err := findEndpoint(r.checker, snapshot.Namespace, snapshot.Spec.Cluster)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if ep == nil {
+		status.Phase = current.Phase
+		status.Message = noEndpointMessage(snapshot.Spec.Cluster)
+		return coherencerole.EarliestRequeue(result, reconcile.Result{Requeue: true, RequeueAfter: endpointRetry}), nil
+	}
+
+	err = progressSnapshot(ep, snapshot, current, logger)
+	if err == nil && current.Phase == coh.PersistencePhaseUploading {
+		err = r.uploadSnapshot(snapshot, current, logger)
+	}
+	status.Phase = current.Phase
+	status.Message = ""
+	if err != nil {
+		status.Message = err.Error()
+	}
+
+	if !current.Phase.IsFinished() {
+		return coherencerole.EarliestRequeue(result, reconcile.Result{Requeue: true, RequeueAfter: pollInterval}), nil
+	}
+
+	completed := metav1.Now()
+	current.CompletionTime = &completed
+	if current.Phase == coh.PersistencePhaseFailed {
+		if failed := failedServices(current.Services); len(failed) > 0 {
+			status.Message = fmt.Sprintf(snapshotFailedMessage, current.Name, snapshot.Spec.Cluster, strings.Join(failed, ","))
+		} else {
+			status.Message = fmt.Sprintf(uploadFailedMessage, current.Name, snapshot.Spec.Cluster, strings.Join(failedMembers(current.Members), ","))
+		}
+		r.events.Event(snapshot, events.Failed, status.Message)
+	} else {
+		r.events.Event(snapshot, events.Completed, fmt.Sprintf(snapshotCompletedMessage, current.Name, snapshot.Spec.Cluster))
+	}
+
+	if snapshot.Spec.IsScheduled() && snapshot.Spec.Retain != nil {
+		r.applyRetention(ep, snapshot, status, logger)
+	}
+	return result, nil
+}
+
+// evaluateSchedule returns the most recent time a scheduled snapshot was due since the last scheduled snapshot,
+// or the zero time if no snapshot is due, and the time that the next snapshot is due.
+func evaluateSchedule(snapshot *coh.CoherenceSnapshot, now time.Time) (time.Time, time.Time, error) {
+	schedule, err := cron.Parse(*snapshot.Spec.Schedule)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	loc, err := snapshot.Spec.GetLocation()
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	now = now.In(loc)
+	from := snapshot.CreationTimestamp.Time
+	if snapshot.Status.LastScheduleTime != nil {
+		from = snapshot.Status.LastScheduleTime.Time
+	}
+
+	var due time.Time
+	if !from.IsZero() {
+		for t := schedule.Next(from.In(loc)); !t.IsZero() && !t.After(now); t = schedule.Next(t) {
+			due = t
+		}
+	}
+	return due, schedule.Next(now), nil
+}
+
+// startSnapshot adds a new pending snapshot to the status.
+func startSnapshot(status *coh.CoherenceSnapshotStatus, name string, t time.Time) *coh.SnapshotStatus {
+	start := metav1.NewTime(t)
+	status.LastScheduleTime = &start
+	status.Snapshots = append(status.Snapshots, coh.SnapshotStatus{
+		Name:      name,
+		Phase:     coh.PersistencePhasePending,
+		StartTime: &start,
+	})
+	return &status.Snapshots[len(status.Snapshots)-1]
+}
+
+// progressSnapshot moves the snapshot of each service on to its next phase: a pending snapshot is created,
+// a created snapshot is archived if required, and the snapshot completes once the service is idle.
+// Returns an error if the services to snapshot could not be determined.
+func progressSnapshot(ep *endpoint, snapshot *coh.CoherenceSnapshot, current *coh.SnapshotStatus, logger logr.Logger) error {
+	if current.Services == nil {
+		services, err := newServiceStatuses(ep, snapshot.Spec.Services)
+		if err != nil {
+			return err
+		}
+		current.Services = services
+	}
+
+	for i := range current.Services {
+		service := &current.Services[i]
+		switch service.Phase {
+		case coh.PersistencePhasePending:
+			logger.Info(fmt.Sprintf("Creating snapshot %s for service %s", current.Name, service.Name))
+			startOperation(service, coh.PersistencePhaseInProgress, func() error {
+				return ep.client.CreateSnapshot(context.TODO(), service.Name, current.Name)
+			})
+		case coh.PersistencePhaseInProgress:
+			data := getIdlePersistence(ep, service)
+			switch {
+			case data == nil:
+				// the snapshot is still being created
+			case !data.HasSnapshot(current.Name):
+				service.Phase = coh.PersistencePhaseFailed
+				service.Message = fmt.Sprintf("snapshot %s was not created", current.Name)
+			case snapshot.Spec.IsArchive():
+				logger.Info(fmt.Sprintf("Archiving snapshot %s for service %s", current.Name, service.Name))
+				startOperation(service, coh.PersistencePhaseArchiving, func() error {
+					return ep.client.ArchiveSnapshot(context.TODO(), service.Name, current.Name)
+				})
+			default:
+				service.Phase = coh.PersistencePhaseCompleted
+			}
+		case coh.PersistencePhaseArchiving:
+			if data := getIdlePersistence(ep, service); data != nil {
+				service.Phase = coh.PersistencePhaseCompleted
+			}
+		}
+	}
+
+	current.Phase = aggregatePhase(current.Services)
+	switch {
+	case current.Phase == coh.PersistencePhaseInProgress && allPending(current.Services):
+		current.Phase = coh.PersistencePhasePending
+	case current.Phase == coh.PersistencePhaseCompleted && snapshot.Spec.Store != nil:
+		current.Phase = coh.PersistencePhaseUploading
+	}
+	return nil
+}
+
+// uploadSnapshot copies the snapshot files of each storage enabled member to the snapshot store.
+// The snapshot completes once the files of every member have been copied.
+func (r *ReconcileCoherenceSnapshot) uploadSnapshot(snapshot *coh.CoherenceSnapshot, current *coh.SnapshotStatus, logger logr.Logger) error {
+	cfg, err := r.archiver.newConfig(snapshot.Namespace, snapshot.Spec.Cluster, current.Name, snapshot.Spec.Store)
+	if err != nil {
+		return err
+	}
+	phase, err := r.archiver.copyFiles(snapshot.Namespace, &current.Members, archive.OperationArchive, cfg, logger)
+	if err != nil {
+		return err
+	}
+	if phase.IsFinished() {
+		current.Phase = phase
+	}
+	return nil
+}
+
+// applyRetention removes the oldest finished snapshots over the number of snapshots to retain.
+// A snapshot is only removed from the status once it has been removed from every service.
+func (r *ReconcileCoherenceSnapshot) applyRetention(ep *endpoint, snapshot *coh.CoherenceSnapshot, status *coh.CoherenceSnapshotStatus, logger logr.Logger) {
+	retain := int(*snapshot.Spec.Retain)
+	finished := 0
+	for _, s := range status.Snapshots {
+		if s.Phase.IsFinished() {
+			finished++
+		}
+	}
+
+	var retained []coh.SnapshotStatus
+	for _, s := range status.Snapshots {
+		if finished <= retain || !s.Phase.IsFinished() {
+			retained = append(retained, s)
+			continue
+		}
+
+		removed, err := r.removeSnapshot(ep, snapshot, s)
+		if err != nil {
+			logger.Error(err, "Failed to remove snapshot "+s.Name)
+		}
+		if !removed {
+			retained = append(retained, s)
+			continue
+		}
+
+		finished--
+		r.events.Event(snapshot, events.Removed, fmt.Sprintf(snapshotRemovedMessage, s.Name, snapshot.Spec.Cluster))
+	}
+	status.Snapshots = retained
+}
+
+// removeSnapshot removes a snapshot and any archive of the snapshot from each service, and any copy of the
+// snapshot in the snapshot store. A snapshot or archive that does not exist is ignored. Returns false if the
+// snapshot has not been removed, for example while the copy in the snapshot store is still being removed.
+func (r *ReconcileCoherenceSnapshot) removeSnapshot(ep *endpoint, snapshot *coh.CoherenceSnapshot, s coh.SnapshotStatus) (bool, error) {
+	for _, service := range s.Services {
+		if service.Phase == coh.PersistencePhasePending {
+			continue
+		}
+		log.Info(fmt.Sprintf("Removing snapshot %s for service %s", s.Name, service.Name))
+		if err := ep.client.RemoveSnapshot(context.TODO(), service.Name, s.Name); err != nil && !mgmt.IsNotFound(err) {
+			return false, err
+		}
+		if snapshot.Spec.IsArchive() {
+			if err := ep.client.RemoveArchivedSnapshot(context.TODO(), service.Name, s.Name); err != nil && !mgmt.IsNotFound(err) {
+				return false, err
+			}
+		}
+	}
+
+	if snapshot.Spec.Store != nil && len(s.Members) > 0 {
+		log.Info(fmt.Sprintf("Removing snapshot %s from the snapshot store", s.Name))
+		cfg, err := r.archiver.newConfig(snapshot.Namespace, snapshot.Spec.Cluster, s.Name, snapshot.Spec.Store)
+		if err != nil {
+			return false, err
+		}
+		return r.archiver.remove(snapshot.Namespace, cfg)
+	}
+	return true, nil
+}
+
+// allPending returns true if the operation has not been started for any service.
+func allPending(services []coh.ServiceOperationStatus) bool {
+	for _, service := range services {
+		if service.Phase != coh.PersistencePhasePending {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,310 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package coherencesnapshot
+
+import (
+	"context"
+	"fmt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	coherence "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/controller/events"
+	stubs "github.com/oracle/coherence-operator/pkg/fakes"
+	mgmt "github.com/oracle/coherence-operator/pkg/management"
+	"io/ioutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/pointer"
+	"net/http"
+	"os"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"time"
+)
+
+var _ = Describe("coherencesnapshot_controller", func() {
+	const snapshotName = "test-snapshot"
+
+	var (
+		mgr        *stubs.FakeManager
+		management *fakeManagement
+		existing   []runtime.Object
+		snapshot   *coherence.CoherenceSnapshot
+		archiveDir string
+		controller *ReconcileCoherenceSnapshot
+		result     reconcile.Result
+		err        error
+	)
+
+	reconcileSnapshot := func() {
+		request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: snapshotName}}
+		result, err = controller.Reconcile(request)
+	}
+
+	getStatus := func() coherence.CoherenceSnapshotStatus {
+		s := &coherence.CoherenceSnapshot{}
+		e := mgr.Client.Get(context.TODO(), types.NamespacedName{Namespace: testNamespace, Name: snapshotName}, s)
+		Expect(e).NotTo(HaveOccurred())
+		return s.Status
+	}
+
+	BeforeEach(func() {
+		management = newFakeManagement("PartitionedCache", "OtherCache")
+		existing = []runtime.Object{newManagedRole(), newReadyPod()}
+		snapshot = &coherence.CoherenceSnapshot{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: snapshotName},
+			Spec:       coherence.CoherenceSnapshotSpec{Cluster: testClusterName},
+		}
+	})
+
+	JustBeforeEach(func() {
+		mgr, err = stubs.NewFakeManager(append(existing, snapshot)...)
+		Expect(err).NotTo(HaveOccurred())
+		controller = newReconciler(mgr)
+		useFakeManagement(management, controller.checker)
+		if archiveDir != "" {
+			useFakeArchiver(controller.archiver, archiveDir)
+		}
+		reconcileSnapshot()
+	})
+
+	AfterEach(func() {
+		management.server.Close()
+	})
+
+	When("a snapshot is created", func() {
+		It("should create the snapshot for each partitioned cache service", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Requeue).To(BeTrue())
+			Expect(management.Requests()).To(ConsistOf(
+				"POST /PartitionedCache/persistence/snapshots/test-snapshot",
+				"POST /OtherCache/persistence/snapshots/test-snapshot"))
+
+			status := getStatus()
+			Expect(status.Phase).To(Equal(coherence.PersistencePhaseInProgress))
+			Expect(status.Snapshots).To(HaveLen(1))
+			Expect(status.Snapshots[0].Name).To(Equal(snapshotName))
+		})
+
+		It("should complete the snapshot once the services are idle", func() {
+			reconcileSnapshot()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Requeue).To(BeFalse())
+
+			status := getStatus()
+			Expect(status.Phase).To(Equal(coherence.PersistencePhaseCompleted))
+			Expect(status.Snapshots[0].CompletionTime).NotTo(BeNil())
+
+			event := mgr.AssertEvent()
+			Expect(event.Type).To(Equal(corev1.EventTypeNormal))
+			Expect(event.Reason).To(Equal(string(events.Completed)))
+		})
+
+		It("should not create the snapshot again", func() {
+			reconcileSnapshot()
+			reconcileSnapshot()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(management.Requests()).To(HaveLen(2))
+		})
+	})
+
+	When("the snapshot is archived", func() {
+		BeforeEach(func() {
+			snapshot.Spec.Archive = pointer.BoolPtr(true)
+			snapshot.Spec.Services = []string{"PartitionedCache"}
+		})
+
+		It("should archive the snapshot once it has been created", func() {
+			reconcileSnapshot()
+			Expect(getStatus().Phase).To(Equal(coherence.PersistencePhaseInProgress))
+			Expect(management.Requests()).To(Equal([]string{
+				"POST /PartitionedCache/persistence/snapshots/test-snapshot",
+				"POST /PartitionedCache/persistence/archives/test-snapshot"}))
+
+			reconcileSnapshot()
+			Expect(getStatus().Phase).To(Equal(coherence.PersistencePhaseCompleted))
+		})
+	})
+
+	When("the snapshot is copied to a snapshot store", func() {
+		var server *stubs.FakeS3Server
+
+		BeforeEach(func() {
+			server = stubs.NewFakeS3Server(testAccessKey, testBucket)
+			archiveDir, err = ioutil.TempDir("", "snapshot")
+			Expect(err).NotTo(HaveOccurred())
+			snapshot.Spec.Services = []string{"PartitionedCache"}
+			snapshot.Spec.Store = newS3Store(server)
+			existing = append(existing, newStoreSecret())
+		})
+
+		AfterEach(func() {
+			server.Close()
+			_ = os.RemoveAll(archiveDir)
+			archiveDir = ""
+		})
+
+		It("should copy the snapshot files of each member once the snapshot has been created", func() {
+			writeSnapshotFile(archiveDir, "PartitionedCache", snapshotName, "partition data")
+
+			reconcileSnapshot()
+			Expect(getStatus().Phase).To(Equal(coherence.PersistencePhaseUploading))
+			waitForArchiver(controller.archiver)
+			reconcileSnapshot()
+			Expect(err).NotTo(HaveOccurred())
+			status := getStatus()
+			Expect(status.Phase).To(Equal(coherence.PersistencePhaseCompleted))
+			Expect(status.Snapshots[0].Members).To(Equal([]coherence.MemberOperationStatus{
+				{Pod: testRoleName + "-0", Phase: coherence.PersistencePhaseCompleted}}))
+
+			key := testClusterName + "/" + snapshotName + "/" + testRoleName + "-0/"
+			Expect(server.Keys(testBucket)).To(ConsistOf(key+"manifest.json", key+"PartitionedCache/"+snapshotName+"/data"))
+		})
+
+		It("should fail the snapshot if the snapshot files cannot be copied", func() {
+			reconcileSnapshot()
+			waitForArchiver(controller.archiver)
+			reconcileSnapshot()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Requeue).To(BeFalse())
+			status := getStatus()
+			Expect(status.Phase).To(Equal(coherence.PersistencePhaseFailed))
+			Expect(status.Message).To(Equal(fmt.Sprintf(uploadFailedMessage, snapshotName, testClusterName, testRoleName+"-0")))
+			Expect(status.Snapshots[0].Members[0].Message).To(ContainSubstring("there are no files for snapshot"))
+		})
+
+		It("should not wait for the snapshot-archiver to finish", func() {
+			release := make(chan struct{})
+			defer close(release)
+			controller.archiver.exec = func(*mgmt.ExecRequest, *rest.Config) (int, string, string, error) {
+				<-release
+				return 0, "", "", nil
+			}
+
+			reconcileSnapshot()
+			reconcileSnapshot()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(pollInterval))
+			status := getStatus()
+			Expect(status.Phase).To(Equal(coherence.PersistencePhaseUploading))
+			Expect(status.Snapshots[0].Members).To(Equal([]coherence.MemberOperationStatus{
+				{Pod: testRoleName + "-0", Phase: coherence.PersistencePhaseInProgress}}))
+		})
+
+		When("the store Secret does not exist", func() {
+			BeforeEach(func() {
+				existing = []runtime.Object{newManagedRole(), newReadyPod()}
+			})
+
+			It("should wait for the Secret", func() {
+				reconcileSnapshot()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(Equal(pollInterval))
+				status := getStatus()
+				Expect(status.Phase).To(Equal(coherence.PersistencePhaseUploading))
+				Expect(status.Message).To(HavePrefix("failed to get the snapshot store Secret " + testSecretName))
+			})
+		})
+	})
+
+	When("the snapshot request is rejected", func() {
+		BeforeEach(func() {
+			management.status = http.StatusBadRequest
+		})
+
+		It("should fail the snapshot", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Requeue).To(BeFalse())
+			status := getStatus()
+			Expect(status.Phase).To(Equal(coherence.PersistencePhaseFailed))
+			Expect(status.Message).To(ContainSubstring("failed to create snapshot test-snapshot"))
+		})
+	})
+
+	When("the cluster does not have management over ReST enabled", func() {
+		BeforeEach(func() {
+			role := newManagedRole()
+			role.Spec.Coherence = nil
+			existing = []runtime.Object{role, newReadyPod()}
+		})
+
+		It("should wait for a management endpoint", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(reconcile.Result{Requeue: true, RequeueAfter: endpointRetry}))
+			status := getStatus()
+			Expect(status.Phase).To(Equal(coherence.PersistencePhasePending))
+			Expect(status.Message).To(Equal(noEndpointMessage(testClusterName)))
+			Expect(management.Requests()).To(BeEmpty())
+		})
+	})
+
+	When("snapshots are scheduled", func() {
+		BeforeEach(func() {
+			snapshot.Spec.Schedule = pointer.StringPtr("0 * * * *")
+			snapshot.Spec.Services = []string{"PartitionedCache"}
+			snapshot.CreationTimestamp = metav1.NewTime(time.Now().Add(-3 * time.Hour))
+		})
+
+		It("should create the most recent snapshot that is due", func() {
+			Expect(err).NotTo(HaveOccurred())
+			due := time.Now().UTC().Truncate(time.Hour)
+			name := snapshotName + "-" + due.Format(snapshotTimeFormat)
+			Expect(management.Requests()).To(Equal([]string{"POST /PartitionedCache/persistence/snapshots/" + name}))
+
+			status := getStatus()
+			Expect(status.Snapshots).To(HaveLen(1))
+			Expect(status.Snapshots[0].Name).To(Equal(name))
+			Expect(status.NextScheduleTime.Time).To(BeTemporally("==", due.Add(time.Hour)))
+		})
+
+		It("should re-queue the request for the next snapshot", func() {
+			reconcileSnapshot()
+			Expect(getStatus().Phase).To(Equal(coherence.PersistencePhaseCompleted))
+			Expect(result.Requeue).To(BeTrue())
+			Expect(result.RequeueAfter).To(BeNumerically("<=", time.Hour))
+		})
+
+		When("snapshots are retained", func() {
+			BeforeEach(func() {
+				snapshot.Spec.Retain = pointer.Int32Ptr(1)
+				snapshot.Status.Snapshots = []coherence.SnapshotStatus{{
+					Name:     "old-snapshot",
+					Phase:    coherence.PersistencePhaseCompleted,
+					Services: []coherence.ServiceOperationStatus{{Name: "PartitionedCache", Phase: coherence.PersistencePhaseCompleted}},
+				}}
+				last := metav1.NewTime(time.Now().Add(-90 * time.Minute))
+				snapshot.Status.LastScheduleTime = &last
+			})
+
+			It("should remove the oldest snapshots once a snapshot completes", func() {
+				reconcileSnapshot()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(management.Requests()).To(ContainElement("DELETE /PartitionedCache/persistence/snapshots/old-snapshot"))
+
+				status := getStatus()
+				Expect(status.Snapshots).To(HaveLen(1))
+				Expect(status.Snapshots[0].Name).NotTo(Equal("old-snapshot"))
+			})
+		})
+	})
+
+	When("the schedule is invalid", func() {
+		BeforeEach(func() {
+			snapshot.Spec.Schedule = pointer.StringPtr("not a schedule")
+		})
+
+		It("should fail the snapshot", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Requeue).To(BeFalse())
+			status := getStatus()
+			Expect(status.Phase).To(Equal(coherence.PersistencePhaseFailed))
+			Expect(status.Message).To(HavePrefix("invalid snapshot schedule"))
+		})
+	})
+})
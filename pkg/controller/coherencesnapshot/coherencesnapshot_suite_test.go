@@ -0,0 +1,203 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package coherencesnapshot
+
+import (
+	"encoding/json"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/reporters"
+	. "github.com/onsi/gomega"
+	coherence "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/archive"
+	"github.com/oracle/coherence-operator/pkg/controller/coherencerole"
+	stubs "github.com/oracle/coherence-operator/pkg/fakes"
+	mgmt "github.com/oracle/coherence-operator/pkg/management"
+	"github.com/oracle/coherence-operator/pkg/resources"
+	"io/ioutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/pointer"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	testNamespace   = "coherence-test"
+	testClusterName = "test-cluster"
+	testRoleName    = testClusterName + "-storage"
+	testSecretName  = "snapshot-store"
+	testAccessKey   = "test-access-key"
+	testBucket      = "snapshots"
+)
+
+func TestCoherenceSnapshotController(t *testing.T) {
+	RegisterFailHandler(Fail)
+	junitReporter := reporters.NewJUnitReporter("test-report.xml")
+	RunSpecsWithDefaultAndCustomReporters(t, "CoherenceSnapshot Controller Suite", []Reporter{junitReporter})
+}
+
+// fakeManagement is a fake Coherence management over ReST server that records the persistence
+// operations invoked and the snapshots of each service.
+type fakeManagement struct {
+	server    *httptest.Server
+	mutex     sync.Mutex
+	services  []string
+	snapshots map[string][]string
+	idle      bool
+	requests  []string
+	status    int
+}
+
+// newFakeManagement starts a fake management server for the specified services.
+func newFakeManagement(services ...string) *fakeManagement {
+	m := &fakeManagement{services: services, snapshots: make(map[string][]string), idle: true, status: http.StatusOK}
+	m.server = httptest.NewServer(http.HandlerFunc(m.handle))
+	return m
+}
+
+func (m *fakeManagement) handle(w http.ResponseWriter, req *http.Request) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	path := strings.TrimPrefix(req.URL.Path, "/management/coherence/cluster/services")
+	if req.Method != http.MethodGet {
+		m.requests = append(m.requests, req.Method+" "+path)
+		if m.status != http.StatusOK {
+			w.WriteHeader(m.status)
+			return
+		}
+	}
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case path == "":
+		data := mgmt.ServicesData{}
+		for _, name := range m.services {
+			data.Items = append(data.Items, mgmt.ServiceData{Name: name, Type: mgmt.DistributedCacheType})
+		}
+		_ = json.NewEncoder(w).Encode(data)
+	case len(parts) == 2 && parts[1] == "persistence":
+		data := mgmt.PersistenceData{Idle: m.idle, Snapshots: m.snapshots[parts[0]]}
+		_ = json.NewEncoder(w).Encode(data)
+	case len(parts) == 4 && parts[2] == "snapshots" && req.Method == http.MethodPost:
+		m.snapshots[parts[0]] = append(m.snapshots[parts[0]], parts[3])
+	}
+}
+
+// Requests returns the persistence operations that have been invoked.
+func (m *fakeManagement) Requests() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]string{}, m.requests...)
+}
+
+// Port returns the port that the fake management server is listening on.
+func (m *fakeManagement) Port() int {
+	u, err := url.Parse(m.server.URL)
+	Expect(err).NotTo(HaveOccurred())
+	port, err := strconv.Atoi(u.Port())
+	Expect(err).NotTo(HaveOccurred())
+	return port
+}
+
+// useFakeManagement configures a ScalableChecker to send management requests to the fake management server.
+func useFakeManagement(m *fakeManagement, checker *coherencerole.ScalableChecker) {
+	checker.SetGetPodHostName(func(pod corev1.Pod) string { return "127.0.0.1" })
+	checker.SetTranslatePort(func(name string, port int) int { return m.Port() })
+}
+
+// useFakeArchiver configures an archiver to run the snapshot-archiver in-process instead of in the Pods,
+// using a sub-directory of the specified directory named after each Pod as the Pod's snapshot directory.
+func useFakeArchiver(a *archiver, dir string) {
+	a.exec = func(req *mgmt.ExecRequest, _ *rest.Config) (int, string, string, error) {
+		cfg := archive.Config{}
+		if err := json.Unmarshal([]byte(strings.Join(req.Arg, "\n")), &cfg); err != nil {
+			return 1, "", err.Error(), nil
+		}
+		cfg.SnapshotDir = filepath.Join(dir, req.Pod)
+		if _, err := archive.Run(req.Command[1], cfg); err != nil {
+			return 1, "", err.Error(), nil
+		}
+		return 0, "", "", nil
+	}
+}
+
+// waitForArchiver waits for the snapshot-archiver runs started by an archiver to finish.
+func waitForArchiver(a *archiver) {
+	a.mutex.Lock()
+	var runs []*archiverRun
+	for _, run := range a.runs {
+		runs = append(runs, run)
+	}
+	a.mutex.Unlock()
+
+	for _, run := range runs {
+		Eventually(run.done).Should(BeClosed())
+	}
+}
+
+// newStoreSecret returns a Secret containing the credentials of an S3 snapshot store.
+func newStoreSecret() *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testSecretName},
+		Data:       map[string][]byte{accessKeyName: []byte(testAccessKey), secretKeyName: []byte("secret")},
+	}
+}
+
+// newS3Store returns a snapshot store that uses the fake S3 server.
+func newS3Store(server *stubs.FakeS3Server) *coherence.SnapshotStoreSpec {
+	return &coherence.SnapshotStoreSpec{
+		S3: &coherence.S3StoreSpec{Endpoint: server.URL(), Bucket: testBucket, SecretName: testSecretName},
+	}
+}
+
+// writeSnapshotFile writes a snapshot file for a service in the snapshot directory of the test Pod.
+func writeSnapshotFile(dir, service, snapshot, content string) {
+	name := filepath.Join(dir, testRoleName+"-0", testClusterName, service, snapshot, "data")
+	Expect(os.MkdirAll(filepath.Dir(name), os.ModePerm)).To(Succeed())
+	Expect(ioutil.WriteFile(name, []byte(content), 0600)).To(Succeed())
+}
+
+// newManagedRole returns a role in the test cluster with management over ReST enabled.
+func newManagedRole() *coherence.CoherenceRole {
+	return &coherence.CoherenceRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      testRoleName,
+			Labels:    map[string]string{coherence.CoherenceClusterLabel: testClusterName},
+		},
+		Spec: coherence.CoherenceRoleSpec{
+			Role: "storage",
+			Coherence: &coherence.CoherenceSpec{
+				Management: &coherence.PortSpecWithSSL{Enabled: pointer.BoolPtr(true)},
+			},
+		},
+	}
+}
+
+// newReadyPod returns a ready Pod in the test role.
+func newReadyPod() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      testRoleName + "-0",
+			Labels:    map[string]string{resources.CoherenceDeploymentLabel: testRoleName},
+		},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
@@ -0,0 +1,9 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+// Package coherencesnapshot contains the Coherence Operator controllers for the CoherenceSnapshot
+// and CoherenceRestore crds.
+package coherencesnapshot
@@ -0,0 +1,158 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package coherencesnapshot
+
+import (
+	"context"
+	"fmt"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/controller/coherencerole"
+	mgmt "github.com/oracle/coherence-operator/pkg/management"
+	"github.com/oracle/coherence-operator/pkg/resources"
+	corev1 "k8s.io/api/core/v1"
+	"net/http"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"time"
+)
+
+const (
+	// The timeout for Coherence management requests made by the persistence controllers.
+	managementTimeout = time.Second * 30
+	// The interval between checks of the progress of persistence operations.
+	pollInterval = time.Second * 5
+	// The interval between attempts to find a Coherence management endpoint for a cluster.
+	endpointRetry = time.Minute
+)
+
+// endpoint is the Coherence management over ReST endpoint of a cluster.
+type endpoint struct {
+	client *mgmt.Client
+}
+
+// findEndpoint finds the Coherence management over ReST endpoint of a ready Pod in one of the cluster's
+// roles that has management over ReST enabled. Returns nil if there is no such Pod.
+func findEndpoint(checker *coherencerole.ScalableChecker, namespace, cluster string) (*endpoint, error) {
+	roles := coh.CoherenceRoleList{}
+	err := checker.Client.List(context.TODO(), &roles, client.InNamespace(namespace), client.MatchingLabels{coh.CoherenceClusterLabel: cluster})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range roles.Items {
+		role := &roles.Items[i]
+		port, enabled := coherencerole.GetManagementPort(role)
+		if !enabled {
+			continue
+		}
+
+		pods := corev1.PodList{}
+		err := checker.Client.List(context.TODO(), &pods, client.InNamespace(namespace), client.MatchingLabels{resources.CoherenceDeploymentLabel: role.Name})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pod := range pods.Items {
+			if pod.Status.Phase == corev1.PodRunning && coherencerole.IsPodReady(pod) {
+				tlsConfig, err := checker.GetManagementTLSConfig(role)
+				if err != nil {
+					return nil, err
+				}
+				return &endpoint{client: checker.NewManagementClient(pod, port, tlsConfig, managementTimeout)}, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// noEndpointMessage returns the status message used while there is no management endpoint for a cluster.
+func noEndpointMessage(cluster string) string {
+	return fmt.Sprintf("waiting for a ready Pod with management over ReST enabled in CoherenceCluster %s", cluster)
+}
+
+// newServiceStatuses returns the initial status of an operation on each of the specified services, or on each of
+// the cluster's partitioned cache services if no services are specified.
+func newServiceStatuses(ep *endpoint, services []string) ([]coh.ServiceOperationStatus, error) {
+	if len(services) == 0 {
+		data, err := ep.client.GetServices(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		found := make(map[string]bool)
+		for _, service := range data.Items {
+			if service.Type == mgmt.DistributedCacheType && !found[service.Name] {
+				found[service.Name] = true
+				services = append(services, service.Name)
+			}
+		}
+	}
+
+	statuses := make([]coh.ServiceOperationStatus, len(services))
+	for i, name := range services {
+		statuses[i] = coh.ServiceOperationStatus{Name: name, Phase: coh.PersistencePhasePending}
+	}
+	return statuses, nil
+}
+
+// startOperation invokes a persistence operation on a service. A rejected request fails the operation,
+// any other error leaves the operation pending so that it is retried.
+func startOperation(service *coh.ServiceOperationStatus, next coh.PersistencePhase, fn func() error) {
+	err := fn()
+	status := mgmt.StatusCode(err)
+	switch {
+	case err == nil:
+		service.Phase = next
+		service.Message = ""
+	case status >= http.StatusBadRequest && status < http.StatusInternalServerError:
+		service.Phase = coh.PersistencePhaseFailed
+		service.Message = err.Error()
+	default:
+		service.Message = err.Error()
+	}
+}
+
+// getIdlePersistence returns the persistence status of a service if it has no persistence operation in progress,
+// otherwise nil is returned and the service's status message is updated.
+func getIdlePersistence(ep *endpoint, service *coh.ServiceOperationStatus) *mgmt.PersistenceData {
+	data, err := ep.client.GetPersistence(context.TODO(), service.Name)
+	if err != nil {
+		service.Message = err.Error()
+		return nil
+	}
+	if !data.Idle {
+		service.Message = data.OperationStatus
+		return nil
+	}
+	service.Message = ""
+	return data
+}
+
+// aggregatePhase returns the phase of an operation on a number of services. The operation has finished once it
+// has finished for every service, and has failed if it failed for any service.
+func aggregatePhase(services []coh.ServiceOperationStatus) coh.PersistencePhase {
+	phase := coh.PersistencePhaseCompleted
+	for _, service := range services {
+		switch {
+		case !service.Phase.IsFinished():
+			return coh.PersistencePhaseInProgress
+		case service.Phase == coh.PersistencePhaseFailed:
+			phase = coh.PersistencePhaseFailed
+		}
+	}
+	return phase
+}
+
+// failedServices returns the names of the services that an operation failed for.
+func failedServices(services []coh.ServiceOperationStatus) []string {
+	var names []string
+	for _, service := range services {
+		if service.Phase == coh.PersistencePhaseFailed {
+			names = append(names, service.Name)
+		}
+	}
+	return names
+}
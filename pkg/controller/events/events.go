@@ -0,0 +1,134 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+// Package events contains the catalogue of the Kubernetes events raised by the Operator's controllers.
+package events
+
+import (
+	"fmt"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// Reason is the reason of an event raised by the Operator. Each reason has a fixed severity so that
+// failures are always raised as Warning events and everything else as Normal events.
+type Reason string
+
+// Resource events.
+const (
+	Created      Reason = "SuccessfulCreate"
+	FailedCreate Reason = "FailedCreate"
+	Updated      Reason = "SuccessfulUpdate"
+	FailedUpdate Reason = "FailedUpdate"
+	Deleted      Reason = "SuccessfulDelete"
+	FailedDelete Reason = "FailedDelete"
+	// SpecReset is raised when a CoherenceRole that was changed directly is reset to match its CoherenceCluster.
+	SpecReset       Reason = "SpecReset"
+	ReconcileFailed Reason = "ReconcileFailed"
+)
+
+// Scaling events.
+const (
+	Scaled      Reason = "SuccessfulScale"
+	FailedScale Reason = "FailedScale"
+	Autoscaled  Reason = "Autoscaled"
+	// AutoscalingMetricsFailed is raised when the autoscaler cannot read the metrics of a role's autoscaling policies.
+	AutoscalingMetricsFailed Reason = "AutoscalingMetricsFailed"
+	// ScheduledScale is raised when the replicas of a role are changed by the role's scaling schedule.
+	ScheduledScale Reason = "ScheduledScale"
+	// ScaleBlockedNotStatusHA is raised when a safe scaling operation is waiting for the cluster to be StatusHA.
+	ScaleBlockedNotStatusHA Reason = "ScaleBlockedNotStatusHA"
+)
+
+// Rolling upgrade events.
+const (
+	UpgradeStarted      Reason = "UpgradeStarted"
+	UpgradePodRestarted Reason = "UpgradePodRestarted"
+	UpgradeCompleted    Reason = "UpgradeCompleted"
+)
+
+// Cluster membership events.
+const (
+	// QuorumWaiting is raised when roles of a cluster are waiting for their start quorum to be met.
+	QuorumWaiting Reason = "QuorumWaiting"
+	QuorumMet     Reason = "QuorumMet"
+	// SplitBrainSuspected is raised when the members of a role report different Coherence cluster sizes.
+	SplitBrainSuspected Reason = "SplitBrainSuspected"
+)
+
+// Persistence snapshot events.
+const (
+	Completed Reason = "Completed"
+	Failed    Reason = "Failed"
+	Removed   Reason = "Removed"
+	// SnapshotSkipped is raised when the snapshot of a deleted role cannot be created because management is disabled.
+	SnapshotSkipped Reason = "SnapshotSkipped"
+)
+
+// The reasons that are raised as Warning events.
+var warnings = map[Reason]bool{
+	FailedCreate:             true,
+	FailedUpdate:             true,
+	FailedDelete:             true,
+	SpecReset:                true,
+	ReconcileFailed:          true,
+	FailedScale:              true,
+	AutoscalingMetricsFailed: true,
+	ScaleBlockedNotStatusHA:  true,
+	SplitBrainSuspected:      true,
+	Failed:                   true,
+	SnapshotSkipped:          true,
+}
+
+// Type returns the event type of the reason, either Warning or Normal.
+func (in Reason) Type() string {
+	if warnings[in] {
+		return corev1.EventTypeWarning
+	}
+	return corev1.EventTypeNormal
+}
+
+// Recorder raises the Operator's events. An event raised for a CoherenceRole is also raised for the role's
+// parent CoherenceCluster so that describing the cluster shows the events of all of its roles.
+type Recorder struct {
+	recorder record.EventRecorder
+}
+
+// NewRecorder creates a Recorder that raises events using the specified EventRecorder.
+func NewRecorder(recorder record.EventRecorder) *Recorder {
+	return &Recorder{recorder: recorder}
+}
+
+// Event raises an event for the object with the severity of the reason.
+func (in *Recorder) Event(object runtime.Object, reason Reason, message string) {
+	in.recorder.Event(object, reason.Type(), string(reason), message)
+	if role, ok := object.(*coh.CoherenceRole); ok {
+		if cluster := parentCluster(role); cluster != nil {
+			in.recorder.Event(cluster, reason.Type(), string(reason), message)
+		}
+	}
+}
+
+// Eventf raises an event for the object with a message created from the format and arguments.
+func (in *Recorder) Eventf(object runtime.Object, reason Reason, format string, args ...interface{}) {
+	in.Event(object, reason, fmt.Sprintf(format, args...))
+}
+
+// parentCluster returns a reference to the CoherenceCluster that controls the role, or nil if the role
+// does not have a controlling CoherenceCluster.
+func parentCluster(role *coh.CoherenceRole) *coh.CoherenceCluster {
+	owner := metav1.GetControllerOf(role)
+	if owner == nil || owner.Kind != "CoherenceCluster" {
+		return nil
+	}
+	return &coh.CoherenceCluster{
+		TypeMeta:   metav1.TypeMeta{APIVersion: owner.APIVersion, Kind: owner.Kind},
+		ObjectMeta: metav1.ObjectMeta{Namespace: role.Namespace, Name: owner.Name, UID: owner.UID},
+	}
+}
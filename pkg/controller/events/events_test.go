@@ -0,0 +1,70 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package events
+
+import (
+	. "github.com/onsi/gomega"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	stubs "github.com/oracle/coherence-operator/pkg/fakes"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"testing"
+)
+
+func TestFailuresShouldBeWarningEvents(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(FailedScale.Type()).To(Equal(corev1.EventTypeWarning))
+	g.Expect(ReconcileFailed.Type()).To(Equal(corev1.EventTypeWarning))
+	g.Expect(ScaleBlockedNotStatusHA.Type()).To(Equal(corev1.EventTypeWarning))
+	g.Expect(Scaled.Type()).To(Equal(corev1.EventTypeNormal))
+	g.Expect(UpgradeCompleted.Type()).To(Equal(corev1.EventTypeNormal))
+	g.Expect(QuorumWaiting.Type()).To(Equal(corev1.EventTypeNormal))
+}
+
+func TestRoleEventShouldAlsoBeRaisedForParentCluster(t *testing.T) {
+	g := NewGomegaWithT(t)
+	fake := stubs.NewFakeEventRecorder(10)
+	recorder := NewRecorder(fake)
+
+	cluster := &coh.CoherenceCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "test-cluster", UID: "1234"}}
+	gvk := schema.GroupVersionKind{Group: "coherence.oracle.com", Version: "v1", Kind: "CoherenceCluster"}
+	role := &coh.CoherenceRole{ObjectMeta: metav1.ObjectMeta{
+		Namespace:       "test",
+		Name:            "test-cluster-storage",
+		OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(cluster, gvk)},
+	}}
+
+	recorder.Eventf(role, FailedScale, "failed to scale %s", role.Name)
+
+	event := <-fake.Events
+	g.Expect(event.Owner).To(Equal(role))
+	g.Expect(event.Type).To(Equal(corev1.EventTypeWarning))
+	g.Expect(event.Reason).To(Equal(string(FailedScale)))
+	g.Expect(event.Message).To(Equal("failed to scale test-cluster-storage"))
+
+	event = <-fake.Events
+	parent, ok := event.Owner.(*coh.CoherenceCluster)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(parent.Name).To(Equal(cluster.Name))
+	g.Expect(parent.Namespace).To(Equal(cluster.Namespace))
+	g.Expect(parent.UID).To(Equal(cluster.UID))
+	g.Expect(parent.Kind).To(Equal("CoherenceCluster"))
+	g.Expect(event.Reason).To(Equal(string(FailedScale)))
+	g.Expect(event.Message).To(Equal("failed to scale test-cluster-storage"))
+	g.Expect(fake.Events).To(BeEmpty())
+}
+
+func TestEventForRoleWithoutParentCluster(t *testing.T) {
+	g := NewGomegaWithT(t)
+	fake := stubs.NewFakeEventRecorder(10)
+	role := &coh.CoherenceRole{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "test-cluster-storage"}}
+
+	NewRecorder(fake).Event(role, Scaled, "scaled")
+
+	g.Expect(fake.Events).To(HaveLen(1))
+}
@@ -0,0 +1,225 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+// The cron package parses standard five field cron expressions and calculates the times that they are due.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	minute  uint64
+	hour    uint64
+	dom     uint64
+	month   uint64
+	dow     uint64
+	domStar bool
+	dowStar bool
+}
+
+// bounds are the valid values of a cron expression field.
+type bounds struct {
+	min   uint
+	max   uint
+	names map[string]uint
+}
+
+var (
+	minutes = bounds{min: 0, max: 59}
+	hours   = bounds{min: 0, max: 23}
+	dom     = bounds{min: 1, max: 31}
+	months  = bounds{min: 1, max: 12, names: map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// day of week allows 7 as well as 0 for Sunday
+	dow = bounds{min: 0, max: 7, names: map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// The predefined schedules that can be used in place of a five field expression.
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// The number of years to search for the next time that a schedule is due, which bounds the
+// search for expressions that can never be due, for example the 30th of February.
+const searchYears = 5
+
+// Parse parses a standard five field cron expression of the form "minute hour day-of-month month day-of-week".
+// Each field may be a '*', a value, a range of values such as "1-5", or a comma separated list of these, each
+// optionally followed by a step such as "*/15". Months and days of the week may also be specified using their
+// three letter English names, for example "MON-FRI". The descriptors @yearly, @annually, @monthly, @weekly,
+// @daily, @midnight and @hourly may be used in place of an expression.
+func Parse(spec string) (*Schedule, error) {
+	expression := strings.TrimSpace(spec)
+	if d, found := descriptors[strings.ToLower(expression)]; found {
+		expression = d
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have five fields but has %d", spec, len(fields))
+	}
+
+	var err error
+	s := &Schedule{}
+	if s.minute, err = parseField(fields[0], minutes); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseField(fields[1], hours); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseField(fields[2], dom); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseField(fields[3], months); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseField(fields[4], dow); err != nil {
+		return nil, err
+	}
+
+	// Sunday may be specified as either 0 or 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = fields[2] == "*" || fields[2] == "?"
+	s.dowStar = fields[4] == "*" || fields[4] == "?"
+
+	return s, nil
+}
+
+// parseField parses a single field of a cron expression returning a bit set of the field's values.
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		var err error
+		var start, end, step uint
+
+		rangeAndStep := strings.Split(part, "/")
+		if len(rangeAndStep) > 2 {
+			return 0, fmt.Errorf("invalid cron field %q", field)
+		}
+
+		step = 1
+		if len(rangeAndStep) == 2 {
+			if step, err = parseValue(rangeAndStep[1], bounds{}); err != nil || step == 0 {
+				return 0, fmt.Errorf("invalid step in cron field %q", field)
+			}
+		}
+
+		startAndEnd := strings.Split(rangeAndStep[0], "-")
+		switch {
+		case rangeAndStep[0] == "*" || rangeAndStep[0] == "?":
+			start, end = b.min, b.max
+		case len(startAndEnd) == 1:
+			if start, err = parseValue(startAndEnd[0], b); err != nil {
+				return 0, fmt.Errorf("invalid value in cron field %q", field)
+			}
+			end = start
+			if len(rangeAndStep) == 2 {
+				// a value with a step such as 5/15 means every step from the value
+				end = b.max
+			}
+		case len(startAndEnd) == 2:
+			if start, err = parseValue(startAndEnd[0], b); err != nil {
+				return 0, fmt.Errorf("invalid range start in cron field %q", field)
+			}
+			if end, err = parseValue(startAndEnd[1], b); err != nil {
+				return 0, fmt.Errorf("invalid range end in cron field %q", field)
+			}
+		default:
+			return 0, fmt.Errorf("invalid range in cron field %q", field)
+		}
+
+		if start < b.min || end > b.max || start > end {
+			return 0, fmt.Errorf("cron field %q is out of the range %d-%d", field, b.min, b.max)
+		}
+
+		for i := start; i <= end; i += step {
+			bits |= 1 << i
+		}
+	}
+	return bits, nil
+}
+
+// parseValue parses a single numeric or named value of a cron field.
+func parseValue(value string, b bounds) (uint, error) {
+	if n, found := b.names[strings.ToLower(value)]; found {
+		return n, nil
+	}
+	n, err := strconv.ParseUint(value, 10, 32)
+	return uint(n), err
+}
+
+// Next returns the first time after the specified time that the schedule is due, in the location of the
+// specified time. If the schedule is not due within the next five years the zero time is returned.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+
+	// start from the next whole minute
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Year() + searchYears
+
+search:
+	for t.Year() <= limit {
+		for s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			if t.Month() == time.January {
+				continue search
+			}
+		}
+
+		for !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			if t.Day() == 1 {
+				continue search
+			}
+		}
+
+		for s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			if t.Hour() == 0 {
+				continue search
+			}
+		}
+
+		for s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			if t.Minute() == 0 {
+				continue search
+			}
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+// dayMatches returns true if the day of the specified time matches the schedule. If both the day of month and
+// day of week are restricted the day matches if either of them match, as with the standard cron implementation.
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package cron
+
+import (
+	. "github.com/onsi/gomega"
+	"testing"
+	"time"
+)
+
+// Wednesday 15th January 2020 10:30 UTC
+var now = time.Date(2020, time.January, 15, 10, 30, 0, 0, time.UTC)
+
+func next(g *GomegaWithT, spec string, t time.Time) time.Time {
+	s, err := Parse(spec)
+	g.Expect(err).NotTo(HaveOccurred())
+	return s.Next(t)
+}
+
+func TestNextEveryMinute(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(next(g, "* * * * *", now)).To(Equal(now.Add(time.Minute)))
+}
+
+func TestNextIsAfterTimeWithSeconds(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(next(g, "* * * * *", now.Add(time.Second*10))).To(Equal(now.Add(time.Minute)))
+}
+
+func TestNextLaterToday(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(next(g, "0 20 * * *", now)).To(Equal(time.Date(2020, time.January, 15, 20, 0, 0, 0, time.UTC)))
+}
+
+func TestNextTomorrow(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(next(g, "0 7 * * *", now)).To(Equal(time.Date(2020, time.January, 16, 7, 0, 0, 0, time.UTC)))
+}
+
+func TestNextWeekdays(t *testing.T) {
+	g := NewGomegaWithT(t)
+	friday := time.Date(2020, time.January, 17, 8, 0, 0, 0, time.UTC)
+	g.Expect(next(g, "0 7 * * MON-FRI", friday)).To(Equal(time.Date(2020, time.January, 20, 7, 0, 0, 0, time.UTC)))
+}
+
+func TestNextSundayAsSeven(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(next(g, "0 0 * * 7", now)).To(Equal(time.Date(2020, time.January, 19, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestNextWithStep(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(next(g, "*/20 * * * *", now)).To(Equal(time.Date(2020, time.January, 15, 10, 40, 0, 0, time.UTC)))
+}
+
+func TestNextWithList(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(next(g, "0 7,20 * * *", now)).To(Equal(time.Date(2020, time.January, 15, 20, 0, 0, 0, time.UTC)))
+}
+
+func TestNextMonthAndDay(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(next(g, "0 0 1 mar *", now)).To(Equal(time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestNextLeapDay(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(next(g, "0 0 29 2 *", now)).To(Equal(time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestNextDayOfMonthOrDayOfWeek(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// the 20th or any Friday, the 17th is the next Friday
+	g.Expect(next(g, "0 0 20 * FRI", now)).To(Equal(time.Date(2020, time.January, 17, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestNextDescriptor(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(next(g, "@daily", now)).To(Equal(time.Date(2020, time.January, 16, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestNextInLocation(t *testing.T) {
+	g := NewGomegaWithT(t)
+	loc := time.FixedZone("test", 2*60*60)
+	g.Expect(next(g, "0 7 * * *", now.In(loc))).To(Equal(time.Date(2020, time.January, 16, 7, 0, 0, 0, loc)))
+}
+
+func TestNextNeverDue(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(next(g, "0 0 30 2 *", now).IsZero()).To(BeTrue())
+}
+
+func TestParseInvalidExpressions(t *testing.T) {
+	g := NewGomegaWithT(t)
+	for _, spec := range []string{"", "* * * *", "* * * * * *", "60 * * * *", "* 24 * * *", "* * 0 * *",
+		"* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "foo * * * *", "* * * * MON-FOO"} {
+		_, err := Parse(spec)
+		g.Expect(err).To(HaveOccurred(), "expected error for %q", spec)
+	}
+}
@@ -8,6 +8,7 @@ package fakes
 
 import (
 	"context"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -154,9 +155,37 @@ func (c *clientWithErrors) Status() client.StatusWriter {
 }
 
 func (c *clientWithErrors) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch.Type() == types.ApplyPatchType {
+		return c.apply(ctx, obj)
+	}
 	return c.wrapped.Patch(ctx, obj, patch, opts...)
 }
 
+// apply emulates a server-side apply, which the controller-runtime fake client does not support,
+// by creating the object if it does not exist or replacing it if it does.
+func (c *clientWithErrors) apply(ctx context.Context, obj runtime.Object) error {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+
+	existing := obj.DeepCopyObject()
+	err = c.Get(ctx, types.NamespacedName{Namespace: m.GetNamespace(), Name: m.GetName()}, existing)
+	switch {
+	case err != nil && errors.IsNotFound(err):
+		return c.Create(ctx, obj)
+	case err != nil:
+		return err
+	}
+
+	em, err := meta.Accessor(existing)
+	if err != nil {
+		return err
+	}
+	m.SetResourceVersion(em.GetResourceVersion())
+	return c.Update(ctx, obj)
+}
+
 func (c *clientWithErrors) DeleteAllOf(ctx context.Context, obj runtime.Object, opts ...client.DeleteAllOfOption) error {
 	return c.wrapped.DeleteAllOf(ctx, obj, opts...)
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/ghodss/yaml"
 	"github.com/operator-framework/operator-sdk/pkg/helm/client"
 	cohv1 "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/resources"
 	"github.com/oracle/coherence-operator/test/e2e/helper"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart/loader"
@@ -21,6 +22,8 @@ import (
 	storagev3 "helm.sh/helm/v3/pkg/storage"
 	driverv3 "helm.sh/helm/v3/pkg/storage/driver"
 	"io"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -31,6 +34,7 @@ import (
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/helm/pkg/chartutil"
 	cpb "k8s.io/helm/pkg/proto/hapi/chart"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"strings"
@@ -43,8 +47,8 @@ var (
 // A fake Helm install
 type FakeHelm interface {
 	// HelmInstallFromCoherenceCluster takes a CoherenceCluster and passes it through
-	// the operator reconciler chain to create a Helm install and returns the result of
-	// the install. The result will contain all of the resources created by the Helm install.
+	// the operator reconciler chain and returns the result. The result will contain
+	// all of the resources created by the role controller for the cluster's roles.
 	HelmInstallFromCoherenceCluster(cluster *cohv1.CoherenceCluster) (*HelmInstallResult, error)
 	// Perform a fake Operator helm install.
 	FakeOperatorHelmInstall(mgr *FakeManager, namespace string, values helper.OperatorValues) (*HelmInstallResult, error)
@@ -117,8 +121,6 @@ func (f *fakeHelm) HelmInstallFromCoherenceCluster(cluster *cohv1.CoherenceClust
 		return nil, err
 	}
 
-	var result *HelmInstallResult
-
 	for _, role := range list.Items {
 		roleRequest := reconcile.Request{
 			NamespacedName: apitypes.NamespacedName{
@@ -131,37 +133,67 @@ func (f *fakeHelm) HelmInstallFromCoherenceCluster(cluster *cohv1.CoherenceClust
 		if err != nil {
 			return nil, err
 		}
+	}
 
-		values, err := f.mgr.AssertCoherenceInternalExists(role.Namespace, role.Name)
-		if err != nil {
-			return nil, err
-		}
+	result, err := f.appliedResources(cluster)
+	if err != nil {
+		return nil, err
+	}
 
-		valuesMap, ok := values.Object["spec"].(map[string]interface{})
-		if !ok {
-			return nil, fmt.Errorf("failed to get spec: expected map[string]interface{}")
-		}
+	err = cl.Delete(context.TODO(), cluster)
+
+	return result, err
+}
+
+// appliedResources obtains the ConfigMaps, Services and StatefulSets applied by the role controller for a cluster.
+func (f *fakeHelm) appliedResources(cluster *cohv1.CoherenceCluster) (*HelmInstallResult, error) {
+	s := f.mgr.GetScheme()
+	result := &HelmInstallResult{
+		resources: make(map[schema.GroupVersionResource]map[string]runtime.Object),
+		mgr:       f.mgr,
+		decoder:   scheme.Codecs.UniversalDecoder(),
+	}
 
-		chartDir, err := helper.FindCoherenceHelmChartDir()
+	lists := []runtime.Object{&corev1.ConfigMapList{}, &corev1.ServiceList{}, &appsv1.StatefulSetList{}}
+	for _, list := range lists {
+		err := f.mgr.GetClient().List(context.TODO(), list, crclient.InNamespace(cluster.Namespace), crclient.MatchingLabels{cohv1.CoherenceClusterLabel: cluster.Name})
 		if err != nil {
 			return nil, err
 		}
 
-		r, err := f.fakeHelmInstall(f.mgr, cluster.GetNamespace(), chartDir, valuesMap)
+		items, err := meta.ExtractList(list)
 		if err != nil {
 			return nil, err
 		}
 
-		if result == nil {
-			result = r
-		} else {
-			result.Merge(r)
+		for _, o := range items {
+			m, err := meta.Accessor(o)
+			if err != nil {
+				return nil, err
+			}
+			// skip resources that do not belong to a role, such as the cluster's WKA Service
+			if _, found := m.GetLabels()[resources.CoherenceDeploymentLabel]; !found {
+				continue
+			}
+			// remove owner references and server set fields
+			m.SetOwnerReferences(nil)
+			m.SetResourceVersion("")
+
+			gvr, err := result.getGVRFromObject(o, s)
+			if err != nil {
+				return nil, err
+			}
+			byName, ok := result.resources[gvr]
+			if !ok {
+				byName = make(map[string]runtime.Object)
+				result.resources[gvr] = byName
+			}
+			byName[m.GetName()] = o
+			result.ordered = append(result.ordered, o)
 		}
 	}
 
-	err = cl.Delete(context.TODO(), cluster)
-
-	return result, err
+	return result, nil
 }
 
 func (f *fakeHelm) FakeOperatorHelmInstall(mgr *FakeManager, namespace string, values helper.OperatorValues) (*HelmInstallResult, error) {
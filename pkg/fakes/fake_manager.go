@@ -11,6 +11,7 @@ import (
 	. "github.com/onsi/gomega"
 	coherence "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
 	"github.com/oracle/coherence-operator/test/e2e/helper"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -38,6 +39,10 @@ func NewFakeManager(initObjs ...runtime.Object) (*FakeManager, error) {
 	s.AddKnownTypes(gv, &coherence.CoherenceClusterList{})
 	s.AddKnownTypes(gv, &coherence.CoherenceRole{})
 	s.AddKnownTypes(gv, &coherence.CoherenceRoleList{})
+	s.AddKnownTypes(gv, &coherence.CoherenceSnapshot{})
+	s.AddKnownTypes(gv, &coherence.CoherenceSnapshotList{})
+	s.AddKnownTypes(gv, &coherence.CoherenceRestore{})
+	s.AddKnownTypes(gv, &coherence.CoherenceRestoreList{})
 
 	gvk := coherence.GetCoherenceInternalGroupVersionKind(s)
 	s.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
@@ -266,6 +271,27 @@ func (f *FakeManager) GetCoherenceInternals(namespace string) unstructured.Unstr
 	return list
 }
 
+// AssertStatefulSetExists asserts that the specified StatefulSet exists in the namespace and returns it
+func (f *FakeManager) AssertStatefulSetExists(namespace, name string) *appsv1.StatefulSet {
+	sts := &appsv1.StatefulSet{}
+	err := f.Client.Get(context.TODO(), apitypes.NamespacedName{Namespace: namespace, Name: name}, sts)
+	Expect(err).NotTo(HaveOccurred())
+	return sts
+}
+
+// AssertStatefulSets asserts that the specified number of StatefulSet resources exist in a namespace
+func (f *FakeManager) AssertStatefulSets(namespace string, count int) {
+	list := f.GetStatefulSets(namespace)
+	Expect(len(list.Items)).To(Equal(count))
+}
+
+// GetStatefulSets obtains the StatefulSets for the specified namespace
+func (f *FakeManager) GetStatefulSets(namespace string) appsv1.StatefulSetList {
+	list := appsv1.StatefulSetList{}
+	_ = f.Client.List(context.TODO(), &list, client.InNamespace(namespace))
+	return list
+}
+
 // AssertWkaService asserts that a headless service to use for WKA exists for a given cluster in a namespace.
 func (f *FakeManager) AssertWkaService(namespace string, cluster *coherence.CoherenceCluster) {
 	service, err := f.GetService(namespace, cluster.GetWkaServiceName())
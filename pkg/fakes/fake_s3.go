@@ -0,0 +1,178 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package fakes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FakeS3Server is a minimal in-memory stand-in for an S3 compatible object store, such as MinIO, that can be
+// used in tests. It supports path style put, get and delete object requests and ListObjectsV2 requests,
+// checks that requests are signed with the expected access key and verifies the SHA-256 checksum of the
+// content of put requests.
+type FakeS3Server struct {
+	// MaxKeys is the maximum number of keys returned by a single list request.
+	MaxKeys   int
+	server    *httptest.Server
+	accessKey string
+	mutex     sync.Mutex
+	buckets   map[string]map[string][]byte
+}
+
+// NewFakeS3Server creates and starts a FakeS3Server that accepts requests signed with the specified access key.
+func NewFakeS3Server(accessKey string, buckets ...string) *FakeS3Server {
+	s := &FakeS3Server{MaxKeys: 1000, accessKey: accessKey, buckets: make(map[string]map[string][]byte)}
+	for _, bucket := range buckets {
+		s.buckets[bucket] = make(map[string][]byte)
+	}
+	s.server = httptest.NewServer(s)
+	return s
+}
+
+// URL returns the endpoint URL of the server.
+func (s *FakeS3Server) URL() string {
+	return s.server.URL
+}
+
+// Close shuts the server down.
+func (s *FakeS3Server) Close() {
+	s.server.Close()
+}
+
+// GetObject returns the content of an object.
+func (s *FakeS3Server) GetObject(bucket, key string) ([]byte, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	data, found := s.buckets[bucket][key]
+	return data, found
+}
+
+// PutObject sets the content of an object, which can be used to simulate corrupted objects.
+func (s *FakeS3Server) PutObject(bucket, key string, data []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.buckets[bucket][key] = data
+}
+
+// Keys returns the sorted keys of the objects in a bucket.
+func (s *FakeS3Server) Keys(bucket string) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var keys []string
+	for key := range s.buckets[bucket] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *FakeS3Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !strings.Contains(req.Header.Get("Authorization"), "Credential="+s.accessKey+"/") {
+		s3Error(w, http.StatusForbidden, "InvalidAccessKeyId")
+		return
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/"), "/", 2)
+	objects, found := s.buckets[parts[0]]
+	if !found {
+		s3Error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	if len(parts) == 1 || parts[1] == "" {
+		if req.Method != http.MethodGet || req.URL.Query().Get("list-type") != "2" {
+			s3Error(w, http.StatusNotImplemented, "NotImplemented")
+			return
+		}
+		s.list(w, req, objects)
+		return
+	}
+
+	key := parts[1]
+	switch req.Method {
+	case http.MethodPut:
+		data, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			s3Error(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		sum := sha256.Sum256(data)
+		if req.Header.Get("x-amz-content-sha256") != hex.EncodeToString(sum[:]) {
+			s3Error(w, http.StatusBadRequest, "XAmzContentSHA256Mismatch")
+			return
+		}
+		objects[key] = data
+	case http.MethodGet:
+		data, found := objects[key]
+		if !found {
+			s3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		_, _ = w.Write(data)
+	case http.MethodDelete:
+		delete(objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s3Error(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+// list responds to a ListObjectsV2 request, using the index of the next key as the continuation token.
+func (s *FakeS3Server) list(w http.ResponseWriter, req *http.Request, objects map[string][]byte) {
+	prefix := req.URL.Query().Get("prefix")
+	var keys []string
+	for key := range objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	start, _ := strconv.Atoi(req.URL.Query().Get("continuation-token"))
+	end := start + s.MaxKeys
+	if end > len(keys) {
+		end = len(keys)
+	}
+
+	type contents struct {
+		Key string `xml:"Key"`
+	}
+	result := struct {
+		XMLName               xml.Name   `xml:"ListBucketResult"`
+		Contents              []contents `xml:"Contents"`
+		IsTruncated           bool       `xml:"IsTruncated"`
+		NextContinuationToken string     `xml:"NextContinuationToken,omitempty"`
+	}{IsTruncated: end < len(keys)}
+	for _, key := range keys[start:end] {
+		result.Contents = append(result.Contents, contents{Key: key})
+	}
+	if result.IsTruncated {
+		result.NextContinuationToken = strconv.Itoa(end)
+	}
+
+	data, _ := xml.Marshal(result)
+	_, _ = w.Write(data)
+}
+
+// s3Error writes an S3 error document.
+func s3Error(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, http.StatusText(status))
+}
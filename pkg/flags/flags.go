@@ -16,12 +16,19 @@ import (
 )
 
 const (
-	zoneLabel = "failure-domain.beta.kubernetes.io/zone"
-	//	regionLabel            = "failure-domain.beta.kubernetes.io/region"
-	DefaultSiteLabel       = zoneLabel
-	DefaultRackLabel       = zoneLabel
-	DefaultRestHost        = "0.0.0.0"
-	DefaultRestPort  int32 = 8000
+	zoneLabel                 = "failure-domain.beta.kubernetes.io/zone"
+	regionLabel               = "failure-domain.beta.kubernetes.io/region"
+	topologyZoneLabel         = "topology.kubernetes.io/zone"
+	topologyRegionLabel       = "topology.kubernetes.io/region"
+	DefaultSiteLabel          = zoneLabel
+	DefaultRackLabel          = zoneLabel
+	DefaultRestHost           = "0.0.0.0"
+	DefaultRestPort     int32 = 8000
+
+	DefaultWebhookPort    int32 = 9443
+	DefaultWebhookCertDir       = "/tmp/k8s-webhook-server/serving-certs"
+
+	DefaultMaxConcurrentReconciles = 1
 
 	// The environment variable holding the default Coherence image name
 	coherenceImageEnv = "HELM_COHERENCE_IMAGE"
@@ -38,8 +45,28 @@ const (
 	FlagAlwaysPullTags = "force-always-pull-tags"
 	FlagCoherenceImage = "coherence-image"
 	FlagUtilsImage     = "utils-image"
+	FlagEnableWebhooks = "enable-webhooks"
+	FlagWebhookPort    = "webhook-port"
+	FlagWebhookCertDir = "webhook-cert-dir"
+	FlagScriptsDir     = "scripts-dir"
+
+	FlagMaxConcurrentReconciles = "max-concurrent-reconciles"
+	FlagHealthProbeAddress      = "health-probe-address"
+	FlagZoneLabels              = "zone-labels"
+	FlagRegionLabels            = "region-labels"
+	FlagRestTLS                 = "rest-tls"
+	FlagRestTLSSecret           = "rest-tls-secret"
+	FlagRestAuthentication      = "rest-authentication"
 )
 
+// DefaultZoneLabels is the default ordered list of node labels used to obtain a node's zone,
+// the current topology label followed by the deprecated failure-domain label.
+var DefaultZoneLabels = []string{topologyZoneLabel, zoneLabel}
+
+// DefaultRegionLabels is the default ordered list of node labels used to obtain a node's region,
+// the current topology label followed by the deprecated failure-domain label.
+var DefaultRegionLabels = []string{topologyRegionLabel, regionLabel}
+
 // The default CRD location
 var defaultCrds string
 
@@ -62,12 +89,38 @@ type CoherenceOperatorFlags struct {
 	SiteLabel string
 	// The label to use to obtain the rack value for a Node.
 	RackLabel string
+	// The ordered list of labels to use to obtain the zone value for a Node, the first label
+	// present on the Node is used. The zone is also used as the site if the site label is not present.
+	ZoneLabels []string
+	// The ordered list of labels to use to obtain the region value for a Node, the first label
+	// present on the Node is used.
+	RegionLabels []string
 	// If any image names in the CoherenceCluster spec end with any suffix in the specified comma-delimited list the imagePullPolicy will be forced to ALWAYS.
 	AlwaysPullSuffixes string
 	// The default Coherence image to use if one is not specified for a role.
 	CoherenceImage string
 	// The default Coherence Utils image to use if one is not specified for a role.
 	CoherenceUtilsImage string
+	// Whether the admission web-hooks are enabled.
+	EnableWebhooks bool
+	// The port that the admission web-hook server binds to.
+	WebhookPort int32
+	// The directory containing the admission web-hook server's tls.crt and tls.key files.
+	WebhookCertDir string
+	// The directory containing the scripts added to each role's scripts ConfigMap.
+	ScriptsDir string
+	// The maximum number of CoherenceClusters and CoherenceRoles that each controller reconciles in parallel.
+	MaxConcurrentReconciles int
+	// The address that the Manager's health probe endpoints bind to, or empty to not serve the health probes.
+	HealthProbeAddress string
+	// Whether the ReST server serves HTTPS.
+	RestTLS bool
+	// The name of the Secret in the Operator's namespace containing the ReST server's tls.crt, tls.key and
+	// optional ca.crt files. If not set a self-signed certificate is generated when TLS is enabled.
+	RestTLSSecret string
+	// Whether ReST requests must be authenticated with the bearer token of a service account in a namespace
+	// managed by the Operator.
+	RestAuthentication bool
 }
 
 // cohf is the struct containing the command line flags.
@@ -111,6 +164,16 @@ func (f *CoherenceOperatorFlags) AddTo(flagSet *pflag.FlagSet, helpTextPrefix ..
 		DefaultRackLabel,
 		strings.Join(append(helpTextPrefix, "The node label to use when obtaining a value for a Pod's Coherence rack."), " "),
 	)
+	flagSet.StringSliceVar(&f.ZoneLabels,
+		FlagZoneLabels,
+		DefaultZoneLabels,
+		strings.Join(append(helpTextPrefix, "The comma-delimited, ordered list of node labels to use when obtaining a value for a node's zone. The zone is used for a Pod's Coherence site if the node does not have the site label."), " "),
+	)
+	flagSet.StringSliceVar(&f.RegionLabels,
+		FlagRegionLabels,
+		DefaultRegionLabels,
+		strings.Join(append(helpTextPrefix, "The comma-delimited, ordered list of node labels to use when obtaining a value for a node's region."), " "),
+	)
 	flagSet.StringVar(&f.AlwaysPullSuffixes,
 		FlagAlwaysPullTags,
 		"",
@@ -130,6 +193,60 @@ func (f *CoherenceOperatorFlags) AddTo(flagSet *pflag.FlagSet, helpTextPrefix ..
 		utilsImg,
 		strings.Join(append(helpTextPrefix, "The Coherence Utils image to use if one is not specified for a role."), " "),
 	)
+	flagSet.BoolVar(&f.EnableWebhooks,
+		FlagEnableWebhooks,
+		false,
+		strings.Join(append(helpTextPrefix, "Enable the admission web-hooks that set defaults in and validate CoherenceCluster and CoherenceRole resources and validate evictions of Coherence Pods."), " "),
+	)
+	flagSet.Int32Var(&f.WebhookPort,
+		FlagWebhookPort,
+		DefaultWebhookPort,
+		strings.Join(append(helpTextPrefix, "The port that the admission web-hook server will bind to"), " "),
+	)
+	flagSet.StringVar(&f.WebhookCertDir,
+		FlagWebhookCertDir,
+		DefaultWebhookCertDir,
+		strings.Join(append(helpTextPrefix, "The directory containing the tls.crt and tls.key files used by the admission web-hook server"), " "),
+	)
+	flagSet.StringVar(&f.ScriptsDir,
+		FlagScriptsDir,
+		f.DefaultScriptsDir(),
+		strings.Join(append(helpTextPrefix, "The directory containing the scripts added to each role's scripts ConfigMap"), " "),
+	)
+	flagSet.IntVar(&f.MaxConcurrentReconciles,
+		FlagMaxConcurrentReconciles,
+		DefaultMaxConcurrentReconciles,
+		strings.Join(append(helpTextPrefix, "The maximum number of different CoherenceClusters and CoherenceRoles that each controller will reconcile in parallel. Requests for the same resource are never reconciled in parallel."), " "),
+	)
+	flagSet.StringVar(&f.HealthProbeAddress,
+		FlagHealthProbeAddress,
+		"",
+		strings.Join(append(helpTextPrefix, "The address that the Manager's /healthz and /readyz endpoints will bind to. The endpoints are not served if not set, the same checks are always available on the ReST server"), " "),
+	)
+	flagSet.BoolVar(&f.RestTLS,
+		FlagRestTLS,
+		false,
+		strings.Join(append(helpTextPrefix, "Serve the ReST endpoints using HTTPS"), " "),
+	)
+	flagSet.StringVar(&f.RestTLSSecret,
+		FlagRestTLSSecret,
+		"",
+		strings.Join(append(helpTextPrefix, "The name of the Secret in the Operator's namespace containing the tls.crt, tls.key and optional ca.crt files used by the ReST server. If not set a self-signed certificate is generated"), " "),
+	)
+	flagSet.BoolVar(&f.RestAuthentication,
+		FlagRestAuthentication,
+		false,
+		strings.Join(append(helpTextPrefix, "Require ReST requests to be authenticated with the token of a service account in a namespace managed by the Operator"), " "),
+	)
+}
+
+// GetMaxConcurrentReconciles returns the maximum number of resources that a controller
+// reconciles in parallel, which is at least one.
+func (f *CoherenceOperatorFlags) GetMaxConcurrentReconciles() int {
+	if f == nil || f.MaxConcurrentReconciles < 1 {
+		return DefaultMaxConcurrentReconciles
+	}
+	return f.MaxConcurrentReconciles
 }
 
 func (f *CoherenceOperatorFlags) DefaultCrdFiles() string {
@@ -153,6 +270,25 @@ func (f *CoherenceOperatorFlags) DefaultCrdFiles() string {
 	return crds
 }
 
+// DefaultScriptsDir returns the default scripts directory, which is
+// the Coherence chart's scripts directory in the Operator image.
+func (f *CoherenceOperatorFlags) DefaultScriptsDir() string {
+	if f == nil {
+		return ""
+	}
+
+	dir := ""
+	u, err := user.Current()
+	if err == nil {
+		s := strings.Join([]string{u.HomeDir, "helm-charts", "coherence", "scripts"}, string(os.PathSeparator))
+		_, err = os.Stat(s)
+		if err == nil {
+			dir = s
+		}
+	}
+	return dir
+}
+
 func GetDefaultCoherenceImage() *string {
 	img, ok := os.LookupEnv(coherenceImageEnv)
 	if ok {
@@ -77,6 +77,33 @@ var _ = Describe("Coherence Operator Flags tests", func() {
 			It("should have negative service port", func() {
 				Expect(cohFlags.CoherenceUtilsImage).To(Equal(dfltUtilsImg))
 			})
+
+			It("should have web-hooks disabled", func() {
+				Expect(cohFlags.EnableWebhooks).To(BeFalse())
+			})
+
+			It("should have the default web-hook port", func() {
+				Expect(cohFlags.WebhookPort).To(Equal(flags.DefaultWebhookPort))
+			})
+
+			It("should have the default zone and region labels", func() {
+				Expect(cohFlags.ZoneLabels).To(Equal(flags.DefaultZoneLabels))
+				Expect(cohFlags.RegionLabels).To(Equal(flags.DefaultRegionLabels))
+			})
+
+			It("should have ReST TLS and authentication disabled", func() {
+				Expect(cohFlags.RestTLS).To(BeFalse())
+				Expect(cohFlags.RestTLSSecret).To(Equal(""))
+				Expect(cohFlags.RestAuthentication).To(BeFalse())
+			})
+
+			It("should have empty health probe address", func() {
+				Expect(cohFlags.HealthProbeAddress).To(Equal(""))
+			})
+
+			It("should have the default max concurrent reconciles", func() {
+				Expect(cohFlags.MaxConcurrentReconciles).To(Equal(flags.DefaultMaxConcurrentReconciles))
+			})
 		})
 
 		When("crd-files set", func() {
@@ -86,16 +113,22 @@ var _ = Describe("Coherence Operator Flags tests", func() {
 				crds := "/test-crds"
 				args = []string{"--crd-files", crds}
 				expected = flags.CoherenceOperatorFlags{
-					CrdFiles:            crds,
-					RestHost:            flags.DefaultRestHost,
-					RestPort:            flags.DefaultRestPort,
-					ServiceName:         "",
-					ServicePort:         -1,
-					SiteLabel:           flags.DefaultSiteLabel,
-					RackLabel:           flags.DefaultRackLabel,
-					AlwaysPullSuffixes:  "",
-					CoherenceImage:      dfltCohImg,
-					CoherenceUtilsImage: dfltUtilsImg,
+					CrdFiles:                crds,
+					RestHost:                flags.DefaultRestHost,
+					RestPort:                flags.DefaultRestPort,
+					ServiceName:             "",
+					ServicePort:             -1,
+					SiteLabel:               flags.DefaultSiteLabel,
+					RackLabel:               flags.DefaultRackLabel,
+					ZoneLabels:              flags.DefaultZoneLabels,
+					RegionLabels:            flags.DefaultRegionLabels,
+					AlwaysPullSuffixes:      "",
+					CoherenceImage:          dfltCohImg,
+					CoherenceUtilsImage:     dfltUtilsImg,
+					WebhookPort:             flags.DefaultWebhookPort,
+					WebhookCertDir:          flags.DefaultWebhookCertDir,
+					ScriptsDir:              cohFlags.DefaultScriptsDir(),
+					MaxConcurrentReconciles: flags.DefaultMaxConcurrentReconciles,
 				}
 			})
 
@@ -111,16 +144,22 @@ var _ = Describe("Coherence Operator Flags tests", func() {
 				restHost := "10.10.123.0"
 				args = []string{"--rest-host", restHost}
 				expected = flags.CoherenceOperatorFlags{
-					CrdFiles:            cohFlags.DefaultCrdFiles(),
-					RestHost:            restHost,
-					RestPort:            flags.DefaultRestPort,
-					ServiceName:         "",
-					ServicePort:         -1,
-					SiteLabel:           flags.DefaultSiteLabel,
-					RackLabel:           flags.DefaultRackLabel,
-					AlwaysPullSuffixes:  "",
-					CoherenceImage:      dfltCohImg,
-					CoherenceUtilsImage: dfltUtilsImg,
+					CrdFiles:                cohFlags.DefaultCrdFiles(),
+					RestHost:                restHost,
+					RestPort:                flags.DefaultRestPort,
+					ServiceName:             "",
+					ServicePort:             -1,
+					SiteLabel:               flags.DefaultSiteLabel,
+					RackLabel:               flags.DefaultRackLabel,
+					ZoneLabels:              flags.DefaultZoneLabels,
+					RegionLabels:            flags.DefaultRegionLabels,
+					AlwaysPullSuffixes:      "",
+					CoherenceImage:          dfltCohImg,
+					CoherenceUtilsImage:     dfltUtilsImg,
+					WebhookPort:             flags.DefaultWebhookPort,
+					WebhookCertDir:          flags.DefaultWebhookCertDir,
+					ScriptsDir:              cohFlags.DefaultScriptsDir(),
+					MaxConcurrentReconciles: flags.DefaultMaxConcurrentReconciles,
 				}
 			})
 
@@ -135,16 +174,22 @@ var _ = Describe("Coherence Operator Flags tests", func() {
 			BeforeEach(func() {
 				args = []string{"--rest-port", "9000"}
 				expected = flags.CoherenceOperatorFlags{
-					CrdFiles:            cohFlags.DefaultCrdFiles(),
-					RestHost:            flags.DefaultRestHost,
-					RestPort:            9000,
-					ServiceName:         "",
-					ServicePort:         -1,
-					SiteLabel:           flags.DefaultSiteLabel,
-					RackLabel:           flags.DefaultRackLabel,
-					AlwaysPullSuffixes:  "",
-					CoherenceImage:      dfltCohImg,
-					CoherenceUtilsImage: dfltUtilsImg,
+					CrdFiles:                cohFlags.DefaultCrdFiles(),
+					RestHost:                flags.DefaultRestHost,
+					RestPort:                9000,
+					ServiceName:             "",
+					ServicePort:             -1,
+					SiteLabel:               flags.DefaultSiteLabel,
+					RackLabel:               flags.DefaultRackLabel,
+					ZoneLabels:              flags.DefaultZoneLabels,
+					RegionLabels:            flags.DefaultRegionLabels,
+					AlwaysPullSuffixes:      "",
+					CoherenceImage:          dfltCohImg,
+					CoherenceUtilsImage:     dfltUtilsImg,
+					WebhookPort:             flags.DefaultWebhookPort,
+					WebhookCertDir:          flags.DefaultWebhookCertDir,
+					ScriptsDir:              cohFlags.DefaultScriptsDir(),
+					MaxConcurrentReconciles: flags.DefaultMaxConcurrentReconciles,
 				}
 			})
 
@@ -159,16 +204,22 @@ var _ = Describe("Coherence Operator Flags tests", func() {
 			BeforeEach(func() {
 				args = []string{"--service-name", "foo.com"}
 				expected = flags.CoherenceOperatorFlags{
-					CrdFiles:            cohFlags.DefaultCrdFiles(),
-					RestHost:            flags.DefaultRestHost,
-					RestPort:            flags.DefaultRestPort,
-					ServiceName:         "foo.com",
-					ServicePort:         -1,
-					SiteLabel:           flags.DefaultSiteLabel,
-					RackLabel:           flags.DefaultRackLabel,
-					AlwaysPullSuffixes:  "",
-					CoherenceImage:      dfltCohImg,
-					CoherenceUtilsImage: dfltUtilsImg,
+					CrdFiles:                cohFlags.DefaultCrdFiles(),
+					RestHost:                flags.DefaultRestHost,
+					RestPort:                flags.DefaultRestPort,
+					ServiceName:             "foo.com",
+					ServicePort:             -1,
+					SiteLabel:               flags.DefaultSiteLabel,
+					RackLabel:               flags.DefaultRackLabel,
+					ZoneLabels:              flags.DefaultZoneLabels,
+					RegionLabels:            flags.DefaultRegionLabels,
+					AlwaysPullSuffixes:      "",
+					CoherenceImage:          dfltCohImg,
+					CoherenceUtilsImage:     dfltUtilsImg,
+					WebhookPort:             flags.DefaultWebhookPort,
+					WebhookCertDir:          flags.DefaultWebhookCertDir,
+					ScriptsDir:              cohFlags.DefaultScriptsDir(),
+					MaxConcurrentReconciles: flags.DefaultMaxConcurrentReconciles,
 				}
 			})
 
@@ -183,16 +234,22 @@ var _ = Describe("Coherence Operator Flags tests", func() {
 			BeforeEach(func() {
 				args = []string{"--service-port", "80"}
 				expected = flags.CoherenceOperatorFlags{
-					CrdFiles:            cohFlags.DefaultCrdFiles(),
-					RestHost:            flags.DefaultRestHost,
-					RestPort:            flags.DefaultRestPort,
-					ServiceName:         "",
-					ServicePort:         80,
-					SiteLabel:           flags.DefaultSiteLabel,
-					RackLabel:           flags.DefaultRackLabel,
-					AlwaysPullSuffixes:  "",
-					CoherenceImage:      dfltCohImg,
-					CoherenceUtilsImage: dfltUtilsImg,
+					CrdFiles:                cohFlags.DefaultCrdFiles(),
+					RestHost:                flags.DefaultRestHost,
+					RestPort:                flags.DefaultRestPort,
+					ServiceName:             "",
+					ServicePort:             80,
+					SiteLabel:               flags.DefaultSiteLabel,
+					RackLabel:               flags.DefaultRackLabel,
+					ZoneLabels:              flags.DefaultZoneLabels,
+					RegionLabels:            flags.DefaultRegionLabels,
+					AlwaysPullSuffixes:      "",
+					CoherenceImage:          dfltCohImg,
+					CoherenceUtilsImage:     dfltUtilsImg,
+					WebhookPort:             flags.DefaultWebhookPort,
+					WebhookCertDir:          flags.DefaultWebhookCertDir,
+					ScriptsDir:              cohFlags.DefaultScriptsDir(),
+					MaxConcurrentReconciles: flags.DefaultMaxConcurrentReconciles,
 				}
 			})
 
@@ -207,16 +264,22 @@ var _ = Describe("Coherence Operator Flags tests", func() {
 			BeforeEach(func() {
 				args = []string{"--site-label", "foo"}
 				expected = flags.CoherenceOperatorFlags{
-					CrdFiles:            cohFlags.DefaultCrdFiles(),
-					RestHost:            flags.DefaultRestHost,
-					RestPort:            flags.DefaultRestPort,
-					ServiceName:         "",
-					ServicePort:         -1,
-					SiteLabel:           "foo",
-					RackLabel:           flags.DefaultRackLabel,
-					AlwaysPullSuffixes:  "",
-					CoherenceImage:      dfltCohImg,
-					CoherenceUtilsImage: dfltUtilsImg,
+					CrdFiles:                cohFlags.DefaultCrdFiles(),
+					RestHost:                flags.DefaultRestHost,
+					RestPort:                flags.DefaultRestPort,
+					ServiceName:             "",
+					ServicePort:             -1,
+					SiteLabel:               "foo",
+					RackLabel:               flags.DefaultRackLabel,
+					ZoneLabels:              flags.DefaultZoneLabels,
+					RegionLabels:            flags.DefaultRegionLabels,
+					AlwaysPullSuffixes:      "",
+					CoherenceImage:          dfltCohImg,
+					CoherenceUtilsImage:     dfltUtilsImg,
+					WebhookPort:             flags.DefaultWebhookPort,
+					WebhookCertDir:          flags.DefaultWebhookCertDir,
+					ScriptsDir:              cohFlags.DefaultScriptsDir(),
+					MaxConcurrentReconciles: flags.DefaultMaxConcurrentReconciles,
 				}
 			})
 
@@ -231,16 +294,22 @@ var _ = Describe("Coherence Operator Flags tests", func() {
 			BeforeEach(func() {
 				args = []string{"--rack-label", "foo"}
 				expected = flags.CoherenceOperatorFlags{
-					CrdFiles:            cohFlags.DefaultCrdFiles(),
-					RestHost:            flags.DefaultRestHost,
-					RestPort:            flags.DefaultRestPort,
-					ServiceName:         "",
-					ServicePort:         -1,
-					SiteLabel:           flags.DefaultSiteLabel,
-					RackLabel:           "foo",
-					AlwaysPullSuffixes:  "",
-					CoherenceImage:      dfltCohImg,
-					CoherenceUtilsImage: dfltUtilsImg,
+					CrdFiles:                cohFlags.DefaultCrdFiles(),
+					RestHost:                flags.DefaultRestHost,
+					RestPort:                flags.DefaultRestPort,
+					ServiceName:             "",
+					ServicePort:             -1,
+					SiteLabel:               flags.DefaultSiteLabel,
+					RackLabel:               "foo",
+					ZoneLabels:              flags.DefaultZoneLabels,
+					RegionLabels:            flags.DefaultRegionLabels,
+					AlwaysPullSuffixes:      "",
+					CoherenceImage:          dfltCohImg,
+					CoherenceUtilsImage:     dfltUtilsImg,
+					WebhookPort:             flags.DefaultWebhookPort,
+					WebhookCertDir:          flags.DefaultWebhookCertDir,
+					ScriptsDir:              cohFlags.DefaultScriptsDir(),
+					MaxConcurrentReconciles: flags.DefaultMaxConcurrentReconciles,
 				}
 			})
 
@@ -255,16 +324,53 @@ var _ = Describe("Coherence Operator Flags tests", func() {
 			BeforeEach(func() {
 				args = []string{"--force-always-pull-tags", "-ci,latest"}
 				expected = flags.CoherenceOperatorFlags{
-					CrdFiles:            cohFlags.DefaultCrdFiles(),
-					RestHost:            flags.DefaultRestHost,
-					RestPort:            flags.DefaultRestPort,
-					ServiceName:         "",
-					ServicePort:         -1,
-					SiteLabel:           flags.DefaultSiteLabel,
-					RackLabel:           flags.DefaultRackLabel,
-					AlwaysPullSuffixes:  "-ci,latest",
-					CoherenceImage:      dfltCohImg,
-					CoherenceUtilsImage: dfltUtilsImg,
+					CrdFiles:                cohFlags.DefaultCrdFiles(),
+					RestHost:                flags.DefaultRestHost,
+					RestPort:                flags.DefaultRestPort,
+					ServiceName:             "",
+					ServicePort:             -1,
+					SiteLabel:               flags.DefaultSiteLabel,
+					RackLabel:               flags.DefaultRackLabel,
+					ZoneLabels:              flags.DefaultZoneLabels,
+					RegionLabels:            flags.DefaultRegionLabels,
+					AlwaysPullSuffixes:      "-ci,latest",
+					CoherenceImage:          dfltCohImg,
+					CoherenceUtilsImage:     dfltUtilsImg,
+					WebhookPort:             flags.DefaultWebhookPort,
+					WebhookCertDir:          flags.DefaultWebhookCertDir,
+					ScriptsDir:              cohFlags.DefaultScriptsDir(),
+					MaxConcurrentReconciles: flags.DefaultMaxConcurrentReconciles,
+				}
+			})
+
+			It("should have the correct flags", func() {
+				Expect(reflect.DeepEqual(cohFlags, expected)).To(BeTrue())
+			})
+		})
+
+		When("enable-webhooks set", func() {
+			var expected flags.CoherenceOperatorFlags
+
+			BeforeEach(func() {
+				args = []string{"--enable-webhooks", "--webhook-port", "8443", "--webhook-cert-dir", "/certs"}
+				expected = flags.CoherenceOperatorFlags{
+					CrdFiles:                cohFlags.DefaultCrdFiles(),
+					RestHost:                flags.DefaultRestHost,
+					RestPort:                flags.DefaultRestPort,
+					ServiceName:             "",
+					ServicePort:             -1,
+					SiteLabel:               flags.DefaultSiteLabel,
+					RackLabel:               flags.DefaultRackLabel,
+					ZoneLabels:              flags.DefaultZoneLabels,
+					RegionLabels:            flags.DefaultRegionLabels,
+					AlwaysPullSuffixes:      "",
+					CoherenceImage:          dfltCohImg,
+					CoherenceUtilsImage:     dfltUtilsImg,
+					EnableWebhooks:          true,
+					WebhookPort:             8443,
+					WebhookCertDir:          "/certs",
+					ScriptsDir:              cohFlags.DefaultScriptsDir(),
+					MaxConcurrentReconciles: flags.DefaultMaxConcurrentReconciles,
 				}
 			})
 
@@ -272,5 +378,49 @@ var _ = Describe("Coherence Operator Flags tests", func() {
 				Expect(reflect.DeepEqual(cohFlags, expected)).To(BeTrue())
 			})
 		})
+
+		When("max-concurrent-reconciles set", func() {
+			BeforeEach(func() {
+				args = []string{"--max-concurrent-reconciles", "4"}
+			})
+
+			It("should have the correct max concurrent reconciles", func() {
+				Expect(cohFlags.MaxConcurrentReconciles).To(Equal(4))
+				Expect(cohFlags.GetMaxConcurrentReconciles()).To(Equal(4))
+			})
+		})
+
+		When("max-concurrent-reconciles is less than one", func() {
+			BeforeEach(func() {
+				args = []string{"--max-concurrent-reconciles", "0"}
+			})
+
+			It("should reconcile one resource at a time", func() {
+				Expect(cohFlags.GetMaxConcurrentReconciles()).To(Equal(1))
+			})
+		})
+
+		When("zone-labels and region-labels set", func() {
+			BeforeEach(func() {
+				args = []string{"--zone-labels", "foo,bar", "--region-labels", "baz"}
+			})
+
+			It("should have the correct zone and region labels", func() {
+				Expect(cohFlags.ZoneLabels).To(Equal([]string{"foo", "bar"}))
+				Expect(cohFlags.RegionLabels).To(Equal([]string{"baz"}))
+			})
+		})
+
+		When("rest-tls, rest-tls-secret and rest-authentication set", func() {
+			BeforeEach(func() {
+				args = []string{"--rest-tls", "--rest-tls-secret", "operator-tls", "--rest-authentication"}
+			})
+
+			It("should have ReST TLS and authentication enabled", func() {
+				Expect(cohFlags.RestTLS).To(BeTrue())
+				Expect(cohFlags.RestTLSSecret).To(Equal("operator-tls"))
+				Expect(cohFlags.RestAuthentication).To(BeTrue())
+			})
+		})
 	})
 })
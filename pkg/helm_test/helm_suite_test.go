@@ -6,7 +6,7 @@
 
 // The helm_test package contains tests that take a CoherenceCluster and
 // pass it through the operator controllers to verify that the resulting
-// kubernetes resources generated by the Helm install are correct.
+// kubernetes resources generated for the roles are correct.
 package helm_test
 
 import (
@@ -18,6 +18,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"path/filepath"
 
 	stubs "github.com/oracle/coherence-operator/pkg/fakes"
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
@@ -34,7 +35,7 @@ const (
 )
 
 // Use the specified yaml files to create a CoherenceCluster and trigger a fake end-to-end
-// reconcile to obtain the resources that would have been created by the Helm operator.
+// reconcile to obtain the resources that would have been created by the role controller.
 func CreateCluster(yamlFile string) (*stubs.HelmInstallResult, *cohv1.CoherenceCluster, error) {
 	namespace := "test-namespace"
 	cluster, err := helper.NewCoherenceClusterFromYaml(namespace, yamlFile)
@@ -47,7 +48,12 @@ func CreateCluster(yamlFile string) (*stubs.HelmInstallResult, *cohv1.CoherenceC
 		return nil, nil, err
 	}
 
-	opFlags := &flags.CoherenceOperatorFlags{}
+	root, err := helper.FindProjectRootDir()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opFlags := &flags.CoherenceOperatorFlags{ScriptsDir: filepath.Join(root, "helm-charts", "coherence", "scripts")}
 	cr := coherencecluster.NewClusterReconciler(mgr, opFlags)
 	// skip initialization for unit tests
 	cr.SetInitialized(true)
@@ -0,0 +1,402 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package management
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// The path of the Coherence management over ReST cluster resource.
+	clusterPath = "/management/coherence/cluster"
+
+	// The maximum number of attempts made for a query that fails to connect.
+	maxQueryAttempts = 5
+	// The interval between attempts of a query that fails to connect.
+	queryRetryInterval = time.Second
+
+	// The relation of the link to the next page of a Coherence management collection.
+	nextLinkRel = "next"
+)
+
+// Client is a client for the Coherence management over ReST endpoint of a Coherence member.
+// Queries that fail to connect are retried, collections are read by following the "next" link
+// of each page and every request is cancelled when its context is done.
+type Client struct {
+	http    *http.Client
+	baseURL string
+}
+
+// NewClient creates a Client for the Coherence management over ReST endpoint at the specified
+// base URL, for example https://my-cluster-storage-0:30000, that uses the specified http.Client.
+func NewClient(cl *http.Client, baseURL string) *Client {
+	return &Client{http: cl, baseURL: baseURL + clusterPath}
+}
+
+// NewHTTPClient creates an http.Client with the specified timeout for Coherence management requests.
+// If the TLS configuration is not nil the client uses it for https requests.
+func NewHTTPClient(timeout time.Duration, tlsConfig *tls.Config) *http.Client {
+	cl := &http.Client{Timeout: timeout}
+	if tlsConfig != nil {
+		cl.Transport = &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsConfig}
+	}
+	return cl
+}
+
+// EndpointURL returns the base URL of the Coherence management over ReST endpoint on the specified host and port.
+func EndpointURL(host string, port int32, secure bool) string {
+	scheme := "http"
+	if secure {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s:%d", scheme, host, port)
+}
+
+// StatusError is the error returned when Coherence management over ReST responds to a request
+// with a status other than 200.
+type StatusError struct {
+	// The description of the request.
+	Operation string
+	// The http response status.
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s returned status %d", e.Operation, e.StatusCode)
+}
+
+// StatusCode returns the http response status of a Coherence management request from the error
+// that the request returned: 200 if there was no error, the status of a StatusError or 500 for
+// any other error.
+func StatusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	if e, ok := err.(*StatusError); ok {
+		return e.StatusCode
+	}
+	return http.StatusInternalServerError
+}
+
+// IsNotFound returns true if the error is a StatusError with a 404 status.
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+// ----- cluster and members ------------------------------------------------
+
+// GetCluster returns the cluster's details.
+func (c *Client) GetCluster(ctx context.Context) (*ClusterData, error) {
+	data := &ClusterData{}
+	return data, c.get(ctx, "cluster query", c.url(""), data)
+}
+
+// GetMembers returns the details of every member of the cluster.
+func (c *Client) GetMembers(ctx context.Context) (*MembersData, error) {
+	data := &MembersData{}
+	return data, c.getCollection(ctx, "members query", c.url("/members"), data)
+}
+
+// GetMember returns the details of a member, identified by its node id or member name.
+func (c *Client) GetMember(ctx context.Context, member string) (*MemberData, error) {
+	data := &MemberData{}
+	return data, c.get(ctx, "member query for member "+member, c.url("/members", member), data)
+}
+
+// ShutdownMember shuts down the clustered services of a member, identified by its node id or member name.
+func (c *Client) ShutdownMember(ctx context.Context, member string) error {
+	return c.post(ctx, "shutdown of member "+member, c.url("/members", member, "shutdown"), nil)
+}
+
+// SetLoggingLevel sets the Coherence logging level, from 0 to 9, of a member identified by its node id or member name.
+func (c *Client) SetLoggingLevel(ctx context.Context, member string, level int) error {
+	if level < 0 || level > 9 {
+		return fmt.Errorf("invalid logging level %d, the logging level must be between 0 and 9", level)
+	}
+	body := map[string]interface{}{"loggingLevel": level}
+	return c.post(ctx, fmt.Sprintf("set logging level %d for member %s", level, member), c.url("/members", member), body)
+}
+
+// ----- services -----------------------------------------------------------
+
+// GetServices returns the details of every service in the cluster.
+func (c *Client) GetServices(ctx context.Context) (*ServicesData, error) {
+	data := &ServicesData{}
+	return data, c.getCollection(ctx, "services query", c.url("/services"), data)
+}
+
+// GetService returns the details of a service.
+func (c *Client) GetService(ctx context.Context, service string) (*ServiceData, error) {
+	data := &ServiceData{}
+	return data, c.get(ctx, "service query for service "+service, c.url("/services", service), data)
+}
+
+// GetServiceMembers returns the details of every member running a service.
+func (c *Client) GetServiceMembers(ctx context.Context, service string) (*ServiceMembersData, error) {
+	data := &ServiceMembersData{}
+	return data, c.getCollection(ctx, "service members query for service "+service, c.url("/services", service, "members"), data)
+}
+
+// GetPartitionAssignment returns the partition assignment of a partitioned cache service.
+func (c *Client) GetPartitionAssignment(ctx context.Context, service string) (*PartitionData, error) {
+	data := &PartitionData{}
+	return data, c.get(ctx, "partition assignment query for service "+service, c.url("/services", service, "partition"), data)
+}
+
+// StartServiceMember starts a service on a member, identified by its node id or member name.
+func (c *Client) StartServiceMember(ctx context.Context, service, member string) error {
+	return c.post(ctx, fmt.Sprintf("start of service %s on member %s", service, member), c.url("/services", service, "members", member, "start"), nil)
+}
+
+// StopServiceMember stops a service on a member, identified by its node id or member name.
+func (c *Client) StopServiceMember(ctx context.Context, service, member string) error {
+	return c.post(ctx, fmt.Sprintf("stop of service %s on member %s", service, member), c.url("/services", service, "members", member, "stop"), nil)
+}
+
+// RestartServiceMember restarts a service on a member by stopping and then starting the service.
+func (c *Client) RestartServiceMember(ctx context.Context, service, member string) error {
+	if err := c.StopServiceMember(ctx, service, member); err != nil {
+		return err
+	}
+	return c.StartServiceMember(ctx, service, member)
+}
+
+// ----- caches -------------------------------------------------------------
+
+// GetCaches returns the details of every cache in the cluster.
+func (c *Client) GetCaches(ctx context.Context) (*CachesData, error) {
+	data := &CachesData{}
+	return data, c.getCollection(ctx, "caches query", c.url("/caches"), data)
+}
+
+// GetCache returns the details of a cache, aggregated across the members storing the cache.
+// If the service name is not empty only the cache in that service is queried.
+func (c *Client) GetCache(ctx context.Context, service, cache string) (*CacheData, error) {
+	data := &CacheData{}
+	return data, c.get(ctx, "cache query for cache "+cache, c.cacheURL(service, cache), data)
+}
+
+// GetCacheMembers returns the details of a cache on each member storing the cache.
+// If the service name is not empty only the cache in that service is queried.
+func (c *Client) GetCacheMembers(ctx context.Context, service, cache string) (*CacheMembersData, error) {
+	data := &CacheMembersData{}
+	return data, c.getCollection(ctx, "cache members query for cache "+cache, c.cacheURL(service, cache)+"/members", data)
+}
+
+// ----- persistence --------------------------------------------------------
+
+// GetPersistence returns the persistence status of a partitioned cache service.
+func (c *Client) GetPersistence(ctx context.Context, service string) (*PersistenceData, error) {
+	data := &PersistenceData{}
+	return data, c.get(ctx, "persistence query for service "+service, c.url("/services", service, "persistence"), data)
+}
+
+// CreateSnapshot creates a persistence snapshot with the specified name for a service.
+func (c *Client) CreateSnapshot(ctx context.Context, service, name string) error {
+	return c.post(ctx, fmt.Sprintf("create snapshot %s for service %s", name, service), c.snapshotURL(service, "snapshots", name), nil)
+}
+
+// RemoveSnapshot removes the persistence snapshot with the specified name from a service.
+func (c *Client) RemoveSnapshot(ctx context.Context, service, name string) error {
+	return c.delete(ctx, fmt.Sprintf("remove snapshot %s for service %s", name, service), c.snapshotURL(service, "snapshots", name))
+}
+
+// RecoverSnapshot recovers a service from the persistence snapshot with the specified name.
+func (c *Client) RecoverSnapshot(ctx context.Context, service, name string) error {
+	return c.post(ctx, fmt.Sprintf("recover snapshot %s for service %s", name, service), c.snapshotURL(service, "snapshots", name)+"/recover", nil)
+}
+
+// GetArchivedSnapshots returns the names of the archived persistence snapshots of a service.
+func (c *Client) GetArchivedSnapshots(ctx context.Context, service string) ([]string, error) {
+	data := &ArchivesData{}
+	err := c.get(ctx, "archived snapshots query for service "+service, c.url("/services", service, "persistence", "archives"), data)
+	return data.Archives, err
+}
+
+// ArchiveSnapshot archives the persistence snapshot with the specified name for a service.
+func (c *Client) ArchiveSnapshot(ctx context.Context, service, name string) error {
+	return c.post(ctx, fmt.Sprintf("archive snapshot %s for service %s", name, service), c.snapshotURL(service, "archives", name), nil)
+}
+
+// RetrieveArchivedSnapshot retrieves the archived persistence snapshot with the specified name for a service,
+// so that the snapshot can be recovered.
+func (c *Client) RetrieveArchivedSnapshot(ctx context.Context, service, name string) error {
+	return c.post(ctx, fmt.Sprintf("retrieve archived snapshot %s for service %s", name, service), c.snapshotURL(service, "archives", name)+"/retrieve", nil)
+}
+
+// RemoveArchivedSnapshot removes the archived persistence snapshot with the specified name for a service.
+func (c *Client) RemoveArchivedSnapshot(ctx context.Context, service, name string) error {
+	return c.delete(ctx, fmt.Sprintf("remove archived snapshot %s for service %s", name, service), c.snapshotURL(service, "archives", name))
+}
+
+// ----- reporter -----------------------------------------------------------
+
+// GetReporters returns the status of the Coherence reporter on every member.
+func (c *Client) GetReporters(ctx context.Context) (*ReportersData, error) {
+	data := &ReportersData{}
+	return data, c.getCollection(ctx, "reporters query", c.url("/reporters"), data)
+}
+
+// GetReporter returns the status of the Coherence reporter on a member, identified by its node id or member name.
+func (c *Client) GetReporter(ctx context.Context, member string) (*ReporterData, error) {
+	data := &ReporterData{}
+	return data, c.get(ctx, "reporter query for member "+member, c.url("/reporters", member), data)
+}
+
+// StartReporter starts the Coherence reporter on a member, identified by its node id or member name.
+func (c *Client) StartReporter(ctx context.Context, member string) error {
+	return c.post(ctx, "start of reporter on member "+member, c.url("/reporters", member, "start"), nil)
+}
+
+// StopReporter stops the Coherence reporter on a member, identified by its node id or member name.
+func (c *Client) StopReporter(ctx context.Context, member string) error {
+	return c.post(ctx, "stop of reporter on member "+member, c.url("/reporters", member, "stop"), nil)
+}
+
+// ----- helpers ------------------------------------------------------------
+
+// url returns the URL of a resource below the cluster resource, escaping each of the segments after the path.
+func (c *Client) url(path string, segments ...string) string {
+	u := c.baseURL + path
+	for _, s := range segments {
+		u = u + "/" + url.PathEscape(s)
+	}
+	return u
+}
+
+// cacheURL returns the URL of a cache, optionally in a specific service.
+func (c *Client) cacheURL(service, cache string) string {
+	if service == "" {
+		return c.url("/caches", cache)
+	}
+	return c.url("/services", service, "caches", cache)
+}
+
+// snapshotURL returns the URL of a persistence snapshot or archived snapshot of a service.
+func (c *Client) snapshotURL(service, kind, name string) string {
+	return c.url("/services", service, "persistence", kind, name)
+}
+
+// collectionPage is a single page of a Coherence management collection.
+type collectionPage struct {
+	Links []map[string]string `json:"links"`
+	Items []json.RawMessage   `json:"items"`
+}
+
+// getCollection reads every page of a collection, following the "next" link of each page, and parses the items
+// of all of the pages together with the links of the first page into v.
+func (c *Client) getCollection(ctx context.Context, operation, u string, v interface{}) error {
+	all := collectionPage{}
+	visited := make(map[string]bool)
+	for u != "" && !visited[u] {
+		visited[u] = true
+		page := collectionPage{}
+		if err := c.get(ctx, operation, u, &page); err != nil {
+			return err
+		}
+		if all.Links == nil {
+			all.Links = page.Links
+		}
+		all.Items = append(all.Items, page.Items...)
+		u = findLink(page.Links, nextLinkRel)
+	}
+
+	data, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// findLink returns the href of the link with the specified relation, or an empty string if there is no such link.
+func findLink(links []map[string]string, rel string) string {
+	for _, link := range links {
+		if link["rel"] == rel {
+			return link["href"]
+		}
+	}
+	return ""
+}
+
+// get performs a query and parses the json response into v. A query that fails to connect is retried
+// until it has been attempted a maximum of five times or the context is done.
+func (c *Client) get(ctx context.Context, operation, u string, v interface{}) error {
+	var data []byte
+	var err error
+	for i := 0; i < maxQueryAttempts; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(queryRetryInterval):
+			}
+		}
+		data, err = c.do(ctx, http.MethodGet, operation, u, nil)
+		if _, ok := err.(*StatusError); err == nil || ok || ctx.Err() != nil {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// post performs an operation with an optional json body.
+func (c *Client) post(ctx context.Context, operation, u string, body interface{}) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	_, err := c.do(ctx, http.MethodPost, operation, u, r)
+	return err
+}
+
+// delete performs an operation that removes a resource.
+func (c *Client) delete(ctx context.Context, operation, u string) error {
+	_, err := c.do(ctx, http.MethodDelete, operation, u, nil)
+	return err
+}
+
+// do performs a request returning the response body, or a StatusError if the response status is not 200.
+func (c *Client) do(ctx context.Context, method, operation, u string, body io.Reader) ([]byte, error) {
+	request, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	request = request.WithContext(ctx)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := c.http.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, &StatusError{Operation: operation, StatusCode: response.StatusCode}
+	}
+	return data, nil
+}
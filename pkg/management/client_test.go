@@ -0,0 +1,253 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package management
+
+import (
+	"context"
+	"fmt"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeEndpoint is a fake Coherence management over ReST endpoint that records each request
+// and responds with the configured response for the request's path.
+type fakeEndpoint struct {
+	server    *httptest.Server
+	lock      sync.Mutex
+	requests  []string
+	bodies    []string
+	responses map[string]string
+	status    int
+}
+
+func newFakeEndpoint() *fakeEndpoint {
+	f := &fakeEndpoint{responses: make(map[string]string), status: http.StatusOK}
+	f.server = httptest.NewServer(f)
+	return f
+}
+
+func (f *fakeEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	path := r.URL.EscapedPath()
+	if r.URL.RawQuery != "" {
+		path = path + "?" + r.URL.RawQuery
+	}
+
+	f.lock.Lock()
+	f.requests = append(f.requests, r.Method+" "+path)
+	f.bodies = append(f.bodies, string(body))
+	f.lock.Unlock()
+
+	if f.status != http.StatusOK {
+		w.WriteHeader(f.status)
+		return
+	}
+	response, found := f.responses[path]
+	if !found {
+		response = "{}"
+	}
+	_, _ = w.Write([]byte(response))
+}
+
+func (f *fakeEndpoint) client() *Client {
+	return NewClient(&http.Client{}, f.server.URL)
+}
+
+func TestClientGetCluster(t *testing.T) {
+	g := NewGomegaWithT(t)
+	f := newFakeEndpoint()
+	defer f.server.Close()
+	f.responses[clusterPath] = `{"clusterName":"test-cluster","clusterSize":3,"running":true}`
+
+	data, err := f.client().GetCluster(context.TODO())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(data.ClusterName).To(Equal("test-cluster"))
+	g.Expect(data.ClusterSize).To(Equal(3))
+	g.Expect(data.Running).To(BeTrue())
+}
+
+func TestClientShouldReadEveryPageOfCollection(t *testing.T) {
+	g := NewGomegaWithT(t)
+	f := newFakeEndpoint()
+	defer f.server.Close()
+	next := f.server.URL + clusterPath + "/services?page=2"
+	f.responses[clusterPath+"/services"] = fmt.Sprintf(`{"links":[{"rel":"self","href":"self"},{"rel":"next","href":"%s"}],
+		"items":[{"name":"PartitionedCache","type":"DistributedCache"}]}`, next)
+	f.responses[clusterPath+"/services?page=2"] = `{"links":[],"items":[{"name":"Proxy","type":"Proxy"}]}`
+
+	data, err := f.client().GetServices(context.TODO())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(data.Items).To(Equal([]ServiceData{{Name: "PartitionedCache", Type: "DistributedCache"}, {Name: "Proxy", Type: "Proxy"}}))
+	g.Expect(data.Links).To(HaveLen(2))
+	g.Expect(f.requests).To(Equal([]string{"GET " + clusterPath + "/services", "GET " + clusterPath + "/services?page=2"}))
+}
+
+func TestClientShouldNotFollowLinkToPreviousPage(t *testing.T) {
+	g := NewGomegaWithT(t)
+	f := newFakeEndpoint()
+	defer f.server.Close()
+	self := f.server.URL + clusterPath + "/members"
+	f.responses[clusterPath+"/members"] = fmt.Sprintf(`{"links":[{"rel":"next","href":"%s"}],"items":[{"nodeId":"1"}]}`, self)
+
+	data, err := f.client().GetMembers(context.TODO())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(data.Items).To(HaveLen(1))
+	g.Expect(f.requests).To(HaveLen(1))
+}
+
+func TestClientGetCache(t *testing.T) {
+	g := NewGomegaWithT(t)
+	f := newFakeEndpoint()
+	defer f.server.Close()
+	f.responses[clusterPath+"/services/PartitionedCache/caches/test"] = `{"name":"test","service":"PartitionedCache",
+		"size":10,"units":2048,"totalGets":100,"cacheHits":75,"cacheMisses":25,"hitProbability":0.75}`
+
+	data, err := f.client().GetCache(context.TODO(), "PartitionedCache", "test")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(*data).To(Equal(CacheData{Name: "test", Service: "PartitionedCache", Size: 10, Units: 2048,
+		TotalGets: 100, CacheHits: 75, CacheMisses: 25, HitProbability: 0.75}))
+}
+
+func TestClientShouldEscapeNames(t *testing.T) {
+	g := NewGomegaWithT(t)
+	f := newFakeEndpoint()
+	defer f.server.Close()
+
+	_, err := f.client().GetCacheMembers(context.TODO(), "", "dist/test cache")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(f.requests).To(Equal([]string{"GET " + clusterPath + "/caches/dist%2Ftest%20cache/members"}))
+}
+
+func TestClientSetLoggingLevel(t *testing.T) {
+	g := NewGomegaWithT(t)
+	f := newFakeEndpoint()
+	defer f.server.Close()
+
+	err := f.client().SetLoggingLevel(context.TODO(), "storage-0", 7)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(f.requests).To(Equal([]string{"POST " + clusterPath + "/members/storage-0"}))
+	g.Expect(f.bodies[0]).To(MatchJSON(`{"loggingLevel":7}`))
+}
+
+func TestClientShouldRejectInvalidLoggingLevel(t *testing.T) {
+	g := NewGomegaWithT(t)
+	f := newFakeEndpoint()
+	defer f.server.Close()
+
+	err := f.client().SetLoggingLevel(context.TODO(), "storage-0", 10)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(f.requests).To(BeEmpty())
+}
+
+func TestClientRestartServiceMember(t *testing.T) {
+	g := NewGomegaWithT(t)
+	f := newFakeEndpoint()
+	defer f.server.Close()
+
+	err := f.client().RestartServiceMember(context.TODO(), "PartitionedCache", "2")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(f.requests).To(Equal([]string{
+		"POST " + clusterPath + "/services/PartitionedCache/members/2/stop",
+		"POST " + clusterPath + "/services/PartitionedCache/members/2/start"}))
+}
+
+func TestClientMemberAndReporterOperations(t *testing.T) {
+	g := NewGomegaWithT(t)
+	f := newFakeEndpoint()
+	defer f.server.Close()
+	cl := f.client()
+
+	g.Expect(cl.ShutdownMember(context.TODO(), "1")).To(Succeed())
+	g.Expect(cl.StartReporter(context.TODO(), "1")).To(Succeed())
+	g.Expect(cl.StopReporter(context.TODO(), "1")).To(Succeed())
+	g.Expect(f.requests).To(Equal([]string{
+		"POST " + clusterPath + "/members/1/shutdown",
+		"POST " + clusterPath + "/reporters/1/start",
+		"POST " + clusterPath + "/reporters/1/stop"}))
+}
+
+func TestClientPersistenceOperations(t *testing.T) {
+	g := NewGomegaWithT(t)
+	f := newFakeEndpoint()
+	defer f.server.Close()
+	f.responses[clusterPath+"/services/PartitionedCache/persistence/archives"] = `{"archives":["one","two"]}`
+	cl := f.client()
+
+	g.Expect(cl.CreateSnapshot(context.TODO(), "PartitionedCache", "snap")).To(Succeed())
+	g.Expect(cl.ArchiveSnapshot(context.TODO(), "PartitionedCache", "snap")).To(Succeed())
+	g.Expect(cl.RetrieveArchivedSnapshot(context.TODO(), "PartitionedCache", "snap")).To(Succeed())
+	g.Expect(cl.RecoverSnapshot(context.TODO(), "PartitionedCache", "snap")).To(Succeed())
+	g.Expect(cl.RemoveSnapshot(context.TODO(), "PartitionedCache", "snap")).To(Succeed())
+	g.Expect(cl.RemoveArchivedSnapshot(context.TODO(), "PartitionedCache", "snap")).To(Succeed())
+	archives, err := cl.GetArchivedSnapshots(context.TODO(), "PartitionedCache")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(archives).To(Equal([]string{"one", "two"}))
+
+	p := clusterPath + "/services/PartitionedCache/persistence"
+	g.Expect(f.requests).To(Equal([]string{
+		"POST " + p + "/snapshots/snap",
+		"POST " + p + "/archives/snap",
+		"POST " + p + "/archives/snap/retrieve",
+		"POST " + p + "/snapshots/snap/recover",
+		"DELETE " + p + "/snapshots/snap",
+		"DELETE " + p + "/archives/snap",
+		"GET " + p + "/archives"}))
+}
+
+func TestClientShouldReturnStatusError(t *testing.T) {
+	g := NewGomegaWithT(t)
+	f := newFakeEndpoint()
+	defer f.server.Close()
+	f.status = http.StatusNotFound
+
+	err := f.client().RecoverSnapshot(context.TODO(), "PartitionedCache", "snap")
+	g.Expect(err).To(MatchError("recover snapshot snap for service PartitionedCache returned status 404"))
+	g.Expect(IsNotFound(err)).To(BeTrue())
+	g.Expect(StatusCode(err)).To(Equal(http.StatusNotFound))
+	g.Expect(StatusCode(nil)).To(Equal(http.StatusOK))
+	g.Expect(StatusCode(fmt.Errorf("connection refused"))).To(Equal(http.StatusInternalServerError))
+}
+
+func TestClientShouldStopRetryingWhenContextIsDone(t *testing.T) {
+	g := NewGomegaWithT(t)
+	f := newFakeEndpoint()
+	f.server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	start := time.Now()
+	_, err := f.client().GetCluster(ctx)
+	g.Expect(err).To(Equal(context.DeadlineExceeded))
+	g.Expect(time.Since(start)).To(BeNumerically("<", queryRetryInterval))
+}
+
+func TestQueryFunctionsShouldReturnStatusWithoutError(t *testing.T) {
+	g := NewGomegaWithT(t)
+	f := newFakeEndpoint()
+	defer f.server.Close()
+	f.status = http.StatusServiceUnavailable
+
+	host, p, err := net.SplitHostPort(f.server.Listener.Addr().String())
+	g.Expect(err).NotTo(HaveOccurred())
+	port, err := strconv.Atoi(p)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	_, status, err := GetServices(&http.Client{}, host, int32(port))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(status).To(Equal(http.StatusServiceUnavailable))
+
+	status, err = CreateSnapshot(&http.Client{}, host, int32(port), "PartitionedCache", "snap")
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(status).To(Equal(http.StatusServiceUnavailable))
+}
@@ -1,5 +1,5 @@
 /*
- * Copyright (c) 2019, Oracle and/or its affiliates. All rights reserved.
+ * Copyright (c) 2019, 2020 Oracle and/or its affiliates. All rights reserved.
  * Licensed under the Universal Permissive License v 1.0 as shown at
  * http://oss.oracle.com/licenses/upl.
  */
@@ -8,25 +8,18 @@
 package management
 
 import (
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
+	"context"
 	"net/http"
-	"time"
 )
 
 const (
-	// The URL pattern for Coherence management cluster query.
-	clusterFormat = "http://%s:%d/management/coherence/cluster"
-	// The URL pattern for Coherence management members query.
-	membersFormat = "http://%s:%d/management/coherence/cluster/members"
-	// The URL pattern for Coherence management services query.
-	servicesFormat = "http://%s:%d/management/coherence/cluster/services"
-	// The URL pattern for Coherence management partition assignment query.
-	partitionFormat = "http://%s:%d/management/coherence/cluster/services/%s/partition"
-
 	// The default name of the management port in a Coherence container.
 	PortName = "mgmt-port"
+	// The default port that Coherence management over ReST binds to in a Coherence container.
+	DefaultPort int32 = 30000
+
+	// The service type of a Coherence partitioned cache service.
+	DistributedCacheType = "DistributedCache"
 )
 
 // A struct to use to hold the results of a generic Coherence management ReST query.
@@ -73,6 +66,123 @@ type PartitionData struct {
 	RemainingDistributionCount int                 `json:"remainingDistributionCount"`
 	BackupCount                int                 `json:"backupCount"`
 	ServiceNodeCount           int                 `json:"serviceNodeCount"`
+	PartitionCount             int                 `json:"partitionCount"`
+}
+
+// A struct to use to hold the results of a Coherence management ReST persistence query
+// http://localhost:30000/management/coherence/cluster/services/%s/persistence
+// This structure only contains a sub-set of the fields available in the response json. If other
+// fields are required they should be added to this struct.
+type PersistenceData struct {
+	Links           []map[string]string `json:"Links"`
+	Idle            bool                `json:"idle"`
+	OperationStatus string              `json:"operationStatus"`
+	Snapshots       []string            `json:"snapshots"`
+}
+
+// HasSnapshot returns true if the service has a snapshot with the specified name.
+func (in *PersistenceData) HasSnapshot(name string) bool {
+	for _, s := range in.Snapshots {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// A struct to use to hold the results of a Coherence management ReST service members query
+// http://localhost:30000/management/coherence/cluster/services/%s/members
+type ServiceMembersData struct {
+	Links []map[string]string `json:"Links"`
+	Items []ServiceMemberData
+}
+
+// A struct to use to hold the results of a Coherence management ReST service member query.
+// This structure only contains a sub-set of the fields available in the response json. If other
+// fields are required they should be added to this struct.
+type ServiceMemberData struct {
+	Links               []map[string]string `json:"Links"`
+	NodeID              string              `json:"nodeId"`
+	TaskBacklog         int                 `json:"taskBacklog"`
+	RequestPendingCount int                 `json:"requestPendingCount"`
+}
+
+// A struct to use to hold the results of a Coherence management ReST cache members query
+// http://localhost:30000/management/coherence/cluster/caches/%s/members
+type CacheMembersData struct {
+	Links []map[string]string `json:"Links"`
+	Items []CacheMemberData
+}
+
+// A struct to use to hold the results of a Coherence management ReST cache member query.
+// This structure only contains a sub-set of the fields available in the response json. If other
+// fields are required they should be added to this struct.
+type CacheMemberData struct {
+	Links          []map[string]string `json:"Links"`
+	NodeID         string              `json:"nodeId"`
+	Tier           string              `json:"tier"`
+	Size           int                 `json:"size"`
+	Units          int64               `json:"units"`
+	UnitFactor     int                 `json:"unitFactor"`
+	TotalGets      int64               `json:"totalGets"`
+	TotalPuts      int64               `json:"totalPuts"`
+	CacheHits      int64               `json:"cacheHits"`
+	CacheMisses    int64               `json:"cacheMisses"`
+	HitProbability float64             `json:"hitProbability"`
+}
+
+// A struct to use to hold the results of a Coherence management ReST caches query
+// http://localhost:30000/management/coherence/cluster/caches
+type CachesData struct {
+	Links []map[string]string `json:"Links"`
+	Items []CacheData
+}
+
+// A struct to use to hold the results of a Coherence management ReST cache query, where the values are
+// aggregated across the members storing the cache
+// http://localhost:30000/management/coherence/cluster/caches/%s
+// This structure only contains a sub-set of the fields available in the response json. If other
+// fields are required they should be added to this struct.
+type CacheData struct {
+	Links          []map[string]string `json:"Links"`
+	Name           string              `json:"name"`
+	Service        string              `json:"service"`
+	Size           int                 `json:"size"`
+	Units          int64               `json:"units"`
+	TotalGets      int64               `json:"totalGets"`
+	TotalPuts      int64               `json:"totalPuts"`
+	CacheHits      int64               `json:"cacheHits"`
+	CacheMisses    int64               `json:"cacheMisses"`
+	HitProbability float64             `json:"hitProbability"`
+}
+
+// A struct to use to hold the results of a Coherence management ReST archived snapshots query
+// http://localhost:30000/management/coherence/cluster/services/%s/persistence/archives
+type ArchivesData struct {
+	Links    []map[string]string `json:"Links"`
+	Archives []string            `json:"archives"`
+}
+
+// A struct to use to hold the results of a Coherence management ReST reporters query
+// http://localhost:30000/management/coherence/cluster/reporters
+type ReportersData struct {
+	Links []map[string]string `json:"Links"`
+	Items []ReporterData
+}
+
+// A struct to use to hold the results of a Coherence management ReST reporter query
+// http://localhost:30000/management/coherence/cluster/reporters/<member-id>
+// This structure only contains a sub-set of the fields available in the response json. If other
+// fields are required they should be added to this struct.
+type ReporterData struct {
+	Links           []map[string]string `json:"Links"`
+	NodeID          string              `json:"nodeId"`
+	State           string              `json:"state"`
+	AutoStart       bool                `json:"autoStart"`
+	ConfigFile      string              `json:"configFile"`
+	OutputPath      string              `json:"outputPath"`
+	IntervalSeconds int64               `json:"intervalSeconds"`
+	CurrentBatch    int64               `json:"currentBatch"`
 }
 
 // A struct to use to hold the results of a Coherence management ReST members query
@@ -87,82 +197,127 @@ type MembersData struct {
 // This structure only contains a sub-set of the fields available in the response json. If other
 // fields are required they should be added to this struct.
 type MemberData struct {
-	Links        []map[string]string `json:"Links"`
-	SiteName     string              `json:"siteName"`
-	RackName     string              `json:"rackName"`
-	MachineName  string              `json:"machineName"`
-	MachineID    int                 `json:"machineId"`
-	MemberName   string              `json:"memberName"`
-	RoleName     string              `json:"roleName"`
-	ID           int                 `json:"id"`
-	NodeID       string              `json:"nodeId"`
-	LoggingLevel int                 `json:"loggingLevel"`
+	Links             []map[string]string `json:"Links"`
+	SiteName          string              `json:"siteName"`
+	RackName          string              `json:"rackName"`
+	MachineName       string              `json:"machineName"`
+	MachineID         int                 `json:"machineId"`
+	MemberName        string              `json:"memberName"`
+	RoleName          string              `json:"roleName"`
+	ID                int                 `json:"id"`
+	NodeID            string              `json:"nodeId"`
+	LoggingLevel      int                 `json:"loggingLevel"`
+	MemoryMaxMB       int                 `json:"memoryMaxMB"`
+	MemoryAvailableMB int                 `json:"memoryAvailableMB"`
 }
 
 // Perform a Management over ReST cluster query http://localhost:30000/management/coherence/cluster
 // and return the results, the http response status and any error.
 func GetCluster(cl *http.Client, host string, port int32) (*ClusterData, int, error) {
-	url := fmt.Sprintf(clusterFormat, host, port)
-	data := &ClusterData{}
-	status, err := query(cl, url, data)
+	data, err := newClient(cl, host, port).GetCluster(context.TODO())
+	status, err := queryStatus(err)
 	return data, status, err
 }
 
 // Perform a Management over ReST members query http://localhost:30000/management/coherence/cluster/members
 // and return the results, the http response status and any error.
 func GetMembers(cl *http.Client, host string, port int32) (*MembersData, int, error) {
-	url := fmt.Sprintf(membersFormat, host, port)
-	data := &MembersData{}
-	status, err := query(cl, url, data)
+	data, err := newClient(cl, host, port).GetMembers(context.TODO())
+	status, err := queryStatus(err)
 	return data, status, err
 }
 
 // Perform a Management over ReST members query http://localhost:30000/management/coherence/cluster/services
 // and return the results, the http response status and any error.
 func GetServices(cl *http.Client, host string, port int32) (*ServicesData, int, error) {
-	url := fmt.Sprintf(servicesFormat, host, port)
-	data := &ServicesData{}
-	status, err := query(cl, url, data)
+	data, err := newClient(cl, host, port).GetServices(context.TODO())
+	status, err := queryStatus(err)
 	return data, status, err
 }
 
 // Perform a Management over ReST members query http://localhost:30000/management/coherence/cluster/services/%s/partition
 // and return the results, the http response status and any error.
 func GetPartitionAssignment(cl *http.Client, host string, port int32, service string) (*PartitionData, int, error) {
-	url := fmt.Sprintf(partitionFormat, host, port, service)
-	data := &PartitionData{}
-	status, err := query(cl, url, data)
+	data, err := newClient(cl, host, port).GetPartitionAssignment(context.TODO(), service)
+	status, err := queryStatus(err)
 	return data, status, err
 }
 
-// Perform a Management over ReST query and parse the json response if the response code is 200
-// returning the response code any any error.
-func query(cl *http.Client, url string, v interface{}) (int, error) {
-	var response *http.Response
-	var err error
+// Perform a Management over ReST service members query http://localhost:30000/management/coherence/cluster/services/%s/members
+// and return the results, the http response status and any error.
+func GetServiceMembers(cl *http.Client, host string, port int32, service string) (*ServiceMembersData, int, error) {
+	data, err := newClient(cl, host, port).GetServiceMembers(context.TODO(), service)
+	status, err := queryStatus(err)
+	return data, status, err
+}
 
-	// re-try a max of 5 times
-	for i := 0; i < 5; i++ {
-		response, err = cl.Get(url)
-		if err == nil {
-			break
-		}
-		time.Sleep(1 * time.Second)
-	}
+// Perform a Management over ReST cache members query http://localhost:30000/management/coherence/cluster/caches/%s/members
+// and return the results, the http response status and any error. If the service name is not empty only the
+// cache in that service is queried.
+func GetCacheMembers(cl *http.Client, host string, port int32, service, cache string) (*CacheMembersData, int, error) {
+	data, err := newClient(cl, host, port).GetCacheMembers(context.TODO(), service, cache)
+	status, err := queryStatus(err)
+	return data, status, err
+}
 
-	if err != nil {
-		var status = http.StatusInternalServerError
-		if response != nil {
-			status = response.StatusCode
-		}
-		return status, err
-	}
+// Perform a Management over ReST persistence query http://localhost:30000/management/coherence/cluster/services/%s/persistence
+// and return the results, the http response status and any error.
+func GetPersistence(cl *http.Client, host string, port int32, service string) (*PersistenceData, int, error) {
+	data, err := newClient(cl, host, port).GetPersistence(context.TODO(), service)
+	status, err := queryStatus(err)
+	return data, status, err
+}
 
-	if response.StatusCode == http.StatusOK {
-		data, _ := ioutil.ReadAll(response.Body)
+// Perform a Management over ReST request to create a persistence snapshot with the specified name for a service
+// http://localhost:30000/management/coherence/cluster/services/%s/persistence/snapshots/%s
+// and return the http response status and any error.
+func CreateSnapshot(cl *http.Client, host string, port int32, service, name string) (int, error) {
+	err := newClient(cl, host, port).CreateSnapshot(context.TODO(), service, name)
+	return StatusCode(err), err
+}
 
-		err = json.Unmarshal(data, v)
-	}
+// Perform a Management over ReST request to remove the persistence snapshot with the specified name from a service
+// http://localhost:30000/management/coherence/cluster/services/%s/persistence/snapshots/%s
+// and return the http response status and any error.
+func RemoveSnapshot(cl *http.Client, host string, port int32, service, name string) (int, error) {
+	err := newClient(cl, host, port).RemoveSnapshot(context.TODO(), service, name)
+	return StatusCode(err), err
+}
+
+// Perform a Management over ReST request to recover a service from the persistence snapshot with the specified name
+// http://localhost:30000/management/coherence/cluster/services/%s/persistence/snapshots/%s/recover
+// and return the http response status and any error.
+func RecoverSnapshot(cl *http.Client, host string, port int32, service, name string) (int, error) {
+	err := newClient(cl, host, port).RecoverSnapshot(context.TODO(), service, name)
+	return StatusCode(err), err
+}
+
+// Perform a Management over ReST request to archive the persistence snapshot with the specified name for a service
+// http://localhost:30000/management/coherence/cluster/services/%s/persistence/archives/%s
+// and return the http response status and any error.
+func ArchiveSnapshot(cl *http.Client, host string, port int32, service, name string) (int, error) {
+	err := newClient(cl, host, port).ArchiveSnapshot(context.TODO(), service, name)
+	return StatusCode(err), err
+}
+
+// Perform a Management over ReST request to remove the archived persistence snapshot with the specified name
+// for a service http://localhost:30000/management/coherence/cluster/services/%s/persistence/archives/%s
+// and return the http response status and any error.
+func RemoveArchivedSnapshot(cl *http.Client, host string, port int32, service, name string) (int, error) {
+	err := newClient(cl, host, port).RemoveArchivedSnapshot(context.TODO(), service, name)
+	return StatusCode(err), err
+}
+
+// newClient creates a Client for the plain http management endpoint on the specified host and port.
+func newClient(cl *http.Client, host string, port int32) *Client {
+	return NewClient(cl, EndpointURL(host, port, false))
+}
 
-	return response.StatusCode, err
+// queryStatus returns the http response status and error of a query. A response status other than 200 is
+// returned without an error, leaving the caller to check the status.
+func queryStatus(err error) (int, error) {
+	if e, ok := err.(*StatusError); ok {
+		return e.StatusCode, nil
+	}
+	return StatusCode(err), err
 }
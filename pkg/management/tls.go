@@ -0,0 +1,91 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package management
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// The key of the PEM encoded CA certificate in an SSL Secret that is used to verify the management endpoint.
+	CACertKey = "ca.crt"
+	// The key of the PEM encoded client certificate in an SSL Secret.
+	ClientCertKey = corev1.TLSCertKey
+	// The key of the PEM encoded client private key in an SSL Secret.
+	ClientKeyKey = corev1.TLSPrivateKeyKey
+)
+
+// NewTLSConfig creates the TLS configuration used to connect to a Coherence management over ReST endpoint
+// configured with the specified SSLSpec. Returns nil if SSL is not enabled.
+//
+// Go clients cannot read the Java key stores in the SSLSpec's Secret, so the Secret must also contain PEM
+// encoded entries: a ca.crt entry used to verify the endpoint's certificate, otherwise the system's trusted
+// certificates are used, and if the endpoint requires client certificates tls.crt and tls.key entries.
+func NewTLSConfig(ssl *coh.SSLSpec, secret *corev1.Secret) (*tls.Config, error) {
+	if ssl == nil || ssl.Enabled == nil || !*ssl.Enabled {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if secret == nil {
+		if ssl.RequireClientCert != nil && *ssl.RequireClientCert {
+			return nil, fmt.Errorf("a Secret containing the %s and %s entries is required for a client certificate", ClientCertKey, ClientKeyKey)
+		}
+		return cfg, nil
+	}
+
+	if ca, found := secret.Data[CACertKey]; found {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("the %s entry in Secret %s does not contain a PEM encoded certificate", CACertKey, secret.Name)
+		}
+		cfg.RootCAs = pool
+	}
+
+	cert, hasCert := secret.Data[ClientCertKey]
+	key, hasKey := secret.Data[ClientKeyKey]
+	switch {
+	case hasCert && hasKey:
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("the client certificate in Secret %s is invalid: %s", secret.Name, err.Error())
+		}
+		cfg.Certificates = []tls.Certificate{pair}
+	case ssl.RequireClientCert != nil && *ssl.RequireClientCert:
+		return nil, fmt.Errorf("Secret %s does not contain the %s and %s entries required for a client certificate", secret.Name, ClientCertKey, ClientKeyKey)
+	}
+
+	return cfg, nil
+}
+
+// NewPodTLSConfig returns a copy of the TLS configuration used to connect to the management over ReST endpoint of a
+// Pod. The endpoint is connected to using the Pod's IP address but the certificates of Coherence members are normally
+// issued for the Pod's stable DNS name, so the server name verified is the Pod's DNS name,
+// <hostname>.<subdomain>.<namespace>.svc, if the Pod has a hostname and subdomain. Returns nil if cfg is nil.
+func NewPodTLSConfig(cfg *tls.Config, pod corev1.Pod) *tls.Config {
+	if cfg == nil {
+		return nil
+	}
+	podCfg := cfg.Clone()
+	if name := PodServerName(pod); name != "" {
+		podCfg.ServerName = name
+	}
+	return podCfg
+}
+
+// PodServerName returns the stable DNS name of a Pod, <hostname>.<subdomain>.<namespace>.svc, which the StatefulSet
+// controller gives to every Pod of a StatefulSet. Returns "" if the Pod does not have a hostname and subdomain.
+func PodServerName(pod corev1.Pod) string {
+	if pod.Spec.Hostname == "" || pod.Spec.Subdomain == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s.%s.%s.svc", pod.Spec.Hostname, pod.Spec.Subdomain, pod.Namespace)
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package management
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	. "github.com/onsi/gomega"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newClientCert creates a PEM encoded self-signed client certificate and private key.
+func newClientCert(g *GomegaWithT) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "coherence-operator"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	g.Expect(err).NotTo(HaveOccurred())
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	g.Expect(err).NotTo(HaveOccurred())
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+func newSecret(data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "mgmt-ssl"}, Data: data}
+}
+
+func TestTLSConfigWhenSSLNotEnabled(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cfg, err := NewTLSConfig(nil, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg).To(BeNil())
+
+	cfg, err = NewTLSConfig(&coh.SSLSpec{Enabled: pointer.BoolPtr(false)}, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg).To(BeNil())
+}
+
+func TestTLSConfigWithoutSecretUsesSystemCertificates(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cfg, err := NewTLSConfig(&coh.SSLSpec{Enabled: pointer.BoolPtr(true)}, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.RootCAs).To(BeNil())
+	g.Expect(cfg.Certificates).To(BeEmpty())
+}
+
+func TestTLSConfigShouldVerifyServerUsingCACert(t *testing.T) {
+	g := NewGomegaWithT(t)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"clusterName":"test-cluster"}`))
+	}))
+	defer server.Close()
+
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	cfg, err := NewTLSConfig(&coh.SSLSpec{Enabled: pointer.BoolPtr(true)}, newSecret(map[string][]byte{CACertKey: ca}))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	data, err := NewClient(NewHTTPClient(time.Second*10, cfg), server.URL).GetCluster(context.TODO())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(data.ClusterName).To(Equal("test-cluster"))
+}
+
+func TestTLSConfigShouldPresentClientCertificate(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cert, key := newClientCert(g)
+	pool := x509.NewCertPool()
+	g.Expect(pool.AppendCertsFromPEM(cert)).To(BeTrue())
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"clusterName":"test-cluster"}`))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	server.StartTLS()
+	defer server.Close()
+
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	ssl := &coh.SSLSpec{Enabled: pointer.BoolPtr(true), RequireClientCert: pointer.BoolPtr(true)}
+	cfg, err := NewTLSConfig(ssl, newSecret(map[string][]byte{CACertKey: ca, ClientCertKey: cert, ClientKeyKey: key}))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	_, err = NewClient(NewHTTPClient(time.Second*10, cfg), server.URL).GetCluster(context.TODO())
+	g.Expect(err).NotTo(HaveOccurred())
+}
+
+func TestTLSConfigShouldRequireClientCertificate(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ssl := &coh.SSLSpec{Enabled: pointer.BoolPtr(true), RequireClientCert: pointer.BoolPtr(true)}
+
+	_, err := NewTLSConfig(ssl, nil)
+	g.Expect(err).To(HaveOccurred())
+
+	_, err = NewTLSConfig(ssl, newSecret(map[string][]byte{}))
+	g.Expect(err).To(MatchError("Secret mgmt-ssl does not contain the tls.crt and tls.key entries required for a client certificate"))
+}
+
+func TestTLSConfigShouldRejectInvalidCACert(t *testing.T) {
+	g := NewGomegaWithT(t)
+	_, err := NewTLSConfig(&coh.SSLSpec{Enabled: pointer.BoolPtr(true)}, newSecret(map[string][]byte{CACertKey: []byte("not a cert")}))
+	g.Expect(err).To(MatchError("the ca.crt entry in Secret mgmt-ssl does not contain a PEM encoded certificate"))
+}
+
+func TestPodTLSConfigShouldVerifyThePodDNSName(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "coherence-test", Name: "test-cluster-storage-0"},
+		Spec:       corev1.PodSpec{Hostname: "test-cluster-storage-0", Subdomain: "test-cluster-storage-headless"},
+	}
+
+	podCfg := NewPodTLSConfig(cfg, pod)
+	g.Expect(podCfg.ServerName).To(Equal("test-cluster-storage-0.test-cluster-storage-headless.coherence-test.svc"))
+	g.Expect(podCfg.InsecureSkipVerify).To(BeFalse())
+	g.Expect(cfg.ServerName).To(BeEmpty())
+
+	pod.Spec = corev1.PodSpec{}
+	g.Expect(NewPodTLSConfig(cfg, pod).ServerName).To(BeEmpty())
+	g.Expect(NewPodTLSConfig(nil, pod)).To(BeNil())
+}
@@ -0,0 +1,234 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+// Package metrics contains the Coherence Operator's Prometheus metrics. The metrics are registered with the
+// controller-runtime metrics registry so they are served with the controller metrics on the manager's metrics port.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"time"
+)
+
+// The prefix of the names of the Operator's metrics.
+const metricsNamespace = "coherence_operator"
+
+// The results of a reconcile.
+const (
+	ResultSuccess = "success"
+	ResultRequeue = "requeue"
+	ResultError   = "error"
+)
+
+// The types of StatusHA probe.
+const (
+	ProbeExec = "exec"
+	ProbeHTTP = "http"
+	ProbeTCP  = "tcp"
+)
+
+// The results of a StatusHA check.
+const (
+	ResultStatusHA    = "ha"
+	ResultNotStatusHA = "not_ha"
+)
+
+// The directions of a scale operation.
+const (
+	DirectionUp   = "up"
+	DirectionDown = "down"
+)
+
+// The results of a lookup of a node by the ReST server.
+const (
+	ResultCacheHit  = "hit"
+	ResultCacheMiss = "miss"
+	ResultNotFound  = "not_found"
+)
+
+var (
+	reconcileTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "reconcile_total",
+		Help:      "The total number of reconciles per controller and result (success, requeue or error).",
+	}, []string{"controller", "result"})
+
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "reconcile_duration_seconds",
+		Help:      "The duration of reconciles per controller.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 14),
+	}, []string{"controller"})
+
+	statusHAChecksTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "status_ha_checks_total",
+		Help:      "The total number of StatusHA checks per probe type and result (ha, not_ha or error).",
+	}, []string{"probe", "result"})
+
+	statusHACheckDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "status_ha_check_duration_seconds",
+		Help:      "The duration of StatusHA checks per probe type.",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 14),
+	}, []string{"probe"})
+
+	scaleOperationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "scale_operations_total",
+		Help:      "The total number of changes to the size of a role's StatefulSet per scaling policy and direction.",
+	}, []string{"policy", "direction"})
+
+	startQuorumWait = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "start_quorum_wait_seconds",
+		Help:      "The time that the roles of a cluster waited for their start quorum to be met.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	})
+
+	rolesWaitingForStartQuorum = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "roles_waiting_for_start_quorum",
+		Help:      "The number of roles of a cluster that are waiting for their start quorum to be met.",
+	}, []string{"namespace", "cluster"})
+
+	rollingUpgradeOutdatedPods = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "rolling_upgrade_outdated_pods",
+		Help:      "The number of a role's Pods that have not yet been updated to the latest revision by a rolling upgrade.",
+	}, []string{"namespace", "role"})
+
+	rollingUpgradePodRestartsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "rolling_upgrade_pod_restarts_total",
+		Help:      "The total number of a role's Pods restarted by safe rolling upgrades.",
+	}, []string{"namespace", "role"})
+
+	nodeLookupsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "rest_node_lookups_total",
+		Help:      "The total number of node lookups by the ReST server per result (hit, miss, not_found or error).",
+	}, []string{"result"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		reconcileTotal,
+		reconcileDuration,
+		statusHAChecksTotal,
+		statusHACheckDuration,
+		scaleOperationsTotal,
+		startQuorumWait,
+		rolesWaitingForStartQuorum,
+		rollingUpgradeOutdatedPods,
+		rollingUpgradePodRestartsTotal,
+		nodeLookupsTotal,
+	)
+}
+
+// ----- reconcile metrics --------------------------------------------------
+
+// InstrumentReconciler wraps a reconciler so that the duration and result of each of its reconciles
+// is recorded for the named controller.
+func InstrumentReconciler(controller string, r reconcile.Reconciler) reconcile.Reconciler {
+	return &instrumentedReconciler{controller: controller, delegate: r}
+}
+
+type instrumentedReconciler struct {
+	controller string
+	delegate   reconcile.Reconciler
+}
+
+// Reconcile calls the wrapped reconciler and records the duration and result of the reconcile.
+func (in *instrumentedReconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	start := time.Now()
+	result, err := in.delegate.Reconcile(request)
+	ObserveReconcile(in.controller, start, result, err)
+	return result, err
+}
+
+// ObserveReconcile records the duration and result of a reconcile started at the specified time.
+func ObserveReconcile(controller string, start time.Time, result reconcile.Result, err error) {
+	outcome := ResultSuccess
+	switch {
+	case err != nil:
+		outcome = ResultError
+	case result.Requeue || result.RequeueAfter > 0:
+		outcome = ResultRequeue
+	}
+	reconcileDuration.WithLabelValues(controller).Observe(time.Since(start).Seconds())
+	reconcileTotal.WithLabelValues(controller, outcome).Inc()
+}
+
+// ----- scaling metrics ----------------------------------------------------
+
+// ObserveStatusHACheck records the duration and result of a StatusHA check started at the specified time
+// using the specified type of probe.
+func ObserveStatusHACheck(probe string, start time.Time, ha bool, err error) {
+	outcome := ResultNotStatusHA
+	switch {
+	case err != nil:
+		outcome = ResultError
+	case ha:
+		outcome = ResultStatusHA
+	}
+	statusHACheckDuration.WithLabelValues(probe).Observe(time.Since(start).Seconds())
+	statusHAChecksTotal.WithLabelValues(probe, outcome).Inc()
+}
+
+// ObserveScale records a change to the size of a role's StatefulSet using the specified scaling policy.
+func ObserveScale(policy string, current, desired int32) {
+	direction := DirectionUp
+	if desired < current {
+		direction = DirectionDown
+	}
+	scaleOperationsTotal.WithLabelValues(policy, direction).Inc()
+}
+
+// ----- start quorum metrics -----------------------------------------------
+
+// SetRolesWaitingForStartQuorum records the number of roles of a cluster that are waiting for their start quorum.
+func SetRolesWaitingForStartQuorum(namespace, cluster string, count int) {
+	rolesWaitingForStartQuorum.WithLabelValues(namespace, cluster).Set(float64(count))
+}
+
+// DeleteClusterMetrics removes the metric series of a cluster that has been deleted.
+func DeleteClusterMetrics(namespace, cluster string) {
+	rolesWaitingForStartQuorum.DeleteLabelValues(namespace, cluster)
+}
+
+// ObserveStartQuorumWait records the time that the roles of a cluster waited for their start quorum to be met.
+func ObserveStartQuorumWait(wait time.Duration) {
+	startQuorumWait.Observe(wait.Seconds())
+}
+
+// ----- rolling upgrade metrics --------------------------------------------
+
+// SetRollingUpgradeOutdatedPods records the number of a role's Pods not yet updated to the latest StatefulSet revision.
+func SetRollingUpgradeOutdatedPods(namespace, role string, count int) {
+	rollingUpgradeOutdatedPods.WithLabelValues(namespace, role).Set(float64(count))
+}
+
+// IncRollingUpgradePodRestarts records that one of a role's Pods was restarted by a safe rolling upgrade.
+func IncRollingUpgradePodRestarts(namespace, role string) {
+	rollingUpgradePodRestartsTotal.WithLabelValues(namespace, role).Inc()
+}
+
+// DeleteRoleMetrics removes the metric series of a role that has been deleted.
+func DeleteRoleMetrics(namespace, role string) {
+	rollingUpgradeOutdatedPods.DeleteLabelValues(namespace, role)
+	rollingUpgradePodRestartsTotal.DeleteLabelValues(namespace, role)
+}
+
+// ----- ReST server metrics ------------------------------------------------
+
+// ObserveNodeLookup records the result of a lookup of a node by the ReST server, either a hit in the
+// node cache, a miss in the cache that was found using the API server, not found or an error.
+func ObserveNodeLookup(result string) {
+	nodeLookupsTotal.WithLabelValues(result).Inc()
+}
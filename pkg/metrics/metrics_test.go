@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package metrics
+
+import (
+	"fmt"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"testing"
+	"time"
+)
+
+type fakeReconciler struct {
+	result reconcile.Result
+	err    error
+}
+
+func (f *fakeReconciler) Reconcile(reconcile.Request) (reconcile.Result, error) {
+	return f.result, f.err
+}
+
+func TestInstrumentedReconcilerShouldRecordResult(t *testing.T) {
+	g := NewGomegaWithT(t)
+	const controller = "test.controller"
+
+	r := &fakeReconciler{}
+	instrumented := InstrumentReconciler(controller, r)
+
+	_, _ = instrumented.Reconcile(reconcile.Request{})
+	r.result = reconcile.Result{RequeueAfter: time.Minute}
+	_, _ = instrumented.Reconcile(reconcile.Request{})
+	r.err = fmt.Errorf("failed")
+	result, err := instrumented.Reconcile(reconcile.Request{})
+
+	g.Expect(result).To(Equal(r.result))
+	g.Expect(err).To(Equal(r.err))
+	g.Expect(testutil.ToFloat64(reconcileTotal.WithLabelValues(controller, ResultSuccess))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(reconcileTotal.WithLabelValues(controller, ResultRequeue))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(reconcileTotal.WithLabelValues(controller, ResultError))).To(Equal(1.0))
+}
+
+func TestObserveStatusHACheck(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ObserveStatusHACheck(ProbeTCP, time.Now(), true, nil)
+	ObserveStatusHACheck(ProbeTCP, time.Now(), false, nil)
+	ObserveStatusHACheck(ProbeTCP, time.Now(), false, fmt.Errorf("connection refused"))
+	ObserveStatusHACheck(ProbeTCP, time.Now(), false, fmt.Errorf("connection refused"))
+
+	g.Expect(testutil.ToFloat64(statusHAChecksTotal.WithLabelValues(ProbeTCP, ResultStatusHA))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(statusHAChecksTotal.WithLabelValues(ProbeTCP, ResultNotStatusHA))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(statusHAChecksTotal.WithLabelValues(ProbeTCP, ResultError))).To(Equal(2.0))
+}
+
+func TestObserveScale(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ObserveScale("Parallel", 1, 3)
+	ObserveScale("Safe", 3, 2)
+	ObserveScale("Safe", 2, 1)
+
+	g.Expect(testutil.ToFloat64(scaleOperationsTotal.WithLabelValues("Parallel", DirectionUp))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(scaleOperationsTotal.WithLabelValues("Safe", DirectionDown))).To(Equal(2.0))
+}
+
+func TestRollingUpgradeMetrics(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	SetRollingUpgradeOutdatedPods("test", "test-cluster-storage", 3)
+	IncRollingUpgradePodRestarts("test", "test-cluster-storage")
+	SetRollingUpgradeOutdatedPods("test", "test-cluster-storage", 2)
+
+	g.Expect(testutil.ToFloat64(rollingUpgradeOutdatedPods.WithLabelValues("test", "test-cluster-storage"))).To(Equal(2.0))
+	g.Expect(testutil.ToFloat64(rollingUpgradePodRestartsTotal.WithLabelValues("test", "test-cluster-storage"))).To(Equal(1.0))
+}
+
+func TestDeleteRoleMetrics(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	SetRollingUpgradeOutdatedPods("test", "deleted-cluster-storage", 1)
+	IncRollingUpgradePodRestarts("test", "deleted-cluster-storage")
+	DeleteRoleMetrics("test", "deleted-cluster-storage")
+
+	g.Expect(rollingUpgradeOutdatedPods.DeleteLabelValues("test", "deleted-cluster-storage")).To(BeFalse())
+	g.Expect(rollingUpgradePodRestartsTotal.DeleteLabelValues("test", "deleted-cluster-storage")).To(BeFalse())
+}
+
+func TestDeleteClusterMetrics(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	SetRolesWaitingForStartQuorum("test", "deleted-cluster", 2)
+	DeleteClusterMetrics("test", "deleted-cluster")
+
+	g.Expect(rolesWaitingForStartQuorum.DeleteLabelValues("test", "deleted-cluster")).To(BeFalse())
+}
+
+func TestObserveNodeLookup(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ObserveNodeLookup(ResultCacheHit)
+	ObserveNodeLookup(ResultCacheHit)
+	ObserveNodeLookup(ResultNotFound)
+
+	g.Expect(testutil.ToFloat64(nodeLookupsTotal.WithLabelValues(ResultCacheHit))).To(Equal(2.0))
+	g.Expect(testutil.ToFloat64(nodeLookupsTotal.WithLabelValues(ResultNotFound))).To(Equal(1.0))
+}
+
+func TestMetricsShouldBeRegistered(t *testing.T) {
+	g := NewGomegaWithT(t)
+	SetRolesWaitingForStartQuorum("test", "test-cluster", 1)
+	ObserveStartQuorumWait(time.Second * 30)
+
+	families, err := metrics.Registry.Gather()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	var names []string
+	for _, family := range families {
+		names = append(names, family.GetName())
+	}
+	g.Expect(names).To(ContainElement("coherence_operator_roles_waiting_for_start_quorum"))
+	g.Expect(names).To(ContainElement("coherence_operator_start_quorum_wait_seconds"))
+}
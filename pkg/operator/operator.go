@@ -18,6 +18,7 @@ import (
 	"os"
 	"path/filepath"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strconv"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
@@ -26,20 +27,51 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/version"
+	k8sversion "k8s.io/apimachinery/pkg/version"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
 const (
 	// configName is the name of the internal Coherence Operator configuration secret.
 	configName = "coherence-operator-config"
+
+	// MinimumKubernetesVersion is the earliest version of Kubernetes that the Operator supports.
+	// The Operator uses server-side apply to create and update the resources of each role.
+	MinimumKubernetesVersion = "v1.16.0"
 )
 
-var restHostAndPort string
+var (
+	restHostAndPort string
+	restTLS         bool
+	restCACert      []byte
+)
 
 func SetHostAndPort(hostAndPort string) {
 	restHostAndPort = hostAndPort
 }
 
+// SetTLS sets whether the Operator ReST server serves HTTPS and the PEM encoded CA certificate
+// that Coherence Pods should use to verify it.
+func SetTLS(tls bool, caCert []byte) {
+	restTLS = tls
+	restCACert = caCert
+}
+
+// CheckKubernetesVersion returns an error if the version of the Kubernetes API server is earlier than
+// the MinimumKubernetesVersion.
+func CheckKubernetesVersion(info *k8sversion.Info) error {
+	current, err := version.ParseGeneric(info.GitVersion)
+	if err != nil {
+		return fmt.Errorf("unable to parse the Kubernetes server version %s: %s", info.GitVersion, err.Error())
+	}
+	if !current.AtLeast(version.MustParseGeneric(MinimumKubernetesVersion)) {
+		return fmt.Errorf("the Kubernetes server version %s is not supported, the Operator requires %s or later",
+			info.GitVersion, MinimumKubernetesVersion)
+	}
+	return nil
+}
+
 // EnsureCRDs ensures that the Operator configuration secret exists in the namespace.
 func EnsureCRDs(mgr manager.Manager, cohFlags *flags.CoherenceOperatorFlags, log logr.Logger) error {
 	// Create the CRD client
@@ -147,6 +179,8 @@ func EnsureOperatorSecret(namespace string, c client.Client, log logr.Logger) er
 	}
 
 	secret.StringData["operatorhost"] = restHostAndPort
+	secret.StringData["operatortls"] = strconv.FormatBool(restTLS)
+	secret.StringData["operatorca"] = string(restCACert)
 
 	if errors.IsNotFound(err) {
 		// for some reason we're getting here even if the secret exists so delete it!!
@@ -14,9 +14,11 @@ import (
 	"github.com/oracle/coherence-operator/pkg/operator"
 	"github.com/oracle/coherence-operator/test/e2e/helper"
 	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	k8sversion "k8s.io/apimachinery/pkg/version"
 	"k8s.io/apimachinery/pkg/watch"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"testing"
@@ -54,12 +56,14 @@ func TestShouldCreateCRDs(t *testing.T) {
 	err = mgr.Client.List(context.TODO(), &crdList)
 	g.Expect(err).NotTo(HaveOccurred())
 
-	g.Expect(len(crdList.Items)).To(Equal(3))
+	g.Expect(len(crdList.Items)).To(Equal(5))
 
 	expected := map[string]bool{
 		"coherenceclusters.coherence.oracle.com":  false,
 		"coherenceinternals.coherence.oracle.com": false,
+		"coherencerestores.coherence.oracle.com":  false,
 		"coherenceroles.coherence.oracle.com":     false,
+		"coherencesnapshots.coherence.oracle.com": false,
 	}
 
 	for _, crd := range crdList.Items {
@@ -85,7 +89,9 @@ func TestShouldUpdateCRDs(t *testing.T) {
 	oldCRDs := map[string]*v1beta1.CustomResourceDefinition{
 		"coherenceclusters.coherence.oracle.com":  nil,
 		"coherenceinternals.coherence.oracle.com": nil,
+		"coherencerestores.coherence.oracle.com":  nil,
 		"coherenceroles.coherence.oracle.com":     nil,
+		"coherencesnapshots.coherence.oracle.com": nil,
 	}
 
 	for name := range oldCRDs {
@@ -118,7 +124,7 @@ func TestShouldUpdateCRDs(t *testing.T) {
 	err = mgr.Client.List(context.TODO(), &crdList)
 	g.Expect(err).NotTo(HaveOccurred())
 
-	g.Expect(len(crdList.Items)).To(Equal(3))
+	g.Expect(len(crdList.Items)).To(Equal(5))
 
 	for _, crd := range crdList.Items {
 		oldCRD := oldCRDs[crd.Name]
@@ -127,6 +133,26 @@ func TestShouldUpdateCRDs(t *testing.T) {
 	}
 }
 
+func TestShouldCreateOperatorSecretWithTLSSettings(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mgr, err := fakes.NewFakeManager()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	operator.SetHostAndPort("operator.test.svc:8000")
+	operator.SetTLS(true, []byte("ca-cert"))
+	defer operator.SetTLS(false, nil)
+
+	err = operator.EnsureOperatorSecret("test", mgr.GetClient(), fakes.TestLogger{T: t})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	secret := &corev1.Secret{}
+	err = mgr.GetClient().Get(context.TODO(), types.NamespacedName{Namespace: "test", Name: "coherence-operator-config"}, secret)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(secret.StringData["operatorhost"]).To(Equal("operator.test.svc:8000"))
+	g.Expect(secret.StringData["operatortls"]).To(Equal("true"))
+	g.Expect(secret.StringData["operatorca"]).To(Equal("ca-cert"))
+}
+
 type FakeCustomResourceDefinitionInterface struct {
 	Mgr manager.Manager
 }
@@ -176,3 +202,12 @@ func (f FakeCustomResourceDefinitionInterface) Watch(opts metav1.ListOptions) (w
 func (f FakeCustomResourceDefinitionInterface) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1beta1.CustomResourceDefinition, err error) {
 	panic("implement me")
 }
+
+func TestShouldCheckKubernetesVersion(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(operator.CheckKubernetesVersion(&k8sversion.Info{GitVersion: "v1.15.3"})).NotTo(Succeed())
+	g.Expect(operator.CheckKubernetesVersion(&k8sversion.Info{GitVersion: "v1.16.0"})).To(Succeed())
+	g.Expect(operator.CheckKubernetesVersion(&k8sversion.Info{GitVersion: "v1.17.2+k3s1"})).To(Succeed())
+	g.Expect(operator.CheckKubernetesVersion(&k8sversion.Info{GitVersion: "unknown"})).NotTo(Succeed())
+}
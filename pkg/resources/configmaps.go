@@ -0,0 +1,166 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package resources
+
+import (
+	"fmt"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"io/ioutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// The key in the Fluentd ConfigMap holding the Fluentd configuration.
+	fluentdConfigKey = "fluentd-coherence.conf"
+)
+
+// LoadScripts loads the scripts to add to the role scripts ConfigMap from the specified directory.
+// The map returned has a key for each regular file in the directory mapped to the file's contents.
+func LoadScripts(dir string) (map[string]string, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("the Coherence scripts directory has not been configured")
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	scripts := make(map[string]string)
+	for _, f := range files {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		scripts[f.Name()] = string(data)
+	}
+
+	return scripts, nil
+}
+
+// GetScriptsConfigMapName returns the name of the ConfigMap holding a role's start-up scripts.
+func GetScriptsConfigMapName(spec *coh.CoherenceInternalSpec) string {
+	return GetFullName(spec) + "-scripts"
+}
+
+// GetFluentdConfigMapName returns the name of the ConfigMap holding a role's Fluentd configuration.
+func GetFluentdConfigMapName(spec *coh.CoherenceInternalSpec) string {
+	return GetFullName(spec) + "-efk-config"
+}
+
+// NewScriptsConfigMap creates the ConfigMap holding the start-up scripts mounted into the Coherence container.
+func NewScriptsConfigMap(namespace string, spec *coh.CoherenceInternalSpec, scripts map[string]string) *corev1.ConfigMap {
+	data := make(map[string]string)
+	for k, v := range scripts {
+		data[k] = v
+	}
+
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      GetScriptsConfigMapName(spec),
+			Labels:    componentLabels(spec, "coherence-scripts"),
+		},
+		Data: data,
+	}
+}
+
+// NewFluentdConfigMap creates the ConfigMap holding the Fluentd side-car configuration,
+// or returns nil if Fluentd is not enabled for the role.
+func NewFluentdConfigMap(namespace string, spec *coh.CoherenceInternalSpec) *corev1.ConfigMap {
+	if !isFluentdEnabled(spec) {
+		return nil
+	}
+
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      GetFluentdConfigMapName(spec),
+			Labels:    componentLabels(spec, "coherence-config"),
+		},
+		Data: map[string]string{fluentdConfigKey: fluentdConfig(spec)},
+	}
+}
+
+// isFluentdEnabled returns true if the Fluentd side-car is enabled for the role.
+func isFluentdEnabled(spec *coh.CoherenceInternalSpec) bool {
+	return spec.Logging != nil && spec.Logging.Fluentd != nil && isTrue(spec.Logging.Fluentd.Enabled)
+}
+
+// fluentdConfig creates the Fluentd configuration that ships the Coherence logs to Elasticsearch.
+func fluentdConfig(spec *coh.CoherenceInternalSpec) string {
+	fluentd := spec.Logging.Fluentd
+	sb := strings.Builder{}
+
+	sb.WriteString("# Optional application specific fluentd source(s)  i.e. Java Logger messages from server-side app classes such as entryprocessor, interceptor, ...\n")
+	if notEmpty(fluentd.ConfigFile) {
+		sb.WriteString(fmt.Sprintf("@include %s\n", *fluentd.ConfigFile))
+	}
+
+	sb.WriteString(`
+# Ignore fluentd messages
+<match fluent.**>
+  @type null
+</match>
+
+# Coherence Logs
+<source>
+  @type tail
+  path /logs/coherence-*.log
+  pos_file /tmp/cohrence.log.pos
+  read_from_head true
+  tag coherence-cluster
+  multiline_flush_interval 20s
+  <parse>
+   @type multiline
+   format_firstline /^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}.\d{3}/
+   format1 /^(?<time>\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}.\d{3})\/(?<uptime>[0-9\.]+) (?<product>.+) <(?<level>[^\s]+)> \(thread=(?<thread>.+), member=(?<member>.+)\):[\S\s](?<log>.*)/
+  </parse>
+</source>
+
+<filter coherence-cluster>
+ @type record_transformer
+ <record>
+`)
+	sb.WriteString(fmt.Sprintf("   cluster %q\n", spec.Cluster))
+	sb.WriteString(fmt.Sprintf("   role %q\n", spec.GetRoleName()))
+	sb.WriteString(`   host "#{ENV['HOSTNAME']}"
+   pod-uid "#{ENV['COHERENCE_POD_ID']}"
+ </record>
+</filter>
+`)
+	sb.WriteString(elasticsearchMatch("coherence-cluster", "coherence-cluster"))
+
+	if notEmpty(fluentd.Tag) {
+		sb.WriteString(elasticsearchMatch(*fluentd.Tag+" ", *fluentd.Tag))
+	}
+
+	return sb.String()
+}
+
+// elasticsearchMatch creates a Fluentd match directive sending the matched records to Elasticsearch.
+func elasticsearchMatch(pattern, prefix string) string {
+	return fmt.Sprintf(`
+<match %s>
+  @type elasticsearch
+  host "#{ENV['ELASTICSEARCH_HOST']}"
+  port "#{ENV['ELASTICSEARCH_PORT']}"
+  user "#{ENV['ELASTICSEARCH_USER']}"
+  password "#{ENV['ELASTICSEARCH_PASSWORD']}"
+  logstash_format true
+  logstash_prefix %s
+</match>
+`, pattern, prefix)
+}
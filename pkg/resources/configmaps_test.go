@@ -0,0 +1,69 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package resources
+
+import (
+	. "github.com/onsi/gomega"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadScripts(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "scripts")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	g.Expect(ioutil.WriteFile(filepath.Join(dir, "startCoherence.sh"), []byte("echo start"), 0644)).To(Succeed())
+	g.Expect(os.Mkdir(filepath.Join(dir, "nested"), 0755)).To(Succeed())
+
+	scripts, err := LoadScripts(dir)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(scripts).To(Equal(map[string]string{"startCoherence.sh": "echo start"}))
+}
+
+func TestLoadScriptsWithoutDirectory(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	_, err := LoadScripts("")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestScriptsConfigMap(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cm := NewScriptsConfigMap("test-ns", newSpec(), map[string]string{"startCoherence.sh": "echo start"})
+
+	g.Expect(cm.Name).To(Equal("test-cluster-data-scripts"))
+	g.Expect(cm.Labels).To(HaveKeyWithValue(CoherenceDeploymentLabel, "test-cluster-data"))
+	g.Expect(cm.Data).To(HaveKeyWithValue("startCoherence.sh", "echo start"))
+}
+
+func TestFluentdConfigMapWhenDisabled(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(NewFluentdConfigMap("test-ns", newSpec())).To(BeNil())
+}
+
+func TestFluentdConfigMapWhenEnabled(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	spec := newSpec()
+	enabled := true
+	tag := "app"
+	spec.Logging = &coh.LoggingSpec{Fluentd: &coh.FluentdSpec{Enabled: &enabled, Tag: &tag}}
+
+	cm := NewFluentdConfigMap("test-ns", spec)
+	g.Expect(cm).NotTo(BeNil())
+	g.Expect(cm.Name).To(Equal("test-cluster-data-efk-config"))
+	g.Expect(cm.Data[fluentdConfigKey]).To(ContainSubstring(`cluster "test-cluster"`))
+	g.Expect(cm.Data[fluentdConfigKey]).To(ContainSubstring("logstash_prefix app"))
+}
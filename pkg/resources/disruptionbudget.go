@@ -0,0 +1,65 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package resources
+
+import (
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// The maximum number of unavailable Pods if a PodDisruptionBudget does not specify either
+// the minimum available or maximum unavailable Pods.
+const defaultMaxUnavailable = 1
+
+// GetPodDisruptionBudgetName returns the name of a role's PodDisruptionBudget.
+func GetPodDisruptionBudgetName(spec *coh.CoherenceInternalSpec) string {
+	return GetFullName(spec)
+}
+
+// NewPodDisruptionBudget creates the PodDisruptionBudget for a role's Pods,
+// or returns nil if the role does not have a PodDisruptionBudget.
+func NewPodDisruptionBudget(namespace string, spec *coh.CoherenceInternalSpec) *policyv1beta1.PodDisruptionBudget {
+	if !spec.IsPodDisruptionBudgetEnabled() {
+		return nil
+	}
+
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		TypeMeta: metav1.TypeMeta{APIVersion: "policy/v1beta1", Kind: "PodDisruptionBudget"},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      GetPodDisruptionBudgetName(spec),
+			Labels:    componentLabels(spec, "coherence-pdb"),
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: podSelectorLabels(spec)},
+		},
+	}
+
+	budget := spec.PodDisruptionBudget
+	switch {
+	case budget != nil && budget.MinAvailable != nil:
+		minAvailable := *budget.MinAvailable
+		pdb.Spec.MinAvailable = &minAvailable
+	case budget != nil && budget.MaxUnavailable != nil:
+		maxUnavailable := *budget.MaxUnavailable
+		pdb.Spec.MaxUnavailable = &maxUnavailable
+	default:
+		maxUnavailable := intstr.FromInt(defaultMaxUnavailable)
+		pdb.Spec.MaxUnavailable = &maxUnavailable
+	}
+
+	return pdb
+}
+
+// LockPodDisruptionBudget changes a PodDisruptionBudget so that none of the role's Pods can be evicted.
+func LockPodDisruptionBudget(pdb *policyv1beta1.PodDisruptionBudget) {
+	maxUnavailable := intstr.FromInt(0)
+	pdb.Spec.MinAvailable = nil
+	pdb.Spec.MaxUnavailable = &maxUnavailable
+}
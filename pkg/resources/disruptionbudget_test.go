@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package resources
+
+import (
+	. "github.com/onsi/gomega"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
+	"testing"
+)
+
+func TestPodDisruptionBudgetDefaultsForStorageEnabledRole(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	pdb := NewPodDisruptionBudget("test-ns", newSpec())
+	g.Expect(pdb).NotTo(BeNil())
+
+	maxUnavailable := intstr.FromInt(1)
+	g.Expect(pdb.Namespace).To(Equal("test-ns"))
+	g.Expect(pdb.Name).To(Equal("test-cluster-data"))
+	g.Expect(pdb.Spec.MinAvailable).To(BeNil())
+	g.Expect(pdb.Spec.MaxUnavailable).To(Equal(&maxUnavailable))
+	g.Expect(pdb.Spec.Selector.MatchLabels).To(Equal(map[string]string{
+		CoherenceDeploymentLabel:    "test-cluster-data",
+		coh.CoherenceComponentLabel: "coherencePod",
+	}))
+}
+
+func TestNoPodDisruptionBudgetForStorageDisabledRole(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	spec := newSpec()
+	spec.Coherence = &coh.CoherenceSpec{StorageEnabled: pointer.BoolPtr(false)}
+	g.Expect(NewPodDisruptionBudget("test-ns", spec)).To(BeNil())
+
+	spec.PodDisruptionBudget = &coh.PodDisruptionBudgetSpec{Enabled: pointer.BoolPtr(true)}
+	g.Expect(NewPodDisruptionBudget("test-ns", spec)).NotTo(BeNil())
+}
+
+func TestNoPodDisruptionBudgetWhenDisabled(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	spec := newSpec()
+	spec.PodDisruptionBudget = &coh.PodDisruptionBudgetSpec{Enabled: pointer.BoolPtr(false)}
+	g.Expect(NewPodDisruptionBudget("test-ns", spec)).To(BeNil())
+}
+
+func TestPodDisruptionBudgetWithMinAvailable(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	minAvailable := intstr.FromString("50%")
+	spec := newSpec()
+	spec.PodDisruptionBudget = &coh.PodDisruptionBudgetSpec{MinAvailable: &minAvailable}
+
+	pdb := NewPodDisruptionBudget("test-ns", spec)
+	g.Expect(pdb.Spec.MinAvailable).To(Equal(&minAvailable))
+	g.Expect(pdb.Spec.MaxUnavailable).To(BeNil())
+}
+
+func TestLockPodDisruptionBudget(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	minAvailable := intstr.FromInt(2)
+	spec := newSpec()
+	spec.PodDisruptionBudget = &coh.PodDisruptionBudgetSpec{MinAvailable: &minAvailable}
+
+	pdb := NewPodDisruptionBudget("test-ns", spec)
+	LockPodDisruptionBudget(pdb)
+
+	maxUnavailable := intstr.FromInt(0)
+	g.Expect(pdb.Spec.MinAvailable).To(BeNil())
+	g.Expect(pdb.Spec.MaxUnavailable).To(Equal(&maxUnavailable))
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+// Package resources builds the Kubernetes resources that make up a Coherence role
+// (the StatefulSet, Services, ConfigMaps and PodDisruptionBudget) from a CoherenceInternalSpec.
+package resources
+
+import (
+	"encoding/json"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"strings"
+)
+
+const (
+	// The annotation on a role's StatefulSet holding the json of the CoherenceInternalSpec it was created from.
+	SpecAnnotation = "coherence.oracle.com/spec"
+
+	// The label holding the full name of the role that a resource belongs to.
+	CoherenceDeploymentLabel = "coherenceDeployment"
+	// The label used to mark Coherence Pods as members of the cluster's WKA list.
+	CoherenceWKAMemberLabel = "coherenceWKAMember"
+
+	// The name of the Coherence container in the Coherence Pods
+	CoherenceContainerName = "coherence"
+	// The name of the Coherence Utils init-container in the Coherence Pods
+	CoherenceUtilsContainerName = "coherence-k8s-utils"
+	// The name of the application init-container in the Coherence Pods
+	ApplicationContainerName = "application"
+	// The name of the Fluentd side-car container in the Coherence Pods
+	FluentdContainerName = "fluentd"
+
+	// The name of the StatefulSet's governing service; this is a fixed value
+	// as the field is immutable and roles created by previous Operator versions used it.
+	defaultServiceName = "coherence"
+
+	// The maximum length of a resource name.
+	maxNameLength = 63
+)
+
+// New creates the resources for a role in the order they should be applied. The PodDisruptionBudget is not
+// included as it is locked and unlocked as the role's StatusHA changes, so it is created and updated separately
+// by the role controller rather than being re-applied with the role's other resources.
+func New(namespace string, spec *coh.CoherenceInternalSpec, scripts map[string]string) ([]runtime.Object, error) {
+	var objects []runtime.Object
+
+	objects = append(objects, NewScriptsConfigMap(namespace, spec, scripts))
+	if cm := NewFluentdConfigMap(namespace, spec); cm != nil {
+		objects = append(objects, cm)
+	}
+
+	objects = append(objects, NewHeadlessService(namespace, spec))
+	for _, svc := range NewPortServices(namespace, spec) {
+		objects = append(objects, svc)
+	}
+
+	sts, err := NewStatefulSet(namespace, spec)
+	if err != nil {
+		return nil, err
+	}
+	objects = append(objects, sts)
+
+	// annotate every resource with the hash of the spec it was created from
+	hash, err := SpecHash(spec)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range objects {
+		m, err := meta.Accessor(o)
+		if err != nil {
+			return nil, err
+		}
+		// copy the annotations as they may be shared with the spec
+		annotations := make(map[string]string)
+		for k, v := range m.GetAnnotations() {
+			annotations[k] = v
+		}
+		annotations[coh.SpecHashAnnotation] = hash
+		m.SetAnnotations(annotations)
+	}
+
+	return objects, nil
+}
+
+// SpecHash returns the hash of a CoherenceInternalSpec ignoring the replica count,
+// so that scaling a role is not seen as a change to the role's spec.
+func SpecHash(spec *coh.CoherenceInternalSpec) (string, error) {
+	clone := spec.DeepCopy()
+	clone.Replicas = nil
+	return coh.ComputeHash(clone)
+}
+
+// GetFullName returns the name of a role's StatefulSet; other resource names are derived from it.
+func GetFullName(spec *coh.CoherenceInternalSpec) string {
+	return truncateName(spec.FullnameOverride)
+}
+
+// GetAppliedSpec returns the CoherenceInternalSpec stored in the SpecAnnotation
+// of a StatefulSet, or nil if the annotation is not present.
+func GetAppliedSpec(annotations map[string]string) (*coh.CoherenceInternalSpec, error) {
+	data, found := annotations[SpecAnnotation]
+	if !found {
+		return nil, nil
+	}
+
+	spec := &coh.CoherenceInternalSpec{}
+	if err := json.Unmarshal([]byte(data), spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// releaseLabels returns the labels common to all of a role's resources.
+func releaseLabels(spec *coh.CoherenceInternalSpec) map[string]string {
+	labels := make(map[string]string)
+	labels[CoherenceDeploymentLabel] = GetFullName(spec)
+	labels[coh.CoherenceClusterLabel] = spec.Cluster
+	labels[coh.CoherenceRoleLabel] = spec.GetRoleName()
+	return labels
+}
+
+// componentLabels returns the common labels plus the specified component label.
+func componentLabels(spec *coh.CoherenceInternalSpec, component string) map[string]string {
+	labels := releaseLabels(spec)
+	labels[coh.CoherenceComponentLabel] = component
+	return labels
+}
+
+// truncateName truncates a name to the maximum length allowed for a resource name.
+func truncateName(name string) string {
+	if len(name) > maxNameLength {
+		name = name[:maxNameLength]
+	}
+	return strings.TrimSuffix(name, "-")
+}
+
+// notEmpty returns true if the string pointer is not nil and not blank.
+func notEmpty(s *string) bool {
+	return s != nil && *s != ""
+}
+
+// isTrue returns true if the bool pointer is not nil and true.
+func isTrue(b *bool) bool {
+	return b != nil && *b
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package resources
+
+import (
+	. "github.com/onsi/gomega"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/utils/pointer"
+	"testing"
+)
+
+func TestNewAnnotatesResourcesWithSpecHash(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	spec := newSpec()
+	spec.Ports = []coh.NamedPortSpec{
+		{Name: "extend", PortSpec: coh.PortSpec{Port: 20000}},
+	}
+
+	hash, err := SpecHash(spec)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	objects, err := New("test-ns", spec, map[string]string{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(len(objects)).To(BeNumerically(">", 0))
+
+	for _, o := range objects {
+		m, err := meta.Accessor(o)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(coh.GetSpecHash(m)).To(Equal(hash))
+	}
+}
+
+func TestSpecHashIgnoresReplicas(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	spec := newSpec()
+	scaled := spec.DeepCopy()
+	scaled.Replicas = pointer.Int32Ptr(10)
+
+	hash, err := SpecHash(spec)
+	g.Expect(err).NotTo(HaveOccurred())
+	hashScaled, err := SpecHash(scaled)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(hashScaled).To(Equal(hash))
+}
+
+func TestSpecHashChangesWhenSpecChanges(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	spec := newSpec()
+	updated := spec.DeepCopy()
+	updated.Labels = map[string]string{"foo": "bar"}
+
+	hash, err := SpecHash(spec)
+	g.Expect(err).NotTo(HaveOccurred())
+	hashUpdated, err := SpecHash(updated)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(hashUpdated).NotTo(Equal(hash))
+}
+
+func TestSpecHashIsIndependentOfMapOrdering(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	one := newSpec()
+	one.Labels = map[string]string{"a": "1", "b": "2", "c": "3"}
+	two := newSpec()
+	two.Labels = map[string]string{"c": "3", "a": "1", "b": "2"}
+
+	hashOne, err := SpecHash(one)
+	g.Expect(err).NotTo(HaveOccurred())
+	hashTwo, err := SpecHash(two)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(hashTwo).To(Equal(hashOne))
+}
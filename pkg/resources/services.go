@@ -0,0 +1,147 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package resources
+
+import (
+	"fmt"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// The port used by the Coherence container for cluster membership.
+	coherencePort int32 = 7
+	// The name of the Coherence cluster port.
+	coherencePortName = "coherence"
+)
+
+// GetHeadlessServiceName returns the name of a role's headless Service.
+func GetHeadlessServiceName(spec *coh.CoherenceInternalSpec) string {
+	return GetFullName(spec) + "-headless"
+}
+
+// GetPortServiceName returns the name of the Service exposing a role's named port.
+func GetPortServiceName(spec *coh.CoherenceInternalSpec, port coh.NamedPortSpec) string {
+	if port.Service != nil && notEmpty(port.Service.Name) {
+		return *port.Service.Name
+	}
+	return fmt.Sprintf("%s-%s-%s", spec.Cluster, spec.GetRoleName(), port.Name)
+}
+
+// NewHeadlessService creates the headless Service for a role's Pods.
+func NewHeadlessService(namespace string, spec *coh.CoherenceInternalSpec) *corev1.Service {
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        GetHeadlessServiceName(spec),
+			Labels:      componentLabels(spec, "coherence-headless"),
+			Annotations: map[string]string{"service.alpha.kubernetes.io/tolerate-unready-endpoints": "true"},
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: corev1.ClusterIPNone,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       coherencePortName,
+					Protocol:   corev1.ProtocolTCP,
+					Port:       coherencePort,
+					TargetPort: intstr.FromInt(int(coherencePort)),
+				},
+			},
+			Selector: podSelectorLabels(spec),
+		},
+	}
+}
+
+// NewPortServices creates a Service for each of the role's additional ports that has a Service enabled.
+func NewPortServices(namespace string, spec *coh.CoherenceInternalSpec) []*corev1.Service {
+	var services []*corev1.Service
+	for _, port := range spec.Ports {
+		if port.Service != nil && port.Service.Enabled != nil && !*port.Service.Enabled {
+			continue
+		}
+		services = append(services, newPortService(namespace, spec, port))
+	}
+	return services
+}
+
+// newPortService creates the Service exposing a single named port.
+func newPortService(namespace string, spec *coh.CoherenceInternalSpec, port coh.NamedPortSpec) *corev1.Service {
+	labels := releaseLabels(spec)
+	var annotations map[string]string
+	svcPort := port.Port
+	svcSpec := corev1.ServiceSpec{}
+
+	if s := port.Service; s != nil {
+		for k, v := range s.Labels {
+			labels[k] = v
+		}
+		annotations = s.Annotations
+		if s.Port != nil {
+			svcPort = *s.Port
+		}
+		if s.Type != nil {
+			svcSpec.Type = *s.Type
+		}
+		if s.LoadBalancerIP != nil {
+			svcSpec.LoadBalancerIP = *s.LoadBalancerIP
+		}
+		if s.SessionAffinity != nil {
+			svcSpec.SessionAffinity = *s.SessionAffinity
+		}
+		svcSpec.LoadBalancerSourceRanges = s.LoadBalancerSourceRanges
+		if s.ExternalName != nil {
+			svcSpec.ExternalName = *s.ExternalName
+		}
+		if s.ExternalTrafficPolicy != nil {
+			svcSpec.ExternalTrafficPolicy = *s.ExternalTrafficPolicy
+		}
+		if s.HealthCheckNodePort != nil {
+			svcSpec.HealthCheckNodePort = *s.HealthCheckNodePort
+		}
+		if s.PublishNotReadyAddresses != nil {
+			svcSpec.PublishNotReadyAddresses = *s.PublishNotReadyAddresses
+		}
+		svcSpec.SessionAffinityConfig = s.SessionAffinityConfig
+	}
+	labels[coh.CoherenceComponentLabel] = "coherence-service-" + port.Name
+
+	svcSpec.Ports = []corev1.ServicePort{
+		{
+			Name:       port.Name,
+			Protocol:   getProtocol(port.Protocol),
+			Port:       svcPort,
+			TargetPort: intstr.FromString(port.Name),
+		},
+	}
+
+	selector := podSelectorLabels(spec)
+	selector[coh.CoherenceClusterLabel] = spec.Cluster
+	selector[coh.CoherenceRoleLabel] = spec.GetRoleName()
+	svcSpec.Selector = selector
+
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        GetPortServiceName(spec, port),
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: svcSpec,
+	}
+}
+
+// getProtocol returns the protocol for a port, defaulting to TCP.
+func getProtocol(protocol *string) corev1.Protocol {
+	if notEmpty(protocol) {
+		return corev1.Protocol(*protocol)
+	}
+	return corev1.ProtocolTCP
+}
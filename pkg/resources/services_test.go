@@ -0,0 +1,58 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package resources
+
+import (
+	. "github.com/onsi/gomega"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"testing"
+)
+
+func TestHeadlessService(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	svc := NewHeadlessService("test-ns", newSpec())
+
+	g.Expect(svc.Namespace).To(Equal("test-ns"))
+	g.Expect(svc.Name).To(Equal("test-cluster-data-headless"))
+	g.Expect(svc.Spec.ClusterIP).To(Equal(corev1.ClusterIPNone))
+	g.Expect(svc.Spec.Selector).To(Equal(map[string]string{
+		CoherenceDeploymentLabel:    "test-cluster-data",
+		coh.CoherenceComponentLabel: "coherencePod",
+	}))
+}
+
+func TestPortServices(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	spec := newSpec()
+	disabled := false
+	name := "my-extend"
+	port := int32(9099)
+	protocol := "UDP"
+	spec.Ports = []coh.NamedPortSpec{
+		{Name: "extend", PortSpec: coh.PortSpec{Port: 20000, Service: &coh.ServiceSpec{Name: &name, Port: &port}}},
+		{Name: "disabled", PortSpec: coh.PortSpec{Port: 20001, Service: &coh.ServiceSpec{Enabled: &disabled}}},
+		{Name: "udp", PortSpec: coh.PortSpec{Port: 20002, Protocol: &protocol}},
+	}
+
+	services := NewPortServices("test-ns", spec)
+	g.Expect(len(services)).To(Equal(2))
+
+	g.Expect(services[0].Name).To(Equal("my-extend"))
+	g.Expect(services[0].Spec.Ports).To(Equal([]corev1.ServicePort{
+		{Name: "extend", Protocol: corev1.ProtocolTCP, Port: 9099, TargetPort: intstr.FromString("extend")},
+	}))
+	g.Expect(services[0].Labels).To(HaveKeyWithValue(coh.CoherenceComponentLabel, "coherence-service-extend"))
+	g.Expect(services[0].Spec.Selector).To(HaveKeyWithValue(coh.CoherenceRoleLabel, "data"))
+
+	g.Expect(services[1].Name).To(Equal("test-cluster-data-udp"))
+	g.Expect(services[1].Spec.Ports[0].Protocol).To(Equal(corev1.ProtocolUDP))
+	g.Expect(services[1].Spec.Ports[0].Port).To(Equal(int32(20002)))
+}
@@ -0,0 +1,837 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/management"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"strconv"
+	"strings"
+)
+
+const (
+	// The directories in the application image that artifacts are copied from.
+	extAppDir  = "/u01/oracle/oracle_home/coherence/app"
+	extLibDir  = extAppDir + "/lib"
+	extConfDir = extAppDir + "/conf"
+
+	// The directories that application artifacts are copied to.
+	defaultAppDir  = "/app"
+	defaultLibDir  = "/app/lib"
+	defaultConfDir = "/app/conf"
+
+	utilsDir           = "/utils"
+	scriptsDir         = "/scripts"
+	loggingConfigDir   = "/loggingconfig"
+	persistenceDir     = "/persistence"
+	snapshotDir        = "/snapshot"
+	legacySnapshotDir  = "/root/coherence/snapshot"
+	managementCertsDir = "/coherence/certs/management"
+	metricsCertsDir    = "/coherence/certs/metrics"
+
+	volumeLogs           = "log-dir"
+	volumeUtils          = "utils-dir"
+	volumeApplication    = "application-dir"
+	volumeJVM            = "jvm"
+	volumeScripts        = "coherence-scripts"
+	volumeLoggingConfig  = "logging-config"
+	volumeManagementSSL  = "management-ssl-config"
+	volumeMetricsSSL     = "metrics-ssl-config"
+	volumePersistence    = "persistence-volume"
+	volumeSnapshot       = "snapshot-volume"
+	volumeFluentdConfig  = "fluentd-coherence-conf"
+	defaultLoggingConfig = scriptsDir + "/logging.properties"
+
+	defaultFluentdImage       = "fluent/fluentd-kubernetes-daemonset:v1.3.3-debian-elasticsearch-1.3"
+	operatorConfigSecret      = "coherence-operator-config"
+	monitoringConfigSecret    = "coherence-monitoring-config"
+	defaultManagementPort     = management.DefaultPort
+	defaultMetricsPort        = 9612
+	defaultDebugPort          = 5005
+	defaultRequestTimeout     = 120
+	defaultRevisionHistory    = 5
+	defaultAntiAffinityWeight = 1
+	zoneTopologyKey           = "failure-domain.beta.kubernetes.io/zone"
+)
+
+// NewStatefulSet creates the StatefulSet for a role.
+// The spec the StatefulSet was created from is stored in the SpecAnnotation annotation.
+func NewStatefulSet(namespace string, spec *coh.CoherenceInternalSpec) (*appsv1.StatefulSet, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	replicas := spec.GetReplicas()
+	revisionHistory := int32(defaultRevisionHistory)
+
+	sts := &appsv1.StatefulSet{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "StatefulSet"},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        GetFullName(spec),
+			Labels:      componentLabels(spec, "coherence"),
+			Annotations: map[string]string{SpecAnnotation: string(data)},
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:             &replicas,
+			PodManagementPolicy:  appsv1.ParallelPodManagement,
+			UpdateStrategy:       getUpdateStrategy(spec),
+			RevisionHistoryLimit: &revisionHistory,
+			Selector:             &metav1.LabelSelector{MatchLabels: podSelectorLabels(spec)},
+			ServiceName:          getServiceName(spec),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      podLabels(spec),
+					Annotations: spec.Annotations,
+				},
+				Spec: newPodSpec(spec),
+			},
+			VolumeClaimTemplates: newVolumeClaimTemplates(spec),
+		},
+	}
+
+	return sts, nil
+}
+
+// getUpdateStrategy returns the StatefulSet's update strategy for the role's upgrade policy.
+// With the Safe upgrade policy the Operator deletes the Pods itself so the OnDelete strategy is used.
+func getUpdateStrategy(spec *coh.CoherenceInternalSpec) appsv1.StatefulSetUpdateStrategy {
+	if spec.GetEffectiveUpgradePolicy() == coh.SafeUpgrade {
+		return appsv1.StatefulSetUpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType}
+	}
+	return appsv1.StatefulSetUpdateStrategy{Type: appsv1.RollingUpdateStatefulSetStrategyType}
+}
+
+// getServiceName returns the StatefulSet's governing service name.
+func getServiceName(spec *coh.CoherenceInternalSpec) string {
+	if spec.NameOverride != "" {
+		return truncateName(spec.NameOverride)
+	}
+	return defaultServiceName
+}
+
+// podSelectorLabels returns the labels used to select a role's Pods.
+func podSelectorLabels(spec *coh.CoherenceInternalSpec) map[string]string {
+	return map[string]string{
+		CoherenceDeploymentLabel:    GetFullName(spec),
+		coh.CoherenceComponentLabel: "coherencePod",
+	}
+}
+
+// podLabels returns the labels applied to a role's Pods.
+func podLabels(spec *coh.CoherenceInternalSpec) map[string]string {
+	labels := componentLabels(spec, "coherencePod")
+	labels[CoherenceWKAMemberLabel] = strconv.FormatBool(spec.Coherence == nil || !isTrue(spec.Coherence.ExcludeFromWKA))
+	for k, v := range spec.Labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// newPodSpec creates the Pod spec for a role's Pods.
+func newPodSpec(spec *coh.CoherenceInternalSpec) corev1.PodSpec {
+	podSpec := corev1.PodSpec{
+		AutomountServiceAccountToken: spec.AutomountServiceAccountToken,
+		SecurityContext:              spec.SecurityContext,
+		ShareProcessNamespace:        spec.ShareProcessNamespace,
+		Tolerations:                  spec.Tolerations,
+		NodeSelector:                 spec.NodeSelector,
+		Affinity:                     getAffinity(spec),
+		InitContainers:               newInitContainers(spec),
+		Containers:                   newContainers(spec),
+		Volumes:                      newVolumes(spec),
+	}
+
+	if spec.ServiceAccountName != "default" {
+		podSpec.ServiceAccountName = spec.ServiceAccountName
+	}
+
+	if spec.HostIPC != nil {
+		podSpec.HostIPC = *spec.HostIPC
+	}
+
+	for _, s := range spec.ImagePullSecrets {
+		podSpec.ImagePullSecrets = append(podSpec.ImagePullSecrets, corev1.LocalObjectReference{Name: s.Name})
+	}
+
+	if n := spec.Network; n != nil {
+		if n.DNSConfig != nil {
+			podSpec.DNSConfig = &corev1.PodDNSConfig{
+				Nameservers: n.DNSConfig.Nameservers,
+				Searches:    n.DNSConfig.Searches,
+				Options:     n.DNSConfig.Options,
+			}
+		}
+		if notEmpty(n.DNSPolicy) {
+			podSpec.DNSPolicy = corev1.DNSPolicy(*n.DNSPolicy)
+		}
+		podSpec.HostAliases = n.HostAliases
+		if n.HostNetwork != nil {
+			podSpec.HostNetwork = *n.HostNetwork
+		}
+		if n.Hostname != nil {
+			podSpec.Hostname = *n.Hostname
+		}
+	}
+
+	return podSpec
+}
+
+// getAffinity returns the role's affinity or a default anti-affinity that spreads the Pods across zones.
+func getAffinity(spec *coh.CoherenceInternalSpec) *corev1.Affinity {
+	if spec.Affinity != nil {
+		return spec.Affinity
+	}
+
+	return &corev1.Affinity{
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+				{
+					Weight: defaultAntiAffinityWeight,
+					PodAffinityTerm: corev1.PodAffinityTerm{
+						LabelSelector: &metav1.LabelSelector{
+							MatchExpressions: []metav1.LabelSelectorRequirement{
+								{
+									Key:      coh.CoherenceClusterLabel,
+									Operator: metav1.LabelSelectorOpIn,
+									Values:   []string{spec.Cluster},
+								},
+								{
+									Key:      coh.CoherenceRoleLabel,
+									Operator: metav1.LabelSelectorOpIn,
+									Values:   []string{spec.GetRoleName()},
+								},
+							},
+						},
+						TopologyKey: zoneTopologyKey,
+					},
+				},
+			},
+		},
+	}
+}
+
+// newInitContainers creates the init-containers that copy the utilities and application artifacts into the Pod.
+func newInitContainers(spec *coh.CoherenceInternalSpec) []corev1.Container {
+	utils := corev1.Container{
+		Name:  CoherenceUtilsContainerName,
+		Image: getImage(spec.CoherenceUtils),
+		Env: []corev1.EnvVar{
+			{Name: "COH_UTIL_DIR", Value: utilsDir},
+			{Name: "COH_CLUSTER_NAME", Value: spec.Cluster},
+		},
+		VolumeMounts: []corev1.VolumeMount{{Name: volumeUtils, MountPath: utilsDir}},
+		Command:      []string{"/files/utils-init"},
+	}
+	utils.ImagePullPolicy = getPullPolicy(spec.CoherenceUtils)
+	utils.VolumeMounts = append(utils.VolumeMounts, storageVolumeMounts(spec)...)
+
+	containers := []corev1.Container{utils}
+
+	if app := spec.Application; app != nil && notEmpty(app.Image) {
+		containers = append(containers, corev1.Container{
+			Name:            ApplicationContainerName,
+			Image:           *app.Image,
+			ImagePullPolicy: getPullPolicy(&app.ImageSpec),
+			Env: []corev1.EnvVar{
+				{Name: "EXTERNAL_APP_DIR", Value: extAppDir},
+				{Name: "APP_DIR", Value: stringOrDefault(app.AppDir, defaultAppDir)},
+				{Name: "EXTERNAL_LIB_DIR", Value: extLibDir},
+				{Name: "LIB_DIR", Value: stringOrDefault(app.LibDir, defaultLibDir)},
+				{Name: "EXTERNAL_CONF_DIR", Value: extConfDir},
+				{Name: "CONF_DIR", Value: stringOrDefault(app.ConfigDir, defaultConfDir)},
+			},
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: volumeUtils, MountPath: utilsDir},
+				{Name: volumeApplication, MountPath: extAppDir},
+			},
+			Command: []string{utilsDir + "/copy"},
+		})
+	}
+
+	return containers
+}
+
+// newContainers creates the Coherence container and, if enabled, the Fluentd side-car.
+func newContainers(spec *coh.CoherenceInternalSpec) []corev1.Container {
+	containers := []corev1.Container{newCoherenceContainer(spec)}
+	if isFluentdEnabled(spec) {
+		containers = append(containers, newFluentdContainer(spec))
+	}
+	return containers
+}
+
+// newCoherenceContainer creates the container running the Coherence JVM.
+func newCoherenceContainer(spec *coh.CoherenceInternalSpec) corev1.Container {
+	var image *coh.ImageSpec
+	if spec.Coherence != nil {
+		image = &spec.Coherence.ImageSpec
+	}
+
+	c := corev1.Container{
+		Name:            CoherenceContainerName,
+		Image:           getImage(image),
+		ImagePullPolicy: getPullPolicy(image),
+		Ports:           newContainerPorts(spec),
+		Env:             newCoherenceEnv(spec),
+		ReadinessProbe:  newProbe(spec, spec.ReadinessProbe, "/ready", probeDefaults{30, 60, 50, 5}),
+		LivenessProbe:   newProbe(spec, spec.LivenessProbe, "/healthz", probeDefaults{45, 60, 5, 5}),
+		Command:         []string{"/bin/sh", "-x", scriptsDir + "/startCoherence.sh", "server"},
+		Resources:       getResources(spec),
+		VolumeMounts:    newCoherenceVolumeMounts(spec),
+	}
+
+	// the liveness probe does not have a success threshold
+	c.LivenessProbe.SuccessThreshold = 0
+
+	return c
+}
+
+// newContainerPorts creates the ports exposed by the Coherence container.
+func newContainerPorts(spec *coh.CoherenceInternalSpec) []corev1.ContainerPort {
+	ports := []corev1.ContainerPort{
+		{Name: coherencePortName, ContainerPort: coherencePort},
+		{Name: "health", ContainerPort: spec.GetHealthPort()},
+	}
+
+	if debug := getDebugSpec(spec); debug != nil {
+		ports = append(ports, corev1.ContainerPort{Name: "debug-port", ContainerPort: int32OrDefault(debug.Port, defaultDebugPort)})
+	}
+
+	for _, p := range spec.Ports {
+		ports = append(ports, corev1.ContainerPort{Name: p.Name, ContainerPort: p.Port, Protocol: getProtocol(p.Protocol)})
+	}
+
+	return ports
+}
+
+// newCoherenceEnv creates the environment variables for the Coherence container.
+func newCoherenceEnv(spec *coh.CoherenceInternalSpec) []corev1.EnvVar {
+	var env []corev1.EnvVar
+	env = append(env, spec.Env...)
+
+	mgmt := getPortSpecWithSSL(spec, func(c *coh.CoherenceSpec) *coh.PortSpecWithSSL { return c.Management })
+	metrics := getPortSpecWithSSL(spec, func(c *coh.CoherenceSpec) *coh.PortSpecWithSSL { return c.Metrics })
+
+	env = append(env,
+		corev1.EnvVar{Name: "COH_WKA", Value: spec.WKA},
+		corev1.EnvVar{Name: "COH_APP_DIR", Value: extAppDir},
+		corev1.EnvVar{Name: "COH_EXTRA_CLASSPATH", Value: fmt.Sprintf("%s/*:%s", extLibDir, extConfDir)},
+		corev1.EnvVar{Name: "COH_MGMT_HTTP_PORT", Value: strconv.Itoa(int(int32OrDefault(mgmt.Port, defaultManagementPort)))},
+		corev1.EnvVar{Name: "COH_METRICS_PORT", Value: strconv.Itoa(int(int32OrDefault(metrics.Port, defaultMetricsPort)))},
+		fieldRefEnv("COH_MACHINE_NAME", "spec.nodeName"),
+		fieldRefEnv("COH_MEMBER_NAME", "metadata.name"),
+		fieldRefEnv("COH_POD_UID", "metadata.uid"),
+		secretKeyEnv("OPERATOR_HOST", operatorConfigSecret, "operatorhost", true),
+		secretKeyEnv("OPERATOR_TLS", operatorConfigSecret, "operatortls", true),
+		secretKeyEnv("OPERATOR_CA", operatorConfigSecret, "operatorca", true),
+		corev1.EnvVar{Name: "COH_SITE_INFO_LOCATION", Value: "http://$(OPERATOR_HOST)/site/$(COH_MACHINE_NAME)"},
+		corev1.EnvVar{Name: "COH_RACK_INFO_LOCATION", Value: "http://$(OPERATOR_HOST)/rack/$(COH_MACHINE_NAME)"},
+		corev1.EnvVar{Name: "COH_TOPOLOGY_INFO_LOCATION", Value: "http://$(OPERATOR_HOST)/topology/$(COH_MACHINE_NAME)"},
+		corev1.EnvVar{Name: "COH_CLUSTER_NAME", Value: spec.Cluster},
+		corev1.EnvVar{Name: "COH_ROLE", Value: spec.GetRoleName()},
+		corev1.EnvVar{Name: "COH_UTIL_DIR", Value: utilsDir},
+	)
+
+	env = append(env, applicationEnv(spec)...)
+	env = append(env, jvmEnv(spec)...)
+	env = append(env, coherenceEnv(spec)...)
+
+	env = append(env, corev1.EnvVar{Name: "COH_MGMT_ENABLED", Value: strconv.FormatBool(isTrue(mgmt.Enabled))})
+	if isTrue(mgmt.Enabled) {
+		env = append(env, sslEnv("COH_MGMT", managementCertsDir, mgmt.SSL)...)
+	}
+	env = append(env, corev1.EnvVar{Name: "COH_METRICS_ENABLED", Value: strconv.FormatBool(isTrue(metrics.Enabled))})
+	if isTrue(metrics.Enabled) {
+		env = append(env, sslEnv("COH_METRICS", metricsCertsDir, metrics.SSL)...)
+	}
+
+	env = append(env, corev1.EnvVar{Name: "COH_HEALTH_PORT", Value: strconv.Itoa(int(spec.GetHealthPort()))})
+	if spec.Coherence != nil && spec.Coherence.LogLevel != nil {
+		env = append(env, corev1.EnvVar{Name: "COH_LOG_LEVEL", Value: strconv.Itoa(int(*spec.Coherence.LogLevel))})
+	}
+	env = append(env, corev1.EnvVar{Name: "COH_LOGGING_CONFIG", Value: getLoggingConfig(spec)})
+
+	return env
+}
+
+// applicationEnv creates the environment variables configuring the application's main class and arguments.
+func applicationEnv(spec *coh.CoherenceInternalSpec) []corev1.EnvVar {
+	var env []corev1.EnvVar
+	app := spec.Application
+	if app == nil {
+		return env
+	}
+
+	if notEmpty(app.Type) {
+		env = append(env, corev1.EnvVar{Name: "APP_TYPE", Value: *app.Type})
+	}
+	if notEmpty(app.Main) {
+		env = append(env, corev1.EnvVar{Name: "COH_MAIN_CLASS", Value: *app.Main})
+	}
+	if len(app.Args) > 0 {
+		env = append(env, corev1.EnvVar{Name: "COH_MAIN_ARGS", Value: strings.Join(app.Args, " ")})
+	}
+	return env
+}
+
+// jvmEnv creates the environment variables configuring the JVM.
+func jvmEnv(spec *coh.CoherenceInternalSpec) []corev1.EnvVar {
+	var env []corev1.EnvVar
+	jvm := spec.JVM
+	if jvm == nil {
+		jvm = &coh.JVMSpec{}
+	}
+
+	if len(jvm.Args) > 0 {
+		env = append(env, corev1.EnvVar{Name: "JVM_ARGS", Value: strings.Join(jvm.Args, " ")})
+	}
+	env = append(env, corev1.EnvVar{Name: "JVM_USE_CONTAINER_LIMITS", Value: boolOrDefault(jvm.UseContainerLimits, true)})
+	env = append(env, corev1.EnvVar{Name: "JVM_FLIGHT_RECORDER", Value: boolOrDefault(jvm.FlightRecorder, true)})
+
+	if mem := jvm.Memory; mem != nil {
+		env = appendStringEnv(env, "JVM_HEAP_SIZE", mem.HeapSize)
+		env = appendStringEnv(env, "JVM_DIRECT_MEMORY_SIZE", mem.DirectMemorySize)
+		env = appendStringEnv(env, "JVM_STACK_SIZE", mem.StackSize)
+		env = appendStringEnv(env, "JVM_METASPACE_SIZE", mem.MetaspaceSize)
+		env = appendStringEnv(env, "JVM_NATIVE_MEMORY_TRACKING", mem.NativeMemoryTracking)
+	}
+
+	if jvm.Jmxmp != nil && isTrue(jvm.Jmxmp.Enabled) {
+		port := ""
+		if jvm.Jmxmp.Port != nil {
+			port = strconv.Itoa(int(*jvm.Jmxmp.Port))
+		}
+		env = append(env, corev1.EnvVar{Name: "JVM_JMXMP_ENABLED", Value: "true"})
+		env = append(env, corev1.EnvVar{Name: "JVM_JMXMP_PORT", Value: port})
+	}
+
+	if jvm.Memory != nil && jvm.Memory.OnOutOfMemory != nil {
+		oom := jvm.Memory.OnOutOfMemory
+		if oom.Exit != nil {
+			env = append(env, corev1.EnvVar{Name: "JVM_OOM_EXIT", Value: strconv.FormatBool(*oom.Exit)})
+		}
+		if oom.HeapDump != nil {
+			env = append(env, corev1.EnvVar{Name: "JVM_OOM_HEAP_DUMP", Value: strconv.FormatBool(*oom.HeapDump)})
+		}
+	}
+
+	gc := jvm.Gc
+	if gc == nil {
+		gc = &coh.JvmGarbageCollectorSpec{}
+	}
+	if len(gc.Args) > 0 {
+		env = append(env, corev1.EnvVar{Name: "JVM_GC_ARGS", Value: strings.Join(gc.Args, " ")})
+	}
+	env = appendStringEnv(env, "JVM_GC_COLLECTOR", gc.Collector)
+	env = append(env, corev1.EnvVar{Name: "JVM_GC_LOGGING", Value: boolOrDefault(gc.Logging, true)})
+
+	if debug := getDebugSpec(spec); debug != nil {
+		env = append(env, corev1.EnvVar{Name: "JVM_DEBUG_ENABLED", Value: "true"})
+		if debug.Port != nil {
+			env = append(env, corev1.EnvVar{Name: "JVM_DEBUG_PORT", Value: strconv.Itoa(int(*debug.Port))})
+		}
+		if isTrue(debug.Suspend) {
+			env = append(env, corev1.EnvVar{Name: "JVM_DEBUG_SUSPEND", Value: "true"})
+		}
+		env = appendStringEnv(env, "JVM_DEBUG_ATTACH", debug.Attach)
+	}
+
+	return env
+}
+
+// coherenceEnv creates the environment variables configuring Coherence.
+func coherenceEnv(spec *coh.CoherenceInternalSpec) []corev1.EnvVar {
+	var env []corev1.EnvVar
+	c := spec.Coherence
+	if c == nil {
+		c = &coh.CoherenceSpec{}
+	}
+
+	env = appendStringEnv(env, "COH_CACHE_CONFIG", c.CacheConfig)
+	env = appendStringEnv(env, "COH_OVERRIDE_CONFIG", c.OverrideConfig)
+	if c.StorageEnabled != nil {
+		env = append(env, corev1.EnvVar{Name: "COH_STORAGE_ENABLED", Value: strconv.FormatBool(*c.StorageEnabled)})
+	}
+
+	timeout := int32OrDefault(spec.OperatorRequestTimeout, defaultRequestTimeout)
+	env = append(env, corev1.EnvVar{Name: "OPERATOR_REQUEST_TIMEOUT", Value: strconv.Itoa(int(timeout))})
+
+	if c.Persistence.IsEnabled() {
+		env = append(env, corev1.EnvVar{Name: "COH_PERSISTENCE_ENABLED", Value: "true"})
+	}
+	if c.Snapshot.IsEnabled() {
+		env = append(env, corev1.EnvVar{Name: "COH_SNAPSHOT_ENABLED", Value: "true"})
+	}
+
+	return env
+}
+
+// sslEnv creates the environment variables configuring SSL for the management or metrics endpoint.
+func sslEnv(prefix, certsDir string, ssl *coh.SSLSpec) []corev1.EnvVar {
+	var env []corev1.EnvVar
+	if ssl == nil {
+		return env
+	}
+
+	if isTrue(ssl.Enabled) {
+		env = append(env, corev1.EnvVar{Name: prefix + "_SSL_ENABLED", Value: "true"})
+	}
+	if notEmpty(ssl.Secrets) {
+		env = append(env, corev1.EnvVar{Name: prefix + "_SSL_CERTS", Value: certsDir})
+	}
+	env = appendStringEnv(env, prefix+"_SSL_KEYSTORE", ssl.KeyStore)
+	env = appendStringEnv(env, prefix+"_SSL_KEYSTORE_PASSWORD_FILE", ssl.KeyStorePasswordFile)
+	env = appendStringEnv(env, prefix+"_SSL_KEY_PASSWORD_FILE", ssl.KeyPasswordFile)
+	env = appendStringEnv(env, prefix+"_SSL_KEYSTORE_ALGORITHM", ssl.KeyStoreAlgorithm)
+	env = appendStringEnv(env, prefix+"_SSL_KEYSTORE_PROVIDER", ssl.KeyStoreProvider)
+	env = appendStringEnv(env, prefix+"_SSL_KEYSTORE_TYPE", ssl.KeyStoreType)
+	env = appendStringEnv(env, prefix+"_SSL_TRUSTSTORE", ssl.TrustStore)
+	env = appendStringEnv(env, prefix+"_SSL_TRUSTSTORE_PASSWORD_FILE", ssl.TrustStorePasswordFile)
+	env = appendStringEnv(env, prefix+"_SSL_TRUSTSTORE_ALGORITHM", ssl.TrustStoreAlgorithm)
+	env = appendStringEnv(env, prefix+"_SSL_TRUSTSTORE_PROVIDER", ssl.TrustStoreProvider)
+	env = appendStringEnv(env, prefix+"_SSL_TRUSTSTORE_TYPE", ssl.TrustStoreType)
+	if isTrue(ssl.RequireClientCert) {
+		env = append(env, corev1.EnvVar{Name: prefix + "_SSL_REQUIRE_CLIENT_CERT", Value: "true"})
+	}
+
+	return env
+}
+
+// getLoggingConfig returns the location of the Java util logging configuration file.
+func getLoggingConfig(spec *coh.CoherenceInternalSpec) string {
+	logging := spec.Logging
+	if logging == nil || !notEmpty(logging.ConfigFile) {
+		return defaultLoggingConfig
+	}
+
+	switch {
+	case notEmpty(logging.ConfigMapName):
+		// relative to the logging-config ConfigMap volume
+		return loggingConfigDir + "/" + *logging.ConfigFile
+	case spec.Application != nil && notEmpty(spec.Application.Image):
+		// relative to the application image's configuration directory
+		return extConfDir + "/" + *logging.ConfigFile
+	default:
+		return *logging.ConfigFile
+	}
+}
+
+// probeDefaults holds the default timings for a probe.
+type probeDefaults struct {
+	initialDelay     int32
+	period           int32
+	failureThreshold int32
+	timeout          int32
+}
+
+// newProbe creates a readiness or liveness probe, defaulting to an http request to the health port.
+func newProbe(spec *coh.CoherenceInternalSpec, probe *coh.ReadinessProbeSpec, path string, defaults probeDefaults) *corev1.Probe {
+	if probe == nil {
+		probe = &coh.ReadinessProbeSpec{}
+	}
+
+	p := &corev1.Probe{
+		InitialDelaySeconds: int32OrDefault(probe.InitialDelaySeconds, defaults.initialDelay),
+		PeriodSeconds:       int32OrDefault(probe.PeriodSeconds, defaults.period),
+		FailureThreshold:    int32OrDefault(probe.FailureThreshold, defaults.failureThreshold),
+		TimeoutSeconds:      int32OrDefault(probe.TimeoutSeconds, defaults.timeout),
+	}
+	if probe.SuccessThreshold != nil {
+		p.SuccessThreshold = *probe.SuccessThreshold
+	}
+
+	switch {
+	case probe.Exec != nil:
+		p.Exec = probe.Exec
+	case probe.HTTPGet != nil:
+		p.HTTPGet = probe.HTTPGet
+	case probe.TCPSocket != nil:
+		p.TCPSocket = probe.TCPSocket
+	default:
+		p.HTTPGet = &corev1.HTTPGetAction{Path: path, Port: intstr.FromInt(int(spec.GetHealthPort()))}
+	}
+
+	return p
+}
+
+// getResources returns the role's resource requirements or the default CPU request and limit.
+func getResources(spec *coh.CoherenceInternalSpec) corev1.ResourceRequirements {
+	if spec.Resources != nil {
+		return *spec.Resources
+	}
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("0")},
+		Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("32")},
+	}
+}
+
+// newCoherenceVolumeMounts creates the volume mounts for the Coherence container.
+func newCoherenceVolumeMounts(spec *coh.CoherenceInternalSpec) []corev1.VolumeMount {
+	mounts := []corev1.VolumeMount{
+		{Name: volumeLogs, MountPath: "/logs"},
+		{Name: volumeUtils, MountPath: utilsDir},
+		{Name: volumeApplication, MountPath: extAppDir},
+		{Name: volumeScripts, MountPath: scriptsDir},
+		{Name: volumeJVM, MountPath: "/jvm"},
+	}
+
+	mounts = append(mounts, spec.VolumeMounts...)
+
+	if spec.Logging != nil && notEmpty(spec.Logging.ConfigMapName) {
+		mounts = append(mounts, corev1.VolumeMount{Name: volumeLoggingConfig, MountPath: loggingConfigDir})
+	}
+	if hasSSLSecrets(spec, func(c *coh.CoherenceSpec) *coh.PortSpecWithSSL { return c.Management }) {
+		mounts = append(mounts, corev1.VolumeMount{Name: volumeManagementSSL, MountPath: managementCertsDir, ReadOnly: true})
+	}
+	if hasSSLSecrets(spec, func(c *coh.CoherenceSpec) *coh.PortSpecWithSSL { return c.Metrics }) {
+		mounts = append(mounts, corev1.VolumeMount{Name: volumeMetricsSSL, MountPath: metricsCertsDir, ReadOnly: true})
+	}
+
+	return append(mounts, storageVolumeMounts(spec)...)
+}
+
+// storageVolumeMounts creates the persistence and snapshot volume mounts.
+func storageVolumeMounts(spec *coh.CoherenceInternalSpec) []corev1.VolumeMount {
+	var mounts []corev1.VolumeMount
+	if spec.Coherence == nil {
+		return mounts
+	}
+
+	persistence := spec.Coherence.Persistence
+	snapshot := spec.Coherence.Snapshot
+
+	if persistence.IsEnabled() {
+		mounts = append(mounts, corev1.VolumeMount{Name: volumePersistence, MountPath: persistenceDir})
+	}
+
+	switch {
+	case snapshot.IsEnabled():
+		mounts = append(mounts, corev1.VolumeMount{Name: volumeSnapshot, MountPath: snapshotDir})
+	case snapshot != nil && snapshot.Volume != nil && persistence.IsEnabled():
+		// a snapshot volume without snapshots enabled is mounted at the legacy location
+		mounts = append(mounts, corev1.VolumeMount{Name: volumeSnapshot, MountPath: legacySnapshotDir})
+	}
+
+	return mounts
+}
+
+// newFluentdContainer creates the Fluentd side-car that ships the Coherence logs to Elasticsearch.
+func newFluentdContainer(spec *coh.CoherenceInternalSpec) corev1.Container {
+	fluentd := spec.Logging.Fluentd
+
+	policy := corev1.PullIfNotPresent
+	if fluentd.ImagePullPolicy != nil {
+		policy = *fluentd.ImagePullPolicy
+	}
+
+	return corev1.Container{
+		Name:            FluentdContainerName,
+		Image:           stringOrDefault(fluentd.Image, defaultFluentdImage),
+		ImagePullPolicy: policy,
+		Args:            []string{"-c", "/etc/fluent.conf"},
+		Env: []corev1.EnvVar{
+			fieldRefEnv("COHERENCE_POD_ID", "metadata.uid"),
+			{Name: "FLUENTD_CONF", Value: fluentdConfigKey},
+			{Name: "FLUENT_ELASTICSEARCH_SED_DISABLE", Value: "true"},
+			secretKeyEnv("ELASTICSEARCH_HOST", monitoringConfigSecret, "elasticsearchhost", false),
+			secretKeyEnv("ELASTICSEARCH_PORT", monitoringConfigSecret, "elasticsearchport", false),
+			secretKeyEnv("ELASTICSEARCH_USER", monitoringConfigSecret, "elasticsearchuser", false),
+			secretKeyEnv("ELASTICSEARCH_PASSWORD", monitoringConfigSecret, "elasticsearchpassword", false),
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: volumeFluentdConfig, MountPath: "/fluentd/etc/" + fluentdConfigKey, SubPath: fluentdConfigKey},
+			{Name: volumeLogs, MountPath: "/logs"},
+		},
+	}
+}
+
+// newVolumes creates the Pod volumes.
+func newVolumes(spec *coh.CoherenceInternalSpec) []corev1.Volume {
+	jvm := corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}
+	if spec.JVM != nil && spec.JVM.DiagnosticsVolume != nil {
+		jvm = *spec.JVM.DiagnosticsVolume
+	}
+
+	volumes := []corev1.Volume{
+		{Name: volumeLogs, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		{Name: volumeUtils, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		{Name: volumeApplication, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		{Name: volumeJVM, VolumeSource: jvm},
+		configMapVolume(volumeScripts, GetScriptsConfigMapName(spec), 0777),
+	}
+
+	if spec.Logging != nil && notEmpty(spec.Logging.ConfigMapName) {
+		volumes = append(volumes, configMapVolume(volumeLoggingConfig, *spec.Logging.ConfigMapName, 0777))
+	}
+
+	mgmt := getPortSpecWithSSL(spec, func(c *coh.CoherenceSpec) *coh.PortSpecWithSSL { return c.Management })
+	if mgmt.SSL != nil && notEmpty(mgmt.SSL.Secrets) {
+		volumes = append(volumes, secretVolume(volumeManagementSSL, *mgmt.SSL.Secrets))
+	}
+	metrics := getPortSpecWithSSL(spec, func(c *coh.CoherenceSpec) *coh.PortSpecWithSSL { return c.Metrics })
+	if metrics.SSL != nil && notEmpty(metrics.SSL.Secrets) {
+		volumes = append(volumes, secretVolume(volumeMetricsSSL, *metrics.SSL.Secrets))
+	}
+
+	if c := spec.Coherence; c != nil {
+		if c.Persistence != nil && c.Persistence.Volume != nil {
+			volumes = append(volumes, corev1.Volume{Name: volumePersistence, VolumeSource: *c.Persistence.Volume})
+		}
+		if c.Snapshot != nil && c.Snapshot.Volume != nil {
+			volumes = append(volumes, corev1.Volume{Name: volumeSnapshot, VolumeSource: *c.Snapshot.Volume})
+		}
+	}
+
+	volumes = append(volumes, spec.Volumes...)
+
+	if isFluentdEnabled(spec) {
+		volumes = append(volumes, configMapVolume(volumeFluentdConfig, GetFluentdConfigMapName(spec), 420))
+	}
+
+	return volumes
+}
+
+// newVolumeClaimTemplates creates the persistence and snapshot PVC templates plus any specified in the role.
+func newVolumeClaimTemplates(spec *coh.CoherenceInternalSpec) []corev1.PersistentVolumeClaim {
+	var templates []corev1.PersistentVolumeClaim
+
+	if c := spec.Coherence; c != nil {
+		if c.Persistence.IsEnabled() && c.Persistence.Volume == nil {
+			templates = append(templates, newVolumeClaimTemplate(spec, volumePersistence, c.Persistence))
+		}
+		if c.Snapshot.IsEnabled() && c.Snapshot.Volume == nil {
+			templates = append(templates, newVolumeClaimTemplate(spec, volumeSnapshot, c.Snapshot))
+		}
+	}
+
+	return append(templates, spec.VolumeClaimTemplates...)
+}
+
+// newVolumeClaimTemplate creates a PVC template from a persistent storage spec.
+func newVolumeClaimTemplate(spec *coh.CoherenceInternalSpec, name string, storage *coh.PersistentStorageSpec) corev1.PersistentVolumeClaim {
+	pvc := corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: componentLabels(spec, "coherence-vol"),
+		},
+	}
+	if storage.PersistentVolumeClaim != nil {
+		pvc.Spec = *storage.PersistentVolumeClaim
+	}
+	return pvc
+}
+
+// getDebugSpec returns the JVM debug spec if debugging is enabled, otherwise nil.
+func getDebugSpec(spec *coh.CoherenceInternalSpec) *coh.JvmDebugSpec {
+	if spec.JVM != nil && spec.JVM.Debug != nil && isTrue(spec.JVM.Debug.Enabled) {
+		return spec.JVM.Debug
+	}
+	return nil
+}
+
+// getPortSpecWithSSL returns the management or metrics spec selected by fn, never returning nil.
+func getPortSpecWithSSL(spec *coh.CoherenceInternalSpec, fn func(*coh.CoherenceSpec) *coh.PortSpecWithSSL) *coh.PortSpecWithSSL {
+	if spec.Coherence != nil {
+		if p := fn(spec.Coherence); p != nil {
+			return p
+		}
+	}
+	return &coh.PortSpecWithSSL{}
+}
+
+// hasSSLSecrets returns true if the management or metrics spec selected by fn has an SSL secret.
+func hasSSLSecrets(spec *coh.CoherenceInternalSpec, fn func(*coh.CoherenceSpec) *coh.PortSpecWithSSL) bool {
+	p := getPortSpecWithSSL(spec, fn)
+	return p.SSL != nil && notEmpty(p.SSL.Secrets)
+}
+
+// getImage returns the image name from an image spec.
+func getImage(image *coh.ImageSpec) string {
+	if image != nil && image.Image != nil {
+		return *image.Image
+	}
+	return ""
+}
+
+// getPullPolicy returns the image pull policy from an image spec.
+func getPullPolicy(image *coh.ImageSpec) corev1.PullPolicy {
+	if image != nil && image.ImagePullPolicy != nil {
+		return *image.ImagePullPolicy
+	}
+	return ""
+}
+
+func configMapVolume(name, configMap string, mode int32) corev1.Volume {
+	return corev1.Volume{
+		Name: name,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: configMap},
+				DefaultMode:          &mode,
+			},
+		},
+	}
+}
+
+func secretVolume(name, secret string) corev1.Volume {
+	mode := int32(0777)
+	return corev1.Volume{
+		Name: name,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: secret, DefaultMode: &mode},
+		},
+	}
+}
+
+func fieldRefEnv(name, path string) corev1.EnvVar {
+	return corev1.EnvVar{Name: name, ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: path}}}
+}
+
+func secretKeyEnv(name, secret, key string, optional bool) corev1.EnvVar {
+	ref := &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: secret}, Key: key}
+	if optional {
+		ref.Optional = &optional
+	}
+	return corev1.EnvVar{Name: name, ValueFrom: &corev1.EnvVarSource{SecretKeyRef: ref}}
+}
+
+func appendStringEnv(env []corev1.EnvVar, name string, value *string) []corev1.EnvVar {
+	if notEmpty(value) {
+		env = append(env, corev1.EnvVar{Name: name, Value: *value})
+	}
+	return env
+}
+
+func stringOrDefault(s *string, dflt string) string {
+	if notEmpty(s) {
+		return *s
+	}
+	return dflt
+}
+
+func int32OrDefault(i *int32, dflt int32) int32 {
+	if i != nil && *i > 0 {
+		return *i
+	}
+	return dflt
+}
+
+func boolOrDefault(b *bool, dflt bool) string {
+	if b != nil {
+		return strconv.FormatBool(*b)
+	}
+	return strconv.FormatBool(dflt)
+}
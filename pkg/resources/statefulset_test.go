@@ -0,0 +1,199 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package resources
+
+import (
+	. "github.com/onsi/gomega"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"testing"
+)
+
+func TestStatefulSetImmutableFieldsMatchPreviousVersions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sts, err := NewStatefulSet("test-ns", newSpec())
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(sts.Namespace).To(Equal("test-ns"))
+	g.Expect(sts.Name).To(Equal("test-cluster-data"))
+	g.Expect(sts.Spec.ServiceName).To(Equal("coherence"))
+	g.Expect(sts.Spec.PodManagementPolicy).To(Equal(appsv1.ParallelPodManagement))
+	g.Expect(sts.Spec.Selector.MatchLabels).To(Equal(map[string]string{
+		CoherenceDeploymentLabel:    "test-cluster-data",
+		coh.CoherenceComponentLabel: "coherencePod",
+	}))
+	g.Expect(*sts.Spec.Replicas).To(Equal(int32(3)))
+}
+
+func TestStatefulSetStoresAppliedSpec(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	spec := newSpec()
+	sts, err := NewStatefulSet("test-ns", spec)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	applied, err := GetAppliedSpec(sts.Annotations)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(applied).To(Equal(spec))
+}
+
+func TestStatefulSetUpdateStrategy(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sts, err := NewStatefulSet("test-ns", newSpec())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(sts.Spec.UpdateStrategy.Type).To(Equal(appsv1.RollingUpdateStatefulSetStrategyType))
+
+	spec := newSpec()
+	policy := coh.SafeUpgrade
+	spec.UpgradePolicy = &policy
+	sts, err = NewStatefulSet("test-ns", spec)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(sts.Spec.UpdateStrategy.Type).To(Equal(appsv1.OnDeleteStatefulSetStrategyType))
+}
+
+func TestGetAppliedSpecWithoutAnnotation(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	applied, err := GetAppliedSpec(map[string]string{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(applied).To(BeNil())
+}
+
+func TestStatefulSetPodLabels(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	spec := newSpec()
+	exclude := true
+	spec.Coherence = &coh.CoherenceSpec{ExcludeFromWKA: &exclude}
+	spec.Labels = map[string]string{"foo": "bar"}
+
+	sts, err := NewStatefulSet("test-ns", spec)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	labels := sts.Spec.Template.Labels
+	g.Expect(labels).To(HaveKeyWithValue(coh.CoherenceClusterLabel, "test-cluster"))
+	g.Expect(labels).To(HaveKeyWithValue(coh.CoherenceRoleLabel, "data"))
+	g.Expect(labels).To(HaveKeyWithValue(CoherenceWKAMemberLabel, "false"))
+	g.Expect(labels).To(HaveKeyWithValue("foo", "bar"))
+}
+
+func TestStatefulSetDefaultProbes(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sts, err := NewStatefulSet("test-ns", newSpec())
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c := sts.Spec.Template.Spec.Containers[0]
+	g.Expect(c.Name).To(Equal(CoherenceContainerName))
+	g.Expect(c.ReadinessProbe.HTTPGet).To(Equal(&corev1.HTTPGetAction{Path: "/ready", Port: intstr.FromInt(int(coh.DefaultHealthPort))}))
+	g.Expect(c.ReadinessProbe.InitialDelaySeconds).To(Equal(int32(30)))
+	g.Expect(c.LivenessProbe.HTTPGet).To(Equal(&corev1.HTTPGetAction{Path: "/healthz", Port: intstr.FromInt(int(coh.DefaultHealthPort))}))
+	g.Expect(c.LivenessProbe.InitialDelaySeconds).To(Equal(int32(45)))
+}
+
+func TestStatefulSetProbeHandlerFromSpec(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	spec := newSpec()
+	delay := int32(10)
+	spec.ReadinessProbe = &coh.ReadinessProbeSpec{
+		ProbeHandler:        coh.ProbeHandler{Exec: &corev1.ExecAction{Command: []string{"ready.sh"}}},
+		InitialDelaySeconds: &delay,
+	}
+
+	sts, err := NewStatefulSet("test-ns", spec)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	probe := sts.Spec.Template.Spec.Containers[0].ReadinessProbe
+	g.Expect(probe.HTTPGet).To(BeNil())
+	g.Expect(probe.Exec.Command).To(Equal([]string{"ready.sh"}))
+	g.Expect(probe.InitialDelaySeconds).To(Equal(delay))
+}
+
+func TestStatefulSetDefaultAffinity(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sts, err := NewStatefulSet("test-ns", newSpec())
+	g.Expect(err).NotTo(HaveOccurred())
+
+	terms := sts.Spec.Template.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	g.Expect(len(terms)).To(Equal(1))
+	g.Expect(terms[0].PodAffinityTerm.TopologyKey).To(Equal(zoneTopologyKey))
+}
+
+func TestStatefulSetWithFluentd(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	spec := newSpec()
+	enabled := true
+	spec.Logging = &coh.LoggingSpec{Fluentd: &coh.FluentdSpec{Enabled: &enabled}}
+
+	sts, err := NewStatefulSet("test-ns", spec)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	containers := sts.Spec.Template.Spec.Containers
+	g.Expect(len(containers)).To(Equal(2))
+	g.Expect(containers[1].Name).To(Equal(FluentdContainerName))
+	g.Expect(containers[1].Image).To(Equal(defaultFluentdImage))
+
+	var found bool
+	for _, v := range sts.Spec.Template.Spec.Volumes {
+		if v.Name == volumeFluentdConfig {
+			found = true
+			g.Expect(v.ConfigMap.Name).To(Equal(GetFluentdConfigMapName(spec)))
+		}
+	}
+	g.Expect(found).To(BeTrue())
+}
+
+func TestStatefulSetWithPersistence(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	spec := newSpec()
+	enabled := true
+	spec.Coherence = &coh.CoherenceSpec{Persistence: &coh.PersistentStorageSpec{Enabled: &enabled}}
+
+	sts, err := NewStatefulSet("test-ns", spec)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(len(sts.Spec.VolumeClaimTemplates)).To(Equal(1))
+	g.Expect(sts.Spec.VolumeClaimTemplates[0].Name).To(Equal(volumePersistence))
+	g.Expect(sts.Spec.Template.Spec.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{Name: volumePersistence, MountPath: persistenceDir}))
+}
+
+func TestNewCreatesResourcesInOrder(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	spec := newSpec()
+	spec.Ports = []coh.NamedPortSpec{{Name: "extend", PortSpec: coh.PortSpec{Port: 20000}}}
+
+	objects, err := New("test-ns", spec, map[string]string{"startCoherence.sh": "echo"})
+	g.Expect(err).NotTo(HaveOccurred())
+	// the PodDisruptionBudget is managed separately by the role controller
+	g.Expect(len(objects)).To(Equal(4))
+	g.Expect(objects[0]).To(BeAssignableToTypeOf(&corev1.ConfigMap{}))
+	g.Expect(objects[1]).To(BeAssignableToTypeOf(&corev1.Service{}))
+	g.Expect(objects[2]).To(BeAssignableToTypeOf(&corev1.Service{}))
+	g.Expect(objects[3]).To(BeAssignableToTypeOf(&appsv1.StatefulSet{}))
+}
+
+func newSpec() *coh.CoherenceInternalSpec {
+	replicas := int32(3)
+	return &coh.CoherenceInternalSpec{
+		FullnameOverride: "test-cluster-data",
+		Cluster:          "test-cluster",
+		WKA:              "test-cluster-wka",
+		CoherenceRoleSpec: coh.CoherenceRoleSpec{
+			Role:     "data",
+			Replicas: &replicas,
+		},
+	}
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package rest
+
+import (
+	"context"
+	"fmt"
+	authv1 "k8s.io/api/authentication/v1"
+	"net/http"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+// The prefix of the user name of a k8s service account, followed by the namespace and name of the account.
+const serviceAccountPrefix = "system:serviceaccount:"
+
+// namespaceKey is the request context key of the namespace of the service account that made an authenticated request.
+type namespaceKey struct{}
+
+// tokenReviewer reviews a bearer token, returning the status of the review.
+type tokenReviewer func(token string) (*authv1.TokenReviewStatus, error)
+
+// newTokenReviewer creates a tokenReviewer that uses the k8s TokenReview API.
+func newTokenReviewer(c client.Client) tokenReviewer {
+	return func(token string) (*authv1.TokenReviewStatus, error) {
+		review := &authv1.TokenReview{Spec: authv1.TokenReviewSpec{Token: token}}
+		if err := c.Create(context.TODO(), review); err != nil {
+			return nil, err
+		}
+		return &review.Status, nil
+	}
+}
+
+// authenticated wraps a request handler so that, if authentication is enabled, the request is only served
+// if it has the bearer token of a service account in a namespace managed by the Operator.
+func (s *server) authenticated(fn func(w http.ResponseWriter, r *http.Request)) handler {
+	if s.reviewToken == nil {
+		return handler{fn: fn}
+	}
+
+	return handler{fn: func(w http.ResponseWriter, r *http.Request) {
+		namespace, status, err := s.authenticate(r)
+		if err != nil {
+			log.Info(fmt.Sprintf("Rejected ReST request for URL %s: %s", r.URL.Path, err.Error()))
+			w.WriteHeader(status)
+			return
+		}
+		fn(w, r.WithContext(context.WithValue(r.Context(), namespaceKey{}, namespace)))
+	}}
+}
+
+// authenticate reviews the request's bearer token, returning the namespace of the service account that made the
+// request, or an error and the HTTP status to respond with if the request is not from a service account in a
+// namespace managed by the Operator.
+func (s *server) authenticate(r *http.Request) (string, int, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return "", http.StatusUnauthorized, fmt.Errorf("no bearer token")
+	}
+
+	status, err := s.reviewToken(strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")))
+	switch {
+	case err != nil:
+		return "", http.StatusInternalServerError, err
+	case !status.Authenticated:
+		return "", http.StatusUnauthorized, fmt.Errorf("invalid bearer token: %s", status.Error)
+	}
+
+	user := status.User.Username
+	if !strings.HasPrefix(user, serviceAccountPrefix) {
+		return "", http.StatusForbidden, fmt.Errorf("user %s is not a service account", user)
+	}
+
+	namespace := strings.SplitN(strings.TrimPrefix(user, serviceAccountPrefix), ":", 2)[0]
+	if !s.isManagedNamespace(namespace) {
+		return "", http.StatusForbidden, fmt.Errorf("service account %s is not in a namespace managed by the Operator", user)
+	}
+	return namespace, http.StatusOK, nil
+}
+
+// canAccessNamespace returns true if the request may read the resources in a namespace. An authenticated
+// request may only read the resources in the namespace of its service account.
+func (s *server) canAccessNamespace(r *http.Request, namespace string) bool {
+	if requester, ok := r.Context().Value(namespaceKey{}).(string); ok {
+		return requester == namespace
+	}
+	return true
+}
+
+// isManagedNamespace returns true if the Operator manages the namespace. An Operator with no
+// watched namespaces manages all namespaces.
+func (s *server) isManagedNamespace(namespace string) bool {
+	if len(s.namespaces) == 0 {
+		return true
+	}
+	for _, ns := range s.namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,390 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/controller/coherencerole"
+	mgmt "github.com/oracle/coherence-operator/pkg/management"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"net/http"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The timeout for the Coherence management requests made to a role's Pods by the members endpoint.
+const managementTimeout = time.Second * 10
+
+// ClusterView is the read-only view of a CoherenceCluster returned by the /clusters/<namespace>/<name> endpoint.
+type ClusterView struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// The number of the cluster's roles that are ready.
+	Ready int32 `json:"ready"`
+	// The status conditions of the cluster.
+	Conditions coh.Conditions `json:"conditions,omitempty"`
+	// The cluster's roles, sorted by name.
+	Roles []RoleView `json:"roles"`
+}
+
+// RoleView is the read-only view of a role of a CoherenceCluster returned by the
+// /clusters/<namespace>/<name>/roles/<role> endpoint.
+type RoleView struct {
+	// The name of the role.
+	Name string `json:"name"`
+	// The name of the CoherenceRole and StatefulSet of the role.
+	FullName string `json:"fullName"`
+	// The desired number of replicas in the cluster's spec.
+	Replicas          int32  `json:"replicas"`
+	Image             string `json:"image,omitempty"`
+	StorageEnabled    bool   `json:"storageEnabled"`
+	ManagementEnabled bool   `json:"managementEnabled"`
+	// The status of the CoherenceRole, or nil if the Operator has not yet created the CoherenceRole.
+	Status *coh.CoherenceRoleStatus `json:"status,omitempty"`
+	// The role's Pods, sorted by name.
+	Pods []PodView `json:"pods"`
+}
+
+// PodView is the read-only view of a Pod of a role.
+type PodView struct {
+	Name  string          `json:"name"`
+	Phase corev1.PodPhase `json:"phase"`
+	Ready bool            `json:"ready"`
+	Node  string          `json:"node,omitempty"`
+	IP    string          `json:"ip,omitempty"`
+}
+
+// MembersView is the read-only view of the Coherence cluster members of a role returned by the
+// /clusters/<namespace>/<name>/roles/<role>/members endpoint, read using Coherence management over ReST.
+type MembersView struct {
+	Namespace string `json:"namespace"`
+	Cluster   string `json:"cluster"`
+	Role      string `json:"role"`
+	// The Pod that the Coherence cluster was read from.
+	Pod string `json:"pod"`
+	// The Coherence cluster members of the role.
+	Members []MemberView `json:"members"`
+	// The names of the role's ready Pods that have not joined the Coherence cluster.
+	NotJoined []string `json:"notJoined,omitempty"`
+	// The HA status of each of the Coherence cluster's partitioned cache services, sorted by name.
+	Services []ServiceView `json:"services"`
+}
+
+// MemberView is a Coherence cluster member.
+type MemberView struct {
+	ID          int    `json:"id"`
+	MemberName  string `json:"memberName"`
+	RoleName    string `json:"roleName"`
+	MachineName string `json:"machineName"`
+	SiteName    string `json:"siteName"`
+	RackName    string `json:"rackName"`
+}
+
+// ServiceView is the current StatusHA of a Coherence partitioned cache service.
+type ServiceView struct {
+	Name                       string `json:"name"`
+	HAStatus                   string `json:"haStatus"`
+	HAStatusCode               int    `json:"haStatusCode"`
+	RemainingDistributionCount int    `json:"remainingDistributionCount"`
+}
+
+// errorView is the body of an error response of the cluster endpoints that has a message.
+type errorView struct {
+	Error string `json:"error"`
+}
+
+// getCluster is a GET request that returns a read-only view of a CoherenceCluster, one of its roles, or the
+// Coherence cluster members of one of its roles as json, depending on the request path. Only the clusters in
+// the namespaces managed by the Operator are served and an authenticated request may only read the clusters
+// in the namespace of its service account.
+func (s *server) getCluster(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/clusters/"), "/"), "/")
+	if len(segments) < 2 || !s.isManagedNamespace(segments[0]) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if !s.canAccessNamespace(r, segments[0]) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	cluster := &coh.CoherenceCluster{}
+	err := s.checker.Client.Get(context.TODO(), types.NamespacedName{Namespace: segments[0], Name: segments[1]}, cluster)
+	switch {
+	case errors.IsNotFound(err):
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case err != nil:
+		log.Error(err, "Error getting CoherenceCluster "+segments[1]+" in namespace "+segments[0])
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	switch {
+	case len(segments) == 2:
+		s.writeView(w, r, func() (interface{}, int, error) { return s.getClusterView(cluster) })
+	case len(segments) == 4 && segments[2] == "roles":
+		s.writeView(w, r, func() (interface{}, int, error) { return s.getRoleView(cluster, segments[3]) })
+	case len(segments) == 5 && segments[2] == "roles" && segments[4] == "members":
+		s.writeView(w, r, func() (interface{}, int, error) { return s.getMembersView(cluster, segments[3]) })
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// writeView writes the json view returned by the function, or the error response for the status code if
+// the function returns an error.
+func (s *server) writeView(w http.ResponseWriter, r *http.Request, fn func() (interface{}, int, error)) {
+	view, status, err := fn()
+	if err != nil {
+		if status == http.StatusInternalServerError {
+			log.Error(err, "Error serving ReST request for URL "+r.URL.Path)
+		}
+		view = errorView{Error: err.Error()}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(view); err != nil {
+		log.Error(err, "Error writing response for URL "+r.URL.Path)
+	}
+}
+
+// getClusterView returns the view of a cluster and all of its roles.
+func (s *server) getClusterView(cluster *coh.CoherenceCluster) (interface{}, int, error) {
+	view := ClusterView{
+		Namespace:  cluster.Namespace,
+		Name:       cluster.Name,
+		Ready:      cluster.Status.Ready,
+		Conditions: cluster.Status.Conditions,
+		Roles:      []RoleView{},
+	}
+
+	for _, spec := range cluster.GetRoles() {
+		role, err := s.newRoleView(cluster, spec)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		view.Roles = append(view.Roles, role)
+	}
+	sort.Slice(view.Roles, func(i, j int) bool { return view.Roles[i].Name < view.Roles[j].Name })
+
+	return view, http.StatusOK, nil
+}
+
+// getRoleView returns the view of one of a cluster's roles.
+func (s *server) getRoleView(cluster *coh.CoherenceCluster, name string) (interface{}, int, error) {
+	spec, found := cluster.GetRoles()[name]
+	if !found {
+		return nil, http.StatusNotFound, fmt.Errorf("CoherenceCluster %s has no role %s", cluster.Name, name)
+	}
+
+	view, err := s.newRoleView(cluster, spec)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return view, http.StatusOK, nil
+}
+
+// newRoleView creates the view of a role from the role's spec in the cluster, the status of its CoherenceRole
+// and the Pods of its StatefulSet.
+func (s *server) newRoleView(cluster *coh.CoherenceCluster, spec coh.CoherenceRoleSpec) (RoleView, error) {
+	_, management := coherencerole.GetManagementPort(&coh.CoherenceRole{Spec: spec})
+	view := RoleView{
+		Name:              spec.GetRoleName(),
+		FullName:          spec.GetFullRoleName(cluster),
+		Replicas:          spec.GetReplicas(),
+		StorageEnabled:    spec.IsStorageEnabled(),
+		ManagementEnabled: management,
+		Pods:              []PodView{},
+	}
+	if image := spec.GetCoherenceImage(); image != nil {
+		view.Image = *image
+	}
+
+	role, pods, err := s.getRoleAndPods(cluster.Namespace, view.FullName)
+	if err != nil {
+		return view, err
+	}
+	if role != nil {
+		view.Status = &role.Status
+	}
+
+	for _, pod := range pods {
+		view.Pods = append(view.Pods, PodView{
+			Name:  pod.Name,
+			Phase: pod.Status.Phase,
+			Ready: coherencerole.IsPodReady(pod),
+			Node:  pod.Spec.NodeName,
+			IP:    pod.Status.PodIP,
+		})
+	}
+	return view, nil
+}
+
+// getMembersView returns the Coherence cluster members of one of a cluster's roles and the StatusHA of the
+// Coherence cluster's partitioned cache services, read using management over ReST on the first of the role's
+// ready Pods that responds.
+func (s *server) getMembersView(cluster *coh.CoherenceCluster, name string) (interface{}, int, error) {
+	spec, found := cluster.GetRoles()[name]
+	if !found {
+		return nil, http.StatusNotFound, fmt.Errorf("CoherenceCluster %s has no role %s", cluster.Name, name)
+	}
+
+	role, pods, err := s.getRoleAndPods(cluster.Namespace, spec.GetFullRoleName(cluster))
+	switch {
+	case err != nil:
+		return nil, http.StatusInternalServerError, err
+	case role == nil:
+		return nil, http.StatusNotFound, fmt.Errorf("CoherenceRole %s has not been created", spec.GetFullRoleName(cluster))
+	}
+
+	port, enabled := coherencerole.GetManagementPort(role)
+	if !enabled {
+		return nil, http.StatusBadRequest, fmt.Errorf("management over ReST is not enabled for CoherenceRole %s", role.Name)
+	}
+
+	tlsConfig, err := s.checker.GetManagementTLSConfig(role)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	var ready []corev1.Pod
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodRunning && coherencerole.IsPodReady(pod) {
+			ready = append(ready, pod)
+		}
+	}
+
+	for _, pod := range ready {
+		cl := s.checker.NewManagementClient(pod, port, tlsConfig, managementTimeout)
+		view, err := readMembers(cl, spec.GetRoleName(), ready)
+		if err != nil {
+			log.Info(fmt.Sprintf("Unable to read Coherence cluster from Pod %s: %s", pod.Name, err.Error()))
+			continue
+		}
+		view.Namespace = cluster.Namespace
+		view.Cluster = cluster.Name
+		view.Role = spec.GetRoleName()
+		view.Pod = pod.Name
+		return view, http.StatusOK, nil
+	}
+
+	return nil, http.StatusServiceUnavailable, fmt.Errorf("unable to read the Coherence cluster from any ready Pod of CoherenceRole %s", role.Name)
+}
+
+// readMembers uses Coherence management over ReST to read the members of a role and the StatusHA of the
+// partitioned cache services. The ready Pods of the role are matched to the members to find the Pods that
+// have not joined the cluster.
+func readMembers(cl *mgmt.Client, roleName string, ready []corev1.Pod) (MembersView, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), managementTimeout)
+	defer cancel()
+
+	view := MembersView{Members: []MemberView{}, Services: []ServiceView{}}
+
+	members, err := cl.GetMembers(ctx)
+	if err != nil {
+		return view, err
+	}
+	for _, member := range members.Items {
+		if member.RoleName == roleName {
+			view.Members = append(view.Members, MemberView{
+				ID:          member.ID,
+				MemberName:  member.MemberName,
+				RoleName:    member.RoleName,
+				MachineName: member.MachineName,
+				SiteName:    member.SiteName,
+				RackName:    member.RackName,
+			})
+		}
+	}
+	sort.Slice(view.Members, func(i, j int) bool { return view.Members[i].ID < view.Members[j].ID })
+
+	for _, pod := range ready {
+		if !coherencerole.IsClusterMember(pod, roleName, members.Items) {
+			view.NotJoined = append(view.NotJoined, pod.Name)
+		}
+	}
+
+	services, err := cl.GetServices(ctx)
+	if err != nil {
+		return view, err
+	}
+	seen := make(map[string]bool)
+	for _, service := range services.Items {
+		if service.Type != mgmt.DistributedCacheType || seen[service.Name] {
+			continue
+		}
+		seen[service.Name] = true
+
+		partitions, err := cl.GetPartitionAssignment(ctx, service.Name)
+		if err != nil {
+			return view, err
+		}
+		view.Services = append(view.Services, ServiceView{
+			Name:                       service.Name,
+			HAStatus:                   partitions.HAStatus,
+			HAStatusCode:               partitions.HAStatusCode,
+			RemainingDistributionCount: partitions.RemainingDistributionCount,
+		})
+	}
+	sort.Slice(view.Services, func(i, j int) bool { return view.Services[i].Name < view.Services[j].Name })
+
+	return view, nil
+}
+
+// getRoleAndPods returns the named CoherenceRole, or nil if it does not exist, and the Pods of the role's
+// StatefulSet sorted by name.
+func (s *server) getRoleAndPods(namespace, name string) (*coh.CoherenceRole, []corev1.Pod, error) {
+	key := types.NamespacedName{Namespace: namespace, Name: name}
+
+	role := &coh.CoherenceRole{}
+	if err := s.checker.Client.Get(context.TODO(), key, role); err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, nil, err
+		}
+		role = nil
+	}
+
+	sts := &appsv1.StatefulSet{}
+	if err := s.checker.Client.Get(context.TODO(), key, sts); err != nil {
+		if errors.IsNotFound(err) {
+			return role, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	if sts.Spec.Selector == nil {
+		return role, nil, nil
+	}
+
+	list := corev1.PodList{}
+	labels := client.MatchingLabels{}
+	for k, v := range sts.Spec.Selector.MatchLabels {
+		labels[k] = v
+	}
+	if err := s.checker.Client.List(context.TODO(), &list, client.InNamespace(namespace), labels); err != nil {
+		return nil, nil, err
+	}
+
+	pods := list.Items
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+	return role, pods, nil
+}
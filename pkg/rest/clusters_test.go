@@ -0,0 +1,242 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	. "github.com/onsi/gomega"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/controller/coherencerole"
+	appsv1 "k8s.io/api/apps/v1"
+	authv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"strconv"
+	"testing"
+)
+
+func TestClusterEndpoint(t *testing.T) {
+	g := NewGomegaWithT(t)
+	s := newClusterServer(g)
+
+	resp := serve(s, "/clusters/coh/test")
+	g.Expect(resp.Code).To(Equal(http.StatusOK))
+	g.Expect(resp.Header().Get("Content-Type")).To(Equal("application/json"))
+
+	view := ClusterView{}
+	g.Expect(json.Unmarshal(resp.Body.Bytes(), &view)).To(Succeed())
+	g.Expect(view.Namespace).To(Equal("coh"))
+	g.Expect(view.Name).To(Equal("test"))
+	g.Expect(view.Ready).To(Equal(int32(1)))
+	g.Expect(view.Roles).To(HaveLen(2))
+
+	proxy := view.Roles[0]
+	g.Expect(proxy.Name).To(Equal("proxy"))
+	g.Expect(proxy.FullName).To(Equal("test-proxy"))
+	g.Expect(proxy.StorageEnabled).To(BeFalse())
+	g.Expect(proxy.Status).To(BeNil())
+	g.Expect(proxy.Pods).To(BeEmpty())
+
+	storage := view.Roles[1]
+	g.Expect(storage.Name).To(Equal("storage"))
+	g.Expect(storage.FullName).To(Equal("test-storage"))
+	g.Expect(storage.Replicas).To(Equal(int32(2)))
+	g.Expect(storage.Image).To(Equal("coherence:12.2.1.4.0"))
+	g.Expect(storage.StorageEnabled).To(BeTrue())
+	g.Expect(storage.ManagementEnabled).To(BeTrue())
+	g.Expect(storage.Status).NotTo(BeNil())
+	g.Expect(storage.Status.ReadyReplicas).To(Equal(int32(2)))
+	g.Expect(storage.Pods).To(Equal([]PodView{
+		{Name: "test-storage-0", Phase: corev1.PodRunning, Ready: true, Node: "node-1", IP: "10.0.0.1"},
+		{Name: "test-storage-1", Phase: corev1.PodRunning, Ready: true, Node: "node-2", IP: "10.0.0.2"},
+	}))
+}
+
+func TestRoleEndpoint(t *testing.T) {
+	g := NewGomegaWithT(t)
+	s := newClusterServer(g)
+
+	resp := serve(s, "/clusters/coh/test/roles/storage")
+	g.Expect(resp.Code).To(Equal(http.StatusOK))
+	view := RoleView{}
+	g.Expect(json.Unmarshal(resp.Body.Bytes(), &view)).To(Succeed())
+	g.Expect(view.Name).To(Equal("storage"))
+	g.Expect(view.Pods).To(HaveLen(2))
+
+	g.Expect(serve(s, "/clusters/coh/test/roles/unknown").Code).To(Equal(http.StatusNotFound))
+}
+
+func TestClusterEndpointNotFound(t *testing.T) {
+	g := NewGomegaWithT(t)
+	s := newClusterServer(g)
+
+	g.Expect(serve(s, "/clusters/coh/unknown").Code).To(Equal(http.StatusNotFound))
+	g.Expect(serve(s, "/clusters/coh").Code).To(Equal(http.StatusNotFound))
+	g.Expect(serve(s, "/clusters/coh/test/unknown").Code).To(Equal(http.StatusNotFound))
+
+	// only the clusters in the watched namespaces are served
+	s.namespaces = []string{"other"}
+	g.Expect(serve(s, "/clusters/coh/test").Code).To(Equal(http.StatusNotFound))
+}
+
+func TestClusterEndpointShouldOnlyServeNamespaceOfServiceAccount(t *testing.T) {
+	g := NewGomegaWithT(t)
+	s := newClusterServer(g)
+	s.reviewToken = func(token string) (*authv1.TokenReviewStatus, error) {
+		user := authv1.UserInfo{Username: "system:serviceaccount:" + token + ":default"}
+		return &authv1.TokenReviewStatus{Authenticated: true, User: user}, nil
+	}
+
+	g.Expect(serveWithToken(s, "/clusters/coh/test", "coh").Code).To(Equal(http.StatusOK))
+	g.Expect(serveWithToken(s, "/clusters/coh/test", "other").Code).To(Equal(http.StatusForbidden))
+}
+
+func TestMembersEndpoint(t *testing.T) {
+	g := NewGomegaWithT(t)
+	s := newClusterServer(g)
+	m := newManagementServer()
+	defer m.Close()
+	useManagement(g, s, m)
+
+	resp := serve(s, "/clusters/coh/test/roles/storage/members")
+	g.Expect(resp.Code).To(Equal(http.StatusOK))
+
+	view := MembersView{}
+	g.Expect(json.Unmarshal(resp.Body.Bytes(), &view)).To(Succeed())
+	g.Expect(view).To(Equal(MembersView{
+		Namespace: "coh",
+		Cluster:   "test",
+		Role:      "storage",
+		Pod:       "test-storage-0",
+		Members: []MemberView{
+			{ID: 1, MemberName: "test-storage-0", RoleName: "storage", MachineName: "node-1", SiteName: "zone-a", RackName: "rack-1"},
+		},
+		NotJoined: []string{"test-storage-1"},
+		Services: []ServiceView{
+			{Name: "PartitionedCache", HAStatus: "NODE-SAFE", HAStatusCode: 2},
+			{Name: "PartitionedTopic", HAStatus: "ENDANGERED", HAStatusCode: 1, RemainingDistributionCount: 10},
+		},
+	}))
+}
+
+func TestMembersEndpointWithoutManagement(t *testing.T) {
+	g := NewGomegaWithT(t)
+	s := newClusterServer(g)
+
+	// the proxy role has not been created and the storage role's Pods cannot be reached
+	g.Expect(serve(s, "/clusters/coh/test/roles/proxy/members").Code).To(Equal(http.StatusNotFound))
+	m := httptest.NewServer(http.NotFoundHandler())
+	defer m.Close()
+	useManagement(g, s, m)
+	resp := serve(s, "/clusters/coh/test/roles/storage/members")
+	g.Expect(resp.Code).To(Equal(http.StatusServiceUnavailable))
+	g.Expect(resp.Body.String()).To(ContainSubstring("unable to read the Coherence cluster"))
+}
+
+// newClusterServer creates a server with a CoherenceCluster named test in namespace coh with a proxy role that has
+// not yet been created and a storage role with two ready Pods.
+func newClusterServer(g *WithT) *server {
+	enabled := true
+	storage := false
+	image := "coherence:12.2.1.4.0"
+	replicas := int32(2)
+
+	cluster := &coh.CoherenceCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "coh", Name: "test"},
+		Spec: coh.CoherenceClusterSpec{Roles: []coh.CoherenceRoleSpec{
+			{
+				Role:     "storage",
+				Replicas: &replicas,
+				Coherence: &coh.CoherenceSpec{
+					ImageSpec:  coh.ImageSpec{Image: &image},
+					Management: &coh.PortSpecWithSSL{Enabled: &enabled},
+				},
+			},
+			{Role: "proxy", Coherence: &coh.CoherenceSpec{StorageEnabled: &storage}},
+		}},
+		Status: coh.CoherenceClusterStatus{Roles: 2, Ready: 1},
+	}
+
+	role := &coh.CoherenceRole{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "coh", Name: "test-storage"},
+		Spec:       cluster.Spec.Roles[0],
+		Status:     coh.CoherenceRoleStatus{Replicas: 2, CurrentReplicas: 2, ReadyReplicas: 2},
+	}
+
+	selector := map[string]string{"coherenceDeployment": "test-storage"}
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "coh", Name: "test-storage"},
+		Spec:       appsv1.StatefulSetSpec{Selector: &metav1.LabelSelector{MatchLabels: selector}},
+	}
+
+	scheme := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	g.Expect(coh.SchemeBuilder.AddToScheme(scheme)).To(Succeed())
+
+	objs := []runtime.Object{cluster, role, sts, newPod("test-storage-1", "node-2", "10.0.0.2", selector),
+		newPod("test-storage-0", "node-1", "10.0.0.1", selector), newPod("other-0", "node-1", "10.0.0.3", nil)}
+	c := fake.NewFakeClientWithScheme(scheme, objs...)
+
+	return &server{checker: &coherencerole.ScalableChecker{Client: c}}
+}
+
+func newPod(name, node, ip string, labels map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "coh", Name: name, Labels: labels},
+		Spec:       corev1.PodSpec{NodeName: node},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			PodIP:      ip,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
+// newManagementServer creates a fake Coherence management over ReST server for a cluster in which only the first of
+// the storage role's Pods has joined the cluster.
+func newManagementServer() *httptest.Server {
+	responses := map[string]string{
+		"/management/coherence/cluster/members": `{"items": [
+			{"id": 2, "memberName": "test-proxy-0", "roleName": "proxy", "machineName": "node-2"},
+			{"id": 1, "memberName": "test-storage-0", "roleName": "storage", "machineName": "node-1", "siteName": "zone-a", "rackName": "rack-1"}]}`,
+		"/management/coherence/cluster/services": `{"items": [
+			{"name": "PartitionedTopic", "type": "DistributedCache"},
+			{"name": "Proxy", "type": "Proxy"},
+			{"name": "PartitionedCache", "type": "DistributedCache"},
+			{"name": "PartitionedCache", "type": "DistributedCache"}]}`,
+		"/management/coherence/cluster/services/PartitionedCache/partition": `{"HAStatus": "NODE-SAFE", "HAStatusCode": 2}`,
+		"/management/coherence/cluster/services/PartitionedTopic/partition": `{"HAStatus": "ENDANGERED", "HAStatusCode": 1, "remainingDistributionCount": 10}`,
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, found := responses[r.URL.Path]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, body)
+	}))
+}
+
+// useManagement configures the server to send management requests for every Pod to the management server.
+func useManagement(g *WithT, s *server, m *httptest.Server) {
+	u, err := url.Parse(m.URL)
+	g.Expect(err).NotTo(HaveOccurred())
+	port, err := strconv.Atoi(u.Port())
+	g.Expect(err).NotTo(HaveOccurred())
+
+	s.checker.SetGetPodHostName(func(pod corev1.Pod) string { return "127.0.0.1" })
+	s.checker.SetTranslatePort(func(name string, p int) int { return port })
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package rest
+
+import (
+	"fmt"
+	"github.com/oracle/coherence-operator/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/metadata/metadatainformer"
+	"k8s.io/client-go/metadata/metadatalister"
+	"k8s.io/client-go/tools/cache"
+	"time"
+)
+
+// The resource of the Nodes held in the node cache.
+var nodesResource = corev1.SchemeGroupVersion.WithResource("nodes")
+
+const (
+	// The period at which the node cache is re-synced with the API server.
+	nodeResyncPeriod = time.Minute * 10
+	// The time to wait for the node cache to sync before logging that Nodes will be obtained from the API server.
+	nodeSyncTimeout = time.Minute
+)
+
+// nodeCache serves the metadata of the k8s Nodes from memory using a metadata-only informer so that
+// starting Coherence Pods do not each make a request to the API server. A Node that is not in the cache,
+// for example a Node that has only just joined the k8s cluster, is obtained from the API server. If the informer
+// cannot sync, for example because the Operator is not allowed to list Nodes, every Node is obtained from the
+// API server.
+type nodeCache struct {
+	client metadata.Interface
+	lister metadatalister.Lister
+	synced cache.InformerSynced
+}
+
+// newNodeCache creates a node cache and starts its informer, which runs until the stop channel is closed.
+func newNodeCache(client metadata.Interface, stop <-chan struct{}) *nodeCache {
+	informer := metadatainformer.NewFilteredMetadataInformer(client, nodesResource, metav1.NamespaceAll,
+		nodeResyncPeriod, cache.Indexers{}, nil).Informer()
+	go informer.Run(stop)
+	go waitForNodeCacheSync(informer.HasSynced, stop)
+
+	return &nodeCache{
+		client: client,
+		lister: metadatalister.New(informer.GetIndexer(), nodesResource),
+		synced: informer.HasSynced,
+	}
+}
+
+// waitForNodeCacheSync logs an error if the node cache has not synced within the nodeSyncTimeout.
+func waitForNodeCacheSync(synced cache.InformerSynced, stop <-chan struct{}) {
+	timeout := make(chan struct{})
+	timer := time.AfterFunc(nodeSyncTimeout, func() { close(timeout) })
+	defer timer.Stop()
+
+	if !cache.WaitForCacheSync(timeout, synced) {
+		select {
+		case <-stop:
+		default:
+			log.Error(fmt.Errorf("the node cache did not sync within %s", nodeSyncTimeout),
+				"Node lookups will be served from the API server, check that the Operator is allowed to list and watch Nodes")
+		}
+	}
+}
+
+// getNode returns the metadata of the named Node, or a NotFound error if the Node does not exist.
+func (in *nodeCache) getNode(name string) (*metav1.PartialObjectMetadata, error) {
+	node, err := in.lister.Get(name)
+	if err == nil {
+		metrics.ObserveNodeLookup(metrics.ResultCacheHit)
+		return node, nil
+	}
+
+	node, err = in.client.Resource(nodesResource).Get(name, metav1.GetOptions{})
+	switch {
+	case err == nil:
+		metrics.ObserveNodeLookup(metrics.ResultCacheMiss)
+	case errors.IsNotFound(err):
+		metrics.ObserveNodeLookup(metrics.ResultNotFound)
+	default:
+		metrics.ObserveNodeLookup(metrics.ResultError)
+	}
+	return node, err
+}
@@ -8,21 +8,57 @@
 package rest
 
 import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
+	"github.com/oracle/coherence-operator/pkg/controller/coherencerole"
 	"github.com/oracle/coherence-operator/pkg/flags"
 	onet "github.com/oracle/coherence-operator/pkg/net"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	k8s "k8s.io/client-go/kubernetes"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/metadata"
 	"net"
 	"net/http"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // The logger to use to log messages
 var log = logf.Log.WithName("rest-server")
 
+// The time allowed for in-flight requests to complete when the server is shut down.
+const shutdownTimeout = time.Second * 10
+
+// VersionInfo is the Coherence Operator build information served by the version endpoint.
+type VersionInfo struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"gitCommit"`
+	BuildDate string `json:"buildDate"`
+}
+
+// ParseBuildInfo parses the pipe delimited build information injected by the Go linker at build time.
+func ParseBuildInfo(buildInfo string) VersionInfo {
+	info := VersionInfo{}
+	if buildInfo == "" {
+		return info
+	}
+
+	parts := strings.Split(buildInfo, "|")
+	info.Version = parts[0]
+	if len(parts) > 1 {
+		info.GitCommit = parts[1]
+	}
+	if len(parts) > 2 {
+		info.BuildDate = strings.Replace(parts[2], ".", " ", -1)
+	}
+	return info
+}
+
 type handler struct {
 	fn func(w http.ResponseWriter, r *http.Request)
 }
@@ -40,122 +76,283 @@ type Server interface {
 	Close() error
 	// GetHostAndPort returns the address that the ReST server should be reached on by external processes
 	GetHostAndPort(*flags.CoherenceOperatorFlags) string
+	// Healthz is a health check that passes while this server is serving requests.
+	Healthz(*http.Request) error
+	// Readyz is a health check that passes once the Manager's caches have synced and until this server is shut down.
+	// It does not wait for the node cache, which serves any Node it does not hold from the API server.
+	Readyz(*http.Request) error
+	// IsTLS returns true if this server serves HTTPS.
+	IsTLS() bool
+	// GetCACertificate returns the PEM encoded CA certificate that clients should use to verify this server
+	// if it serves HTTPS.
+	GetCACertificate() []byte
 }
 
 // StartRestServer starts a ReST server to server Coherence Operator requests,
-// for example node zone information.
-func StartRestServer(m manager.Manager, cf *flags.CoherenceOperatorFlags) (Server, error) {
+// for example node zone information. The server is added to the Manager so that
+// it becomes ready once the Manager's caches have synced and is shut down
+// gracefully when the Manager is stopped. The watched namespaces are a comma-delimited
+// list that is empty for all namespaces. Only the clusters in the watched namespaces are
+// served and, if authentication is enabled, only service accounts in the watched namespaces
+// may make requests other than the health checks.
+func StartRestServer(m manager.Manager, cf *flags.CoherenceOperatorFlags, watchNamespace string, version VersionInfo) (Server, error) {
 	address := fmt.Sprintf("%s:%d", cf.RestHost, cf.RestPort)
 
-	client, err := k8s.NewForConfig(m.GetConfig())
+	client, err := metadata.NewForConfig(m.GetConfig())
 	if err != nil {
 		return nil, err
 	}
 
-	s := server{cohFlags: cf, client: client}
+	s := &server{
+		cohFlags: cf,
+		version:  version,
+		checker:  &coherencerole.ScalableChecker{Client: m.GetClient(), Config: m.GetConfig()},
+	}
+
+	if cf.RestTLS {
+		hosts := []string{getServiceHost(cf), "localhost", "127.0.0.1"}
+		if s.tlsConfig, s.caCert, err = newTLSConfig(m.GetAPIReader(), cf, hosts); err != nil {
+			return nil, err
+		}
+	}
 
-	mux := http.NewServeMux()
-	mux.Handle("/site/", handler{fn: s.getSiteLabelForNode})
-	mux.Handle("/rack/", handler{fn: s.getRackLabelForNode})
+	for _, ns := range strings.Split(watchNamespace, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			s.namespaces = append(s.namespaces, ns)
+		}
+	}
+
+	if cf.RestAuthentication {
+		s.reviewToken = newTokenReviewer(m.GetClient())
+	}
 
 	listener, err := net.Listen("tcp", address)
 	if err != nil {
 		return nil, err
 	}
 
+	s.stop = make(chan struct{})
+	s.nodes = newNodeCache(client, s.stop)
 	s.listener = listener
+	s.httpServer = &http.Server{Handler: s.newServeMux(), TLSConfig: s.tlsConfig}
 
 	go func() {
 		log.Info("Serving ReST requests on " + s.listener.Addr().String())
-		panic(http.Serve(s.listener, mux))
+		serve := s.httpServer.Serve
+		if s.IsTLS() {
+			// the certificate is in the server's TLS config
+			serve = func(l net.Listener) error { return s.httpServer.ServeTLS(l, "", "") }
+		}
+		if err := serve(s.listener); err != nil && err != http.ErrServerClosed {
+			log.Error(err, "ReST server failed")
+		}
 	}()
 
+	if err := m.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+		if m.GetCache().WaitForCacheSync(stop) {
+			atomic.StoreInt32(&s.ready, 1)
+		}
+		<-stop
+		return s.shutdown()
+	})); err != nil {
+		s.stopNodeCache()
+		_ = s.listener.Close()
+		return nil, err
+	}
+
 	return s, nil
 }
 
 type server struct {
-	cohFlags *flags.CoherenceOperatorFlags
-	listener net.Listener
-	client   *k8s.Clientset
+	cohFlags    *flags.CoherenceOperatorFlags
+	listener    net.Listener
+	nodes       *nodeCache
+	httpServer  *http.Server
+	version     VersionInfo
+	ready       int32
+	stopped     int32
+	stop        chan struct{}
+	stopOnce    sync.Once
+	tlsConfig   *tls.Config
+	caCert      []byte
+	reviewToken tokenReviewer
+	namespaces  []string
+	checker     *coherencerole.ScalableChecker
+}
+
+// newServeMux creates the handler for the server's endpoints.
+func (s *server) newServeMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/site/", s.authenticated(s.getSiteLabelForNode))
+	mux.Handle("/rack/", s.authenticated(s.getRackLabelForNode))
+	mux.Handle("/topology/", s.authenticated(s.getTopology))
+	mux.Handle("/version", s.authenticated(s.getVersion))
+	mux.Handle("/clusters/", s.authenticated(s.getCluster))
+	// the health checks are not authenticated so that they can be used by the kubelet probes
+	mux.Handle("/healthz", healthz.CheckHandler{Checker: s.Healthz})
+	mux.Handle("/readyz", healthz.CheckHandler{Checker: s.Readyz})
+	return mux
 }
 
-func (s server) GetAddress() net.Addr {
+func (s *server) GetAddress() net.Addr {
 	return s.listener.Addr()
 }
 
-func (s server) GetPort() int32 {
+func (s *server) GetPort() int32 {
 	t, _ := net.ResolveTCPAddr(s.listener.Addr().Network(), s.listener.Addr().String())
 	return int32(t.Port)
 }
 
-func (s server) Close() error {
-	return s.listener.Close()
+func (s *server) Close() error {
+	atomic.StoreInt32(&s.stopped, 1)
+	s.stopNodeCache()
+	return s.httpServer.Close()
+}
+
+// shutdown stops the server, allowing in-flight requests to complete.
+func (s *server) shutdown() error {
+	atomic.StoreInt32(&s.stopped, 1)
+	defer s.stopNodeCache()
+	log.Info("Shutting down ReST server")
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return s.httpServer.Shutdown(ctx)
+}
+
+// stopNodeCache stops the node cache's informer.
+func (s *server) stopNodeCache() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+}
+
+func (s *server) Healthz(*http.Request) error {
+	if atomic.LoadInt32(&s.stopped) == 1 {
+		return fmt.Errorf("the ReST server has been stopped")
+	}
+	return nil
+}
+
+func (s *server) Readyz(req *http.Request) error {
+	if err := s.Healthz(req); err != nil {
+		return err
+	}
+	if atomic.LoadInt32(&s.ready) == 0 {
+		return fmt.Errorf("waiting for the Manager's caches to sync")
+	}
+	return nil
+}
+
+func (s *server) IsTLS() bool {
+	return s.tlsConfig != nil
+}
+
+func (s *server) GetCACertificate() []byte {
+	return s.caCert
 }
 
 // GetHostAndPort returns the address and port that this endpoint can be reached on by external processes.
-func (s server) GetHostAndPort(cof *flags.CoherenceOperatorFlags) string {
+func (s *server) GetHostAndPort(cof *flags.CoherenceOperatorFlags) string {
 	f := flags.GetOperatorFlags()
 
-	var service string
 	var port int32
+	service := getServiceHost(f)
+
+	switch {
+	case f.ServicePort != -1:
+		port = f.ServicePort
+	case f.RestPort > 0:
+		port = f.RestPort
+	default:
+		port = s.GetPort()
+	}
 
+	return fmt.Sprintf("%s:%d", service, port)
+}
+
+// getServiceHost returns the host name or address that this endpoint can be reached on by external processes.
+func getServiceHost(f *flags.CoherenceOperatorFlags) string {
 	switch {
 	case f.ServiceName != "":
 		// use the service name if it was specifically set
-		service = f.ServiceName
+		return f.ServiceName
 	case f.RestHost != "0.0.0.0":
 		// if no service name was set but ReST is bound to a specific address then use that
-		service = f.RestHost
+		return f.RestHost
 	default:
 		// ReST is bound to 0.0.0.0 so use any of our local addresses.
 		// This does not guarantee we're reachable but would be OK in local testing
 		ip, err := onet.GetLocalAddress()
 		if err == nil && ip != nil {
-			service = fmt.Sprint(ip.String())
+			return ip.String()
 		}
 	}
-
-	switch {
-	case f.ServicePort != -1:
-		port = f.ServicePort
-	case f.RestPort > 0:
-		port = f.RestPort
-	default:
-		port = s.GetPort()
-	}
-
-	return fmt.Sprintf("%s:%d", service, port)
+	return ""
 }
 
 // getSiteLabelForNode is a GET request that returns the node label on a k8s node to use for a Coherence site value.
-func (s server) getSiteLabelForNode(w http.ResponseWriter, r *http.Request) {
-	s.getLabelForNode(s.cohFlags.SiteLabel, w, r)
+func (s *server) getSiteLabelForNode(w http.ResponseWriter, r *http.Request) {
+	if t, ok := s.getTopologyForNode(w, r); ok {
+		s.writeValue(w, t.Site)
+	}
 }
 
 // getRackLabelForNode is a GET request that returns the node label on a k8s node to use for a Coherence rack value.
-func (s server) getRackLabelForNode(w http.ResponseWriter, r *http.Request) {
-	s.getLabelForNode(s.cohFlags.RackLabel, w, r)
+func (s *server) getRackLabelForNode(w http.ResponseWriter, r *http.Request) {
+	if t, ok := s.getTopologyForNode(w, r); ok {
+		s.writeValue(w, t.Rack)
+	}
 }
 
-// getRackLabelForNode is a GET request that returns the node label on a k8s node to use for a Coherence rack value.
-func (s server) getLabelForNode(label string, w http.ResponseWriter, r *http.Request) {
-	var value string
+// getTopology is a GET request that returns the site, rack, machine, region and zone of a k8s node as json
+// so that a Coherence Pod can obtain all of its topology information with a single request.
+func (s *server) getTopology(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.getTopologyForNode(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(t); err != nil {
+		log.Error(err, "Error writing topology response for node "+t.Machine)
+	}
+}
+
+// getTopologyForNode returns the topology of the k8s node named by the last segment of the request path.
+// If the node does not exist a 404 response is written, or a 500 response if the node could not be obtained,
+// and false is returned. The error responses have no body as older Coherence start scripts use the body of
+// the response as the value, whatever the status code.
+func (s *server) getTopologyForNode(w http.ResponseWriter, r *http.Request) (Topology, bool) {
 	pos := strings.LastIndex(r.URL.Path, "/")
 	name := r.URL.Path[1+pos:]
 
-	log.Info(fmt.Sprintf("Querying for node name='%s' URL: %s", name, r.URL.Path))
-
-	node, err := s.client.CoreV1().Nodes().Get(name, metav1.GetOptions{})
-
-	if err == nil {
-		value = node.Labels[label]
-	} else {
+	node, err := s.nodes.getNode(name)
+	switch {
+	case errors.IsNotFound(err):
+		log.Info(fmt.Sprintf("Node '%s' not found for URL: %s", name, r.URL.Path))
+		w.WriteHeader(http.StatusNotFound)
+		return Topology{}, false
+	case err != nil:
 		log.Error(err, "Error getting node "+name+" from k8s")
-		value = ""
+		w.WriteHeader(http.StatusInternalServerError)
+		return Topology{}, false
 	}
+	return GetTopology(node, s.cohFlags), true
+}
+
+// writeValue writes a plain text value response.
+func (s *server) writeValue(w http.ResponseWriter, value string) {
+	w.WriteHeader(http.StatusOK)
+	if _, err := fmt.Fprint(w, value); err != nil {
+		log.Error(err, "Error writing value response")
+	}
+}
 
-	w.WriteHeader(200)
-	if _, err = fmt.Fprint(w, value); err != nil {
-		log.Error(err, "Error writing value response for node "+name)
+// getVersion is a GET request that returns the Coherence Operator build information as json.
+func (s *server) getVersion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(s.version); err != nil {
+		log.Error(err, "Error writing version response")
 	}
 }
@@ -0,0 +1,234 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package rest
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	. "github.com/onsi/gomega"
+	"github.com/oracle/coherence-operator/pkg/flags"
+	authv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/metadata/fake"
+	"k8s.io/client-go/metadata/metadatalister"
+	"k8s.io/client-go/tools/cache"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseBuildInfo(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	info := ParseBuildInfo("3.0.0|2a6c8b1|2020-02-17T10:15:00.UTC")
+	g.Expect(info).To(Equal(VersionInfo{Version: "3.0.0", GitCommit: "2a6c8b1", BuildDate: "2020-02-17T10:15:00 UTC"}))
+	g.Expect(ParseBuildInfo("3.0.0")).To(Equal(VersionInfo{Version: "3.0.0"}))
+	g.Expect(ParseBuildInfo("")).To(Equal(VersionInfo{}))
+}
+
+func TestVersionEndpoint(t *testing.T) {
+	g := NewGomegaWithT(t)
+	s := &server{version: VersionInfo{Version: "3.0.0", GitCommit: "2a6c8b1", BuildDate: "2020-02-17"}}
+
+	resp := serve(s, "/version")
+	g.Expect(resp.Code).To(Equal(http.StatusOK))
+	g.Expect(resp.Header().Get("Content-Type")).To(Equal("application/json"))
+
+	info := VersionInfo{}
+	g.Expect(json.Unmarshal(resp.Body.Bytes(), &info)).To(Succeed())
+	g.Expect(info).To(Equal(s.version))
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// the node cache never syncs, for example if the Operator is not allowed to list Nodes
+	s := &server{nodes: &nodeCache{synced: func() bool { return false }}}
+
+	g.Expect(serve(s, "/healthz").Code).To(Equal(http.StatusOK))
+	g.Expect(serve(s, "/readyz").Code).To(Equal(http.StatusInternalServerError))
+
+	atomic.StoreInt32(&s.ready, 1)
+	g.Expect(serve(s, "/healthz").Code).To(Equal(http.StatusOK))
+	g.Expect(serve(s, "/readyz").Code).To(Equal(http.StatusOK))
+
+	atomic.StoreInt32(&s.stopped, 1)
+	g.Expect(serve(s, "/healthz").Code).To(Equal(http.StatusInternalServerError))
+	g.Expect(serve(s, "/readyz").Code).To(Equal(http.StatusInternalServerError))
+}
+
+func TestTopologyEndpoint(t *testing.T) {
+	g := NewGomegaWithT(t)
+	node := newNode("node-1", map[string]string{
+		"topology.kubernetes.io/zone":              "zone-a",
+		"failure-domain.beta.kubernetes.io/zone":   "zone-old",
+		"failure-domain.beta.kubernetes.io/region": "region-1",
+		"rack": "rack-1",
+	})
+	f := &flags.CoherenceOperatorFlags{
+		SiteLabel:    "site",
+		RackLabel:    "rack",
+		ZoneLabels:   flags.DefaultZoneLabels,
+		RegionLabels: flags.DefaultRegionLabels,
+	}
+	s := &server{cohFlags: f, nodes: newTestNodeCache(g, []*metav1.PartialObjectMetadata{node})}
+
+	resp := serve(s, "/topology/node-1")
+	g.Expect(resp.Code).To(Equal(http.StatusOK))
+	topology := Topology{}
+	g.Expect(json.Unmarshal(resp.Body.Bytes(), &topology)).To(Succeed())
+	g.Expect(topology).To(Equal(Topology{Site: "zone-a", Rack: "rack-1", Machine: "node-1", Region: "region-1", Zone: "zone-a"}))
+
+	g.Expect(serve(s, "/site/node-1").Body.String()).To(Equal("zone-a"))
+	g.Expect(serve(s, "/rack/node-1").Body.String()).To(Equal("rack-1"))
+}
+
+func TestTopologyForNodeNotInCache(t *testing.T) {
+	g := NewGomegaWithT(t)
+	node := newNode("node-1", map[string]string{"topology.kubernetes.io/zone": "zone-a"})
+	s := &server{cohFlags: &flags.CoherenceOperatorFlags{}, nodes: newTestNodeCache(g, nil, node)}
+
+	resp := serve(s, "/site/node-1")
+	g.Expect(resp.Code).To(Equal(http.StatusOK))
+	g.Expect(resp.Body.String()).To(Equal("zone-a"))
+}
+
+func TestTopologyForUnknownNode(t *testing.T) {
+	g := NewGomegaWithT(t)
+	s := &server{cohFlags: &flags.CoherenceOperatorFlags{}, nodes: newTestNodeCache(g, nil)}
+
+	g.Expect(serve(s, "/topology/node-1").Code).To(Equal(http.StatusNotFound))
+	resp := serve(s, "/site/node-1")
+	g.Expect(resp.Code).To(Equal(http.StatusNotFound))
+	g.Expect(resp.Body.String()).To(BeEmpty())
+}
+
+func TestGetTopologyShouldUseSiteAndRackLabels(t *testing.T) {
+	g := NewGomegaWithT(t)
+	node := newNode("node-1", map[string]string{"site": "site-1", "failure-domain.beta.kubernetes.io/zone": "zone-old"})
+
+	topology := GetTopology(node, &flags.CoherenceOperatorFlags{SiteLabel: "site", RackLabel: "rack"})
+	g.Expect(topology).To(Equal(Topology{Site: "site-1", Rack: "site-1", Machine: "node-1", Zone: "zone-old"}))
+}
+
+func TestAuthentication(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tokens := map[string]authv1.TokenReviewStatus{
+		"coh":   {Authenticated: true, User: authv1.UserInfo{Username: "system:serviceaccount:coh:default"}},
+		"other": {Authenticated: true, User: authv1.UserInfo{Username: "system:serviceaccount:other:default"}},
+		"user":  {Authenticated: true, User: authv1.UserInfo{Username: "jane"}},
+	}
+	s := &server{
+		version:    VersionInfo{Version: "3.0.0"},
+		namespaces: []string{"coh"},
+		nodes:      &nodeCache{synced: func() bool { return true }},
+		reviewToken: func(token string) (*authv1.TokenReviewStatus, error) {
+			if token == "error" {
+				return nil, fmt.Errorf("review failed")
+			}
+			status := tokens[token]
+			return &status, nil
+		},
+	}
+	atomic.StoreInt32(&s.ready, 1)
+
+	g.Expect(serveWithToken(s, "/version", "").Code).To(Equal(http.StatusUnauthorized))
+	g.Expect(serveWithToken(s, "/version", "invalid").Code).To(Equal(http.StatusUnauthorized))
+	g.Expect(serveWithToken(s, "/version", "user").Code).To(Equal(http.StatusForbidden))
+	g.Expect(serveWithToken(s, "/version", "other").Code).To(Equal(http.StatusForbidden))
+	g.Expect(serveWithToken(s, "/version", "error").Code).To(Equal(http.StatusInternalServerError))
+	g.Expect(serveWithToken(s, "/version", "coh").Code).To(Equal(http.StatusOK))
+	g.Expect(serveWithToken(s, "/site/node-1", "").Code).To(Equal(http.StatusUnauthorized))
+
+	// the health endpoints are not authenticated
+	g.Expect(serve(s, "/healthz").Code).To(Equal(http.StatusOK))
+	g.Expect(serve(s, "/readyz").Code).To(Equal(http.StatusOK))
+
+	// all namespaces are managed if none are watched
+	s.namespaces = nil
+	g.Expect(serveWithToken(s, "/version", "other").Code).To(Equal(http.StatusOK))
+}
+
+func TestGeneratedCertificate(t *testing.T) {
+	g := NewGomegaWithT(t)
+	hosts := []string{"operator.coh.svc", "localhost", "127.0.0.1", ""}
+
+	config, caPEM, err := newTLSConfig(nil, &flags.CoherenceOperatorFlags{}, hosts)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(config.Certificates).To(HaveLen(1))
+
+	block, _ := pem.Decode(caPEM)
+	g.Expect(block).NotTo(BeNil())
+	cert, err := x509.ParseCertificate(block.Bytes)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cert.DNSNames).To(Equal([]string{"operator.coh.svc", "localhost"}))
+	g.Expect(cert.IPAddresses).To(HaveLen(1))
+	g.Expect(cert.IPAddresses[0].String()).To(Equal("127.0.0.1"))
+	g.Expect(cert.VerifyHostname("operator.coh.svc")).To(Succeed())
+
+	roots := x509.NewCertPool()
+	g.Expect(roots.AppendCertsFromPEM(caPEM)).To(BeTrue())
+	_, err = cert.Verify(x509.VerifyOptions{DNSName: "localhost", Roots: roots})
+	g.Expect(err).NotTo(HaveOccurred())
+}
+
+func serve(s *server, path string) *httptest.ResponseRecorder {
+	return serveWithToken(s, path, "")
+}
+
+func serveWithToken(s *server, path, token string) *httptest.ResponseRecorder {
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	s.newServeMux().ServeHTTP(resp, req)
+	return resp
+}
+
+func newNode(name string, labels map[string]string) *metav1.PartialObjectMetadata {
+	return &metav1.PartialObjectMetadata{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Node"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+	}
+}
+
+// newTestNodeCache creates a node cache holding the cached nodes that obtains any other nodes from a fake API server.
+func newTestNodeCache(g *WithT, cached []*metav1.PartialObjectMetadata, nodes ...runtime.Object) *nodeCache {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, node := range cached {
+		g.Expect(indexer.Add(node)).To(Succeed())
+	}
+
+	scheme := runtime.NewScheme()
+	g.Expect(metav1.AddMetaToScheme(scheme)).To(Succeed())
+
+	return &nodeCache{
+		client: fake.NewSimpleMetadataClient(scheme, nodes...),
+		lister: metadatalister.New(indexer, nodesResource),
+		synced: func() bool { return true },
+	}
+}
+
+func TestNodeCacheShouldBeFilledByInformer(t *testing.T) {
+	g := NewGomegaWithT(t)
+	scheme := runtime.NewScheme()
+	g.Expect(metav1.AddMetaToScheme(scheme)).To(Succeed())
+	client := fake.NewSimpleMetadataClient(scheme, newNode("node-1", map[string]string{"rack": "rack-1"}))
+
+	stop := make(chan struct{})
+	defer close(stop)
+	nodes := newNodeCache(client, stop)
+	g.Eventually(nodes.synced).Should(BeTrue())
+
+	node, err := nodes.lister.Get("node-1")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(node.Labels).To(Equal(map[string]string{"rack": "rack-1"}))
+}
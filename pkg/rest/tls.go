@@ -0,0 +1,116 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package rest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
+	"github.com/oracle/coherence-operator/pkg/flags"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"math/big"
+	"net"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"time"
+)
+
+// The validity period of a generated ReST server certificate.
+const certificateValidity = time.Hour * 24 * 365
+
+// newTLSConfig creates the ReST server's TLS configuration using the certificate in the Secret named by the
+// flags, or a generated self-signed certificate for the hosts if no Secret is named. The PEM encoded CA
+// certificate that clients should use to verify the server is also returned.
+func newTLSConfig(reader client.Reader, cf *flags.CoherenceOperatorFlags, hosts []string) (*tls.Config, []byte, error) {
+	var certPEM, keyPEM, caPEM []byte
+
+	if cf.RestTLSSecret != "" {
+		namespace, err := k8sutil.GetOperatorNamespace()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		secret := &corev1.Secret{}
+		if err := reader.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: cf.RestTLSSecret}, secret); err != nil {
+			return nil, nil, err
+		}
+		certPEM = secret.Data[corev1.TLSCertKey]
+		keyPEM = secret.Data[corev1.TLSPrivateKeyKey]
+		caPEM = secret.Data["ca.crt"]
+	} else {
+		var err error
+		if certPEM, keyPEM, err = generateCertificate(hosts, time.Now()); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid ReST server certificate: %s", err.Error())
+	}
+
+	if len(caPEM) == 0 {
+		// the certificate is self-signed, or is signed by a CA that clients already trust
+		caPEM = certPEM
+	}
+
+	return &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{cert}}, caPEM, nil
+}
+
+// generateCertificate generates a PEM encoded self-signed certificate and private key valid for the hosts,
+// which may be host names or IP addresses.
+func generateCertificate(hosts []string, now time.Time) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"Oracle"}, CommonName: "coherence-operator"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(certificateValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else if host != "" {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package rest
+
+import (
+	"github.com/oracle/coherence-operator/pkg/flags"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Topology is the location of a Kubernetes node used to configure the Coherence members running on the node.
+type Topology struct {
+	Site    string `json:"site"`
+	Rack    string `json:"rack"`
+	Machine string `json:"machine"`
+	Region  string `json:"region"`
+	Zone    string `json:"zone"`
+}
+
+// GetTopology resolves the topology of a node from the node's labels. The zone and region are the values of the
+// first of the configured labels that are present on the node. The site is the value of the site label, or the
+// zone if the node does not have the site label. The rack is the value of the rack label, or the site if the node
+// does not have the rack label. The machine is always the node name, which is the machine name that the Operator
+// expects each Coherence member to have.
+func GetTopology(node metav1.Object, f *flags.CoherenceOperatorFlags) Topology {
+	zoneLabels := f.ZoneLabels
+	if zoneLabels == nil {
+		zoneLabels = flags.DefaultZoneLabels
+	}
+	regionLabels := f.RegionLabels
+	if regionLabels == nil {
+		regionLabels = flags.DefaultRegionLabels
+	}
+
+	t := Topology{
+		Machine: node.GetName(),
+		Zone:    firstLabel(node, zoneLabels...),
+		Region:  firstLabel(node, regionLabels...),
+	}
+
+	t.Site = firstLabel(node, f.SiteLabel)
+	if t.Site == "" {
+		t.Site = t.Zone
+	}
+	t.Rack = firstLabel(node, f.RackLabel)
+	if t.Rack == "" {
+		t.Rack = t.Site
+	}
+	return t
+}
+
+// firstLabel returns the value of the first of the labels that is present on the node.
+func firstLabel(node metav1.Object, labels ...string) string {
+	for _, label := range labels {
+		if value, found := node.GetLabels()[label]; found && label != "" && value != "" {
+			return value
+		}
+	}
+	return ""
+}
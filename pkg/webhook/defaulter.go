@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/flags"
+	"net/http"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// ClusterDefaulter is an admission.Handler that sets the defaults in CoherenceCluster resources.
+type ClusterDefaulter struct {
+	decoder *admission.Decoder
+	opFlags *flags.CoherenceOperatorFlags
+}
+
+var _ admission.Handler = &ClusterDefaulter{}
+var _ admission.DecoderInjector = &ClusterDefaulter{}
+
+// InjectDecoder injects the decoder used to decode admission requests.
+func (d *ClusterDefaulter) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+// Handle sets the defaults in a CoherenceCluster that is being created or updated.
+func (d *ClusterDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	cluster := &coh.CoherenceCluster{}
+	if err := d.decoder.Decode(req, cluster); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if cluster.GetDeletionTimestamp() != nil {
+		return admission.Allowed("")
+	}
+
+	cluster.SetDefaults(d.opFlags.GetCoherenceImage(), d.opFlags.GetCoherenceUtilsImage())
+	return patchResponse(req, cluster)
+}
+
+// RoleDefaulter is an admission.Handler that sets the defaults in CoherenceRole resources.
+type RoleDefaulter struct {
+	decoder *admission.Decoder
+	opFlags *flags.CoherenceOperatorFlags
+}
+
+var _ admission.Handler = &RoleDefaulter{}
+var _ admission.DecoderInjector = &RoleDefaulter{}
+
+// InjectDecoder injects the decoder used to decode admission requests.
+func (d *RoleDefaulter) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+// Handle sets the defaults in a CoherenceRole that is being created or updated.
+func (d *RoleDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	role := &coh.CoherenceRole{}
+	if err := d.decoder.Decode(req, role); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if role.GetDeletionTimestamp() != nil {
+		return admission.Allowed("")
+	}
+
+	role.SetDefaults(d.opFlags.GetCoherenceImage(), d.opFlags.GetCoherenceUtilsImage())
+	return patchResponse(req, role)
+}
+
+// patchResponse creates a response containing the JSON patch from the request object to the defaulted object.
+func patchResponse(req admission.Request, obj interface{}) admission.Response {
+	marshaled, err := json.Marshal(obj)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package webhook
+
+import (
+	"context"
+	. "github.com/onsi/gomega"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/flags"
+	"k8s.io/api/admission/v1beta1"
+	"testing"
+)
+
+func TestClusterDefaulterSetsDefaults(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	opFlags := &flags.CoherenceOperatorFlags{CoherenceImage: "coherence:1.0", CoherenceUtilsImage: "utils:1.0"}
+	defaulter := &ClusterDefaulter{opFlags: opFlags}
+	g.Expect(defaulter.InjectDecoder(newDecoder(g))).To(Succeed())
+
+	cluster := &coh.CoherenceCluster{}
+	resp := defaulter.Handle(context.TODO(), newRequest(g, v1beta1.Create, cluster, nil))
+	g.Expect(resp.Allowed).To(BeTrue())
+
+	paths := make(map[string]interface{})
+	for _, p := range resp.Patches {
+		paths[p.Path] = p.Value
+	}
+	g.Expect(paths).To(HaveKeyWithValue("/spec/role", coh.DefaultRoleName))
+	g.Expect(paths).To(HaveKeyWithValue("/spec/replicas", float64(coh.DefaultReplicas)))
+	g.Expect(paths).To(HaveKeyWithValue("/spec/healthPort", float64(coh.DefaultHealthPort)))
+	g.Expect(paths).NotTo(HaveKey("/spec/scaling"))
+	g.Expect(paths).To(HaveKeyWithValue("/spec/coherence", map[string]interface{}{"image": "coherence:1.0"}))
+	g.Expect(paths).To(HaveKeyWithValue("/spec/coherenceUtils", map[string]interface{}{"image": "utils:1.0"}))
+}
+
+func TestClusterDefaulterSetsScalingPolicyWhenStorageIsExplicit(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	opFlags := &flags.CoherenceOperatorFlags{CoherenceImage: "coherence:1.0", CoherenceUtilsImage: "utils:1.0"}
+	defaulter := &ClusterDefaulter{opFlags: opFlags}
+	g.Expect(defaulter.InjectDecoder(newDecoder(g))).To(Succeed())
+
+	storage := false
+	cluster := &coh.CoherenceCluster{}
+	cluster.Spec.Coherence = &coh.CoherenceSpec{StorageEnabled: &storage}
+	resp := defaulter.Handle(context.TODO(), newRequest(g, v1beta1.Create, cluster, nil))
+	g.Expect(resp.Allowed).To(BeTrue())
+
+	paths := make(map[string]interface{})
+	for _, p := range resp.Patches {
+		paths[p.Path] = p.Value
+	}
+	g.Expect(paths).To(HaveKeyWithValue("/spec/scaling", map[string]interface{}{"policy": string(coh.ParallelScaling)}))
+}
+
+func TestRoleDefaulterDoesNotPatchDefaultedRole(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	opFlags := &flags.CoherenceOperatorFlags{CoherenceImage: "coherence:1.0", CoherenceUtilsImage: "utils:1.0"}
+	defaulter := &RoleDefaulter{opFlags: opFlags}
+	g.Expect(defaulter.InjectDecoder(newDecoder(g))).To(Succeed())
+
+	role := &coh.CoherenceRole{}
+	role.SetDefaults(opFlags.GetCoherenceImage(), opFlags.GetCoherenceUtilsImage())
+
+	resp := defaulter.Handle(context.TODO(), newRequest(g, v1beta1.Update, role, role))
+	g.Expect(resp.Allowed).To(BeTrue())
+	g.Expect(resp.Patches).To(BeEmpty())
+}
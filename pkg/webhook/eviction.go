@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/controller/coherencerole"
+	"github.com/oracle/coherence-operator/pkg/resources"
+	"k8s.io/api/admission/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"net/http"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"time"
+)
+
+// The value of the component label on the Pods of a CoherenceRole.
+const coherencePodComponent = "coherencePod"
+
+// The default time allowed to determine whether an eviction is safe. This is well under the timeout that the
+// web-hook is registered with so that an eviction is denied, rather than allowed by the API server's failure
+// policy, when the Coherence members do not answer in time.
+const defaultEvictionCheckTimeout = time.Second * 10
+
+// EvictionValidator is an admission.Handler that denies the eviction of a Coherence Pod, for example
+// during a node drain, while the partitions of the Pod's cluster are not safe. Evictions are denied
+// while any partitioned cache service is ENDANGERED or partitions are still being transferred.
+type EvictionValidator struct {
+	client client.Client
+	config *rest.Config
+	// timeout is the time allowed to determine whether an eviction is safe, the default is used if not set
+	timeout time.Duration
+}
+
+var _ admission.Handler = &EvictionValidator{}
+var _ inject.Client = &EvictionValidator{}
+var _ inject.Config = &EvictionValidator{}
+
+// InjectClient injects the client used to look up Pods and CoherenceRoles.
+func (v *EvictionValidator) InjectClient(c client.Client) error {
+	v.client = c
+	return nil
+}
+
+// InjectConfig injects the rest config used to exec into Pods.
+func (v *EvictionValidator) InjectConfig(cfg *rest.Config) error {
+	v.config = cfg
+	return nil
+}
+
+// Handle validates the creation of an eviction of a Pod.
+func (v *EvictionValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != v1beta1.Create || req.SubResource != "eviction" {
+		return admission.Allowed("")
+	}
+
+	pod := &corev1.Pod{}
+	if err := v.client.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: req.Name}, pod); err != nil {
+		if !errors.IsNotFound(err) {
+			// the Pod may be in a namespace that the Operator does not manage
+			log.Info(fmt.Sprintf("Allowing eviction of Pod %s/%s as the Pod could not be read: %s", req.Namespace, req.Name, err.Error()))
+		}
+		return admission.Allowed("")
+	}
+
+	name, found := pod.Labels[resources.CoherenceDeploymentLabel]
+	if !found || pod.Labels[coh.CoherenceComponentLabel] != coherencePodComponent || pod.DeletionTimestamp != nil {
+		return admission.Allowed("")
+	}
+
+	role := &coh.CoherenceRole{}
+	if err := v.client.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: name}, role); err != nil {
+		return allowedOrErrored(err)
+	}
+
+	sts := &appsv1.StatefulSet{}
+	if err := v.client.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: name}, sts); err != nil {
+		return allowedOrErrored(err)
+	}
+
+	// storage disabled members do not own any partitions, and the partitions of a single member
+	// role can never be safe, so evicting the Pod cannot be made any safer by waiting
+	if !role.Spec.IsStorageEnabled() || sts.Spec.Replicas == nil || *sts.Spec.Replicas <= 1 {
+		return admission.Allowed("")
+	}
+
+	timeout := v.timeout
+	if timeout <= 0 {
+		timeout = defaultEvictionCheckTimeout
+	}
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	checker := coherencerole.ScalableChecker{Client: v.client, Config: v.config}
+	if checker.IsEvictionSafe(checkCtx, role, sts) {
+		return admission.Allowed("")
+	}
+
+	log.Info(fmt.Sprintf("Denying eviction of Pod %s/%s as the partitions of CoherenceRole %s are not safe", pod.Namespace, pod.Name, role.Name))
+	msg := fmt.Sprintf("cannot evict Pod %s as the partitions of CoherenceRole %s are not safe", pod.Name, role.Name)
+	return tooManyRequests(msg)
+}
+
+// allowedOrErrored allows an eviction if a resource was not found, otherwise returns an error response.
+func allowedOrErrored(err error) admission.Response {
+	if errors.IsNotFound(err) {
+		return admission.Allowed("")
+	}
+	return admission.Errored(http.StatusInternalServerError, err)
+}
+
+// tooManyRequests creates a response denying an eviction with the same status that is returned when an eviction
+// would violate a PodDisruptionBudget, so that callers such as kubectl drain will retry the eviction.
+func tooManyRequests(msg string) admission.Response {
+	return admission.Response{
+		AdmissionResponse: v1beta1.AdmissionResponse{
+			Allowed: false,
+			Result: &metav1.Status{
+				Code:    http.StatusTooManyRequests,
+				Reason:  metav1.StatusReasonTooManyRequests,
+				Message: msg,
+			},
+		},
+	}
+}
@@ -0,0 +1,188 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package webhook
+
+import (
+	"context"
+	. "github.com/onsi/gomega"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"github.com/oracle/coherence-operator/pkg/resources"
+	"k8s.io/api/admission/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const (
+	evictionNamespace = "coherence-test"
+	evictionRoleName  = "test-cluster-storage"
+	evictionPodName   = evictionRoleName + "-0"
+)
+
+func TestEvictionValidatorAllowsNonCoherencePod(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	pod := newEvictionPod()
+	pod.Labels = map[string]string{"app": "nginx"}
+
+	resp := newEvictionValidator(g, pod).Handle(context.TODO(), newEvictionRequest())
+	g.Expect(resp.Allowed).To(BeTrue())
+}
+
+func TestEvictionValidatorAllowsMissingPod(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	resp := newEvictionValidator(g).Handle(context.TODO(), newEvictionRequest())
+	g.Expect(resp.Allowed).To(BeTrue())
+}
+
+func TestEvictionValidatorAllowsSingleMemberRole(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	role := newEvictionRole()
+	sts := newEvictionStatefulSet(1)
+
+	resp := newEvictionValidator(g, newEvictionPod(), role, sts).Handle(context.TODO(), newEvictionRequest())
+	g.Expect(resp.Allowed).To(BeTrue())
+}
+
+func TestEvictionValidatorAllowsStorageDisabledRole(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	role := newEvictionRole()
+	role.Spec.Coherence = &coh.CoherenceSpec{StorageEnabled: pointer.BoolPtr(false)}
+	sts := newEvictionStatefulSet(3)
+
+	resp := newEvictionValidator(g, newEvictionPod(), role, sts).Handle(context.TODO(), newEvictionRequest())
+	g.Expect(resp.Allowed).To(BeTrue())
+}
+
+func TestEvictionValidatorDeniesEvictionWhenPartitionsAreNotSafe(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// management is not enabled so a minimum HA status can never be satisfied
+	status := coh.HAStatusNodeSafe
+	role := newEvictionRole()
+	role.Spec.Scaling = &coh.ScalingSpec{MinimumHAStatus: &status}
+	sts := newEvictionStatefulSet(3)
+
+	resp := newEvictionValidator(g, newEvictionPod(), role, sts).Handle(context.TODO(), newEvictionRequest())
+	g.Expect(resp.Allowed).To(BeFalse())
+	g.Expect(resp.Result.Code).To(Equal(int32(http.StatusTooManyRequests)))
+	g.Expect(resp.Result.Reason).To(Equal(metav1.StatusReasonTooManyRequests))
+	g.Expect(resp.Result.Message).To(ContainSubstring("cannot evict Pod " + evictionPodName))
+}
+
+func TestEvictionValidatorDeniesEvictionWhenMembersDoNotAnswerInTime(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the member hangs until the test completes
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	u, err := url.Parse(server.URL)
+	g.Expect(err).NotTo(HaveOccurred())
+	port, err := strconv.Atoi(u.Port())
+	g.Expect(err).NotTo(HaveOccurred())
+
+	role := newEvictionRole()
+	role.Spec.Coherence = &coh.CoherenceSpec{
+		Management: &coh.PortSpecWithSSL{Enabled: pointer.BoolPtr(true), Port: pointer.Int32Ptr(int32(port))},
+	}
+	sts := newEvictionStatefulSet(3)
+	pod := newEvictionPod()
+	pod.Status = corev1.PodStatus{
+		Phase:      corev1.PodRunning,
+		PodIP:      u.Hostname(),
+		Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+	}
+
+	validator := newEvictionValidator(g, pod, role, sts)
+	validator.timeout = time.Millisecond * 200
+
+	start := time.Now()
+	resp := validator.Handle(context.TODO(), newEvictionRequest())
+	g.Expect(time.Since(start)).To(BeNumerically("<", time.Second*5))
+	g.Expect(resp.Allowed).To(BeFalse())
+	g.Expect(resp.Result.Code).To(Equal(int32(http.StatusTooManyRequests)))
+}
+
+func TestEvictionValidatorIgnoresOtherRequests(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	req := newEvictionRequest()
+	req.SubResource = ""
+
+	resp := newEvictionValidator(g).Handle(context.TODO(), req)
+	g.Expect(resp.Allowed).To(BeTrue())
+}
+
+func newEvictionValidator(g *WithT, objs ...runtime.Object) *EvictionValidator {
+	scheme := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	g.Expect(coh.SchemeBuilder.AddToScheme(scheme)).To(Succeed())
+
+	validator := &EvictionValidator{}
+	g.Expect(validator.InjectClient(fake.NewFakeClientWithScheme(scheme, objs...))).To(Succeed())
+	return validator
+}
+
+func newEvictionRequest() admission.Request {
+	return admission.Request{AdmissionRequest: v1beta1.AdmissionRequest{
+		Operation:   v1beta1.Create,
+		Namespace:   evictionNamespace,
+		Name:        evictionPodName,
+		SubResource: "eviction",
+	}}
+}
+
+func newEvictionPod() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: evictionNamespace,
+			Name:      evictionPodName,
+			Labels: map[string]string{
+				resources.CoherenceDeploymentLabel: evictionRoleName,
+				coh.CoherenceComponentLabel:        coherencePodComponent,
+			},
+		},
+	}
+}
+
+func newEvictionRole() *coh.CoherenceRole {
+	return &coh.CoherenceRole{
+		ObjectMeta: metav1.ObjectMeta{Namespace: evictionNamespace, Name: evictionRoleName},
+		Spec:       coh.CoherenceRoleSpec{Role: "storage"},
+	}
+}
+
+func newEvictionStatefulSet(replicas int32) *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: evictionNamespace, Name: evictionRoleName},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{resources.CoherenceDeploymentLabel: evictionRoleName},
+			},
+		},
+	}
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package webhook
+
+import (
+	"context"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"net/http"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// ClusterValidator is an admission.Handler that validates CoherenceCluster resources.
+type ClusterValidator struct {
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &ClusterValidator{}
+var _ admission.DecoderInjector = &ClusterValidator{}
+
+// InjectDecoder injects the decoder used to decode admission requests.
+func (v *ClusterValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// Handle validates a create or update of a CoherenceCluster.
+func (v *ClusterValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	cluster := &coh.CoherenceCluster{}
+	if err := v.decoder.Decode(req, cluster); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if req.Operation != v1beta1.Update {
+		return toResponse(cluster.Validate())
+	}
+
+	previous := &coh.CoherenceCluster{}
+	if err := v.decoder.DecodeRaw(req.OldObject, previous); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	return toResponse(cluster.ValidateUpdate(previous))
+}
+
+// RoleValidator is an admission.Handler that validates CoherenceRole resources.
+type RoleValidator struct {
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &RoleValidator{}
+var _ admission.DecoderInjector = &RoleValidator{}
+
+// InjectDecoder injects the decoder used to decode admission requests.
+func (v *RoleValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// Handle validates a create or update of a CoherenceRole.
+func (v *RoleValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	role := &coh.CoherenceRole{}
+	if err := v.decoder.Decode(req, role); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if req.Operation != v1beta1.Update {
+		return toResponse(role.Validate())
+	}
+
+	previous := &coh.CoherenceRole{}
+	if err := v.decoder.DecodeRaw(req.OldObject, previous); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	return toResponse(role.ValidateUpdate(previous))
+}
+
+// toResponse converts a validation error to an admission.Response.
+func toResponse(err error) admission.Response {
+	if err == nil {
+		return admission.Allowed("")
+	}
+
+	if statusErr, ok := err.(*errors.StatusError); ok {
+		// return the full status so that kubectl can display the field errors
+		status := statusErr.ErrStatus
+		return admission.Response{AdmissionResponse: v1beta1.AdmissionResponse{Allowed: false, Result: &status}}
+	}
+	return admission.Denied(err.Error())
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	. "github.com/onsi/gomega"
+	coh "github.com/oracle/coherence-operator/pkg/apis/coherence/v1"
+	"k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"net/http"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"testing"
+)
+
+func TestClusterValidatorAllowsValidCluster(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cluster := &coh.CoherenceCluster{}
+	cluster.Spec.Roles = []coh.CoherenceRoleSpec{{Role: "data"}, {Role: "proxy"}}
+
+	resp := newClusterValidator(g).Handle(context.TODO(), newRequest(g, v1beta1.Create, cluster, nil))
+	g.Expect(resp.Allowed).To(BeTrue())
+}
+
+func TestClusterValidatorRejectsDuplicateRoles(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cluster := &coh.CoherenceCluster{}
+	cluster.Spec.Roles = []coh.CoherenceRoleSpec{{Role: "data"}, {Role: "data"}}
+
+	resp := newClusterValidator(g).Handle(context.TODO(), newRequest(g, v1beta1.Create, cluster, nil))
+	g.Expect(resp.Allowed).To(BeFalse())
+	g.Expect(resp.Result.Code).To(Equal(int32(http.StatusUnprocessableEntity)))
+	g.Expect(resp.Result.Message).To(ContainSubstring("spec.roles[1].role: Duplicate value: \"data\""))
+}
+
+func TestClusterValidatorRejectsPersistenceChange(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	enabled := true
+	previous := &coh.CoherenceCluster{}
+	cluster := &coh.CoherenceCluster{}
+	cluster.Spec.Coherence = &coh.CoherenceSpec{Persistence: &coh.PersistentStorageSpec{Enabled: &enabled}}
+
+	resp := newClusterValidator(g).Handle(context.TODO(), newRequest(g, v1beta1.Update, cluster, previous))
+	g.Expect(resp.Allowed).To(BeFalse())
+	g.Expect(resp.Result.Message).To(ContainSubstring("spec.coherence.persistence.enabled: Forbidden"))
+}
+
+func TestRoleValidatorRejectsNegativeReplicas(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	replicas := int32(-1)
+	role := &coh.CoherenceRole{}
+	role.Spec.Replicas = &replicas
+
+	validator := &RoleValidator{}
+	g.Expect(validator.InjectDecoder(newDecoder(g))).To(Succeed())
+
+	resp := validator.Handle(context.TODO(), newRequest(g, v1beta1.Create, role, nil))
+	g.Expect(resp.Allowed).To(BeFalse())
+	g.Expect(resp.Result.Message).To(ContainSubstring("spec.replicas: Invalid value: -1"))
+}
+
+func newClusterValidator(g *WithT) *ClusterValidator {
+	validator := &ClusterValidator{}
+	g.Expect(validator.InjectDecoder(newDecoder(g))).To(Succeed())
+	return validator
+}
+
+func newDecoder(g *WithT) *admission.Decoder {
+	scheme := runtime.NewScheme()
+	g.Expect(coh.SchemeBuilder.AddToScheme(scheme)).To(Succeed())
+	decoder, err := admission.NewDecoder(scheme)
+	g.Expect(err).NotTo(HaveOccurred())
+	return decoder
+}
+
+func newRequest(g *WithT, op v1beta1.Operation, obj, old runtime.Object) admission.Request {
+	req := admission.Request{AdmissionRequest: v1beta1.AdmissionRequest{Operation: op}}
+	raw, err := json.Marshal(obj)
+	g.Expect(err).NotTo(HaveOccurred())
+	req.Object = runtime.RawExtension{Raw: raw}
+	if old != nil {
+		raw, err = json.Marshal(old)
+		g.Expect(err).NotTo(HaveOccurred())
+		req.OldObject = runtime.RawExtension{Raw: raw}
+	}
+	return req
+}
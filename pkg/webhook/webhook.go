@@ -0,0 +1,69 @@
+/*
+ * Copyright (c) 2020 Oracle and/or its affiliates. All rights reserved.
+ * Licensed under the Universal Permissive License v 1.0 as shown at
+ * http://oss.oracle.com/licenses/upl.
+ */
+
+// Package webhook contains the admission web-hooks served by the Coherence Operator.
+package webhook
+
+import (
+	"github.com/oracle/coherence-operator/pkg/flags"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// MutateClusterPath is the path of the CoherenceCluster defaulting web-hook.
+	MutateClusterPath = "/mutate-coherence-oracle-com-v1-coherencecluster"
+	// MutateRolePath is the path of the CoherenceRole defaulting web-hook.
+	MutateRolePath = "/mutate-coherence-oracle-com-v1-coherencerole"
+	// ValidateClusterPath is the path of the CoherenceCluster validating web-hook.
+	ValidateClusterPath = "/validate-coherence-oracle-com-v1-coherencecluster"
+	// ValidateRolePath is the path of the CoherenceRole validating web-hook.
+	ValidateRolePath = "/validate-coherence-oracle-com-v1-coherencerole"
+	// ValidateEvictionPath is the path of the Pod eviction validating web-hook.
+	ValidateEvictionPath = "/validate-v1-pod-eviction"
+)
+
+var log = logf.Log.WithName("webhook")
+
+// AddToManagerFuncs is a list of functions to add web-hooks to the web-hook server.
+var AddToManagerFuncs []func(*webhook.Server, *flags.CoherenceOperatorFlags) error
+
+func init() {
+	AddToManagerFuncs = append(AddToManagerFuncs, addDefaulters, addValidators)
+}
+
+// AddToManager adds all of the web-hooks to the Manager's web-hook server.
+func AddToManager(m manager.Manager, opFlags *flags.CoherenceOperatorFlags) error {
+	server := m.GetWebhookServer()
+	for _, f := range AddToManagerFuncs {
+		if err := f(server, opFlags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addDefaulters registers the mutating web-hooks that set defaults.
+func addDefaulters(server *webhook.Server, opFlags *flags.CoherenceOperatorFlags) error {
+	log.Info("Registering mutating web-hook", "path", MutateClusterPath)
+	server.Register(MutateClusterPath, &admission.Webhook{Handler: &ClusterDefaulter{opFlags: opFlags}})
+	log.Info("Registering mutating web-hook", "path", MutateRolePath)
+	server.Register(MutateRolePath, &admission.Webhook{Handler: &RoleDefaulter{opFlags: opFlags}})
+	return nil
+}
+
+// addValidators registers the validating web-hooks.
+func addValidators(server *webhook.Server, _ *flags.CoherenceOperatorFlags) error {
+	log.Info("Registering validating web-hook", "path", ValidateClusterPath)
+	server.Register(ValidateClusterPath, &admission.Webhook{Handler: &ClusterValidator{}})
+	log.Info("Registering validating web-hook", "path", ValidateRolePath)
+	server.Register(ValidateRolePath, &admission.Webhook{Handler: &RoleValidator{}})
+	log.Info("Registering validating web-hook", "path", ValidateEvictionPath)
+	server.Register(ValidateEvictionPath, &admission.Webhook{Handler: &EvictionValidator{}})
+	return nil
+}
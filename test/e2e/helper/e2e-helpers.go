@@ -1335,6 +1335,14 @@ func UninstallCrds(t *testing.T) error {
 		return err
 	}
 
+	if err := UninstallCrd(t, "coherencesnapshots.coherence.oracle.com"); err != nil {
+		return err
+	}
+
+	if err := UninstallCrd(t, "coherencerestores.coherence.oracle.com"); err != nil {
+		return err
+	}
+
 	return nil
 }
 